@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+// debug reads commands from the input every time the walk stops, until the walk is over. The commands are:
+//
+//	continue, c    run the node, or report its failure if it failed
+//	skip, s        skip the node, and run the nodes depending on it anyway
+//	retry, r       run the node again after it failed
+//	inspect, i     print what has happened to every node so far, and what is waiting to run
+//	clear          remove every breakpoint and continue
+//	abort, a       cancel the rest of the walk
+//
+// Once the input is exhausted, every breakpoint is removed and the walk runs to the end.
+func debug(debugger *graph.Debugger, input io.Reader, output io.Writer) {
+	lines := bufio.NewScanner(input)
+	for stop := range debugger.Stops() {
+		if stop.Err != nil {
+			fmt.Fprintf(output, "stopped at %s: %s\n", stop.Key, stop.Err)
+		} else {
+			fmt.Fprintf(output, "stopped before %s\n", stop.Key)
+		}
+
+		for resumed := false; !resumed; {
+			fmt.Fprint(output, "(graphctl) ")
+			if !lines.Scan() {
+				debugger.Clear()
+				_ = debugger.Continue()
+				break
+			}
+
+			var err error
+			switch command := strings.TrimSpace(lines.Text()); command {
+			case "continue", "c":
+				err, resumed = debugger.Continue(), true
+			case "skip", "s":
+				err, resumed = debugger.Skip(), true
+			case "retry", "r":
+				err, resumed = debugger.Retry(), true
+			case "abort", "a":
+				err, resumed = debugger.Abort(), true
+			case "clear":
+				debugger.Clear()
+				err, resumed = debugger.Continue(), true
+			case "inspect", "i":
+				var state graph.DebugState
+				state, err = debugger.Inspect()
+				if err == nil {
+					inspect(state, output)
+				}
+			case "":
+			default:
+				fmt.Fprintf(output, "unknown command %q, expected continue, skip, retry, inspect, clear or abort\n", command)
+			}
+
+			if err != nil {
+				fmt.Fprintln(output, err)
+				// The command didn't resume the walk, so ask again.
+				resumed = false
+			}
+		}
+	}
+}
+
+// inspect prints the state of the walk.
+func inspect(state graph.DebugState, output io.Writer) {
+	keys := make([]string, 0, len(state.Nodes))
+	for key := range state.Nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		node := state.Nodes[key]
+		if node.Err != nil {
+			fmt.Fprintf(output, "  %s: %s (%s)\n", key, node.Status, node.Err)
+			continue
+		}
+		fmt.Fprintf(output, "  %s: %s\n", key, node.Status)
+	}
+	if len(state.Pending) > 0 {
+		fmt.Fprintf(output, "  waiting: %s\n", strings.Join(state.Pending, ", "))
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pasataleo/go-graph/graph"
+	"github.com/pasataleo/go-graph/graph/makefile"
+)
+
+// definition describes a graph of shell commands.
+type definition struct {
+	Nodes []nodeDefinition `json:"nodes"`
+}
+
+// nodeDefinition describes a single node of a definition.
+type nodeDefinition struct {
+	Key       string   `json:"key"`
+	Commands  []string `json:"commands,omitempty"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+
+	// Estimate is how long the node is expected to take, as parsed by time.ParseDuration.
+	Estimate string `json:"estimate,omitempty"`
+}
+
+// load reads the definition in the file, in the given format or in the one its name suggests if empty: JSON for .json
+// files, YAML for .yaml and .yml files, DOT for .dot and .gv files and make style dependency files for .mk and .d files
+// and files without an extension, like Makefile.
+func load(path string, format string) (*definition, error) {
+	if len(format) == 0 {
+		switch filepath.Ext(path) {
+		case ".json":
+			format = "json"
+		case ".yaml", ".yml":
+			format = "yaml"
+		case ".dot", ".gv":
+			format = "dot"
+		case ".mk", ".d", "":
+			format = "make"
+		default:
+			return nil, fmt.Errorf("can't tell the format of %s from its extension, use -format", path)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch format {
+	case "json":
+		var definition definition
+		if err := json.NewDecoder(file).Decode(&definition); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		return &definition, nil
+	case "yaml":
+		return parseYAML(file)
+	case "dot":
+		return parseDOT(file)
+	case "make":
+		return parseMake(file)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+var (
+	// dotAttribute matches a single attribute of a DOT statement, with its value quoted or not.
+	dotAttribute = regexp.MustCompile(`(\w+)\s*=\s*("(?:[^"\\]|\\.)*"|[^,;\s\]]+)`)
+)
+
+// parseDOT reads a definition from the subset of the DOT language that describes nodes and edges: statements like
+// `a -> b -> c;` connect nodes, and statements like `a [command="make a", tags="build"];` describe them. The command
+// and tags attributes may contain several values separated by newlines and commas respectively.
+func parseDOT(reader io.Reader) (*definition, error) {
+	nodes := make(map[string]*nodeDefinition)
+	var order []string
+	lookup := func(key string) *nodeDefinition {
+		if node, ok := nodes[key]; ok {
+			return node
+		}
+		nodes[key] = &nodeDefinition{Key: key}
+		order = append(order, key)
+		return nodes[key]
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if ix := strings.Index(text, "//"); ix >= 0 && strings.Count(text[:ix], `"`)%2 == 0 {
+			text = strings.TrimSpace(text[:ix])
+		}
+		text = strings.TrimSuffix(text, ";")
+		if len(text) == 0 || strings.HasSuffix(text, "{") || text == "}" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		statement, attributes, _ := strings.Cut(text, "[")
+		switch strings.TrimSpace(statement) {
+		case "graph", "node", "edge":
+			// Default attributes only affect how the graph is drawn.
+			continue
+		}
+		var keys []string
+		for _, id := range strings.Split(statement, "->") {
+			key, err := unquote(strings.TrimSpace(id))
+			if err != nil || len(key) == 0 {
+				return nil, fmt.Errorf("line %d: invalid node %q", line, strings.TrimSpace(id))
+			}
+			keys = append(keys, key)
+		}
+
+		for ix, key := range keys {
+			node := lookup(key)
+			if ix > 0 {
+				node.DependsOn = append(node.DependsOn, keys[ix-1])
+			}
+		}
+		if len(keys) > 1 {
+			continue
+		}
+
+		node := nodes[keys[0]]
+		for _, match := range dotAttribute.FindAllStringSubmatch(attributes, -1) {
+			value, err := unquote(match[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %s", line, match[2])
+			}
+			switch match[1] {
+			case "command":
+				node.Commands = append(node.Commands, strings.Split(value, "\n")...)
+			case "tags":
+				node.Tags = append(node.Tags, strings.Split(value, ",")...)
+			case "estimate":
+				node.Estimate = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	definition := &definition{}
+	for _, key := range order {
+		definition.Nodes = append(definition.Nodes, *nodes[key])
+	}
+	return definition, nil
+}
+
+// unquote removes the quotes from a DOT identifier, if it has any.
+func unquote(id string) (string, error) {
+	if strings.HasPrefix(id, `"`) {
+		return strconv.Unquote(id)
+	}
+	return id, nil
+}
+
+// parseMake reads a definition from a make style dependency file, see makefile.Rules.
+func parseMake(reader io.Reader) (*definition, error) {
+	rules, err := makefile.Rules(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := &definition{}
+	for _, rule := range rules {
+		definition.Nodes = append(definition.Nodes, nodeDefinition{
+			Key:       rule.Target,
+			Commands:  rule.Commands,
+			DependsOn: rule.Dependencies,
+		})
+	}
+	return definition, nil
+}
+
+// targets returns the definition with only the nodes the given targets need to run: the targets themselves and
+// everything they depend on, directly or not.
+func (d *definition) targets(targets []string) (*definition, error) {
+	if len(targets) == 0 {
+		return d, nil
+	}
+
+	nodes := make(map[string]nodeDefinition, len(d.Nodes))
+	for _, node := range d.Nodes {
+		nodes[node.Key] = node
+	}
+
+	needed := make(map[string]bool)
+	var need func(key string) error
+	need = func(key string) error {
+		if needed[key] {
+			return nil
+		}
+		node, ok := nodes[key]
+		if !ok {
+			return fmt.Errorf("node %q does not exist", key)
+		}
+		needed[key] = true
+		for _, dependency := range node.DependsOn {
+			if err := need(dependency); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, target := range targets {
+		if err := need(target); err != nil {
+			return nil, err
+		}
+	}
+
+	selected := &definition{}
+	for _, node := range d.Nodes {
+		if needed[node.Key] {
+			selected.Nodes = append(selected.Nodes, node)
+		}
+	}
+	return selected, nil
+}
+
+// graph builds the graph the definition describes, with every node running its commands in the given directory.
+func (d *definition) graph(dir string) (graph.Graph, error) {
+	g := graph.NewGraph()
+	for _, node := range d.Nodes {
+		meta := graph.Meta{Tags: node.Tags}
+		if len(node.Estimate) > 0 {
+			estimate, err := time.ParseDuration(node.Estimate)
+			if err != nil {
+				return graph.Graph{}, fmt.Errorf("node %q has an invalid estimate: %w", node.Key, err)
+			}
+			meta.Estimate = estimate
+		}
+		if err := g.AddNodeWithMeta(node.Key, &makefile.Exec{Commands: node.Commands, Dir: dir}, meta); err != nil {
+			return graph.Graph{}, err
+		}
+	}
+
+	for _, node := range d.Nodes {
+		dependencies := append([]string(nil), node.DependsOn...)
+		sort.Strings(dependencies)
+		for ix, dependency := range dependencies {
+			if ix > 0 && dependencies[ix-1] == dependency {
+				continue
+			}
+			if err := g.Connect(dependency, node.Key); err != nil {
+				return graph.Graph{}, err
+			}
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,228 @@
+// Command graphctl loads, validates, plans, renders and walks graphs of shell commands.
+//
+// Usage:
+//
+//	graphctl validate [-format json|yaml|dot|make] FILE
+//	graphctl plan [-format json|yaml|dot|make] FILE
+//	graphctl render [-format json|yaml|dot|make] [-output tree|ascii|dot|mermaid|plantuml|svg] FILE
+//	graphctl run [-format json|yaml|dot|make] [-parallelism N] [-target KEY]... [-fail-fast] [-report markdown|junit|github|teamcity] [-break KEY]... [-break-tag TAG]... [-break-on-error] [-step] FILE
+//
+// Graphs are read from JSON or YAML definitions, from DOT files or from make style dependency files, see load.
+//
+// Runs with breakpoints stop before the nodes with the given keys or tags run, and again if they fail, and read what to
+// do next from standard input, see debug. Runs with -break-on-error stop as soon as any node fails, and runs with -step
+// stop before every node and run them one at a time.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/pasataleo/go-graph/graph"
+	"github.com/pasataleo/go-graph/graph/report"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// targets collects the values of a repeated flag.
+type targets []string
+
+func (targets *targets) String() string {
+	return strings.Join(*targets, ",")
+}
+
+func (targets *targets) Set(value string) error {
+	*targets = append(*targets, value)
+	return nil
+}
+
+// run runs graphctl with the given arguments, and returns its exit code: 0 if it succeeded, 1 if the graph is invalid
+// or the walk failed and 2 if the arguments were.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: graphctl validate|plan|render|run [flags] FILE")
+		return 2
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("graphctl "+command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	format := flags.String("format", "", "the format of FILE: json, yaml, dot or make (defaults to its extension)")
+	var output, reporter *string
+	var parallelism *int
+	var failFast, failures, step *bool
+	var selected, breakpoints, tags targets
+	switch command {
+	case "validate", "plan":
+	case "render":
+		output = flags.String("output", "tree", "what to render: tree, ascii, dot, mermaid, plantuml or svg")
+	case "run":
+		parallelism = flags.Int("parallelism", 4, "how many nodes may run at once")
+		failFast = flags.Bool("fail-fast", false, "stop the walk as soon as a node fails")
+		reporter = flags.String("report", "markdown", "how to report the walk: markdown, junit, github or teamcity")
+		flags.Var(&selected, "target", "only run this node and what it depends on, may be repeated")
+		flags.Var(&breakpoints, "break", "stop before this node runs and if it fails, may be repeated")
+		flags.Var(&tags, "break-tag", "stop before nodes with this tag run and if they fail, may be repeated")
+		failures = flags.Bool("break-on-error", false, "stop as soon as any node fails")
+		step = flags.Bool("step", false, "stop before every node, and run them one at a time")
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", command)
+		return 2
+	}
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintf(stderr, "usage: graphctl %s [flags] FILE\n", command)
+		return 2
+	}
+	if parallelism != nil && *parallelism < 1 {
+		fmt.Fprintf(stderr, "-parallelism must be at least 1, got %d\n", *parallelism)
+		fmt.Fprintf(stderr, "usage: graphctl %s [flags] FILE\n", command)
+		return 2
+	}
+	path := flags.Arg(0)
+
+	definition, err := load(path, *format)
+	if err == nil {
+		definition, err = definition.targets(selected)
+	}
+	var g graph.Graph
+	if err == nil {
+		g, err = definition.graph(filepath.Dir(path))
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	switch command {
+	case "validate":
+		return validate(g, stdout, stderr)
+	case "plan":
+		return plan(g, stdout, stderr)
+	case "render":
+		return render(g, *output, stdout, stderr)
+	default:
+		opts := &graph.Opts{Parallelism: *parallelism, FailFast: *failFast, StepMode: *step}
+		if len(breakpoints) > 0 || len(tags) > 0 || *failures || *step {
+			opts.Debugger = graph.NewDebugger()
+			opts.Debugger.Break(breakpoints...)
+			opts.Debugger.BreakOnTag(tags...)
+			if *failures {
+				opts.Debugger.BreakOnError()
+			}
+		}
+		return walk(ctx, g, opts, *reporter, stdin, stdout, stderr)
+	}
+}
+
+func validate(g graph.Graph, stdout io.Writer, stderr io.Writer) int {
+	if err := g.Validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, "ok")
+	return 0
+}
+
+func plan(g graph.Graph, stdout io.Writer, stderr io.Writer) int {
+	plan := g.Plan()
+	if plan.Err != nil {
+		fmt.Fprintln(stderr, plan.Err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "hash: %s\n", plan.Hash)
+	for ix, generation := range plan.Generations {
+		fmt.Fprintf(stdout, "generation %d: %s\n", ix, strings.Join(generation, ", "))
+	}
+	fmt.Fprintf(stdout, "critical path: %s\n", strings.Join(plan.CriticalPath, " -> "))
+	return 0
+}
+
+func render(g graph.Graph, output string, stdout io.Writer, stderr io.Writer) int {
+	var err error
+	switch output {
+	case "tree":
+		err = g.Render(stdout, graph.RenderUnicode)
+	case "ascii":
+		err = g.Render(stdout, graph.RenderASCII)
+	case "dot":
+		err = g.WriteDOT(stdout)
+	case "mermaid":
+		err = g.WriteMermaid(stdout)
+	case "plantuml":
+		err = g.WritePlantUML(stdout)
+	case "svg":
+		err = g.WriteSVG(stdout)
+	default:
+		fmt.Fprintf(stderr, "unknown output %q\n", output)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func walk(ctx context.Context, g graph.Graph, opts *graph.Opts, reporter string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	var annotator *report.Annotator
+	switch reporter {
+	case "markdown", "junit":
+	case "github":
+		annotator = report.GitHubActions(stdout)
+	case "teamcity":
+		annotator = report.TeamCity(stdout)
+	default:
+		fmt.Fprintf(stderr, "unknown report %q\n", reporter)
+		return 2
+	}
+	if annotator != nil {
+		opts.Bus = graph.NewBus(annotator)
+	}
+	if opts.Debugger != nil {
+		// The debugger stops reporting once the walk is over, so wait for it to finish writing before returning.
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			debug(opts.Debugger, stdin, stderr)
+		}()
+		defer func() { <-done }()
+	}
+
+	result, err := g.Run(ctx, opts)
+	if result != nil {
+		var written error
+		switch reporter {
+		case "markdown":
+			_, written = io.WriteString(stdout, report.Markdown(result))
+		case "junit":
+			written = report.JUnit(stdout, result)
+		default:
+			written = annotator.Write(result)
+		}
+		if written != nil {
+			fmt.Fprintln(stderr, written)
+			return 1
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestLoad(t *testing.T) {
+	tcs := map[string]struct {
+		name    string
+		content string
+		targets []string
+		nodes   []nodeDefinition
+		err     string
+	}{
+		"json": {
+			name:    "graph.json",
+			content: `{"nodes": [{"key": "a", "commands": ["echo a"], "tags": ["build"], "estimate": "1s"}, {"key": "b", "depends_on": ["a"]}]}`,
+			nodes: []nodeDefinition{
+				{Key: "a", Commands: []string{"echo a"}, Tags: []string{"build"}, Estimate: "1s"},
+				{Key: "b", DependsOn: []string{"a"}},
+			},
+		},
+		"dot": {
+			name: "graph.dot",
+			content: `digraph {
+	node [shape=box];
+	a [command="echo a", tags="build,fast", estimate=1s];
+	"b c" [command="echo b\necho c"];
+	a -> "b c" -> d; // d runs last
+}
+`,
+			nodes: []nodeDefinition{
+				{Key: "a", Commands: []string{"echo a"}, Tags: []string{"build", "fast"}, Estimate: "1s"},
+				{Key: "b c", Commands: []string{"echo b", "echo c"}, DependsOn: []string{"a"}},
+				{Key: "d", DependsOn: []string{"b c"}},
+			},
+		},
+		"yaml": {
+			name: "graph.yaml",
+			content: `# built by hand
+nodes:
+  - key: a
+    commands:
+      - echo a # the first
+      - "echo '#1'"
+    tags: [build, 'fast, cheap']
+    estimate: 1s
+  - key: "b c"
+    depends_on:
+    - a
+  - key: d
+    depends_on: [a, "b c"]
+`,
+			nodes: []nodeDefinition{
+				{Key: "a", Commands: []string{"echo a", "echo '#1'"}, Tags: []string{"build", "fast, cheap"}, Estimate: "1s"},
+				{Key: "b c", DependsOn: []string{"a"}},
+				{Key: "d", DependsOn: []string{"a", "b c"}},
+			},
+		},
+		"invalid yaml": {
+			name:    "graph.yml",
+			content: "nodes:\n  - key: a\n    command: echo a\n",
+			err:     `line 3: unknown field "command"`,
+		},
+		"misaligned yaml": {
+			name:    "graph.yaml",
+			content: "nodes:\n  - key: a\n    commands:\n      key: b\n",
+			err:     `line 4: field "key" isn't aligned with the other fields of the node`,
+		},
+		"unknown extension": {
+			name:    "graph.txt",
+			content: "a: b\n",
+			err:     "can't tell the format of",
+		},
+		"make": {
+			name: "Makefile",
+			content: `b: a main.go
+	echo b
+a:
+	echo a
+`,
+			nodes: []nodeDefinition{
+				{Key: "a", Commands: []string{"echo a"}},
+				{Key: "b", Commands: []string{"echo b"}, DependsOn: []string{"a"}},
+			},
+		},
+		"targets": {
+			name:    "graph.dot",
+			content: "a -> b -> c\nd -> c\ne\n",
+			targets: []string{"b", "e"},
+			nodes: []nodeDefinition{
+				{Key: "a"},
+				{Key: "b", DependsOn: []string{"a"}},
+				{Key: "e"},
+			},
+		},
+		"missing target": {
+			name:    "graph.dot",
+			content: "a -> b\n",
+			targets: []string{"c"},
+			err:     `node "c" does not exist`,
+		},
+		"invalid json": {
+			name:    "graph.json",
+			content: `{"nodes": [`,
+			err:     "unexpected EOF",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.name)
+			tests.ExecuteE(os.WriteFile(path, []byte(tc.content), 0o644)).NoError(t)
+
+			definition, err := load(path, "")
+			if err == nil {
+				definition, err = definition.targets(tc.targets)
+			}
+			if len(tc.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(definition.Nodes).Equal(t, tc.nodes)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "graph.dot")
+	content := `digraph {
+	a [command="echo a >> log"];
+	b [command="echo b >> log"];
+	c [command="echo c >> log\nfalse"];
+	a -> b;
+	a -> c;
+}
+`
+	tests.ExecuteE(os.WriteFile(path, []byte(content), 0o644)).NoError(t)
+
+	tcs := map[string]struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+		log    string
+	}{
+		"validate": {
+			args:   []string{"validate", path},
+			stdout: "ok\n",
+		},
+		"invalid parallelism": {
+			args:   []string{"run", "-parallelism", "0", path},
+			code:   2,
+			stderr: "-parallelism must be at least 1, got 0\nusage: graphctl run [flags] FILE\n",
+		},
+		"plan": {
+			args:   []string{"plan", "-format", "dot", path},
+			stdout: "generation 0: a\ngeneration 1: b, c\n",
+		},
+		"run target": {
+			args: []string{"run", "-parallelism", "1", "-target", "b", path},
+			log:  "a\nb\n",
+		},
+		"run failure": {
+			args: []string{"run", "-parallelism", "1", path},
+			code: 1,
+			log:  "a\nb\nc\n",
+		},
+		"debug skip": {
+			args:   []string{"run", "-parallelism", "1", "-break", "c", path},
+			stdin:  "inspect\nskip\n",
+			stderr: "stopped before c\n(graphctl)   a: completed\n  b: completed\n  waiting: c\n(graphctl) ",
+			log:    "a\nb\n",
+		},
+		"debug retry": {
+			args:   []string{"run", "-parallelism", "1", "-break", "c", path},
+			stdin:  "continue\nretry\ncontinue\nskip\n",
+			stderr: "stopped at c: failed to execute node",
+			log:    "a\nb\nc\nc\n",
+		},
+		"debug to the end": {
+			args: []string{"run", "-parallelism", "1", "-break-tag", "missing", "-break", "a", path},
+			code: 1,
+			log:  "a\nb\nc\n",
+		},
+		"step": {
+			args:   []string{"run", "-step", path},
+			stdin:  "c\nc\nc\nc\nc\n",
+			code:   1,
+			stderr: "stopped before a\n(graphctl) stopped before b\n(graphctl) stopped before c\n(graphctl) stopped at c",
+			log:    "a\nb\nc\n",
+		},
+		"break on error": {
+			args:   []string{"run", "-parallelism", "1", "-break-on-error", path},
+			stdin:  "i\nabort\n",
+			code:   1,
+			stderr: "(graphctl)   a: completed\n  b: completed\n  c: errored",
+			log:    "a\nb\nc\n",
+		},
+		"unknown command": {
+			args: []string{"explode", path},
+			code: 2,
+		},
+		"missing file": {
+			args: []string{"validate"},
+			code: 2,
+		},
+		"unknown output": {
+			args: []string{"render", "-output", "png", path},
+			code: 2,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			_ = os.Remove(filepath.Join(dir, "log"))
+
+			var stdout, stderr bytes.Buffer
+			code := run(context.Background(), tc.args, strings.NewReader(tc.stdin), &stdout, &stderr)
+			if code != tc.code {
+				t.Fatalf("expected exit code %d, got %d: %s", tc.code, code, stderr.String())
+			}
+			if len(tc.stdout) > 0 && !strings.Contains(stdout.String(), tc.stdout) {
+				t.Fatalf("expected output containing %q, got %q", tc.stdout, stdout.String())
+			}
+			if len(tc.stderr) > 0 && !strings.Contains(stderr.String(), tc.stderr) {
+				t.Fatalf("expected errors containing %q, got %q", tc.stderr, stderr.String())
+			}
+
+			log, _ := os.ReadFile(filepath.Join(dir, "log"))
+			tests.Execute(string(log)).Equal(t, tc.log)
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parseYAML reads a definition from the subset of YAML that describes it, mirroring the JSON format:
+//
+//	nodes:
+//	  - key: a
+//	    commands:
+//	      - echo a
+//	    tags: [build, fast]
+//	    estimate: 1s
+//	  - key: b
+//	    depends_on: [a]
+//
+// Lists can be written in the block or the flow style, and values may be quoted. Anchors, multi-line values and
+// multiple documents aren't supported.
+func parseYAML(reader io.Reader) (*definition, error) {
+	definition := &definition{}
+
+	var (
+		// started is true once the nodes field has been seen.
+		started bool
+
+		// items is the indentation of the dashes starting each node, -1 until the first node.
+		items = -1
+
+		// fields is the indentation of the fields of the current node, -1 until its first field.
+		fields = -1
+
+		// list is the field of the current node that expects a block list, if any.
+		list *[]string
+	)
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := stripYAMLComment(scanner.Text())
+		trimmed := strings.TrimSpace(text)
+		if len(trimmed) == 0 || trimmed == "---" {
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		if strings.HasPrefix(strings.TrimLeft(text, " "), "\t") {
+			return nil, fmt.Errorf("line %d: tabs can't be used for indentation", line)
+		}
+
+		if !started {
+			if trimmed != "nodes:" || indent > 0 {
+				return nil, fmt.Errorf("line %d: expected nodes, found %q", line, trimmed)
+			}
+			started = true
+			continue
+		}
+
+		item, isItem := strings.CutPrefix(trimmed, "-")
+		isItem = isItem && (len(item) == 0 || item[0] == ' ')
+		item = strings.TrimSpace(item)
+
+		switch {
+		case isItem && (items < 0 || indent == items):
+			// A new node, which may have its first field on the same line.
+			items = indent
+			definition.Nodes = append(definition.Nodes, nodeDefinition{})
+			list, fields = nil, -1
+			if len(item) == 0 {
+				continue
+			}
+			indent += len(trimmed) - len(item)
+			trimmed = item
+		case isItem && list != nil && indent > items:
+			value, err := parseYAMLScalar(item)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			*list = append(*list, value)
+			continue
+		case items < 0 || indent <= items:
+			return nil, fmt.Errorf("line %d: expected a node, found %q", line, trimmed)
+		}
+
+		name, value, ok := strings.Cut(trimmed, ":")
+		if !ok || (len(value) > 0 && value[0] != ' ') {
+			return nil, fmt.Errorf("line %d: expected a field, found %q", line, trimmed)
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+		if fields < 0 {
+			fields = indent
+		} else if indent != fields {
+			return nil, fmt.Errorf("line %d: field %q isn't aligned with the other fields of the node", line, name)
+		}
+
+		node := &definition.Nodes[len(definition.Nodes)-1]
+		list = nil
+		switch name {
+		case "key", "estimate":
+			scalar, err := parseYAMLScalar(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if name == "key" {
+				node.Key = scalar
+			} else {
+				node.Estimate = scalar
+			}
+		case "commands", "depends_on", "tags":
+			field := map[string]*[]string{
+				"commands":   &node.Commands,
+				"depends_on": &node.DependsOn,
+				"tags":       &node.Tags,
+			}[name]
+			if len(value) == 0 {
+				list = field
+				continue
+			}
+
+			values, err := parseYAMLList(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			*field = append(*field, values...)
+		default:
+			return nil, fmt.Errorf("line %d: unknown field %q", line, name)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for ix, node := range definition.Nodes {
+		if len(node.Key) == 0 {
+			return nil, fmt.Errorf("node %d has no key", ix+1)
+		}
+	}
+	return definition, nil
+}
+
+// stripYAMLComment removes a trailing comment from the line, ignoring any # within quotes or words.
+func stripYAMLComment(text string) string {
+	var quote byte
+	var escaped bool
+	for ix := 0; ix < len(text); ix++ {
+		char := text[ix]
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if char == '\\' && quote == '"' {
+				escaped = true
+			} else if char == quote {
+				quote = 0
+			}
+		case (char == '"' || char == '\'') && (ix == 0 || strings.IndexByte(" [,", text[ix-1]) >= 0):
+			quote = char
+		case char == '#' && (ix == 0 || text[ix-1] == ' ' || text[ix-1] == '\t'):
+			return strings.TrimRight(text[:ix], " \t")
+		}
+	}
+	return text
+}
+
+// parseYAMLList parses a flow list like [a, "b, c"], or a single value as a list of one.
+func parseYAMLList(value string) ([]string, error) {
+	if !strings.HasPrefix(value, "[") {
+		scalar, err := parseYAMLScalar(value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{scalar}, nil
+	}
+	if !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("unterminated list %s", value)
+	}
+
+	var values []string
+	var quote rune
+	var escaped bool
+	start := 1
+	inner := value[:len(value)-1]
+	for ix, char := range inner {
+		if ix == 0 {
+			continue
+		}
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if char == '\\' && quote == '"' {
+				escaped = true
+			} else if char == quote {
+				quote = 0
+			}
+		case (char == '"' || char == '\'') && strings.IndexByte(" [,", inner[ix-1]) >= 0:
+			quote = char
+		case char == ',':
+			values = append(values, inner[start:ix])
+			start = ix + 1
+		}
+	}
+	if rest := strings.TrimSpace(inner[start:]); len(rest) > 0 || len(values) > 0 {
+		values = append(values, inner[start:])
+	}
+
+	for ix, item := range values {
+		scalar, err := parseYAMLScalar(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		values[ix] = scalar
+	}
+	return values, nil
+}
+
+// parseYAMLScalar removes the quotes from a value, if it has any.
+func parseYAMLScalar(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid value %s", value)
+		}
+		return unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("invalid value %s", value)
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+		return "", fmt.Errorf("multi-line values aren't supported")
+	case strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{"):
+		return "", fmt.Errorf("expected a single value, found %s", value)
+	}
+	return value, nil
+}
@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+)
+
+// AnnotationKind says what an annotation is.
+type AnnotationKind string
+
+const (
+	// AnnotationNote is a named value, such as the version a node deployed.
+	AnnotationNote AnnotationKind = "note"
+
+	// AnnotationLink is a titled link, such as the dashboard of a deployment or the logs of a remote job.
+	AnnotationLink AnnotationKind = "link"
+
+	// AnnotationWarning is something that went wrong without failing the node.
+	AnnotationWarning AnnotationKind = "warning"
+)
+
+// Annotation is a note a node attached to its result while it executed, see Annotate, AnnotateLink and
+// AnnotateWarning. Reports show the annotations of every node alongside its status.
+type Annotation struct {
+	// Kind says what the annotation is.
+	Kind AnnotationKind
+
+	// Name is the name of a note or the title of a link, it is empty for warnings.
+	Name string
+
+	// Value is the value of a note, the URL of a link or the message of a warning.
+	Value string
+
+	// Time is when the node added the annotation.
+	Time time.Time
+}
+
+// Annotate attaches a named value to the result of the node executing with the given context, such as the version it
+// deployed or the number of rows it wrote. Notes with the same name are all kept, in the order they were added.
+//
+// It does nothing if the context doesn't belong to a node.
+func Annotate(ctx context.Context, name string, value string) {
+	annotate(ctx, Annotation{Kind: AnnotationNote, Name: name, Value: value})
+}
+
+// AnnotateLink attaches a titled link to the result of the node executing with the given context, such as the
+// dashboard of what it deployed.
+//
+// It does nothing if the context doesn't belong to a node.
+func AnnotateLink(ctx context.Context, title string, url string) {
+	annotate(ctx, Annotation{Kind: AnnotationLink, Name: title, Value: url})
+}
+
+// AnnotateWarning attaches a warning to the result of the node executing with the given context, for something that
+// went wrong without failing the node. It is the same as calling Warn with an error with the given message.
+//
+// It does nothing if the context doesn't belong to a node.
+func AnnotateWarning(ctx context.Context, message string) {
+	Warn(ctx, stderrors.New(message))
+}
+
+func annotate(ctx context.Context, annotation Annotation) {
+	exec := executionFrom(ctx)
+	if exec == nil {
+		return
+	}
+
+	annotation.Time = time.Now()
+
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	exec.annotations = append(exec.annotations, annotation)
+}
+
+// Annotations returns the annotations of every node that added any, see NodeResult.Annotations.
+func (result *WalkResult) Annotations() map[string][]Annotation {
+	annotations := make(map[string][]Annotation)
+	for key, node := range result.Nodes {
+		if len(node.Annotations) > 0 {
+			annotations[key] = node.Annotations
+		}
+	}
+	return annotations
+}
+
+// Kind returns the annotations of the given kind, in the order they were added.
+func (result *NodeResult) Kind(kind AnnotationKind) []Annotation {
+	var annotations []Annotation
+	for _, annotation := range result.Annotations {
+		if annotation.Kind == kind {
+			annotations = append(annotations, annotation)
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Annotations(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("deploy", Executable(func(ctx context.Context) error {
+		Annotate(ctx, "version", "1.2.3")
+		AnnotateLink(ctx, "dashboard", "https://example.com/deploy")
+		AnnotateWarning(ctx, "canary was slow")
+		Annotate(ctx, "version", "1.2.4")
+		return nil
+	}))
+	g.AddNode("verify", Executable(func(ctx context.Context) error {
+		if Attempt(ctx) == 1 {
+			Annotate(ctx, "attempt", "first")
+			return fmt.Errorf("flaky")
+		}
+		return nil
+	}))
+	g.AddNode("quiet", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+
+	// Annotating outside of a node does nothing.
+	Annotate(context.Background(), "ignored", "value")
+
+	result, _ := g.Run(context.Background(), &Opts{Parallelism: 1})
+
+	// The times differ from run to run, so only compare what the nodes added.
+	annotations := make(map[string][]Annotation)
+	for key, list := range result.Annotations() {
+		for _, annotation := range list {
+			if annotation.Time.IsZero() {
+				t.Errorf("annotation %v of %q has no time", annotation, key)
+			}
+			annotations[key] = append(annotations[key], Annotation{Kind: annotation.Kind, Name: annotation.Name, Value: annotation.Value})
+		}
+	}
+	tests.Execute(annotations).Equal(t, map[string][]Annotation{
+		"deploy": {
+			{Kind: AnnotationNote, Name: "version", Value: "1.2.3"},
+			{Kind: AnnotationLink, Name: "dashboard", Value: "https://example.com/deploy"},
+			{Kind: AnnotationWarning, Value: "canary was slow"},
+			{Kind: AnnotationNote, Name: "version", Value: "1.2.4"},
+		},
+		"verify": {
+			{Kind: AnnotationNote, Name: "attempt", Value: "first"},
+		},
+	})
+
+	warnings := result.Nodes["deploy"].Kind(AnnotationWarning)
+	tests.Execute(len(warnings)).Equal(t, 1)
+	tests.Execute(warnings[0].Value).Equal(t, "canary was slow")
+	tests.Execute(len(result.Nodes["quiet"].Kind(AnnotationNote))).Equal(t, 0)
+}
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Arena(t *testing.T) {
+	const width = 1000
+
+	build := func(g Graph) Graph {
+		noop := Executable(func(ctx context.Context) error {
+			return nil
+		})
+		g.AddNode("root", noop)
+		g.AddNode("sink", noop)
+		for ix := 0; ix < width; ix++ {
+			key := fmt.Sprintf("node-%d", ix)
+			g.AddNode(key, noop)
+			g.Connect("root", key)
+			g.Connect(key, "sink")
+		}
+		return g
+	}
+
+	arena := NewArena()
+	g := build(NewGraphInArena(arena))
+	tests.Execute(g.Hash()).Equal(t, build(NewGraph()).Hash())
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 8, FreeArena: true})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(len(result.Status(StatusCompleted))).Equal(t, width+2)
+	tests.Execute(len(arena.blocks)).Equal(t, 0)
+
+	// The walk result doesn't refer to the arena, so it can still be used.
+	finishers := result.Graph.Finishers()
+	sort.Strings(finishers)
+	tests.Execute(finishers).Equal(t, []string{"sink"})
+
+	// The arena can be used again once it has been freed.
+	g = build(NewGraphInArena(arena))
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 8})).NoError(t)
+	arena.Free()
+}
+
+func TestNewGraphInArena_Allocations(t *testing.T) {
+	const width = 1000
+
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+	keys := make([]string, width)
+	for ix := range keys {
+		keys[ix] = fmt.Sprintf("node-%d", ix)
+	}
+
+	build := func(g Graph) {
+		g.AddNode("root", noop)
+		for _, key := range keys {
+			g.AddNode(key, noop)
+			g.Connect("root", key)
+		}
+	}
+
+	heap := testing.AllocsPerRun(5, func() {
+		build(NewGraph())
+	})
+	arena := testing.AllocsPerRun(5, func() {
+		arena := NewArena()
+		build(NewGraphInArena(arena))
+		arena.Free()
+	})
+	if arena >= heap {
+		t.Errorf("expected fewer than %.0f allocations in an arena, but got %.0f", heap, arena)
+	}
+}
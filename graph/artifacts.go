@@ -0,0 +1,216 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Artifacts stores named blobs produced by nodes, so nodes can exchange files in a structured way.
+//
+// Artifacts are keyed by the key of the node that produced them and a name chosen by that node.
+type Artifacts interface {
+	// Put returns a writer for the named artifact of the given node. The artifact is only visible once the writer has
+	// been closed.
+	Put(ctx context.Context, key string, name string) (io.WriteCloser, error)
+
+	// Get returns a reader for the named artifact of the given node.
+	Get(ctx context.Context, key string, name string) (io.ReadCloser, error)
+
+	// List returns the names of all the artifacts of the given node.
+	List(ctx context.Context, key string) ([]string, error)
+}
+
+// PutArtifact creates a new artifact for the node executing with the given context.
+func PutArtifact(ctx context.Context, name string) (io.WriteCloser, error) {
+	exec := executionFrom(ctx)
+	if exec == nil || exec.artifacts == nil {
+		return nil, errors.New(nil, MissingArtifacts, "no artifact store is configured for this walk")
+	}
+
+	writer, err := exec.artifacts.Put(ctx, exec.key, name)
+	if err != nil {
+		return nil, err
+	}
+
+	exec.mutex.Lock()
+	exec.produced = append(exec.produced, name)
+	exec.mutex.Unlock()
+	return writer, nil
+}
+
+// GetArtifact reads an artifact produced by the given node, typically an ancestor of the node executing with the given
+// context.
+func GetArtifact(ctx context.Context, key string, name string) (io.ReadCloser, error) {
+	exec := executionFrom(ctx)
+	if exec == nil || exec.artifacts == nil {
+		return nil, errors.New(nil, MissingArtifacts, "no artifact store is configured for this walk")
+	}
+	return exec.artifacts.Get(ctx, key, name)
+}
+
+var _ Artifacts = (*memoryArtifacts)(nil)
+
+// memoryArtifacts keeps artifacts in memory.
+type memoryArtifacts struct {
+	mutex sync.RWMutex
+
+	// artifacts maps node keys to artifact names to artifact contents.
+	artifacts map[string]map[string][]byte
+}
+
+// NewMemoryArtifacts creates an artifact store that keeps everything in memory.
+func NewMemoryArtifacts() Artifacts {
+	return &memoryArtifacts{
+		artifacts: make(map[string]map[string][]byte),
+	}
+}
+
+type memoryWriter struct {
+	bytes.Buffer
+	close func(data []byte)
+}
+
+func (writer *memoryWriter) Close() error {
+	writer.close(writer.Bytes())
+	return nil
+}
+
+func (store *memoryArtifacts) Put(_ context.Context, key string, name string) (io.WriteCloser, error) {
+	return &memoryWriter{
+		close: func(data []byte) {
+			store.mutex.Lock()
+			defer store.mutex.Unlock()
+
+			if _, ok := store.artifacts[key]; !ok {
+				store.artifacts[key] = make(map[string][]byte)
+			}
+			store.artifacts[key][name] = data
+		},
+	}, nil
+}
+
+func (store *memoryArtifacts) Get(_ context.Context, key string, name string) (io.ReadCloser, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	data, ok := store.artifacts[key][name]
+	if !ok {
+		return nil, artifactNotFound(key, name)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (store *memoryArtifacts) List(_ context.Context, key string) ([]string, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	var names []string
+	for name := range store.artifacts[key] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+var _ Artifacts = (*dirArtifacts)(nil)
+
+// dirArtifacts keeps artifacts as files in a local directory.
+type dirArtifacts struct {
+	dir string
+}
+
+// NewDirArtifacts creates an artifact store that writes every artifact to a file within dir. Node keys and artifact
+// names are escaped, so they may contain any characters.
+func NewDirArtifacts(dir string) Artifacts {
+	return &dirArtifacts{dir: dir}
+}
+
+func (store *dirArtifacts) path(key string, name string) string {
+	return filepath.Join(store.dir, escapeFile(key), escapeFile(name))
+}
+
+// escapeFile escapes a node key or artifact name so it can be used as the name of a file within the store. Leading
+// dots are escaped as well, so "." and ".." can't escape the store and names can't clash with temporary files.
+func escapeFile(name string) string {
+	escaped := url.PathEscape(name)
+	if strings.HasPrefix(escaped, ".") {
+		escaped = "%2E" + escaped[1:]
+	}
+	return escaped
+}
+
+// dirWriter writes to a temporary file, and moves it into place when closed.
+type dirWriter struct {
+	*os.File
+	target string
+}
+
+func (writer *dirWriter) Close() error {
+	if err := writer.File.Close(); err != nil {
+		return err
+	}
+	return os.Rename(writer.Name(), writer.target)
+}
+
+func (store *dirArtifacts) Put(_ context.Context, key string, name string) (io.WriteCloser, error) {
+	target := store.path(key, name)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(target), ".artifact-*")
+	if err != nil {
+		return nil, err
+	}
+	return &dirWriter{File: file, target: target}, nil
+}
+
+func (store *dirArtifacts) Get(_ context.Context, key string, name string) (io.ReadCloser, error) {
+	file, err := os.Open(store.path(key, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, artifactNotFound(key, name)
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+func (store *dirArtifacts) List(_ context.Context, key string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(store.dir, escapeFile(key)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Base(entry.Name())[0] == '.' {
+			continue
+		}
+
+		name, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func artifactNotFound(key string, name string) error {
+	err := errors.Newf(nil, ArtifactNotFound, "artifact %q of node %q does not exist", name, key)
+	return errors.Embed(err, NodeKey, key)
+}
@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestArtifacts(t *testing.T) {
+	tcs := map[string]func(t *testing.T) Artifacts{
+		"memory": func(t *testing.T) Artifacts {
+			return NewMemoryArtifacts()
+		},
+		"dir": func(t *testing.T) Artifacts {
+			return NewDirArtifacts(t.TempDir())
+		},
+	}
+
+	for name, store := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var received string
+
+			g := NewGraph()
+			g.AddNode("deploy/a", Executable(func(ctx context.Context) error {
+				writer, err := PutArtifact(ctx, "plan.json")
+				if err != nil {
+					return err
+				}
+				if _, err := io.WriteString(writer, "{}"); err != nil {
+					return err
+				}
+				return writer.Close()
+			}))
+			g.AddNode("b", Executable(func(ctx context.Context) error {
+				reader, err := GetArtifact(ctx, "deploy/a", "plan.json")
+				if err != nil {
+					return err
+				}
+				defer reader.Close()
+
+				data, err := io.ReadAll(reader)
+				received = string(data)
+				return err
+			}))
+			g.Connect("deploy/a", "b")
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 1, Artifacts: store(t)})
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(received).Equal(t, "{}")
+			tests.Execute(result.Nodes["deploy/a"].Artifacts).Equal(t, []string{"plan.json"})
+
+			names, err := result.Artifacts.List(context.Background(), "deploy/a")
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(names).Equal(t, []string{"plan.json"})
+		})
+	}
+}
+
+func TestDirArtifacts_Escape(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "store")
+	store := NewDirArtifacts(dir)
+
+	// Keys and names that look like relative paths must stay within the store.
+	for _, key := range []string{"..", ".", ".hidden"} {
+		for _, name := range []string{"..", ".", ".artifact-x", "../escaped"} {
+			writer, err := store.Put(context.Background(), key, name)
+			tests.ExecuteE(err).NoError(t)
+			_, err = io.WriteString(writer, key+" "+name)
+			tests.ExecuteE(err).NoError(t)
+			tests.ExecuteE(writer.Close()).NoError(t)
+
+			reader, err := store.Get(context.Background(), key, name)
+			tests.ExecuteE(err).NoError(t)
+			data, err := io.ReadAll(reader)
+			tests.ExecuteE(err).NoError(t)
+			tests.ExecuteE(reader.Close()).NoError(t)
+			tests.Execute(string(data)).Equal(t, key+" "+name)
+		}
+
+		names, err := store.List(context.Background(), key)
+		tests.ExecuteE(err).NoError(t)
+		tests.Execute(names).Equal(t, []string{".", "..", "../escaped", ".artifact-x"})
+	}
+
+	// Nothing was written next to the store.
+	entries, err := os.ReadDir(filepath.Dir(dir))
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(len(entries)).Equal(t, 1)
+}
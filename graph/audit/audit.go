@@ -0,0 +1,250 @@
+// Package audit writes a tamper-evident log of the decisions made during walks, for pipelines that have to show who
+// started them, who approved what and how every node ended up.
+//
+// Each record is written as a line of JSON holding the hash of the record before it, and a hash of itself that covers
+// that link. Editing, removing or reordering records breaks the chain, which Verify detects.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pasataleo/go-errors/errors"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+var (
+	Tampered      errors.ErrorCode = "audit.tampered"
+	InvalidRecord errors.ErrorCode = "audit.invalid_record"
+	FailedWrite   errors.ErrorCode = "audit.failed_write"
+
+	Sequence = "audit.sequence"
+)
+
+// Kind identifies what a record describes.
+type Kind string
+
+const (
+	// Triggered records who or what started a walk.
+	Triggered Kind = "walk.triggered"
+
+	// Approved records that someone approved a node, for example a gate that waits for a human.
+	Approved Kind = "node.approved"
+
+	// Cancelled records that a node was cancelled, along with the reason.
+	Cancelled Kind = "node.cancelled"
+
+	// Outcome records how a node ended up.
+	Outcome Kind = "node.outcome"
+
+	// Finished records how a walk ended up.
+	Finished Kind = "walk.finished"
+)
+
+// Trigger describes who or what started a walk.
+type Trigger struct {
+	// Principal identifies the person or system that started the walk.
+	Principal string
+
+	// Reason explains why the walk was started, for example the commit or ticket it was started for.
+	Reason string
+}
+
+// Record is a single entry in the log.
+type Record struct {
+	// Sequence is the position of the record in the log, starting from 1.
+	Sequence int `json:"seq"`
+
+	Kind   Kind   `json:"kind"`
+	WalkID string `json:"walk_id"`
+	Key    string `json:"key,omitempty"`
+
+	// Principal is who triggered the walk or approved the node.
+	Principal string `json:"principal,omitempty"`
+
+	// Detail is the reason for a trigger, approval or cancellation, or the status and error of an outcome.
+	Detail string `json:"detail,omitempty"`
+
+	Time time.Time `json:"time"`
+
+	// Previous is the hash of the record before this one, it is empty for the first record of a log.
+	Previous string `json:"prev"`
+
+	// Hash is the hash of this record, covering every other field.
+	Hash string `json:"hash"`
+}
+
+// hash returns the hash of the record, ignoring the Hash field itself.
+func (record Record) hash() string {
+	record.Hash = ""
+	data, _ := json.Marshal(record) // a Record always marshals.
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Opts contains options for a Log.
+type Opts struct {
+	// Trigger is recorded when the walk starts.
+	Trigger Trigger
+
+	// After is the last record already in the log, so a new Log can continue the chain of an existing one. Verify
+	// returns the records of an existing log.
+	//
+	// Optional, the Log starts a new chain if nil.
+	After *Record
+}
+
+var _ graph.Sink = (*Log)(nil)
+
+// Log is a graph.Sink that writes an audit record for every decision made during a walk: the trigger when it starts,
+// every cancellation, the outcome of every node and the outcome of the walk.
+type Log struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	opts   Opts
+
+	// sequence and previous are the sequence number and hash of the last record written.
+	sequence int
+	previous string
+
+	// err is the first error writing a record, after which nothing else is written.
+	err error
+}
+
+// NewLog creates a new log that writes records to the given writer.
+func NewLog(writer io.Writer, opts Opts) *Log {
+	log := &Log{
+		writer: writer,
+		opts:   opts,
+	}
+	if opts.After != nil {
+		log.sequence = opts.After.Sequence
+		log.previous = opts.After.Hash
+	}
+	return log
+}
+
+// Handle implements graph.Sink.
+func (log *Log) Handle(event graph.Event) {
+	record := Record{
+		WalkID: event.WalkID,
+		Key:    event.Key,
+		Time:   event.Time,
+	}
+
+	switch event.Type {
+	case graph.EventWalkStarted:
+		record.Kind = Triggered
+		record.Principal = log.opts.Trigger.Principal
+		record.Detail = log.opts.Trigger.Reason
+	case graph.EventNodeCancelled:
+		record.Kind = Cancelled
+		record.Detail = string(event.Reason)
+	case graph.EventNodeCompleted:
+		record.Kind = Outcome
+		record.Detail = string(graph.StatusCompleted)
+	case graph.EventNodeErrored:
+		record.Kind = Outcome
+		record.Detail = string(graph.StatusErrored) + ": " + event.Err.Error()
+	case graph.EventNodePruned:
+		record.Kind = Outcome
+		record.Detail = string(graph.StatusPruned)
+	case graph.EventWalkFinished:
+		record.Kind = Finished
+		record.Detail = string(graph.StatusCompleted)
+		if event.Err != nil {
+			record.Detail = string(graph.StatusErrored) + ": " + event.Err.Error()
+		}
+	default:
+		return
+	}
+
+	log.write(record)
+}
+
+// Approve records that the principal approved the given node of the given walk.
+func (log *Log) Approve(walkID string, key string, principal string, reason string) {
+	log.write(Record{
+		Kind:      Approved,
+		WalkID:    walkID,
+		Key:       key,
+		Principal: principal,
+		Detail:    reason,
+		Time:      time.Now(),
+	})
+}
+
+// Err returns the first error the log had writing a record. Nothing is written after an error, as the chain would
+// have a gap in it.
+func (log *Log) Err() error {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	return log.err
+}
+
+func (log *Log) write(record Record) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	if log.err != nil {
+		return
+	}
+
+	record.Sequence = log.sequence + 1
+	record.Previous = log.previous
+	record.Hash = record.hash()
+
+	data, _ := json.Marshal(record)
+	if _, err := log.writer.Write(append(data, '\n')); err != nil {
+		log.err = errors.New(err, FailedWrite, "failed to write audit record")
+		return
+	}
+
+	log.sequence = record.Sequence
+	log.previous = record.Hash
+}
+
+// Verify reads a log and checks that its chain of hashes is intact, returning every record in it. It returns an error
+// with the Tampered code, and the sequence number of the first bad record embedded, if any record was modified,
+// removed, inserted or reordered.
+//
+// Removing records from the end of a log can't be detected from the log alone, compare the hash of the last record
+// with one kept somewhere else for that.
+func Verify(reader io.Reader) ([]Record, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return records, errors.Newf(err, InvalidRecord, "record %d is not valid", len(records)+1)
+		}
+
+		if len(records) > 0 {
+			last := records[len(records)-1]
+			if record.Sequence != last.Sequence+1 || record.Previous != last.Hash {
+				return records, tampered(record, "does not follow record %d", last.Sequence)
+			}
+		}
+		if record.Hash != record.hash() {
+			return records, tampered(record, "does not match its hash")
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return records, errors.New(err, InvalidRecord, "failed to read audit log")
+	}
+	return records, nil
+}
+
+func tampered(record Record, format string, args ...interface{}) error {
+	err := errors.Newf(nil, Tampered, "record %d "+format, append([]interface{}{record.Sequence}, args...)...)
+	return errors.Embed(err, Sequence, record.Sequence)
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+// walk walks a graph where a completes and b fails, writing the audit records to the buffer.
+func walk(t *testing.T, buffer *bytes.Buffer, opts Opts) *Log {
+	g := graph.NewGraph()
+	g.AddNode("a", graph.Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNode("b", graph.Executable(func(ctx context.Context) error {
+		return fmt.Errorf("boom")
+	}))
+	g.Connect("a", "b")
+
+	log := NewLog(buffer, opts)
+	result, err := g.Run(context.Background(), &graph.Opts{Parallelism: 1, Bus: graph.NewBus(log)})
+	tests.ExecuteE(err).Error(t)
+	log.Approve(result.WalkID, "b", "bob", "retry approved")
+	tests.ExecuteE(log.Err()).NoError(t)
+	return log
+}
+
+func kinds(records []Record) []string {
+	var kinds []string
+	for _, record := range records {
+		kinds = append(kinds, fmt.Sprintf("%d %s %s %s %s", record.Sequence, record.Kind, record.Key, record.Principal, record.Detail))
+	}
+	return kinds
+}
+
+func TestLog(t *testing.T) {
+	var buffer bytes.Buffer
+	walk(t, &buffer, Opts{Trigger: Trigger{Principal: "alice", Reason: "release 1.2"}})
+
+	records, err := Verify(&buffer)
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(kinds(records)).Equal(t, []string{
+		"1 walk.triggered  alice release 1.2",
+		"2 node.outcome a  completed",
+		"3 node.outcome b  errored: failed to execute node (boom)",
+		"4 walk.finished   errored: b: failed to execute node (boom)",
+		"5 node.approved b bob retry approved",
+	})
+	tests.Execute(records[0].Previous).Equal(t, "")
+	tests.Execute(records[1].Previous).Equal(t, records[0].Hash)
+}
+
+func TestLog_After(t *testing.T) {
+	var buffer bytes.Buffer
+	walk(t, &buffer, Opts{Trigger: Trigger{Principal: "alice"}})
+
+	records, err := Verify(bytes.NewReader(buffer.Bytes()))
+	tests.ExecuteE(err).NoError(t)
+
+	walk(t, &buffer, Opts{Trigger: Trigger{Principal: "bob"}, After: &records[len(records)-1]})
+	records, err = Verify(&buffer)
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(len(records)).Equal(t, 10)
+	tests.Execute(records[5].Principal).Equal(t, "bob")
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	var buffer bytes.Buffer
+	walk(t, &buffer, Opts{Trigger: Trigger{Principal: "alice"}})
+	lines := strings.SplitAfter(buffer.String(), "\n")
+
+	tcs := map[string]struct {
+		log      string
+		err      string
+		sequence int
+	}{
+		"modified": {
+			log:      strings.Replace(buffer.String(), "alice", "mallory", 1),
+			err:      "record 1 does not match its hash",
+			sequence: 1,
+		},
+		"removed": {
+			log:      lines[0] + lines[2] + lines[3],
+			err:      "record 3 does not follow record 1",
+			sequence: 3,
+		},
+		"reordered": {
+			log:      lines[0] + lines[2] + lines[1],
+			err:      "record 3 does not follow record 1",
+			sequence: 3,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			_, err := Verify(strings.NewReader(tc.log))
+			tests.ExecuteE(err).MatchesError(t, tc.err)
+			tests.Execute(errors.GetErrorCode(err)).Equal(t, Tampered)
+			sequence, _ := errors.GetEmbeddedData[int](err, Sequence)
+			tests.Execute(sequence).Equal(t, tc.sequence)
+		})
+	}
+
+	_, err := Verify(strings.NewReader("not json\n"))
+	tests.Execute(errors.GetErrorCode(err)).Equal(t, InvalidRecord)
+}
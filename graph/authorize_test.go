@@ -0,0 +1,43 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+type principalKey struct{}
+
+func TestGraph_Walk_Authorize(t *testing.T) {
+	var ran []string
+	g := NewGraph()
+	for key, tenant := range map[string]string{"a": "blue", "b": "red", "c": "blue"} {
+		g.AddNodeWithMeta(key, Executable(func(ctx context.Context) error {
+			ran = append(ran, key)
+			return nil
+		}), Meta{Labels: map[string]string{"tenant": tenant}})
+	}
+	g.Connect("a", "b")
+	g.Connect("b", "c")
+
+	ctx := context.WithValue(context.Background(), principalKey{}, "blue")
+	result, err := g.Run(ctx, &Opts{
+		Parallelism: 1,
+		Authorize: func(ctx context.Context, key string, meta Meta) error {
+			principal := ctx.Value(principalKey{}).(string)
+			if meta.Labels["tenant"] != principal {
+				return fmt.Errorf("%s can't run nodes owned by %s", principal, meta.Labels["tenant"])
+			}
+			return nil
+		},
+	})
+	tests.ExecuteE(err).MatchesError(t, "b: not authorized to execute node (blue can't run nodes owned by red); graph is incomplete")
+	tests.Execute(ran).Equal(t, []string{"a"})
+
+	tests.Execute(result.Nodes["b"].Status).Equal(t, StatusErrored)
+	tests.Execute(errors.GetErrorCode(result.Nodes["b"].Err)).Equal(t, Forbidden)
+	tests.Execute(result.Nodes["c"].Status).Equal(t, StatusSkipped)
+}
@@ -0,0 +1,78 @@
+// Package backoff decides how long to wait between attempts at something that failed, so retry policies and node
+// authors share the same handful of strategies rather than each pulling in their own.
+//
+// Strategies compose, so a typical policy is an exponential backoff that is capped and then jittered:
+//
+//	backoff.Jittered(backoff.Capped(backoff.Exponential(100*time.Millisecond, 2), 30*time.Second), 0.5)
+package backoff
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff decides how long to wait before trying again.
+type Backoff interface {
+	// Delay returns how long to wait after the given attempt failed, the first attempt being 1.
+	Delay(attempt int) time.Duration
+}
+
+// Func adapts a simple function into a Backoff.
+type Func func(attempt int) time.Duration
+
+// Delay implements Backoff.
+func (fn Func) Delay(attempt int) time.Duration {
+	return fn(attempt)
+}
+
+// Constant waits the same delay after every attempt.
+func Constant(delay time.Duration) Backoff {
+	return Func(func(attempt int) time.Duration {
+		return delay
+	})
+}
+
+// Exponential waits the base delay after the first attempt, and multiplies it by factor after every attempt after that.
+// The delay saturates rather than overflowing, but should usually be capped with Capped.
+func Exponential(base time.Duration, factor float64) Backoff {
+	return Func(func(attempt int) time.Duration {
+		delay := float64(base) * math.Pow(factor, float64(max(attempt, 1)-1))
+		if delay >= math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return time.Duration(delay)
+	})
+}
+
+// Capped never waits longer than limit, whatever the backoff it wraps says.
+func Capped(backoff Backoff, limit time.Duration) Backoff {
+	return Func(func(attempt int) time.Duration {
+		return min(backoff.Delay(attempt), limit)
+	})
+}
+
+// Jittered randomly shortens the delays of the backoff it wraps by up to the given fraction, so many callers backing
+// off at the same time don't all try again at the same time. A fraction of 1 picks any delay up to the full one.
+func Jittered(backoff Backoff, fraction float64) Backoff {
+	fraction = min(max(fraction, 0), 1)
+	return Func(func(attempt int) time.Duration {
+		delay := backoff.Delay(attempt)
+		return delay - time.Duration(rand.Float64()*fraction*float64(delay))
+	})
+}
+
+// Wait blocks for as long as the backoff says to after the given attempt failed. It returns the error of the context
+// if it is done first.
+func Wait(ctx context.Context, backoff Backoff, attempt int) error {
+	timer := time.NewTimer(backoff.Delay(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
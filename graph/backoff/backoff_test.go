@@ -0,0 +1,60 @@
+package backoff
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestBackoff(t *testing.T) {
+	tcs := map[string]struct {
+		backoff  Backoff
+		expected []time.Duration
+	}{
+		"constant": {
+			backoff:  Constant(time.Second),
+			expected: []time.Duration{time.Second, time.Second, time.Second, time.Second},
+		},
+		"exponential": {
+			backoff:  Exponential(100*time.Millisecond, 2),
+			expected: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond},
+		},
+		"capped": {
+			backoff:  Capped(Exponential(100*time.Millisecond, 3), time.Second),
+			expected: []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var delays []time.Duration
+			for attempt := 1; attempt <= len(tc.expected); attempt++ {
+				delays = append(delays, tc.backoff.Delay(attempt))
+			}
+			tests.Execute(delays).Equal(t, tc.expected)
+		})
+	}
+
+	t.Run("saturated", func(t *testing.T) {
+		tests.Execute(Exponential(time.Hour, 10).Delay(100)).Equal(t, time.Duration(math.MaxInt64))
+	})
+
+	t.Run("jittered", func(t *testing.T) {
+		backoff := Jittered(Constant(time.Second), 0.5)
+		for attempt := 1; attempt <= 100; attempt++ {
+			delay := backoff.Delay(attempt)
+			tests.Execute(delay >= 500*time.Millisecond && delay <= time.Second).Equal(t, true)
+		}
+	})
+}
+
+func TestWait(t *testing.T) {
+	tests.ExecuteE(Wait(context.Background(), Constant(time.Millisecond), 1)).NoError(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests.ExecuteE(Wait(ctx, Constant(time.Hour), 1)).MatchesError(t, "context canceled")
+}
@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// wide returns a graph where a single root fans out to width short nodes, which all fan back in to a single sink.
+func wide(width int) Graph {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	g := NewGraph()
+	g.AddNode("root", noop)
+	g.AddNode("sink", noop)
+	for ix := 0; ix < width; ix++ {
+		key := fmt.Sprintf("node-%d", ix)
+		g.AddNode(key, noop)
+		g.Connect("root", key)
+		g.Connect(key, "sink")
+	}
+	return g
+}
+
+// TestGraph_Walk_Allocations guards the dispatch hot path against regressions, most of what remains is allocated by the
+// worker pool rather than the walker.
+func TestGraph_Walk_Allocations(t *testing.T) {
+	const width = 1000
+
+	g := wide(width)
+	allocs := testing.AllocsPerRun(5, func() {
+		if err := g.Walk(context.Background(), &Opts{Parallelism: 8}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if perNode := allocs / width; perNode > 16 {
+		t.Errorf("expected at most 16 allocations per node, but got %.1f", perNode)
+	}
+}
@@ -0,0 +1,100 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+// walk walks the graph b.N times, failing the benchmark if any walk fails.
+func walk(b *testing.B, g graph.Graph, parallelism int) {
+	b.ReportAllocs()
+	for ix := 0; ix < b.N; ix++ {
+		if err := g.Walk(context.Background(), &graph.Opts{Parallelism: parallelism}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWalk_Wide measures the overhead of scheduling many independent nodes.
+func BenchmarkWalk_Wide(b *testing.B) {
+	for _, width := range []int{100, 1000, 10000} {
+		g := Wide(width, Noop)
+		b.Run(fmt.Sprintf("width=%d", width), func(b *testing.B) {
+			walk(b, g, 8)
+		})
+	}
+}
+
+// BenchmarkWalk_Chain measures the latency of handing a node's completion on to its only child.
+func BenchmarkWalk_Chain(b *testing.B) {
+	for _, depth := range []int{100, 1000, 10000} {
+		g := Chain(depth, Noop)
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			walk(b, g, 8)
+		})
+	}
+}
+
+// BenchmarkWalk_Expanding measures the overhead of merging subgraphs into the walk.
+func BenchmarkWalk_Expanding(b *testing.B) {
+	for _, shape := range []struct {
+		fanout int
+		depth  int
+	}{
+		{fanout: 10, depth: 2},
+		{fanout: 10, depth: 3},
+		{fanout: 2, depth: 10},
+	} {
+		g := Expanding(shape.fanout, shape.depth)
+		b.Run(fmt.Sprintf("fanout=%d/depth=%d", shape.fanout, shape.depth), func(b *testing.B) {
+			walk(b, g, 8)
+		})
+	}
+}
+
+// BenchmarkWalk_Parallelism measures how well the walker keeps workers busy when nodes block, as nodes waiting on I/O
+// would.
+func BenchmarkWalk_Parallelism(b *testing.B) {
+	g := Wide(1000, func() graph.ExecutableNode {
+		return Sleep(100 * time.Microsecond)
+	})
+	for _, parallelism := range []int{1, 16, 256} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			walk(b, g, parallelism)
+		})
+	}
+}
+
+func TestShapes(t *testing.T) {
+	tcs := map[string]struct {
+		graph graph.Graph
+		nodes int
+	}{
+		"wide": {
+			graph: Wide(10, Noop),
+			nodes: 12,
+		},
+		"chain": {
+			graph: Chain(10, Noop),
+			nodes: 10,
+		},
+		"expanding": {
+			graph: Expanding(3, 2),
+			nodes: ExpandedNodes(3, 2),
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			result, err := tc.graph.Run(context.Background(), &graph.Opts{Parallelism: 4})
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(len(result.Nodes)).Equal(t, tc.nodes)
+		})
+	}
+}
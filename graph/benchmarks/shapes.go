@@ -0,0 +1,99 @@
+// Package benchmarks contains the graph shapes the scheduler is benchmarked against, and the benchmarks themselves.
+//
+// The benchmarks are named so that benchstat can group and compare them, for example:
+//
+//	go test -run '^$' -bench . -count 10 ./graph/benchmarks > new.txt
+//	benchstat baseline.txt new.txt
+//
+// See BENCHMARKS.md at the root of the repository for the baseline numbers and what each benchmark measures.
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+// Noop returns a node that does nothing, so walking graphs made of it only measures the walker.
+func Noop() graph.ExecutableNode {
+	return graph.Executable(func(ctx context.Context) error {
+		return nil
+	})
+}
+
+// Sleep returns a node that blocks for the given duration, as a stand in for nodes that wait on I/O.
+func Sleep(d time.Duration) graph.ExecutableNode {
+	return graph.Executable(func(ctx context.Context) error {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// Wide returns a graph where a single root fans out to width nodes, which all fan back in to a single sink.
+func Wide(width int, impl func() graph.ExecutableNode) graph.Graph {
+	g := graph.NewGraph()
+	g.AddNode("root", impl())
+	g.AddNode("sink", impl())
+	for ix := 0; ix < width; ix++ {
+		key := fmt.Sprintf("node-%d", ix)
+		g.AddNode(key, impl())
+		g.Connect("root", key)
+		g.Connect(key, "sink")
+	}
+	return g
+}
+
+// Chain returns a graph of depth nodes where each node depends on the one before it, so nothing can run in parallel.
+func Chain(depth int, impl func() graph.ExecutableNode) graph.Graph {
+	g := graph.NewGraph()
+	for ix := 0; ix < depth; ix++ {
+		key := fmt.Sprintf("node-%d", ix)
+		g.AddNode(key, impl())
+		if ix > 0 {
+			g.Connect(fmt.Sprintf("node-%d", ix-1), key)
+		}
+	}
+	return g
+}
+
+// Expanding returns a graph with a single node that expands into fanout nodes, each of which expands into fanout more
+// nodes until depth levels have been expanded. The leaves are no-ops, so the walk ends with fanout^depth of them.
+func Expanding(fanout int, depth int) graph.Graph {
+	g := graph.NewGraph()
+	g.AddNode("root", expander("root", fanout, depth))
+	return g
+}
+
+func expander(prefix string, fanout int, depth int) graph.ExpandableNode {
+	return graph.Expandable(func(ctx context.Context) (graph.Graph, error) {
+		g := graph.NewGraph()
+		for ix := 0; ix < fanout; ix++ {
+			key := fmt.Sprintf("%s.%d", prefix, ix)
+			if depth > 1 {
+				g.AddNode(key, expander(key, fanout, depth-1))
+				continue
+			}
+			g.AddNode(key, Noop())
+		}
+		return g, nil
+	})
+}
+
+// ExpandedNodes returns the number of nodes a walk of Expanding(fanout, depth) ends up with, including the expanded ones.
+func ExpandedNodes(fanout int, depth int) int {
+	nodes, level := 1, 1
+	for ix := 0; ix < depth; ix++ {
+		level *= fanout
+		nodes += level
+	}
+	return nodes
+}
@@ -0,0 +1,124 @@
+package graph
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Blackboard is a key/value store shared by the nodes of a walk.
+//
+// A node may only read values written by its ancestors, which guarantees the value was written before the node
+// started and prevents hidden data races between nodes that are not ordered relative to each other. Each value may
+// only be written by a single node.
+type Blackboard struct {
+	mutex sync.RWMutex
+
+	// values maps names to the values written, along with the node that wrote them.
+	values map[string]blackboardValue
+}
+
+type blackboardValue struct {
+	value  interface{}
+	writer string
+}
+
+// NewBlackboard creates an empty blackboard.
+func NewBlackboard() *Blackboard {
+	return &Blackboard{
+		values: make(map[string]blackboardValue),
+	}
+}
+
+// Get returns the value with the given name, without any ancestry checks. It is intended to be used once the walk has
+// finished.
+func (blackboard *Blackboard) Get(name string) (interface{}, bool) {
+	blackboard.mutex.RLock()
+	defer blackboard.mutex.RUnlock()
+
+	value, ok := blackboard.values[name]
+	return value.value, ok
+}
+
+// Names returns the names of all the values on the blackboard, sorted.
+func (blackboard *Blackboard) Names() []string {
+	blackboard.mutex.RLock()
+	defer blackboard.mutex.RUnlock()
+
+	names := make([]string, 0, len(blackboard.values))
+	for name := range blackboard.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (blackboard *Blackboard) write(writer string, name string, value interface{}) error {
+	blackboard.mutex.Lock()
+	defer blackboard.mutex.Unlock()
+
+	if existing, ok := blackboard.values[name]; ok && existing.writer != writer {
+		err := errors.Newf(nil, BlackboardConflict, "value %q was already written by node %q", name, existing.writer)
+		return errors.Embed(err, NodeKey, writer)
+	}
+
+	blackboard.values[name] = blackboardValue{
+		value:  value,
+		writer: writer,
+	}
+	return nil
+}
+
+func (blackboard *Blackboard) read(reader string, ancestors map[string]bool, name string) (interface{}, error) {
+	blackboard.mutex.RLock()
+	defer blackboard.mutex.RUnlock()
+
+	value, ok := blackboard.values[name]
+	if !ok {
+		err := errors.Newf(nil, BlackboardMissing, "value %q has not been written", name)
+		return nil, errors.Embed(err, NodeKey, reader)
+	}
+
+	if value.writer != reader && !ancestors[value.writer] {
+		err := errors.Newf(nil, BlackboardViolation, "value %q was written by %q, which is not an ancestor of %q", name, value.writer, reader)
+		return nil, errors.Embed(err, NodeKey, reader)
+	}
+	return value.value, nil
+}
+
+// WriteValue writes a value to the blackboard of the walk, on behalf of the node executing with the given context.
+func WriteValue(ctx context.Context, name string, value interface{}) error {
+	exec := executionFrom(ctx)
+	if exec == nil || exec.blackboard == nil {
+		return errors.New(nil, MissingBlackboard, "no blackboard is configured for this walk")
+	}
+	return exec.blackboard.write(exec.key, name, value)
+}
+
+// ReadValue reads a value from the blackboard of the walk. The value must have been written by an ancestor of the node
+// executing with the given context.
+func ReadValue(ctx context.Context, name string) (interface{}, error) {
+	exec := executionFrom(ctx)
+	if exec == nil || exec.blackboard == nil {
+		return nil, errors.New(nil, MissingBlackboard, "no blackboard is configured for this walk")
+	}
+	return exec.blackboard.read(exec.key, exec.ancestors, name)
+}
+
+// ReadValueAs reads a value from the blackboard, exactly like ReadValue, and converts it to the given type.
+func ReadValueAs[T any](ctx context.Context, name string) (T, error) {
+	var zero T
+
+	value, err := ReadValue(ctx, name)
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := value.(T)
+	if !ok {
+		return zero, errors.Newf(nil, BlackboardViolation, "value %q has type %T, not %T", name, value, zero)
+	}
+	return typed, nil
+}
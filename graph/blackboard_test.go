@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestBlackboard(t *testing.T) {
+	var read int
+	var readErr error
+
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		return WriteValue(ctx, "count", 42)
+	}))
+	g.AddNode("b", Expandable(func(ctx context.Context) (Graph, error) {
+		sub := NewGraph()
+		sub.AddNode("b1", Executable(func(ctx context.Context) error {
+			value, err := ReadValueAs[int](ctx, "count")
+			read = value
+			return err
+		}))
+		return sub, nil
+	}))
+	g.AddNode("c", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNode("d", Executable(func(ctx context.Context) error {
+		_, readErr = ReadValue(ctx, "count")
+		return nil
+	}))
+	g.Connect("a", "b")
+	g.Connect("c", "d")
+	g.Connect("b", "d") // d is a descendant of a through b.
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1, Blackboard: NewBlackboard()})).NoError(t)
+	tests.Execute(read).Equal(t, 42)
+	tests.ExecuteE(readErr).NoError(t)
+}
+
+func TestBlackboard_Violation(t *testing.T) {
+	var readErr error
+
+	// a and b are not connected, so b must not see the value written by a even though it already exists.
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		return WriteValue(ctx, "count", 42)
+	}))
+	g.AddNode("b", Executable(func(ctx context.Context) error {
+		_, readErr = ReadValue(ctx, "count")
+		return nil
+	}))
+
+	blackboard := NewBlackboard()
+	tests.ExecuteE(blackboard.write("a", "count", 42)).NoError(t)
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1, Blackboard: blackboard})).NoError(t)
+	tests.Execute(errors.GetErrorCode(readErr)).Equal(t, BlackboardViolation)
+}
+
+func TestBlackboard_Subgraph(t *testing.T) {
+	var siblingErr, readErr error
+	var read string
+
+	// d only starts once the whole subgraph of b has completed, so it can see the value written by b1. b2 runs
+	// alongside b1, so it can't.
+	g := NewGraph()
+	g.AddNode("b", Expandable(func(ctx context.Context) (Graph, error) {
+		sub := NewGraph()
+		sub.AddNode("b1", Executable(func(ctx context.Context) error {
+			return WriteValue(ctx, "x", "written")
+		}))
+		sub.AddNode("b2", Executable(func(ctx context.Context) error {
+			_, siblingErr = ReadValue(ctx, "x")
+			return nil
+		}))
+		return sub, nil
+	}))
+	g.AddNode("d", Executable(func(ctx context.Context) error {
+		read, readErr = ReadValueAs[string](ctx, "x")
+		return nil
+	}))
+	g.Connect("b", "d")
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1, Blackboard: NewBlackboard()})).NoError(t)
+	tests.ExecuteE(readErr).NoError(t)
+	tests.Execute(read).Equal(t, "written")
+	tests.Execute(errors.GetErrorCode(siblingErr)).Equal(t, BlackboardViolation)
+}
@@ -0,0 +1,116 @@
+package graph
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// CircuitBreaker protects a resource shared by many nodes, such as a database or an API, during an incident. Once
+// enough nodes using the same resource have failed in a row the circuit opens, and nodes using it are skipped rather
+// than dispatched until it has cooled down. See Opts.Breakers.
+//
+// A circuit breaker can be shared between walks, so a resource that fails in one walk is also protected from the
+// others.
+type CircuitBreaker struct {
+	// label is the label identifying the resource a node uses, see Meta.Labels.
+	label string
+
+	// threshold is how many failures in a row open the circuit, and cooldown is how long it stays open.
+	threshold int
+	cooldown  time.Duration
+
+	mutex sync.Mutex
+
+	// failures counts the failures in a row of every resource, and opened records when the circuits that are open were
+	// opened.
+	failures map[string]int
+	opened   map[string]time.Time
+}
+
+// NewCircuitBreaker returns a circuit breaker for the resources identified by the given label. Nodes without the label
+// are not affected. The circuit for a resource opens after threshold nodes using it have failed without a success in
+// between, and stays open for the cooldown. Once it closes, a single failure opens it again until a node succeeds.
+func NewCircuitBreaker(label string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		label:     label,
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		failures:  make(map[string]int),
+		opened:    make(map[string]time.Time),
+	}
+}
+
+// Open returns true if the circuit for the given resource is open at the given time.
+func (breaker *CircuitBreaker) Open(resource string, now time.Time) bool {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	opened, ok := breaker.opened[resource]
+	if !ok {
+		return false
+	}
+	if now.Sub(opened) < breaker.cooldown {
+		return true
+	}
+
+	// The circuit is half open, so the next node is let through but the first failure opens it again.
+	delete(breaker.opened, resource)
+	breaker.failures[resource] = breaker.threshold - 1
+	return false
+}
+
+// record records whether a node using the resource succeeded, and returns true if it opened the circuit.
+func (breaker *CircuitBreaker) record(resource string, failed bool, now time.Time) bool {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if !failed {
+		delete(breaker.failures, resource)
+		return false
+	}
+
+	breaker.failures[resource]++
+	if _, open := breaker.opened[resource]; open || breaker.failures[resource] < breaker.threshold {
+		return false
+	}
+	breaker.opened[resource] = now
+	return true
+}
+
+// tripped returns true if the node uses a resource whose circuit is open, in which case it has been skipped.
+func (walker *walker) tripped(key string, opts *Opts) bool {
+	labels := walker.nodes[key].meta.Labels
+	for _, breaker := range opts.Breakers {
+		resource, ok := labels[breaker.label]
+		if !ok || !breaker.Open(resource, walker.now()) {
+			continue
+		}
+
+		walker.tracer.log(slog.LevelDebug, key, "node skipped", slog.String("reason", "circuit open"), slog.String("resource", resource))
+		ready := walker.Skipped(key)
+
+		err := errors.Newf(nil, OpenCircuit, "circuit for %s %q is open", breaker.label, resource)
+		walker.result.Nodes[key].Err = errors.Embed(errors.Embed(err, NodeKey, key), Resource, resource)
+
+		walker.ready(ready...)
+		return true
+	}
+	return false
+}
+
+// trip records the outcome of a node against the circuits of every resource it uses.
+func (walker *walker) trip(key string, failed bool, opts *Opts) {
+	labels := walker.nodes[key].meta.Labels
+	for _, breaker := range opts.Breakers {
+		resource, ok := labels[breaker.label]
+		if !ok {
+			continue
+		}
+		if breaker.record(resource, failed, walker.now()) {
+			walker.tracer.log(slog.LevelDebug, key, "circuit opened", slog.String("resource", resource))
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, time.January, 3, 13, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker("resource", 2, time.Minute)
+
+	// Successes reset the failures in a row.
+	breaker.record("db", true, now)
+	breaker.record("db", false, now)
+	breaker.record("db", true, now)
+	tests.Execute(breaker.Open("db", now)).Equal(t, false)
+
+	// Failures of one resource don't affect another.
+	tests.Execute(breaker.record("db", true, now)).Equal(t, true)
+	tests.Execute(breaker.Open("db", now.Add(time.Second))).Equal(t, true)
+	tests.Execute(breaker.Open("api", now.Add(time.Second))).Equal(t, false)
+
+	// Once it has cooled down, a single failure opens the circuit again.
+	tests.Execute(breaker.Open("db", now.Add(time.Minute))).Equal(t, false)
+	tests.Execute(breaker.record("db", true, now.Add(time.Minute))).Equal(t, true)
+	tests.Execute(breaker.Open("db", now.Add(time.Minute+time.Second))).Equal(t, true)
+
+	// Unless a node succeeds first.
+	tests.Execute(breaker.Open("db", now.Add(2*time.Minute))).Equal(t, false)
+	tests.Execute(breaker.record("db", false, now.Add(2*time.Minute))).Equal(t, false)
+	tests.Execute(breaker.record("db", true, now.Add(2*time.Minute))).Equal(t, false)
+	tests.Execute(breaker.Open("db", now.Add(2*time.Minute))).Equal(t, false)
+}
+
+func TestGraph_Walk_Breakers(t *testing.T) {
+	var ran []string
+	record := func(key string, err error) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			ran = append(ran, key)
+			return err
+		})
+	}
+	db := Meta{Labels: map[string]string{"resource": "db"}}
+
+	// Nodes run in order of their keys, and only the first two fail.
+	g := NewGraph()
+	g.AddNodeWithMeta("a", record("a", errors.New("db is down")), db)
+	g.AddNodeWithMeta("b", record("b", errors.New("db is down")), db)
+	g.AddNodeWithMeta("c", record("c", nil), db)
+	g.AddNodeWithMeta("d", record("d", nil), db)
+	g.AddNode("e", record("e", nil))
+	g.AddNode("f", record("f", nil))
+	g.Connect("c", "f")
+
+	result, err := g.Run(context.Background(), &Opts{
+		Parallelism: 1,
+		Breakers:    []*CircuitBreaker{NewCircuitBreaker("resource", 2, time.Hour)},
+	})
+	tests.ExecuteE(err).MatchesError(t, "a: failed to execute node (db is down); b: failed to execute node (db is down)")
+	tests.Execute(ran).Equal(t, []string{"a", "b", "e", "f"})
+	tests.Execute(result.Status(StatusSkipped)).Equal(t, []string{"c", "d"})
+	tests.ExecuteE(result.Nodes["c"].Err).MatchesError(t, "circuit for resource \"db\" is open")
+}
@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Ports declares the named inputs a node consumes and the named outputs it produces.
+type Ports struct {
+	// Inputs are the names of the values the node consumes.
+	Inputs []string
+
+	// Outputs are the names of the values the node produces.
+	Outputs []string
+}
+
+// Builder builds a graph whose edges are inferred from the inputs and outputs declared by each node, instead of being
+// connected manually. Every input is connected to the single node that produces an output of the same name.
+type Builder struct {
+	graph Graph
+
+	// ports contains the ports declared by each node.
+	ports map[string]Ports
+}
+
+// NewBuilder creates a new builder.
+func NewBuilder() *Builder {
+	return &Builder{
+		graph: NewGraph(),
+		ports: make(map[string]Ports),
+	}
+}
+
+// AddNode adds a node to the builder, along with the ports it declares.
+func (builder *Builder) AddNode(key string, impl interface{}, ports Ports) error {
+	return builder.AddNodeWithMeta(key, impl, Meta{}, ports)
+}
+
+// AddNodeWithMeta adds a node to the builder, along with its metadata and the ports it declares.
+func (builder *Builder) AddNodeWithMeta(key string, impl interface{}, meta Meta, ports Ports) error {
+	if err := builder.graph.AddNodeWithMeta(key, impl, meta); err != nil {
+		return err
+	}
+	builder.ports[key] = ports
+	return nil
+}
+
+// producers maps every output name to the nodes that produce it.
+func (builder *Builder) producers() map[string][]string {
+	producers := make(map[string][]string)
+	for _, key := range builder.keys() {
+		for _, output := range builder.ports[key].Outputs {
+			producers[output] = append(producers[output], key)
+		}
+	}
+	return producers
+}
+
+func (builder *Builder) keys() []string {
+	keys := make([]string, 0, len(builder.ports))
+	for key := range builder.ports {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate returns an error describing every input that no node produces, and every input that more than one node
+// produces.
+func (builder *Builder) Validate() error {
+	producers := builder.producers()
+
+	var multi error
+	for _, key := range builder.keys() {
+		for _, input := range builder.ports[key].Inputs {
+			var candidates []string
+			for _, producer := range producers[input] {
+				if producer != key {
+					candidates = append(candidates, producer)
+				}
+			}
+
+			switch len(candidates) {
+			case 0:
+				err := errors.Newf(nil, UnsetInput, "input %q of node %q is not produced by any node", input, key)
+				multi = errors.Append(multi, errors.Embed(err, NodeKey, key))
+			case 1:
+				// exactly what we want.
+			default:
+				err := errors.Newf(nil, AmbiguousProducer, "input %q of node %q is produced by multiple nodes: %v", input, key, candidates)
+				multi = errors.Append(multi, errors.Embed(err, NodeKey, key))
+			}
+		}
+	}
+	return multi
+}
+
+// Build validates the declared ports, connects every producer to its consumers and returns the resulting graph. The
+// graph is also checked for cycles.
+func (builder *Builder) Build() (Graph, error) {
+	if err := builder.Validate(); err != nil {
+		return Graph{}, err
+	}
+
+	producers := builder.producers()
+	for _, key := range builder.keys() {
+		for _, input := range builder.ports[key].Inputs {
+			for _, producer := range producers[input] {
+				if producer != key && !builder.graph.connected(producer, key) {
+					if err := builder.graph.Connect(producer, key); err != nil {
+						return Graph{}, err
+					}
+				}
+			}
+		}
+	}
+
+	if err := builder.graph.Validate(); err != nil {
+		return Graph{}, err
+	}
+	return builder.graph, nil
+}
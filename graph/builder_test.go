@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestBuilder(t *testing.T) {
+	var builder strings.Builder
+	write := func(value string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			builder.WriteString(value)
+			return nil
+		})
+	}
+
+	b := NewBuilder()
+	b.AddNode("load", write("c"), Ports{Inputs: []string{"cleaned"}})
+	b.AddNode("fetch", write("a"), Ports{Outputs: []string{"raw"}})
+	b.AddNode("clean", write("b"), Ports{Inputs: []string{"raw"}, Outputs: []string{"cleaned"}})
+
+	g, err := b.Build()
+	tests.ExecuteE(err).NoError(t)
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1})).NoError(t)
+	tests.Execute(builder.String()).Equal(t, "abc")
+}
+
+func TestBuilder_Validate_Error(t *testing.T) {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	tcs := []struct {
+		builder     func(b *Builder)
+		expectedErr string
+	}{
+		{
+			builder: func(b *Builder) {
+				b.AddNode("a", noop, Ports{Inputs: []string{"missing"}})
+			},
+			expectedErr: "input \"missing\" of node \"a\" is not produced by any node",
+		},
+		{
+			builder: func(b *Builder) {
+				b.AddNode("a", noop, Ports{Outputs: []string{"value"}})
+				b.AddNode("b", noop, Ports{Outputs: []string{"value"}})
+				b.AddNode("c", noop, Ports{Inputs: []string{"value"}})
+			},
+			expectedErr: "input \"value\" of node \"c\" is produced by multiple nodes: [a b]",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.expectedErr, func(t *testing.T) {
+			b := NewBuilder()
+			tc.builder(b)
+			tests.ExecuteE(b.Validate()).MatchesError(t, tc.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package graph
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// EventType identifies the kind of event published during a walk.
+type EventType string
+
+const (
+	// EventWalkStarted is published once, before any node in the walk is dispatched.
+	EventWalkStarted EventType = "walk.started"
+
+	// EventWalkFinished is published once, after every node in the walk has been processed.
+	EventWalkFinished EventType = "walk.finished"
+
+	// EventNodeStarted is published when a node is dispatched to a worker.
+	EventNodeStarted EventType = "node.started"
+
+	// EventNodeCompleted is published when a node (and any subgraph it expanded into) has completed.
+	EventNodeCompleted EventType = "node.completed"
+
+	// EventNodeExpanded is published when a node has expanded into a subgraph. Err is set if the expansion was partial.
+	EventNodeExpanded EventType = "node.expanded"
+
+	// EventNodeErrored is published when a node returns an error.
+	EventNodeErrored EventType = "node.errored"
+
+	// EventNodePruned is published when an optional node is skipped to meet the deadline of the walk.
+	EventNodePruned EventType = "node.pruned"
+
+	// EventNodeHeld is published when a ready node is held until its window opens or one of its quotas has room.
+	EventNodeHeld EventType = "node.held"
+
+	// EventNodeSkipped is published when a node is skipped because it became ready outside its window, its circuit was
+	// open or a Debugger skipped it.
+	EventNodeSkipped EventType = "node.skipped"
+
+	// EventNodeCancelled is published when a node is cancelled, either before it was dispatched or while it was
+	// running.
+	EventNodeCancelled EventType = "node.cancelled"
+
+	// EventNodeWarned is published for every warning a node raised through Warn, once the node has finished and before
+	// the event reporting how it finished. Err is the warning.
+	EventNodeWarned EventType = "node.warned"
+
+	// EventNodeStarved is published when a node has been ready for longer than Opts.StarvationThreshold without being
+	// dispatched. It is published at most once for every node.
+	EventNodeStarved EventType = "node.starved"
+)
+
+// Event describes something that happened during a walk.
+type Event struct {
+	// Type is the kind of event.
+	Type EventType
+
+	// WalkID identifies the walk that published the event.
+	WalkID string
+
+	// Key is the key of the node the event relates to. It is empty for walk level events.
+	Key string
+
+	// Owner is the owner of the node the event relates to, see Opts.Route. It is empty for walk level events and nodes
+	// without an owner.
+	Owner string
+
+	// Err is the error associated with the event, if any.
+	Err error
+
+	// Reason explains why the node was cancelled, it is only set for EventNodeCancelled.
+	Reason CancelReason
+
+	// Starved explains why the node is waiting to be dispatched, it is only set for EventNodeStarved.
+	Starved StarveReason
+
+	// Time is the time the event was published.
+	Time time.Time
+}
+
+// Sink receives events published on a Bus.
+//
+// Sinks are called synchronously and in order from the goroutine driving the walk, so they should not block for long.
+type Sink interface {
+	Handle(event Event)
+}
+
+// SinkFunc adapts a simple function into a Sink.
+type SinkFunc func(event Event)
+
+// Handle implements Sink.
+func (fn SinkFunc) Handle(event Event) {
+	fn(event)
+}
+
+var _ Sink = (*Bus)(nil)
+
+// Bus fans out walk events to any number of subscribed sinks.
+//
+// A Bus is itself a Sink, so buses can be subscribed to each other.
+type Bus struct {
+	mutex sync.RWMutex
+
+	// sinks contains the subscribed sinks, keyed by subscription id so they can be removed again.
+	sinks map[int]Sink
+
+	// order records the subscription ids in the order they were subscribed.
+	order []int
+
+	// next is the id that will be assigned to the next subscription.
+	next int
+
+	// snapshot contains the sinks in subscription order. It is rebuilt rather than modified whenever the subscriptions
+	// change, so Publish can iterate over it without copying or holding the lock.
+	snapshot []Sink
+}
+
+// NewBus creates a new bus with the given sinks already subscribed.
+func NewBus(sinks ...Sink) *Bus {
+	bus := &Bus{
+		sinks: make(map[int]Sink),
+	}
+	for _, sink := range sinks {
+		bus.Subscribe(sink)
+	}
+	return bus
+}
+
+// Subscribe adds a sink to the bus. The returned function removes the sink again.
+func (bus *Bus) Subscribe(sink Sink) func() {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	id := bus.next
+	bus.next++
+
+	bus.sinks[id] = sink
+	bus.order = append(bus.order, id)
+	bus.rebuild()
+
+	return func() {
+		bus.mutex.Lock()
+		defer bus.mutex.Unlock()
+
+		delete(bus.sinks, id)
+		for ix, candidate := range bus.order {
+			if candidate == id {
+				bus.order = append(bus.order[:ix], bus.order[ix+1:]...)
+				break
+			}
+		}
+		bus.rebuild()
+	}
+}
+
+// rebuild replaces the snapshot of subscribed sinks. Callers must hold the write lock.
+func (bus *Bus) rebuild() {
+	snapshot := make([]Sink, 0, len(bus.order))
+	for _, id := range bus.order {
+		snapshot = append(snapshot, bus.sinks[id])
+	}
+	bus.snapshot = snapshot
+}
+
+// Publish sends the event to every subscribed sink, in the order they were subscribed.
+func (bus *Bus) Publish(event Event) {
+	bus.mutex.RLock()
+	sinks := bus.snapshot
+	bus.mutex.RUnlock()
+
+	for _, sink := range sinks {
+		sink.Handle(event)
+	}
+}
+
+// Handle implements Sink.
+func (bus *Bus) Handle(event Event) {
+	bus.Publish(event)
+}
+
+// newWalkID generates a random identifier for a walk.
+func newWalkID() string {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		// crypto/rand never fails on supported platforms, fall back to the clock just in case.
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestBus_Walk(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNode("b", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect("a", "b")
+
+	var events []string
+	var buffer bytes.Buffer
+	bus := NewBus(JSONLSink(&buffer))
+	bus.Subscribe(SinkFunc(func(event Event) {
+		events = append(events, strings.TrimSpace(string(event.Type)+" "+event.Key))
+	}))
+
+	var completed []string
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{
+		Parallelism: 1,
+		Bus:         bus,
+		Callbacks: Callbacks{
+			OnComplete: func(key string) {
+				completed = append(completed, key)
+			},
+		},
+	})).NoError(t)
+
+	tests.Execute(events).Equal(t, []string{
+		"walk.started",
+		"node.started a",
+		"node.completed a",
+		"node.started b",
+		"node.completed b",
+		"walk.finished",
+	})
+	tests.Execute(completed).Equal(t, []string{"a", "b"})
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	tests.Execute(len(lines)).Equal(t, len(events))
+	for _, line := range lines {
+		var decoded map[string]interface{}
+		tests.ExecuteE(json.Unmarshal([]byte(line), &decoded)).NoError(t)
+	}
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	var count int
+	bus := NewBus()
+	unsubscribe := bus.Subscribe(SinkFunc(func(event Event) {
+		count++
+	}))
+
+	bus.Publish(Event{Type: EventWalkStarted})
+	unsubscribe()
+	bus.Publish(Event{Type: EventWalkFinished})
+
+	tests.Execute(count).Equal(t, 1)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var mutex sync.Mutex
+	var types []EventType
+	var owners []string
+	release := make(chan struct{})
+
+	// The endpoint doesn't respond until a has run, so the walk would never finish if posting events held it up.
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		<-release
+
+		var event eventJSON
+		if err := json.NewDecoder(request.Body).Decode(&event); err != nil {
+			t.Errorf("failed to decode event: %v", err)
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		types = append(types, event.Type)
+		owners = append(owners, event.Owner)
+	}))
+	defer server.Close()
+
+	g := NewGraph()
+	g.AddNodeWithMeta("a", Executable(func(ctx context.Context) error {
+		close(release)
+		return nil
+	}), Meta{Owner: "infra"})
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1, Bus: NewBus(WebhookSink(server.URL, nil))})).NoError(t)
+
+	// Every event has been posted by the time the walk returns.
+	mutex.Lock()
+	defer mutex.Unlock()
+	tests.Execute(types).Equal(t, []EventType{EventWalkStarted, EventNodeStarted, EventNodeCompleted, EventWalkFinished})
+	tests.Execute(owners).Equal(t, []string{"", "infra", "infra", ""})
+}
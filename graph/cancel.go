@@ -0,0 +1,54 @@
+package graph
+
+import "context"
+
+// CancelReason explains why a node was cancelled.
+type CancelReason string
+
+const (
+	// CancelContext means the context the walk was started with was cancelled.
+	CancelContext CancelReason = "context"
+
+	// CancelFailFast means another node failed while Opts.FailFast was set.
+	CancelFailFast CancelReason = "fail_fast"
+
+	// CancelManual means a node cancelled the walk through WalkHandle.Cancel.
+	CancelManual CancelReason = "manual"
+
+	// CancelBudget means the node was never dispatched because the walk had used up Opts.MaxCost.
+	CancelBudget CancelReason = "budget"
+
+	// CancelQuota means the node was never dispatched because one of its quotas was used up for the walk.
+	CancelQuota CancelReason = "quota"
+
+	// CancelRace means the node lost a race, because a child it was racing to unblock no longer needed it. See
+	// Readiness.Cancel.
+	CancelRace CancelReason = "race"
+
+	// CancelDeadline means the subgraph the node belongs to ran out of time, see SubgraphLimits.
+	CancelDeadline CancelReason = "deadline"
+
+	// CancelFanOut means the node was never dispatched because too many nodes in one of its fan-out groups failed, see
+	// FanOut.MaxFailures.
+	CancelFanOut CancelReason = "fan_out"
+
+	// CancelAborted means the walk was aborted through Debugger.Abort.
+	CancelAborted CancelReason = "aborted"
+)
+
+// cancelCause is the cause the walker cancels its context with, so the reason can be recovered from the context.
+type cancelCause struct {
+	reason CancelReason
+}
+
+func (cause *cancelCause) Error() string {
+	return "walk cancelled: " + string(cause.reason)
+}
+
+// cancelReason returns why the given walk context was cancelled.
+func cancelReason(ctx context.Context) CancelReason {
+	if cause, ok := context.Cause(ctx).(*cancelCause); ok {
+		return cause.reason
+	}
+	return CancelContext
+}
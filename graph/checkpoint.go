@@ -0,0 +1,98 @@
+package graph
+
+import (
+	"log/slog"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Checkpoint records which nodes of a walk completed, along with the version of their definitions, so a later walk of
+// the same graph can resume without running them again. See WalkResult.Checkpoint and Opts.Resume.
+//
+// Checkpoints can be marshalled to JSON to keep them between processes. Checkpoints taken within the same process also
+// carry the outputs the completed nodes set, see Output, so the nodes consuming them can run again when resumed. The
+// outputs are lost when a checkpoint is marshalled, so nodes reading the outputs of resumed nodes fail in a walk
+// resuming from an unmarshalled checkpoint.
+type Checkpoint struct {
+	// Nodes maps the key of every node that completed to the version it had, see Meta.Version.
+	Nodes map[string]string `json:"nodes"`
+
+	// values contains the outputs set by the nodes that completed.
+	values *values
+}
+
+// Checkpoint returns a checkpoint recording every node that completed during the walk, including the nodes that were
+// resumed from an earlier checkpoint.
+func (result *WalkResult) Checkpoint() Checkpoint {
+	checkpoint := Checkpoint{Nodes: make(map[string]string), values: newValues()}
+	for key, node := range result.Nodes {
+		if node.Status != StatusCompleted || node.Err != nil {
+			continue
+		}
+		if result.values != nil {
+			checkpoint.values.copy(result.values, key)
+		}
+
+		var version string
+		if node, ok := result.Graph.nodes[key]; ok {
+			version = node.meta.Version
+		}
+		checkpoint.Nodes[key] = version
+	}
+	return checkpoint
+}
+
+// MigrateFunc decides whether a node that completed in a checkpoint with an older version of its definition is still
+// valid with the current one, see Opts.Migrate. It returns true if the node can be resumed, and false to run it again.
+type MigrateFunc func(key string, from string, to string) (bool, error)
+
+// resumed returns true if the node completed in Opts.Resume and doesn't need to run again, in which case it has been
+// completed. Expandable nodes are never resumed, as the walk needs their subgraphs, but the nodes they expand into can be.
+func (walker *walker) resumed(key string, opts *Opts) bool {
+	if opts.Resume == nil {
+		return false
+	}
+
+	from, ok := opts.Resume.Nodes[key]
+	if !ok {
+		return false
+	}
+
+	node := walker.nodes[key]
+	switch node.impl.(type) {
+	case ExpandableNode, StreamingExpandableNode:
+		return false
+	}
+
+	if to := node.meta.Version; from != to {
+		migrate := opts.Migrate
+		if migrate == nil {
+			return false
+		}
+
+		valid, err := migrate(key, from, to)
+		if err != nil {
+			err = errors.Embed(errors.New(err, FailedNode, "failed to migrate node"), NodeKey, key)
+			walker.fail(key, err, opts)
+			return true
+		}
+		if !valid {
+			walker.tracer.log(slog.LevelDebug, key, "checkpoint invalid", slog.String("from", from), slog.String("to", to))
+			return false
+		}
+	}
+
+	walker.tracer.log(slog.LevelDebug, key, "node resumed", slog.String("version", from))
+	if opts.Resume.values != nil {
+		walker.values.copy(opts.Resume.values, key)
+	}
+	walker.result.Nodes[key] = &NodeResult{
+		Key:    key,
+		Owner:  walker.owner(key),
+		Ready:  walker.readyAt[key],
+		Reused: true,
+	}
+	delete(walker.readyAt, key)
+	walker.ready(walker.Completed(key)...)
+	return true
+}
@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Resume(t *testing.T) {
+	tcs := map[string]struct {
+		version string
+		migrate MigrateFunc
+		ran     []string
+		err     string
+	}{
+		"resumed": {
+			version: "v1",
+			ran:     []string{"c"},
+		},
+		"changed": {
+			version: "v2",
+			ran:     []string{"b", "c"},
+		},
+		"migrated": {
+			version: "v2",
+			migrate: func(key string, from string, to string) (bool, error) {
+				return from == "v1" && to == "v2", nil
+			},
+			ran: []string{"c"},
+		},
+		"failed": {
+			version: "v2",
+			migrate: func(key string, from string, to string) (bool, error) {
+				return false, errors.New("unknown version")
+			},
+			err: "b: failed to migrate node (unknown version); graph is incomplete",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var ran []string
+			build := func(version string, fail bool) Graph {
+				record := func(key string, fail bool) ExecutableNode {
+					return Executable(func(ctx context.Context) error {
+						ran = append(ran, key)
+						if fail {
+							return errors.New("boom")
+						}
+						return nil
+					})
+				}
+
+				g := NewGraph()
+				g.AddNode("a", record("a", false))
+				g.AddNodeWithMeta("b", record("b", false), Meta{Version: version})
+				g.AddNode("c", record("c", fail))
+				g.Connect("a", "b")
+				g.Connect("b", "c")
+				return g
+			}
+
+			// The first walk fails at c, and the checkpoint survives a round trip through JSON.
+			result, err := build("v1", true).Run(context.Background(), &Opts{Parallelism: 1})
+			tests.ExecuteE(err).MatchesError(t, "c: failed to execute node (boom)")
+
+			data, err := json.Marshal(result.Checkpoint())
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(string(data)).Equal(t, `{"nodes":{"a":"","b":"v1"}}`)
+
+			var checkpoint Checkpoint
+			tests.ExecuteE(json.Unmarshal(data, &checkpoint)).NoError(t)
+
+			ran = nil
+			result, err = build(tc.version, false).Run(context.Background(), &Opts{
+				Parallelism: 1,
+				Resume:      &checkpoint,
+				Migrate:     tc.migrate,
+			})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+				return
+			}
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(ran).Equal(t, tc.ran)
+			tests.Execute(result.Nodes["a"].Reused).Equal(t, true)
+			tests.Execute(result.Checkpoint().Nodes).Equal(t, map[string]string{"a": "", "b": tc.version, "c": ""})
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package graph
+
+// Outcome summarises how a walk went as a whole, for exit codes and alerts. See Classifier.
+type Outcome string
+
+const (
+	// OutcomeSuccess means every node completed without raising any warnings.
+	OutcomeSuccess Outcome = "success"
+
+	// OutcomeSuccessWithWarnings means every node completed, but some raised warnings. See Warn.
+	OutcomeSuccessWithWarnings Outcome = "success_with_warnings"
+
+	// OutcomePartialFailure means some nodes failed, but none of them were critical. See Classifier.Critical.
+	OutcomePartialFailure Outcome = "partial_failure"
+
+	// OutcomeFailure means at least one critical node failed.
+	OutcomeFailure Outcome = "failure"
+
+	// OutcomeAborted means the walk was cancelled before it could finish, through its context, WalkHandle.Cancel or
+	// Debugger.Abort.
+	OutcomeAborted Outcome = "aborted"
+)
+
+// ClassifierRule maps the walks it matches to an outcome, see Classifier.Rules.
+type ClassifierRule struct {
+	// Match returns true if the rule applies to the walk.
+	Match func(result *WalkResult) bool
+
+	// Outcome is the outcome of the walks the rule applies to.
+	Outcome Outcome
+}
+
+// Classifier decides the Outcome of walks. Unless one of its rules applies, a walk is:
+//
+//   - aborted if any node was cancelled because the walk was, through its context, WalkHandle.Cancel or Debugger.Abort,
+//   - a failure if any critical node failed,
+//   - a partial failure if any other node failed,
+//   - a success with warnings if any node raised a warning,
+//   - and a success otherwise.
+//
+// Nodes failed if they errored, or if they were cancelled because the walk ran out of budget, quota or time, or too many
+// nodes in one of their fan-out groups failed. Nodes cancelled because the walk failed fast or because they lost a race
+// don't count, as the nodes that caused it are accounted for already.
+//
+// The zero value is ready to use, and classifies every node except optional ones as critical.
+type Classifier struct {
+	// Rules are checked in order before anything else, and the first rule that matches decides the outcome. Use them to
+	// treat warnings as failures, or failures of some nodes as acceptable, for example.
+	//
+	// Optional, walks are classified as described above if none of the rules match.
+	Rules []ClassifierRule
+
+	// Critical decides whether the failure of a node fails the walk, rather than making it a partial failure.
+	//
+	// Defaults to every node that isn't Meta.Optional.
+	Critical func(key string, meta Meta) bool
+}
+
+// Classify returns the outcome of the walk.
+func (classifier Classifier) Classify(result *WalkResult) Outcome {
+	for _, rule := range classifier.Rules {
+		if rule.Match(result) {
+			return rule.Outcome
+		}
+	}
+
+	critical := classifier.Critical
+	if critical == nil {
+		critical = func(key string, meta Meta) bool {
+			return !meta.Optional
+		}
+	}
+
+	outcome := OutcomeSuccess
+	if result.Warnings > 0 {
+		outcome = OutcomeSuccessWithWarnings
+	}
+	for _, key := range result.Keys() {
+		node := result.Nodes[key]
+		switch {
+		case node.Status == StatusCancelled && (node.Reason == CancelContext || node.Reason == CancelManual || node.Reason == CancelAborted):
+			return OutcomeAborted
+		case node.Status == StatusErrored || (node.Status == StatusCancelled && node.Reason != CancelFailFast && node.Reason != CancelRace):
+			meta, _ := result.Graph.Meta(key)
+			if critical(key, meta) {
+				outcome = OutcomeFailure
+			} else if outcome != OutcomeFailure {
+				outcome = OutcomePartialFailure
+			}
+		}
+	}
+	return outcome
+}
+
+// Outcome returns the outcome of the walk, as decided by the zero Classifier.
+func (result *WalkResult) Outcome() Outcome {
+	return Classifier{}.Classify(result)
+}
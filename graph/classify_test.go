@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestClassifier_Classify(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("build", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNodeWithMeta("docs", Executable(func(ctx context.Context) error {
+		return nil
+	}), Meta{Optional: true, Tags: []string{"docs"}})
+
+	result := func(warnings int, statuses map[string]Status, reasons map[string]CancelReason) *WalkResult {
+		result := &WalkResult{Graph: g, Warnings: warnings, Nodes: make(map[string]*NodeResult)}
+		for key, status := range statuses {
+			result.Nodes[key] = &NodeResult{Key: key, Status: status, Reason: reasons[key]}
+		}
+		return result
+	}
+
+	tcs := map[string]struct {
+		classifier Classifier
+		result     *WalkResult
+		want       Outcome
+	}{
+		"success": {
+			result: result(0, map[string]Status{"build": StatusCompleted, "docs": StatusCompleted}, nil),
+			want:   OutcomeSuccess,
+		},
+		"warnings": {
+			result: result(2, map[string]Status{"build": StatusCompleted, "docs": StatusCompleted}, nil),
+			want:   OutcomeSuccessWithWarnings,
+		},
+		"critical failure": {
+			result: result(2, map[string]Status{"build": StatusErrored, "docs": StatusCompleted}, nil),
+			want:   OutcomeFailure,
+		},
+		"optional failure": {
+			result: result(0, map[string]Status{"build": StatusCompleted, "docs": StatusErrored}, nil),
+			want:   OutcomePartialFailure,
+		},
+		"out of budget": {
+			result: result(0, map[string]Status{"build": StatusCancelled, "docs": StatusCompleted}, map[string]CancelReason{"build": CancelBudget}),
+			want:   OutcomeFailure,
+		},
+		"lost race": {
+			result: result(0, map[string]Status{"build": StatusCompleted, "docs": StatusCancelled}, map[string]CancelReason{"docs": CancelRace}),
+			want:   OutcomeSuccess,
+		},
+		"aborted": {
+			result: result(0, map[string]Status{"build": StatusErrored, "docs": StatusCancelled}, map[string]CancelReason{"docs": CancelAborted}),
+			want:   OutcomeAborted,
+		},
+		"critical tags": {
+			classifier: Classifier{
+				Critical: func(key string, meta Meta) bool {
+					return len(meta.Tags) > 0
+				},
+			},
+			result: result(0, map[string]Status{"build": StatusErrored, "docs": StatusCompleted}, nil),
+			want:   OutcomePartialFailure,
+		},
+		"warnings fail": {
+			classifier: Classifier{
+				Rules: []ClassifierRule{{
+					Match: func(result *WalkResult) bool {
+						return result.Warnings > 0
+					},
+					Outcome: OutcomeFailure,
+				}},
+			},
+			result: result(1, map[string]Status{"build": StatusCompleted, "docs": StatusCompleted}, nil),
+			want:   OutcomeFailure,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			tests.Execute(tc.classifier.Classify(tc.result)).Equal(t, tc.want)
+		})
+	}
+}
+
+func TestWalkResult_Outcome(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("build", Executable(func(ctx context.Context) error {
+		Warn(ctx, fmt.Errorf("cache unavailable"))
+		return nil
+	}))
+	g.AddNodeWithMeta("docs", Executable(func(ctx context.Context) error {
+		return fmt.Errorf("broken link")
+	}), Meta{Optional: true})
+
+	result, _ := g.Run(context.Background(), &Opts{Parallelism: 1})
+	tests.Execute(result.Outcome()).Equal(t, OutcomePartialFailure)
+}
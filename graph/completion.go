@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"context"
+	"sync"
+)
+
+// CompletionStore is shared between walks so that nodes doing the same work, identified by the same Meta.Fingerprint,
+// only execute once even if they appear in several graphs. This suits many pipelines that share common setup steps.
+//
+// Only successful executions are shared. If the node that executed fails, the next node with the same fingerprint
+// executes instead.
+type CompletionStore interface {
+	// Acquire returns true if the caller should execute the node with the given fingerprint. If a node with the same
+	// fingerprint is already executing, Acquire blocks until it finishes. It returns false once a node with the
+	// fingerprint has completed.
+	Acquire(ctx context.Context, fingerprint string) (bool, error)
+
+	// Complete records the result of executing the node with the given fingerprint after Acquire returned true.
+	Complete(ctx context.Context, fingerprint string, err error) error
+}
+
+var _ CompletionStore = (*MemoryCompletionStore)(nil)
+
+// MemoryCompletionStore is a CompletionStore that keeps track of completions in memory, so it can be shared between
+// walks in the same process.
+type MemoryCompletionStore struct {
+	mutex   sync.Mutex
+	entries map[string]*completion
+}
+
+// completion tracks a single fingerprint. done is closed when the node executing it finishes.
+type completion struct {
+	done      chan struct{}
+	completed bool
+}
+
+// NewMemoryCompletionStore returns a new, empty MemoryCompletionStore.
+func NewMemoryCompletionStore() *MemoryCompletionStore {
+	return &MemoryCompletionStore{
+		entries: make(map[string]*completion),
+	}
+}
+
+// Acquire implements CompletionStore.
+func (store *MemoryCompletionStore) Acquire(ctx context.Context, fingerprint string) (bool, error) {
+	for {
+		store.mutex.Lock()
+		entry, ok := store.entries[fingerprint]
+		if !ok {
+			store.entries[fingerprint] = &completion{done: make(chan struct{})}
+			store.mutex.Unlock()
+			return true, nil
+		}
+		store.mutex.Unlock()
+
+		select {
+		case <-entry.done:
+			if entry.completed {
+				return false, nil
+			}
+			// The node failed, so try to acquire it again.
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// Complete implements CompletionStore.
+func (store *MemoryCompletionStore) Complete(ctx context.Context, fingerprint string, err error) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry, ok := store.entries[fingerprint]
+	if !ok {
+		return nil
+	}
+	if err == nil {
+		entry.completed = true
+	} else {
+		delete(store.entries, fingerprint)
+	}
+	close(entry.done)
+	return nil
+}
@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Completions(t *testing.T) {
+	var setups atomic.Int32
+	store := NewMemoryCompletionStore()
+
+	pipeline := func(name string) Graph {
+		g := NewGraph()
+		g.AddNodeWithMeta("setup", Executable(func(ctx context.Context) error {
+			setups.Add(1)
+			return nil
+		}), Meta{Fingerprint: "setup-v1"})
+		g.AddNode(name, Executable(func(ctx context.Context) error {
+			return nil
+		}))
+		g.Connect("setup", name)
+		return g
+	}
+
+	var wait sync.WaitGroup
+	results := make([]*WalkResult, 5)
+	for ix := range results {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+
+			result, err := pipeline("build").Run(context.Background(), &Opts{Parallelism: 1, Completions: store})
+			tests.ExecuteE(err).NoError(t)
+			results[ix] = result
+		}()
+	}
+	wait.Wait()
+
+	tests.Execute(setups.Load()).Equal(t, int32(1))
+
+	reused := 0
+	for _, result := range results {
+		tests.Execute(result.Nodes["setup"].Status).Equal(t, StatusCompleted)
+		if result.Nodes["setup"].Reused {
+			reused++
+		}
+	}
+	tests.Execute(reused).Equal(t, 4)
+}
+
+func TestGraph_Walk_Completions_Failed(t *testing.T) {
+	store := NewMemoryCompletionStore()
+
+	run := func(err error) (*WalkResult, error) {
+		g := NewGraph()
+		g.AddNodeWithMeta("setup", Executable(func(ctx context.Context) error {
+			return err
+		}), Meta{Fingerprint: "setup-v1"})
+		return g.Run(context.Background(), &Opts{Parallelism: 1, Completions: store})
+	}
+
+	// Failures aren't shared, so the next walk tries again.
+	_, err := run(errors.New("boom"))
+	tests.ExecuteE(err).MatchesError(t, "setup: failed to execute node (boom)")
+
+	result, err := run(nil)
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(result.Nodes["setup"].Reused).Equal(t, false)
+
+	result, err = run(errors.New("not executed"))
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(result.Nodes["setup"].Reused).Equal(t, true)
+}
@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// contextKey is the type of the keys this package stores in node contexts.
+type contextKey int
+
+const (
+	executionKey contextKey = iota
+	speculativeCopyKey
+)
+
+// execution contains the per-node state that is made available to node implementations through their context.
+type execution struct {
+	// mutex protects the fields the node itself may update while it executes.
+	mutex sync.Mutex
+
+	// key is the key of the executing node.
+	key string
+
+	// walkID identifies the walk the node is executing in.
+	walkID string
+
+	// attempt is the attempt number of this execution, starting from 1, and previous is the error the previous attempt
+	// failed with.
+	attempt  int
+	previous error
+
+	// cancel cancels the node on its own if it loses a race, it is nil unless the node is racing. See Readiness.Cancel.
+	cancel context.CancelCauseFunc
+
+	// started is when a worker started running the node, it is set by the worker.
+	started time.Time
+
+	// reused records whether the node was skipped because a node with the same fingerprint had already completed, it is
+	// set by the worker.
+	reused bool
+
+	// speculated records whether a speculative copy of the node was started, it is set by the worker.
+	speculated bool
+
+	// baseLogger is the logger of the walk. logger is derived from it the first time the node asks for it, most nodes
+	// never log and building it is one of the more expensive parts of dispatching a node.
+	baseLogger *slog.Logger
+	logger     *slog.Logger
+
+	// stdout and stderr capture the output of the node, they are allocated the first time the node asks for them.
+	stdout *output
+	stderr *output
+
+	// cost is the cost the node reported through AddCost.
+	cost float64
+
+	// annotations contains the annotations the node added, see Annotate, and warnings the warnings it raised, see Warn.
+	annotations []Annotation
+	warnings    []error
+
+	// env is the resolved environment of the node, it is set by the worker before the node runs.
+	env map[string]string
+
+	// artifacts is the artifact store of the walk, and produced records the names of the artifacts this node put.
+	artifacts Artifacts
+	produced  []string
+
+	// blackboard is the blackboard of the walk, and ancestors contains every node this node may read values from.
+	blackboard *Blackboard
+	ancestors  map[string]bool
+
+	// references resolves the references to nodes in other graphs, see Graph.ConnectRef.
+	references ReferenceResolver
+
+	// values contains the typed outputs set by the nodes of the walk.
+	values *values
+
+	// handle gives the node restricted access to the walk.
+	handle WalkHandle
+}
+
+func withExecution(ctx context.Context, exec *execution) context.Context {
+	return context.WithValue(ctx, executionKey, exec)
+}
+
+func executionFrom(ctx context.Context) *execution {
+	exec, _ := ctx.Value(executionKey).(*execution)
+	if exec == nil {
+		return nil
+	}
+	if candidate, ok := ctx.Value(speculativeCopyKey).(*speculativeCopy); ok {
+		return candidate.execution(exec)
+	}
+	return exec
+}
+
+// Logger returns the logger for the node executing with the given context. The logger carries the node key, walk ID
+// and attempt number as attributes.
+//
+// If the context doesn't belong to a node, the default logger is returned.
+func Logger(ctx context.Context) *slog.Logger {
+	if exec := executionFrom(ctx); exec != nil {
+		exec.mutex.Lock()
+		defer exec.mutex.Unlock()
+
+		if exec.logger == nil {
+			exec.logger = exec.baseLogger.With(
+				slog.String("key", exec.key),
+				slog.String("walk_id", exec.walkID),
+				slog.Int("attempt", exec.attempt))
+		}
+		return exec.logger
+	}
+	return slog.Default()
+}
+
+// Attempt returns the attempt number of the node executing with the given context, starting from 1. It is only more
+// than 1 if the node is being retried, see ChildRetry.
+//
+// If the context doesn't belong to a node, 0 is returned.
+func Attempt(ctx context.Context) int {
+	if exec := executionFrom(ctx); exec != nil {
+		return exec.attempt
+	}
+	return 0
+}
+
+// PreviousError returns the error the previous attempt of the node executing with the given context failed with, so
+// a retried node can adjust what it does, for example by falling back to another endpoint. It returns nil on the first
+// attempt, and if the context doesn't belong to a node.
+func PreviousError(ctx context.Context) error {
+	if exec := executionFrom(ctx); exec != nil {
+		return exec.previous
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestLogger(t *testing.T) {
+	var buffer bytes.Buffer
+
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		Logger(ctx).Info("hello")
+		return nil
+	}))
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{
+		Parallelism: 1,
+		Logger:      slog.New(slog.NewJSONHandler(&buffer, nil)),
+	})).NoError(t)
+
+	var record map[string]interface{}
+	tests.ExecuteE(json.Unmarshal(buffer.Bytes(), &record)).NoError(t)
+	tests.Execute(record["msg"]).Equal(t, interface{}("hello"))
+	tests.Execute(record["key"]).Equal(t, interface{}("a"))
+	tests.Execute(record["attempt"]).Equal(t, interface{}(float64(1)))
+	tests.Execute(len(record["walk_id"].(string))).NotEqual(t, 0)
+}
+
+func TestStdout(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		fmt.Fprint(Stdout(ctx), "out a")
+		return nil
+	}))
+	g.AddNode("b", Executable(func(ctx context.Context) error {
+		fmt.Fprint(Stderr(ctx), "err b")
+		return fmt.Errorf("failed")
+	}))
+	g.AddNode("c", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect("a", "b")
+	g.Connect("b", "c")
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 1})
+	tests.ExecuteE(err).Error(t)
+
+	tests.Execute(string(result.Nodes["a"].Stdout)).Equal(t, "out a")
+	tests.Execute(string(result.Nodes["b"].Stderr)).Equal(t, "err b")
+	tests.Execute(result.Status(StatusCompleted)).Equal(t, []string{"a"})
+	tests.Execute(result.Status(StatusErrored)).Equal(t, []string{"b"})
+	tests.Execute(result.Status(StatusSkipped)).Equal(t, []string{"c"})
+}
+
+func TestAttempt(t *testing.T) {
+	var attempts []string
+
+	g := NewGraph()
+	g.AddNode("deploy", &region{
+		deploy: func(ctx context.Context, region string) error {
+			if region != "us" {
+				return nil
+			}
+
+			attempt := Attempt(ctx)
+			attempts = append(attempts, fmt.Sprintf("%d: %v", attempt, PreviousError(ctx)))
+			if attempt < 3 {
+				return fmt.Errorf("attempt %d failed", attempt)
+			}
+			return nil
+		},
+		handle: func(child string, attempt int, err error) ChildErrorAction {
+			return ChildRetry
+		},
+	})
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1})).NoError(t)
+	tests.Execute(attempts).Equal(t, []string{
+		"1: <nil>",
+		"2: failed to execute node (attempt 1 failed)",
+		"3: failed to execute node (attempt 2 failed)",
+	})
+
+	// Outside of a node there is no attempt.
+	tests.Execute(Attempt(context.Background())).Equal(t, 0)
+	tests.ExecuteE(PreviousError(context.Background())).NoError(t)
+}
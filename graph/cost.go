@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// AddCost adds to the cost of the node executing with the given context, such as the cloud spend or API credits it
+// used. The costs of every node are added up into the cost of the walk, which is limited by Opts.MaxCost.
+//
+// Nodes may call AddCost any number of times. Costs added by nodes that fail still count towards the walk. It does
+// nothing if the context doesn't belong to a node.
+func AddCost(ctx context.Context, cost float64) {
+	exec := executionFrom(ctx)
+	if exec == nil {
+		return
+	}
+
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	exec.cost += cost
+}
+
+// spend adds the cost of a finished node to the walk, and stops dispatching once the budget is exhausted.
+func (walker *walker) spend(key string, cost float64) {
+	if cost == 0 {
+		return
+	}
+
+	walker.result.Cost += cost
+	if walker.budget > 0 && !walker.exhausted && walker.result.Cost >= walker.budget {
+		walker.tracer.log(slog.LevelDebug, key, "budget exhausted",
+			slog.Float64("cost", walker.result.Cost),
+			slog.Float64("budget", walker.budget))
+		walker.exhausted = true
+	}
+}
+
+// overBudget returns an ExceededBudget error describing how much the walk spent.
+func (walker *walker) overBudget() error {
+	err := errors.Newf(nil, ExceededBudget, "walk exceeded its budget of %g (spent %g)", walker.budget, walker.result.Cost)
+	return errors.Embed(err, Cost, walker.result.Cost)
+}
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_MaxCost(t *testing.T) {
+	tcs := map[string]struct {
+		budget    float64
+		err       string
+		cost      float64
+		cancelled []string
+	}{
+		"unbudgeted": {
+			cost: 6,
+		},
+		"within budget": {
+			budget: 10,
+			cost:   6,
+		},
+		"exhausted": {
+			budget:    3,
+			err:       "walk exceeded its budget of 3 (spent 3)",
+			cost:      3,
+			cancelled: []string{"c", "d"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			// a -> b -> c -> d, costing 1, 2, 3 and 0.
+			g := NewGraph()
+			for ix, key := range []string{"a", "b", "c", "d"} {
+				cost := float64((ix + 1) % 4)
+				g.AddNode(key, Executable(func(ctx context.Context) error {
+					AddCost(ctx, cost)
+					return nil
+				}))
+			}
+			g.Connect("a", "b")
+			g.Connect("b", "c")
+			g.Connect("c", "d")
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 1, MaxCost: tc.budget})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+				tests.Execute(errors.GetErrorCode(err)).Equal(t, ExceededBudget)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+			tests.Execute(result.Cost).Equal(t, tc.cost)
+			tests.Execute(result.Nodes["b"].Cost).Equal(t, 2.0)
+			tests.Execute(result.Status(StatusCancelled)).Equal(t, tc.cancelled)
+			for _, key := range tc.cancelled {
+				tests.Execute(result.Nodes[key].Reason).Equal(t, CancelBudget)
+			}
+		})
+	}
+}
+
+func TestGraph_Walk_MaxCost_Failed(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		AddCost(ctx, 5)
+		return context.DeadlineExceeded
+	}))
+	g.AddNode("b", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+
+	// Failed nodes still spend the budget.
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 1, MaxCost: 5})
+	tests.ExecuteE(err).MatchesError(t, "a: failed to execute node (context deadline exceeded); walk exceeded its budget of 5 (spent 5)")
+	tests.Execute(result.Cost).Equal(t, 5.0)
+	tests.Execute(result.Nodes["b"].Reason).Equal(t, CancelBudget)
+}
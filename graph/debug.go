@@ -0,0 +1,418 @@
+package graph
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-threading/threading"
+)
+
+// StopReason explains why a walk stopped, see Debugger.
+type StopReason string
+
+const (
+	// StopBreakpoint means a node with a breakpoint is about to run.
+	StopBreakpoint StopReason = "breakpoint"
+
+	// StopStep means the walk is in Opts.StepMode, and a node is about to run.
+	StopStep StopReason = "step"
+
+	// StopError means a node with a breakpoint failed, or any node failed in Opts.StepMode or while the debugger breaks
+	// on errors. See Debugger.BreakOnError.
+	StopError StopReason = "error"
+)
+
+// Stop describes where a walk stopped.
+type Stop struct {
+	// Reason is why the walk stopped.
+	Reason StopReason
+
+	// Key is the key of the node the walk stopped at.
+	Key string
+
+	// Err is the error the node failed with, it is only set if the Reason is StopError.
+	Err error
+}
+
+// DebugState describes the state of a walk, as seen by a Debugger.
+type DebugState struct {
+	// Stop is where the walk is stopped, it is nil while the walk is running.
+	Stop *Stop
+
+	// Nodes is what has happened so far to every node that has been dispatched, see WalkResult.Nodes. A node the walk
+	// stopped at because it failed is errored, even though the walk might still skip or retry it.
+	Nodes map[string]NodeResult
+
+	// Pending contains the nodes that are ready but haven't been dispatched yet, in the order they will be.
+	Pending []string
+}
+
+// Debugger stops a walk at breakpoints, so whoever is debugging it can inspect its state and decide what happens next.
+//
+// Pass the debugger to a walk through Opts.Debugger. The walk stops whenever a node with a breakpoint is about to run,
+// and again if it fails. While the walk is stopped nodes that are already running are left to finish, but nothing else
+// is dispatched until Continue, Skip or Retry is called. The stop is reported on Stops once they have finished, so the
+// state of the walk doesn't change while it is inspected. Nodes that fail while the walk is already stopped fail as
+// usual, and cancelling or aborting the walk cancels the nodes waiting for it to resume. Calls to Inspect, Continue, Skip and
+// Retry block until the walk has started and handled them, so they are usually made from a separate goroutine. A
+// Debugger can only be used by a single walk.
+type Debugger struct {
+	mutex sync.Mutex
+
+	// keys and tags are the breakpoints, and failures is set if every failure stops the walk.
+	keys     map[string]bool
+	tags     map[string]bool
+	failures bool
+
+	// stops reports where the walk stopped, and commands carry requests to the walker.
+	stops    chan Stop
+	commands chan debugCommand
+
+	// finished is closed by the walker once the walk is over.
+	finished     chan struct{}
+	finishedOnce sync.Once
+}
+
+// debugCommand asks the walker to do something on behalf of a Debugger.
+type debugCommand struct {
+	kind  debugCommandKind
+	reply chan debugReply
+}
+
+type debugCommandKind int
+
+const (
+	debugInspect debugCommandKind = iota
+	debugContinue
+	debugSkip
+	debugRetry
+	debugAbort
+)
+
+type debugReply struct {
+	state DebugState
+	err   error
+}
+
+// NewDebugger creates a new debugger without any breakpoints.
+func NewDebugger() *Debugger {
+	return &Debugger{
+		keys:     make(map[string]bool),
+		tags:     make(map[string]bool),
+		stops:    make(chan Stop, 1),
+		commands: make(chan debugCommand),
+		finished: make(chan struct{}),
+	}
+}
+
+// Break sets a breakpoint on the nodes with the given keys.
+func (debugger *Debugger) Break(keys ...string) {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	for _, key := range keys {
+		debugger.keys[key] = true
+	}
+}
+
+// BreakOnTag sets a breakpoint on every node with any of the given tags.
+func (debugger *Debugger) BreakOnTag(tags ...string) {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	for _, tag := range tags {
+		debugger.tags[tag] = true
+	}
+}
+
+// BreakOnError stops the walk as soon as any node fails, instead of recording the failure and carrying on. Whoever is
+// debugging the walk can then retry the node, skip it or abort the walk.
+func (debugger *Debugger) BreakOnError() {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	debugger.failures = true
+}
+
+// Clear removes every breakpoint, including BreakOnError, so the walk runs to the end once it is continued.
+func (debugger *Debugger) Clear() {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	clear(debugger.keys)
+	clear(debugger.tags)
+	debugger.failures = false
+}
+
+// Stops reports every time the walk stops. Only the latest stop is kept, and the channel is closed once the walk is
+// over.
+func (debugger *Debugger) Stops() <-chan Stop {
+	return debugger.stops
+}
+
+// Inspect returns the current state of the walk, whether it is stopped or not.
+//
+// Inspect returns an error with the ClosedDebugger code if the walk is over.
+func (debugger *Debugger) Inspect() (DebugState, error) {
+	return debugger.send(debugInspect)
+}
+
+// Continue resumes the walk. A node that was about to run runs, and a node that failed is reported as failed.
+//
+// Continue returns an error with the NotStopped code if the walk isn't stopped, and the ClosedDebugger code if the walk
+// is over.
+func (debugger *Debugger) Continue() error {
+	_, err := debugger.send(debugContinue)
+	return err
+}
+
+// Skip resumes the walk without running the node it stopped at, or ignoring its failure if it failed. The node is
+// recorded as skipped, and the nodes that depend on it run as if it had completed.
+//
+// Skip returns an error with the NotStopped code if the walk isn't stopped, and the ClosedDebugger code if the walk is
+// over.
+func (debugger *Debugger) Skip() error {
+	_, err := debugger.send(debugSkip)
+	return err
+}
+
+// Retry resumes the walk by running the node it stopped at again, which is only possible if it failed. The node sees
+// the error of its previous attempt through PreviousError.
+//
+// Retry returns an error with the NotStopped code if the walk isn't stopped at a failed node, and the ClosedDebugger
+// code if the walk is over.
+func (debugger *Debugger) Retry() error {
+	_, err := debugger.send(debugRetry)
+	return err
+}
+
+// Abort cancels the walk with CancelAborted, whether it is stopped or not. Nodes that are running have their context
+// cancelled, nodes that haven't started are never dispatched, and a node the walk stopped at because it failed is
+// reported as failed.
+//
+// Abort returns an error with the ClosedDebugger code if the walk is over.
+func (debugger *Debugger) Abort() error {
+	_, err := debugger.send(debugAbort)
+	return err
+}
+
+func (debugger *Debugger) send(kind debugCommandKind) (DebugState, error) {
+	reply := make(chan debugReply)
+	select {
+	case debugger.commands <- debugCommand{kind: kind, reply: reply}:
+		reply := <-reply
+		return reply.state, reply.err
+	case <-debugger.finished:
+		return DebugState{}, errors.New(nil, ClosedDebugger, "walk is over")
+	}
+}
+
+// fails returns true if the node that failed has a breakpoint, or every failure stops the walk.
+func (debugger *Debugger) fails(key string, meta Meta) bool {
+	debugger.mutex.Lock()
+	failures := debugger.failures
+	debugger.mutex.Unlock()
+	return failures || debugger.breaks(key, meta)
+}
+
+// breaks returns true if the node has a breakpoint.
+func (debugger *Debugger) breaks(key string, meta Meta) bool {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+
+	if debugger.keys[key] {
+		return true
+	}
+	for _, tag := range meta.Tags {
+		if debugger.tags[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// report replaces whatever stop hasn't been received yet with the given one. The walker is the only sender, so the send
+// never blocks.
+func (debugger *Debugger) report(stop Stop) {
+	select {
+	case <-debugger.stops:
+	default:
+	}
+	debugger.stops <- stop
+}
+
+// finish is called by the walker once the walk is over, so any pending commands fail instead of blocking forever.
+func (debugger *Debugger) finish() {
+	debugger.finishedOnce.Do(func() {
+		close(debugger.finished)
+		close(debugger.stops)
+	})
+}
+
+// commands returns the channel commands from Opts.Debugger arrive on, it is nil if the walk isn't being debugged.
+func (walker *walker) commands(opts *Opts) <-chan debugCommand {
+	if opts.Debugger == nil {
+		return nil
+	}
+	return opts.Debugger.commands
+}
+
+// breakpoint returns true if the node has a breakpoint or the walk is in step mode, in which case the walk has stopped
+// before it could run. The node keeps its worker until the walk continues.
+func (walker *walker) breakpoint(key string, opts *Opts) bool {
+	if opts.Debugger == nil {
+		return false
+	}
+	if walker.released[key] {
+		delete(walker.released, key)
+		return false
+	}
+
+	reason := StopBreakpoint
+	if !opts.Debugger.breaks(key, walker.nodes[key].meta) {
+		if !opts.StepMode {
+			return false
+		}
+		reason = StopStep
+	}
+
+	walker.parked = append(walker.parked, key)
+	walker.halt(Stop{Reason: reason, Key: key}, opts)
+	return true
+}
+
+// caught returns true if the node that failed has a breakpoint, the debugger breaks on errors or the walk is in step
+// mode, in which case the walk has stopped instead of recording the failure. Nodes that fail while the walk is already
+// stopped fail as usual.
+func (walker *walker) caught(key string, err error, opts *Opts) bool {
+	if opts.Debugger == nil || walker.stop != nil {
+		return false
+	}
+	if !opts.StepMode && !opts.Debugger.fails(key, walker.nodes[key].meta) {
+		return false
+	}
+
+	walker.halt(Stop{Reason: StopError, Key: key, Err: err}, opts)
+	return true
+}
+
+// halt stops the walk, nothing else is dispatched until it is resumed.
+func (walker *walker) halt(stop Stop, opts *Opts) {
+	walker.tracer.log(slog.LevelDebug, stop.Key, "walk stopped", slog.String("reason", string(stop.Reason)))
+	walker.stop, walker.reported = &stop, false
+	walker.drained(opts)
+}
+
+// drained reports the stop to the debugger once the nodes that were running when the walk stopped have finished.
+func (walker *walker) drained(opts *Opts) {
+	if walker.stop == nil || walker.reported {
+		return
+	}
+	for key := range walker.processing {
+		if key != walker.stop.Key && !slices.Contains(walker.parked, key) {
+			return
+		}
+	}
+
+	walker.tracer.log(slog.LevelDebug, walker.stop.Key, "walk drained")
+	walker.reported = true
+	opts.Debugger.report(*walker.stop)
+}
+
+// resume dispatches the nodes that were parked when the walk stopped, and then anything else that is ready.
+func (walker *walker) resume(ctx context.Context, pool *threading.ThreadPool, worker *worker) {
+	walker.tracer.log(slog.LevelDebug, walker.stop.Key, "walk resumed")
+	parked := walker.parked
+	walker.stop, walker.parked = nil, nil
+	walker.dispatch(ctx, pool, worker, parked)
+	walker.schedule(ctx, pool, worker)
+}
+
+// Debug handles a command from Opts.Debugger.
+func (walker *walker) Debug(ctx context.Context, command debugCommand, pool *threading.ThreadPool, worker *worker) {
+	switch command.kind {
+	case debugInspect:
+		command.reply <- debugReply{state: walker.inspect()}
+		return
+	case debugAbort:
+		walker.tracer.log(slog.LevelDebug, "", "walk aborted")
+		walker.cancel(&cancelCause{reason: CancelAborted})
+		command.reply <- debugReply{}
+		return
+	}
+
+	stop := walker.stop
+	if stop == nil {
+		command.reply <- debugReply{err: errors.New(nil, NotStopped, "walk is not stopped")}
+		return
+	}
+	if command.kind == debugRetry && stop.Reason != StopError {
+		command.reply <- debugReply{err: errors.Newf(nil, NotStopped, "walk is not stopped at a failed node, %q hasn't run yet", stop.Key)}
+		return
+	}
+
+	switch command.kind {
+	case debugContinue:
+		if stop.Reason == StopError {
+			walker.fail(stop.Key, stop.Err, worker.opts)
+			break
+		}
+		walker.released[stop.Key] = true
+	case debugSkip:
+		if stop.Reason == StopBreakpoint {
+			walker.parked = slices.DeleteFunc(walker.parked, func(key string) bool {
+				return key == stop.Key
+			})
+		}
+		walker.tracer.log(slog.LevelDebug, stop.Key, "node skipped", slog.String("reason", "debugger"))
+		ready := walker.Skipped(stop.Key)
+		walker.result.Nodes[stop.Key].Err = stop.Err
+		walker.ready(ready...)
+	case debugRetry:
+		attempt := 1
+		if exec, ok := walker.executions[stop.Key]; ok {
+			attempt = exec.attempt
+		}
+		delete(walker.processing, stop.Key)
+		delete(walker.executions, stop.Key)
+		walker.attempts[stop.Key] = attempt + 1
+		walker.previous[stop.Key] = stop.Err
+		walker.ready(stop.Key)
+	}
+	command.reply <- debugReply{}
+
+	walker.resume(ctx, pool, worker)
+}
+
+// Unstop cancels the nodes that were parked when the walk stopped, because the walk was cancelled while it was stopped.
+// A node that the walk stopped at because it failed is reported as failed.
+func (walker *walker) Unstop(reason CancelReason, opts *Opts) {
+	if walker.stop.Reason == StopError {
+		walker.fail(walker.stop.Key, walker.stop.Err, opts)
+	}
+	for _, key := range walker.parked {
+		walker.Cancelled(key, reason)
+	}
+	walker.stop, walker.parked = nil, nil
+}
+
+// inspect describes the current state of the walk.
+func (walker *walker) inspect() DebugState {
+	state := DebugState{
+		Nodes:   make(map[string]NodeResult, len(walker.result.Nodes)),
+		Pending: append(append([]string(nil), walker.parked...), walker.pending.keys()...),
+	}
+	if walker.stop != nil {
+		stop := *walker.stop
+		state.Stop = &stop
+	}
+	for key, result := range walker.result.Nodes {
+		state.Nodes[key] = *result
+	}
+	if state.Stop != nil && state.Stop.Reason == StopError {
+		// The failure hasn't been recorded yet, as the debugger decides what to do about it.
+		node := state.Nodes[state.Stop.Key]
+		node.Status, node.Err = StatusErrored, state.Stop.Err
+		state.Nodes[state.Stop.Key] = node
+	}
+	return state
+}
@@ -0,0 +1,349 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Debugger(t *testing.T) {
+	type command func(debugger *Debugger) error
+	var (
+		next  command = (*Debugger).Continue
+		skip  command = (*Debugger).Skip
+		retry command = (*Debugger).Retry
+	)
+
+	tcs := map[string]struct {
+		keys     []string
+		tags     []string
+		fails    bool // whether c fails the first time it runs
+		commands []command
+		stops    []string
+		executed []string
+		statuses map[string]Status
+	}{
+		"continue": {
+			keys:     []string{"b"},
+			commands: []command{next},
+			stops:    []string{"breakpoint b"},
+			executed: []string{"a", "b", "c", "d"},
+			statuses: map[string]Status{"a": StatusCompleted, "b": StatusCompleted, "c": StatusCompleted, "d": StatusCompleted},
+		},
+		"skip": {
+			keys:     []string{"b"},
+			commands: []command{skip},
+			stops:    []string{"breakpoint b"},
+			executed: []string{"a", "c", "d"},
+			statuses: map[string]Status{"a": StatusCompleted, "b": StatusSkipped, "c": StatusCompleted, "d": StatusCompleted},
+		},
+		"tags": {
+			tags:     []string{"slow"},
+			commands: []command{next, next},
+			stops:    []string{"breakpoint b", "breakpoint c"},
+			executed: []string{"a", "b", "c", "d"},
+			statuses: map[string]Status{"a": StatusCompleted, "b": StatusCompleted, "c": StatusCompleted, "d": StatusCompleted},
+		},
+		"failure": {
+			keys:     []string{"c"},
+			fails:    true,
+			commands: []command{next, next},
+			stops:    []string{"breakpoint c", "error c: failed to execute node (attempt 1 failed)"},
+			executed: []string{"a", "b", "c"},
+			statuses: map[string]Status{"a": StatusCompleted, "b": StatusCompleted, "c": StatusErrored, "d": StatusSkipped},
+		},
+		"retry": {
+			keys:     []string{"c"},
+			fails:    true,
+			commands: []command{next, retry, next},
+			stops: []string{
+				"breakpoint c",
+				"error c: failed to execute node (attempt 1 failed)",
+				"breakpoint c",
+			},
+			executed: []string{"a", "b", "c", "c", "d"},
+			statuses: map[string]Status{"a": StatusCompleted, "b": StatusCompleted, "c": StatusCompleted, "d": StatusCompleted},
+		},
+		"skip failure": {
+			keys:     []string{"c"},
+			fails:    true,
+			commands: []command{next, skip},
+			stops:    []string{"breakpoint c", "error c: failed to execute node (attempt 1 failed)"},
+			executed: []string{"a", "b", "c", "d"},
+			statuses: map[string]Status{"a": StatusCompleted, "b": StatusCompleted, "c": StatusSkipped, "d": StatusCompleted},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var executed []string
+			node := func(key string) ExecutableNode {
+				return Executable(func(ctx context.Context) error {
+					mutex.Lock()
+					executed = append(executed, key)
+					mutex.Unlock()
+
+					if tc.fails && key == "c" && Attempt(ctx) == 1 {
+						return fmt.Errorf("attempt 1 failed")
+					}
+					return nil
+				})
+			}
+
+			g := NewGraph()
+			g.AddNode("a", node("a"))
+			g.AddNodeWithMeta("b", node("b"), Meta{Tags: []string{"slow"}})
+			g.AddNodeWithMeta("c", node("c"), Meta{Tags: []string{"slow"}})
+			g.AddNode("d", node("d"))
+			g.Connect("a", "b")
+			g.Connect("b", "c")
+			g.Connect("c", "d")
+
+			debugger := NewDebugger()
+			debugger.Break(tc.keys...)
+			debugger.BreakOnTag(tc.tags...)
+
+			var stops []string
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for stop := range debugger.Stops() {
+					description := fmt.Sprintf("%s %s", stop.Reason, stop.Key)
+					if stop.Err != nil {
+						description = fmt.Sprintf("%s: %s", description, stop.Err)
+					}
+					stops = append(stops, description)
+					if len(stops) > len(tc.commands) {
+						t.Errorf("unexpected stop: %v", stop)
+						debugger.Clear()
+						debugger.Continue()
+						continue
+					}
+					if err := tc.commands[len(stops)-1](debugger); err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+				}
+			}()
+
+			result, _ := g.Run(context.Background(), &Opts{Parallelism: 1, Debugger: debugger, Verify: true})
+			<-done
+
+			tests.Execute(stops).Equal(t, tc.stops)
+			tests.Execute(executed).Equal(t, tc.executed)
+			statuses := make(map[string]Status)
+			for key, node := range result.Nodes {
+				statuses[key] = node.Status
+			}
+			tests.Execute(statuses).Equal(t, tc.statuses)
+		})
+	}
+}
+
+func TestDebugger_Inspect(t *testing.T) {
+	g := NewGraph()
+	for _, key := range []string{"a", "b", "c", "d"} {
+		g.AddNode(key, Executable(func(ctx context.Context) error {
+			return nil
+		}))
+	}
+	g.Connect("a", "b")
+	g.Connect("a", "c")
+	g.Connect("a", "d")
+
+	debugger := NewDebugger()
+	debugger.Break("b")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stop := <-debugger.Stops()
+		tests.Execute(stop).Equal(t, Stop{Reason: StopBreakpoint, Key: "b"})
+
+		// Retrying a node that hasn't run doesn't make sense.
+		tests.ExecuteE(debugger.Retry()).MatchesError(t, `walk is not stopped at a failed node, "b" hasn't run yet`)
+
+		state, err := debugger.Inspect()
+		tests.ExecuteE(err).NoError(t)
+		tests.Execute(state.Stop).Equal(t, &Stop{Reason: StopBreakpoint, Key: "b"})
+		tests.Execute(state.Pending).Equal(t, []string{"b", "c", "d"})
+		tests.Execute(state.Nodes["a"].Status).Equal(t, StatusCompleted)
+
+		tests.ExecuteE(debugger.Continue()).NoError(t)
+	}()
+
+	_, err := g.Run(context.Background(), &Opts{Parallelism: 1, Debugger: debugger})
+	tests.ExecuteE(err).NoError(t)
+	<-done
+
+	// The walk is over, so there is nothing left to debug.
+	_, err = debugger.Inspect()
+	tests.ExecuteE(err).MatchesError(t, "walk is over")
+}
+
+func TestDebugger_Cancelled(t *testing.T) {
+	g := NewGraph()
+	for _, key := range []string{"a", "b"} {
+		g.AddNode(key, Executable(func(ctx context.Context) error {
+			return nil
+		}))
+	}
+	g.Connect("a", "b")
+
+	debugger := NewDebugger()
+	debugger.Break("b")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-debugger.Stops()
+		cancel()
+	}()
+
+	result, err := g.Run(ctx, &Opts{Parallelism: 1, Debugger: debugger})
+	tests.ExecuteE(err).MatchesError(t, "walk was cancelled (context) (context canceled)")
+	tests.Execute(result.Nodes["b"].Status).Equal(t, StatusCancelled)
+}
+
+func TestGraph_Walk_StepMode(t *testing.T) {
+	var mutex sync.Mutex
+	var executed []string
+	g := NewGraph()
+	for _, key := range []string{"a", "b", "c", "d"} {
+		g.AddNode(key, Executable(func(ctx context.Context) error {
+			mutex.Lock()
+			executed = append(executed, key)
+			mutex.Unlock()
+
+			if key == "c" {
+				return fmt.Errorf("boom")
+			}
+			return nil
+		}))
+	}
+	g.Connect("a", "b")
+	g.Connect("a", "c")
+	g.Connect("a", "d")
+
+	debugger := NewDebugger()
+	debugger.Break("d")
+
+	var stops []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for stop := range debugger.Stops() {
+			stops = append(stops, fmt.Sprintf("%s %s", stop.Reason, stop.Key))
+
+			// Nothing runs while the walk is stopped, whatever the parallelism.
+			state, err := debugger.Inspect()
+			tests.ExecuteE(err).NoError(t)
+			for key, node := range state.Nodes {
+				if node.Status == StatusRunning {
+					t.Errorf("node %q is running while the walk is stopped", key)
+				}
+			}
+
+			if stop.Reason == StopError {
+				tests.ExecuteE(debugger.Skip()).NoError(t)
+				continue
+			}
+			tests.ExecuteE(debugger.Continue()).NoError(t)
+		}
+	}()
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 4, Debugger: debugger, StepMode: true, Verify: true})
+	<-done
+	tests.ExecuteE(err).NoError(t)
+
+	tests.Execute(stops).Equal(t, []string{"step a", "step b", "step c", "error c", "breakpoint d"})
+	tests.Execute(executed).Equal(t, []string{"a", "b", "c", "d"})
+	tests.Execute(result.Nodes["c"].Status).Equal(t, StatusSkipped)
+}
+
+func TestDebugger_BreakOnError(t *testing.T) {
+	tcs := map[string]struct {
+		command  func(debugger *Debugger) error
+		err      string
+		statuses map[string]Status
+	}{
+		"retry": {
+			command:  (*Debugger).Retry,
+			statuses: map[string]Status{"a": StatusCompleted, "b": StatusCompleted, "c": StatusCompleted, "d": StatusCompleted},
+		},
+		"skip": {
+			command:  (*Debugger).Skip,
+			statuses: map[string]Status{"a": StatusSkipped, "b": StatusCompleted, "c": StatusCompleted, "d": StatusCompleted},
+		},
+		"continue": {
+			command:  (*Debugger).Continue,
+			err:      "a: failed to execute node (boom); graph is incomplete",
+			statuses: map[string]Status{"a": StatusErrored, "b": StatusCompleted, "c": StatusSkipped, "d": StatusCompleted},
+		},
+		"abort": {
+			command:  (*Debugger).Abort,
+			err:      "a: failed to execute node (boom); walk was cancelled (aborted) (context canceled)",
+			statuses: map[string]Status{"a": StatusErrored, "b": StatusCompleted, "c": StatusCancelled, "d": StatusCancelled},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g := NewGraph()
+			g.AddNode("a", Executable(func(ctx context.Context) error {
+				if Attempt(ctx) == 1 {
+					return fmt.Errorf("boom")
+				}
+				return nil
+			}))
+			g.AddNode("b", Executable(func(ctx context.Context) error {
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			}))
+			for _, key := range []string{"c", "d"} {
+				g.AddNode(key, Executable(func(ctx context.Context) error {
+					return nil
+				}))
+			}
+			g.Connect("a", "c")
+			g.Connect("b", "d")
+
+			debugger := NewDebugger()
+			debugger.BreakOnError()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				stop := <-debugger.Stops()
+				tests.Execute(stop.Reason).Equal(t, StopError)
+				tests.Execute(stop.Key).Equal(t, "a")
+
+				// The walk is only reported as stopped once b has finished, and d isn't dispatched until it resumes.
+				state, err := debugger.Inspect()
+				tests.ExecuteE(err).NoError(t)
+				tests.Execute(state.Nodes["a"].Status).Equal(t, StatusErrored)
+				tests.Execute(state.Nodes["b"].Status).Equal(t, StatusCompleted)
+				tests.Execute(state.Pending).Equal(t, []string{"d"})
+
+				tests.ExecuteE(tc.command(debugger)).NoError(t)
+			}()
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 4, Debugger: debugger, Verify: true})
+			<-done
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+
+			statuses := make(map[string]Status)
+			for key, node := range result.Nodes {
+				statuses[key] = node.Status
+			}
+			tests.Execute(statuses).Equal(t, tc.statuses)
+		})
+	}
+}
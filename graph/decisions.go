@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"math"
+)
+
+// LevelTrace is the level the most frequent scheduler decisions are logged at, it is more verbose than
+// slog.LevelDebug.
+const LevelTrace = slog.LevelDebug - 4
+
+// SchedulerTrace configures verbose logging of every decision the walker makes, so questions like "why didn't node Z
+// run yet" can be answered from the logs.
+//
+// Nodes becoming ready, being dispatched and being cancelled are logged at slog.LevelDebug. Nodes that are still
+// waiting on other parents after one of their parents completed are logged at LevelTrace, so the level of the logger
+// controls how verbose the trace is.
+type SchedulerTrace struct {
+	// Logger receives the decisions.
+	//
+	// Defaults to Opts.Logger.
+	Logger *slog.Logger
+
+	// Rate is the fraction of nodes whose decisions are logged, between 0 and 1. Sampling is decided by node key, so
+	// either every decision about a node is logged or none are.
+	//
+	// Defaults to 0, which logs every node unless Keys is set, in which case only Keys are logged.
+	Rate float64
+
+	// Keys are always logged, regardless of Rate.
+	Keys []string
+}
+
+// tracer logs scheduler decisions according to a SchedulerTrace. A nil tracer logs nothing.
+type tracer struct {
+	logger *slog.Logger
+	rate   float64
+	keys   map[string]bool
+}
+
+func newTracer(trace *SchedulerTrace, logger *slog.Logger, walkID string) *tracer {
+	if trace == nil {
+		return nil
+	}
+
+	if trace.Logger != nil {
+		logger = trace.Logger
+	}
+
+	tracer := &tracer{
+		logger: logger.With(slog.String("walk_id", walkID)),
+		rate:   trace.Rate,
+		keys:   make(map[string]bool, len(trace.Keys)),
+	}
+	for _, key := range trace.Keys {
+		tracer.keys[key] = true
+	}
+	return tracer
+}
+
+// sampled returns whether decisions about the given node should be logged.
+func (tracer *tracer) sampled(key string) bool {
+	if tracer.keys[key] {
+		return true
+	}
+
+	switch {
+	case tracer.rate <= 0:
+		return len(tracer.keys) == 0
+	case tracer.rate >= 1:
+		return true
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return float64(hash.Sum32())/math.MaxUint32 < tracer.rate
+}
+
+// log records a decision about the given node.
+func (tracer *tracer) log(level slog.Level, key string, msg string, attrs ...slog.Attr) {
+	if tracer == nil || !tracer.logger.Enabled(context.Background(), level) || !tracer.sampled(key) {
+		return
+	}
+	tracer.logger.LogAttrs(context.Background(), level, msg, append([]slog.Attr{slog.String("key", key)}, attrs...)...)
+}
@@ -0,0 +1,51 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestSchedulerTrace(t *testing.T) {
+	walk := func(level slog.Level, trace SchedulerTrace) []string {
+		g := NewGraph()
+		for _, key := range []string{"a", "b", "c"} {
+			g.AddNode(key, Executable(func(ctx context.Context) error {
+				return nil
+			}))
+		}
+		g.Connect("a", "c")
+		g.Connect("b", "c")
+
+		var buffer bytes.Buffer
+		trace.Logger = slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: level}))
+		tests.ExecuteE(g.Walk(context.Background(), &Opts{
+			Parallelism:    1,
+			SchedulerTrace: &trace,
+		})).NoError(t)
+
+		var decisions []string
+		for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+			var record map[string]interface{}
+			tests.ExecuteE(json.Unmarshal([]byte(line), &record)).NoError(t)
+			decisions = append(decisions, record["key"].(string)+" "+record["msg"].(string))
+		}
+		return decisions
+	}
+
+	tests.Execute(walk(LevelTrace, SchedulerTrace{Keys: []string{"c"}})).Equal(t, []string{
+		"c node waiting",
+		"c node ready",
+		"c node dispatched",
+	})
+	tests.Execute(walk(slog.LevelDebug, SchedulerTrace{Keys: []string{"c"}})).Equal(t, []string{
+		"c node ready",
+		"c node dispatched",
+	})
+	tests.Execute(len(walk(slog.LevelDebug, SchedulerTrace{}))).Equal(t, 6)
+}
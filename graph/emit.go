@@ -0,0 +1,129 @@
+package graph
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// StreamingExpandableNode is a node that expands into a subgraph a piece at a time. The nodes are handed to the walk
+// through the Emitter as soon as they are discovered, and start running while Expand carries on, so a huge expansion
+// doesn't have to be enumerated in full before any of it runs.
+//
+// The node completes once Expand has returned and every node it emitted has completed, exactly like an ExpandableNode
+// completes once its subgraph has. If Expand fails, the nodes that were already emitted still run but the node fails.
+type StreamingExpandableNode interface {
+	Expand(ctx context.Context, emitter *Emitter) error
+}
+
+type streamingExpandable struct {
+	fn func(ctx context.Context, emitter *Emitter) error
+}
+
+// StreamingExpandable creates a new streaming expandable node that is just a simple function.
+func StreamingExpandable(fn func(ctx context.Context, emitter *Emitter) error) StreamingExpandableNode {
+	return &streamingExpandable{fn: fn}
+}
+
+func (e *streamingExpandable) Expand(ctx context.Context, emitter *Emitter) error {
+	return e.fn(ctx, emitter)
+}
+
+// Emitter adds the nodes discovered by a StreamingExpandableNode to the walk. It can only be used until Expand returns.
+type Emitter struct {
+	// key is the key of the expanding node.
+	key string
+
+	// nodes and edges carry requests to the walker.
+	nodes chan<- enqueueRequest
+	edges chan<- connectRequest
+
+	mutex  sync.Mutex
+	closed bool
+}
+
+// AddNode adds a node to the subgraph. The node runs once all the given parents have completed, and immediately if it
+// has none. The key must not already be in use, and the parents must have been emitted by the same node.
+//
+// AddNode returns an error with the ClosedStream code if Expand has already returned.
+func (emitter *Emitter) AddNode(key string, impl interface{}, parents ...string) error {
+	return emitter.AddNodeWithMeta(key, impl, Meta{}, parents...)
+}
+
+// AddNodeWithMeta adds a node to the subgraph, exactly like AddNode, along with metadata describing it.
+func (emitter *Emitter) AddNodeWithMeta(key string, impl interface{}, meta Meta, parents ...string) error {
+	node, err := newNode(key, impl, meta)
+	if err != nil {
+		return err
+	}
+	node.parents = append([]string(nil), parents...)
+
+	if err := emitter.open(); err != nil {
+		return err
+	}
+
+	reply := make(chan error)
+	emitter.nodes <- enqueueRequest{node: node, expander: emitter.key, reply: reply}
+	return <-reply
+}
+
+// Connect adds an edge between two nodes that were emitted by the same node, so that to only runs once from has
+// completed. As with Stream.Connect, to must still be waiting for at least one of its parents.
+//
+// Connect returns an error with the ClosedStream code if Expand has already returned.
+func (emitter *Emitter) Connect(from string, to string) error {
+	if err := emitter.open(); err != nil {
+		return err
+	}
+
+	reply := make(chan error)
+	emitter.edges <- connectRequest{from: from, to: to, expander: emitter.key, reply: reply}
+	return <-reply
+}
+
+func (emitter *Emitter) open() error {
+	emitter.mutex.Lock()
+	defer emitter.mutex.Unlock()
+	if emitter.closed {
+		err := errors.Newf(nil, ClosedStream, "node %q has finished expanding", emitter.key)
+		return errors.Embed(err, NodeKey, emitter.key)
+	}
+	return nil
+}
+
+func (emitter *Emitter) close() {
+	emitter.mutex.Lock()
+	defer emitter.mutex.Unlock()
+	emitter.closed = true
+}
+
+// emitted checks that the given nodes were all emitted by the expander.
+func (walker *walker) emitted(expander string, keys ...string) error {
+	for _, key := range keys {
+		if _, ok := walker.nodes[key]; ok && walker.expandedBy[key] != expander {
+			err := errors.Newf(nil, MissingNode, "node %q was not emitted by %q", key, expander)
+			return errors.Embed(err, NodeKey, key)
+		}
+	}
+	return nil
+}
+
+// Emitted adds a node emitted by a StreamingExpandableNode to the subgraph of the node. Every emitted node counts as a
+// finisher of the subgraph, so the expanding node only completes once all of them have.
+func (walker *walker) Emitted(expander string, key string) {
+	walker.paths = nil
+	walker.expandedBy[key] = expander
+	walker.subgraphStarters[expander] = append(walker.subgraphStarters[expander], key)
+	walker.subgraphFinishers[key] = expander
+	walker.unfinished[expander]++
+}
+
+// Streamed records that a StreamingExpandableNode has finished expanding, and returns the nodes that are now ready.
+func (walker *walker) Streamed(key string) []string {
+	delete(walker.processing, key)
+	if walker.unfinished[key] > 0 {
+		return nil
+	}
+	return walker.Completed(key)
+}
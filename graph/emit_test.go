@@ -0,0 +1,130 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_StreamingExpandable(t *testing.T) {
+	var mutex sync.Mutex
+	var ran []string
+	record := func(key string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			ran = append(ran, key)
+			return nil
+		})
+	}
+
+	// The first page runs before the expansion discovers the second, which only happens once the first has finished.
+	first := make(chan struct{})
+
+	g := NewGraph()
+	g.AddNode("crawl", StreamingExpandable(func(ctx context.Context, emitter *Emitter) error {
+		if err := emitter.AddNode("page-1", Executable(func(ctx context.Context) error {
+			defer close(first)
+			return record("page-1").Execute(ctx)
+		})); err != nil {
+			return err
+		}
+		<-first
+
+		// The second page waits until the rest of the subgraph has been emitted, so the edge can still be added.
+		emitted := make(chan struct{})
+		if err := emitter.AddNode("page-2", Executable(func(ctx context.Context) error {
+			<-emitted
+			return record("page-2").Execute(ctx)
+		})); err != nil {
+			return err
+		}
+		if err := emitter.AddNode("index", record("index"), "page-2"); err != nil {
+			return err
+		}
+		if err := emitter.AddNode("summary", record("summary"), "page-2"); err != nil {
+			return err
+		}
+		defer close(emitted)
+		return emitter.Connect("index", "summary")
+	}))
+	g.AddNode("after", record("after"))
+	g.Connect("crawl", "after")
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 2})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(ran).Equal(t, []string{"page-1", "page-2", "index", "summary", "after"})
+	tests.Execute(result.Nodes["crawl"].Status).Equal(t, StatusCompleted)
+}
+
+func TestGraph_Walk_StreamingExpandable_Errors(t *testing.T) {
+	tcs := map[string]struct {
+		expand func(ctx context.Context, emitter *Emitter) error
+		err    string
+	}{
+		"failed": {
+			expand: func(ctx context.Context, emitter *Emitter) error {
+				if err := emitter.AddNode("x", Executable(func(ctx context.Context) error {
+					return nil
+				})); err != nil {
+					return err
+				}
+				return errors.New("boom")
+			},
+			err: "crawl: failed to expand node (boom); graph is incomplete",
+		},
+		"outside": {
+			expand: func(ctx context.Context, emitter *Emitter) error {
+				return emitter.AddNode("x", Executable(func(ctx context.Context) error {
+					return nil
+				}), "other")
+			},
+			err: "crawl: failed to expand node (node \"other\" was not emitted by \"crawl\"); graph is incomplete",
+		},
+		"duplicate": {
+			expand: func(ctx context.Context, emitter *Emitter) error {
+				return emitter.AddNode("other", Executable(func(ctx context.Context) error {
+					return nil
+				}))
+			},
+			err: "crawl: failed to expand node (node \"other\" already exists); graph is incomplete",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g := NewGraph()
+			g.AddNode("crawl", StreamingExpandable(tc.expand))
+			g.AddNode("other", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			g.AddNode("after", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			g.Connect("crawl", "after")
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 1})
+			tests.ExecuteE(err).MatchesError(t, tc.err)
+			tests.Execute(result.Nodes["after"].Status).Equal(t, StatusSkipped)
+		})
+	}
+}
+
+func TestEmitter_Closed(t *testing.T) {
+	var leaked *Emitter
+
+	g := NewGraph()
+	g.AddNode("crawl", StreamingExpandable(func(ctx context.Context, emitter *Emitter) error {
+		leaked = emitter
+		return nil
+	}))
+	tests.ExecuteE(g.Walk(context.Background(), nil)).NoError(t)
+
+	tests.ExecuteE(leaked.AddNode("late", Executable(func(ctx context.Context) error {
+		return nil
+	}))).MatchesError(t, "node \"crawl\" has finished expanding")
+	tests.ExecuteE(leaked.Connect("a", "b")).MatchesError(t, "node \"crawl\" has finished expanding")
+}
@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Resolver turns the values in the environment of a node into the values the node sees, for example by treating them
+// as references to secrets and looking the secrets up. Keeping the lookup in the resolver keeps it out of the node
+// bodies, and means secrets are only fetched for nodes that actually run.
+type Resolver interface {
+	// Resolve returns the value for the named variable of the given node, given the value it was declared with.
+	Resolve(ctx context.Context, key string, name string, value string) (string, error)
+}
+
+// ResolverFunc adapts a simple function into a Resolver.
+type ResolverFunc func(ctx context.Context, key string, name string, value string) (string, error)
+
+// Resolve implements Resolver.
+func (fn ResolverFunc) Resolve(ctx context.Context, key string, name string, value string) (string, error) {
+	return fn(ctx, key, name, value)
+}
+
+// resolveEnv resolves the environment declared in the metadata of the node. Variables are resolved in order of their
+// names, and the first failure is returned with the name of the variable embedded under EnvName.
+func resolveEnv(ctx context.Context, resolver Resolver, key string, env map[string]string) (map[string]string, error) {
+	if len(env) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := make(map[string]string, len(env))
+	for _, name := range names {
+		value := env[name]
+		if resolver != nil {
+			var err error
+			if value, err = resolver.Resolve(ctx, key, name, value); err != nil {
+				err = errors.Newf(err, UnresolvedEnv, "failed to resolve environment variable %q", name)
+				return nil, errors.Embed(err, EnvName, name)
+			}
+		}
+		resolved[name] = value
+	}
+	return resolved, nil
+}
+
+// Env returns the resolved value of a variable from the environment of the node executing with the given context, see
+// Meta.Env.
+func Env(ctx context.Context, name string) (string, bool) {
+	exec := executionFrom(ctx)
+	if exec == nil {
+		return "", false
+	}
+
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	value, ok := exec.env[name]
+	return value, ok
+}
+
+// Environ returns a copy of the resolved environment of the node executing with the given context, see Meta.Env.
+func Environ(ctx context.Context) map[string]string {
+	exec := executionFrom(ctx)
+	if exec == nil {
+		return nil
+	}
+
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	environ := make(map[string]string, len(exec.env))
+	for name, value := range exec.env {
+		environ[name] = value
+	}
+	return environ
+}
@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestEnv(t *testing.T) {
+	secrets := ResolverFunc(func(ctx context.Context, key string, name string, value string) (string, error) {
+		if !strings.HasPrefix(value, "secret://") {
+			return value, nil
+		}
+		if value == "secret://token" {
+			return "hunter2", nil
+		}
+		return "", fmt.Errorf("no such secret %q", value)
+	})
+
+	tcs := map[string]struct {
+		env      map[string]string
+		resolver Resolver
+		expected map[string]string
+		err      string
+	}{
+		"none": {
+			expected: map[string]string{},
+		},
+		"literal": {
+			env:      map[string]string{"REGION": "eu", "TOKEN": "secret://token"},
+			expected: map[string]string{"REGION": "eu", "TOKEN": "secret://token"},
+		},
+		"resolved": {
+			env:      map[string]string{"REGION": "eu", "TOKEN": "secret://token"},
+			resolver: secrets,
+			expected: map[string]string{"REGION": "eu", "TOKEN": "hunter2"},
+		},
+		"unresolved": {
+			env:      map[string]string{"PASSWORD": "secret://password"},
+			resolver: secrets,
+			err:      "a: failed to resolve environment (failed to resolve environment variable \"PASSWORD\" (no such secret \"secret://password\"))",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var environ map[string]string
+			var token string
+
+			g := NewGraph()
+			g.AddNodeWithMeta("a", Executable(func(ctx context.Context) error {
+				environ = Environ(ctx)
+				token, _ = Env(ctx, "TOKEN")
+				return nil
+			}), Meta{Env: tc.env})
+
+			_, err := g.Run(context.Background(), &Opts{Parallelism: 1, Resolver: tc.resolver})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+				tests.Execute(errors.GetErrorCode(err)).Equal(t, FailedNode)
+				tests.Execute(environ).Equal(t, map[string]string(nil))
+				return
+			}
+
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(environ).Equal(t, tc.expected)
+			tests.Execute(token).Equal(t, tc.expected["TOKEN"])
+		})
+	}
+
+	_, ok := Env(context.Background(), "TOKEN")
+	tests.Execute(ok).Equal(t, false)
+}
@@ -5,9 +5,84 @@ import "github.com/pasataleo/go-errors/errors"
 var (
 	FailedNode      errors.ErrorCode = "graph.failed_node"
 	IncompleteGraph errors.ErrorCode = "graph.incomplete_graph"
+	Cancelled       errors.ErrorCode = "graph.cancelled"
+	UpstreamFailed  errors.ErrorCode = "graph.upstream_failed"
+	Forbidden       errors.ErrorCode = "graph.forbidden"
+
+	InvariantViolation errors.ErrorCode = "graph.invariant_violation"
+
+	MissingArtifacts errors.ErrorCode = "graph.missing_artifacts"
+	ArtifactNotFound errors.ErrorCode = "graph.artifact_not_found"
+
+	MissingNode   errors.ErrorCode = "graph.missing_node"
+	DuplicateNode errors.ErrorCode = "graph.duplicate_node"
+	InvalidNode   errors.ErrorCode = "graph.invalid_node"
+	SelfLoop      errors.ErrorCode = "graph.self_loop"
+	CycleDetected errors.ErrorCode = "graph.cycle_detected"
+
+	ExpansionCollision errors.ErrorCode = "graph.expansion_collision"
+	PartialExpansion   errors.ErrorCode = "graph.partial_expansion"
+
+	ClosedStream errors.ErrorCode = "graph.closed_stream"
+	StartedNode  errors.ErrorCode = "graph.started_node"
+
+	ClosedDebugger errors.ErrorCode = "graph.closed_debugger"
+	NotStopped     errors.ErrorCode = "graph.not_stopped"
+
+	ClosedTransaction errors.ErrorCode = "graph.closed_transaction"
+
+	ClosedHandle errors.ErrorCode = "graph.closed_handle"
+
+	MissingProducer errors.ErrorCode = "graph.missing_producer"
+	MissingOutput   errors.ErrorCode = "graph.missing_output"
+	WrongProducer   errors.ErrorCode = "graph.wrong_producer"
+	TypeMismatch    errors.ErrorCode = "graph.type_mismatch"
+
+	UnsetInput        errors.ErrorCode = "graph.unset_input"
+	AmbiguousProducer errors.ErrorCode = "graph.ambiguous_producer"
+
+	MissingBlackboard   errors.ErrorCode = "graph.missing_blackboard"
+	BlackboardMissing   errors.ErrorCode = "graph.blackboard_missing"
+	BlackboardConflict  errors.ErrorCode = "graph.blackboard_conflict"
+	BlackboardViolation errors.ErrorCode = "graph.blackboard_violation"
+
+	UnknownGraph   errors.ErrorCode = "graph.unknown_graph"
+	DuplicateGraph errors.ErrorCode = "graph.duplicate_graph"
+	FailedBuild    errors.ErrorCode = "graph.failed_build"
+	UnresolvedRef  errors.ErrorCode = "graph.unresolved_ref"
+
+	InvalidEstimate errors.ErrorCode = "graph.invalid_estimate"
+
+	InvalidWindow errors.ErrorCode = "graph.invalid_window"
+	ClosedWindow  errors.ErrorCode = "graph.closed_window"
+
+	UnresolvedEnv errors.ErrorCode = "graph.unresolved_env"
+
+	ExceededBudget errors.ErrorCode = "graph.exceeded_budget"
+
+	OpenCircuit errors.ErrorCode = "graph.open_circuit"
+	Quarantined errors.ErrorCode = "graph.quarantined"
+
+	AbortedRollout errors.ErrorCode = "graph.aborted_rollout"
+
+	UnboundParameter errors.ErrorCode = "graph.unbound_parameter"
+	UnknownParameter errors.ErrorCode = "graph.unknown_parameter"
+	PrefixedPorts    errors.ErrorCode = "graph.prefixed_ports"
+
+	InvalidDepth errors.ErrorCode = "graph.invalid_depth"
 
 	NodeKey        = "graph.key"
 	NodeCount      = "graph.nodes"
 	CompletedCount = "graph.completed"
 	ErroredCount   = "graph.errored"
+	CancelledCount = "graph.cancelled"
+	Reason         = "graph.reason"
+	Ancestry       = "graph.ancestry"
+	Cycle          = "graph.cycle"
+	EnvName        = "graph.env"
+	WindowExpr     = "graph.window"
+	Cost           = "graph.cost"
+	GraphName      = "graph.name"
+	GraphVersion   = "graph.version"
+	Resource       = "graph.resource"
 )
@@ -0,0 +1,215 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// sortedKeys returns the keys of all the nodes in the graph, sorted.
+func (g Graph) sortedKeys() []string {
+	keys := make([]string, 0, len(g.nodes))
+	for key := range g.nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedChildren returns the children of the given node, sorted.
+func (g Graph) sortedChildren(key string) []string {
+	children := append([]string(nil), g.nodes[key].children...)
+	sort.Strings(children)
+	return children
+}
+
+// namespaceTree groups the keys of the graph by namespace, so exporters can render nested clusters.
+type namespaceTree struct {
+	name     string
+	keys     []string
+	children []*namespaceTree
+}
+
+func (g Graph) namespaceTree() *namespaceTree {
+	root := &namespaceTree{}
+	trees := map[string]*namespaceTree{"": root}
+
+	var lookup func(namespace string) *namespaceTree
+	lookup = func(namespace string) *namespaceTree {
+		if tree, ok := trees[namespace]; ok {
+			return tree
+		}
+		tree := &namespaceTree{name: namespace}
+		trees[namespace] = tree
+
+		parent := lookup(Namespace(namespace))
+		parent.children = append(parent.children, tree)
+		return tree
+	}
+
+	for _, key := range g.sortedKeys() {
+		tree := lookup(Namespace(key))
+		tree.keys = append(tree.keys, key)
+	}
+
+	var sortTree func(tree *namespaceTree)
+	sortTree = func(tree *namespaceTree) {
+		sort.Slice(tree.children, func(i, j int) bool {
+			return tree.children[i].name < tree.children[j].name
+		})
+		for _, child := range tree.children {
+			sortTree(child)
+		}
+	}
+	sortTree(root)
+	return root
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language. Nodes with hierarchical keys are grouped into nested clusters
+// by namespace.
+func (g Graph) WriteDOT(writer io.Writer) error {
+	return g.writeDOT(writer, nil)
+}
+
+// writeDOT writes the graph in the Graphviz DOT language, along with a dashed edge from every node in expansions to each
+// of the nodes it expanded into.
+func (g Graph) writeDOT(writer io.Writer, expansions map[string][]string) error {
+	var builder strings.Builder
+	builder.WriteString("digraph {\n")
+
+	var write func(tree *namespaceTree, indent string)
+	write = func(tree *namespaceTree, indent string) {
+		for _, key := range tree.keys {
+			fmt.Fprintf(&builder, "%s%q [label=%q];\n", indent, key, strings.TrimPrefix(key, tree.name+Separator))
+		}
+		for _, child := range tree.children {
+			fmt.Fprintf(&builder, "%ssubgraph %q {\n", indent, "cluster_"+child.name)
+			fmt.Fprintf(&builder, "%s\tlabel=%q;\n", indent, child.name)
+			write(child, indent+"\t")
+			fmt.Fprintf(&builder, "%s}\n", indent)
+		}
+	}
+	write(g.namespaceTree(), "\t")
+
+	for _, key := range g.sortedKeys() {
+		for _, child := range g.sortedChildren(key) {
+			fmt.Fprintf(&builder, "\t%q -> %q;\n", key, child)
+		}
+		for _, child := range expansions[key] {
+			fmt.Fprintf(&builder, "\t%q -> %q [style=dashed];\n", key, child)
+		}
+	}
+
+	builder.WriteString("}\n")
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart. Nodes with hierarchical keys are grouped into nested subgraphs
+// by namespace.
+func (g Graph) WriteMermaid(writer io.Writer) error {
+	// Mermaid identifiers are quite restrictive, so refer to each node by its position instead of its key.
+	ids := make(map[string]string, len(g.nodes))
+	for ix, key := range g.sortedKeys() {
+		ids[key] = fmt.Sprintf("n%d", ix)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("flowchart TD\n")
+
+	clusters := 0
+	var write func(tree *namespaceTree, indent string)
+	write = func(tree *namespaceTree, indent string) {
+		for _, key := range tree.keys {
+			fmt.Fprintf(&builder, "%s%s[%q]\n", indent, ids[key], strings.TrimPrefix(key, tree.name+Separator))
+		}
+		for _, child := range tree.children {
+			fmt.Fprintf(&builder, "%ssubgraph c%d [%q]\n", indent, clusters, child.name)
+			clusters++
+			write(child, indent+"\t")
+			fmt.Fprintf(&builder, "%send\n", indent)
+		}
+	}
+	write(g.namespaceTree(), "\t")
+
+	for _, key := range g.sortedKeys() {
+		for _, child := range g.sortedChildren(key) {
+			fmt.Fprintf(&builder, "\t%s --> %s\n", ids[key], ids[child])
+		}
+	}
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// WritePlantUML writes the graph as a PlantUML component diagram. Nodes with hierarchical keys are grouped into nested
+// packages by namespace.
+func (g Graph) WritePlantUML(writer io.Writer) error {
+	// Like Mermaid, refer to each node by its position so keys don't need escaping outside their labels.
+	ids := make(map[string]string, len(g.nodes))
+	for ix, key := range g.sortedKeys() {
+		ids[key] = fmt.Sprintf("n%d", ix)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("@startuml\n")
+
+	var write func(tree *namespaceTree, indent string)
+	write = func(tree *namespaceTree, indent string) {
+		for _, key := range tree.keys {
+			fmt.Fprintf(&builder, "%scomponent %q as %s\n", indent, strings.TrimPrefix(key, tree.name+Separator), ids[key])
+		}
+		for _, child := range tree.children {
+			fmt.Fprintf(&builder, "%spackage %q {\n", indent, child.name)
+			write(child, indent+"\t")
+			fmt.Fprintf(&builder, "%s}\n", indent)
+		}
+	}
+	write(g.namespaceTree(), "")
+
+	for _, key := range g.sortedKeys() {
+		for _, child := range g.sortedChildren(key) {
+			fmt.Fprintf(&builder, "%s --> %s\n", ids[key], ids[child])
+		}
+	}
+
+	builder.WriteString("@enduml\n")
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// WritePlantUMLActivity writes the graph as a PlantUML activity diagram. Activity diagrams can't draw arbitrary edges,
+// so the nodes are drawn generation by generation, see Plan.Generations, with the nodes in each generation forked to run
+// side by side. Every node still runs after all of its parents, but edges that skip generations aren't shown.
+//
+// Graphs with cycles have no generations, so WritePlantUMLActivity returns a CycleDetected error for them.
+func (g Graph) WritePlantUMLActivity(writer io.Writer) error {
+	if _, err := g.topologicalOrder(); err != nil {
+		return err
+	}
+	plan := g.Plan()
+
+	var builder strings.Builder
+	builder.WriteString("@startuml\nstart\n")
+	for _, generation := range plan.Generations {
+		if len(generation) == 1 {
+			fmt.Fprintf(&builder, ":%s;\n", generation[0])
+			continue
+		}
+
+		for ix, key := range generation {
+			if ix == 0 {
+				builder.WriteString("fork\n")
+			} else {
+				builder.WriteString("fork again\n")
+			}
+			fmt.Fprintf(&builder, "\t:%s;\n", key)
+		}
+		builder.WriteString("end fork\n")
+	}
+	builder.WriteString("stop\n@enduml\n")
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
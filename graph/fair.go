@@ -0,0 +1,129 @@
+package graph
+
+import "sort"
+
+// pending is the queue of nodes that are ready to be dispatched. It is a plain FIFO queue unless Opts.Weights is set,
+// in which case it is a fairQueue.
+type pending interface {
+	// push adds a key to the queue.
+	push(key string)
+
+	// pop removes and returns the next key to dispatch.
+	pop() (string, bool)
+
+	// len returns the number of keys in the queue.
+	len() int
+
+	// keys returns the keys in the queue.
+	keys() []string
+}
+
+var (
+	_ pending = (*queue)(nil)
+	_ pending = (*fairQueue)(nil)
+)
+
+// fairQueue interleaves the nodes that are ready across tags in proportion to their weights, see Opts.Weights, so one
+// tenant's huge fan-out can't starve another's when graphs are merged into a single walk. Nodes are FIFO within a tag.
+//
+// It uses stride scheduling: every tag has a pass that advances by the inverse of its weight each time one of its
+// nodes is dispatched, and the next node always comes from the tag with the lowest pass.
+type fairQueue struct {
+	weights map[string]int
+
+	// class returns the tag the node is scheduled under.
+	class func(key string) string
+
+	// classes contains every tag seen so far, sorted so ties are broken the same way every time.
+	classes []string
+	queues  map[string]*queue
+	passes  map[string]float64
+
+	// clock is the pass of the tag dispatched last. Tags that become ready again start from it, so they can't make up
+	// for the time they had nothing to run.
+	clock float64
+	size  int
+}
+
+func newFairQueue(weights map[string]int, class func(key string) string) *fairQueue {
+	return &fairQueue{
+		weights: weights,
+		class:   class,
+		queues:  make(map[string]*queue),
+		passes:  make(map[string]float64),
+	}
+}
+
+// push implements pending.
+func (fair *fairQueue) push(key string) {
+	class := fair.class(key)
+	waiting, ok := fair.queues[class]
+	if !ok {
+		waiting = new(queue)
+		fair.queues[class] = waiting
+		ix := sort.SearchStrings(fair.classes, class)
+		fair.classes = append(fair.classes[:ix], append([]string{class}, fair.classes[ix:]...)...)
+	}
+	if waiting.len() == 0 {
+		fair.passes[class] = max(fair.passes[class], fair.clock)
+	}
+	waiting.push(key)
+	fair.size++
+}
+
+// pop implements pending.
+func (fair *fairQueue) pop() (string, bool) {
+	next, found := "", false
+	for _, class := range fair.classes {
+		if fair.queues[class].len() == 0 {
+			continue
+		}
+		if !found || fair.passes[class] < fair.passes[next] {
+			next, found = class, true
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	key, _ := fair.queues[next].pop()
+	fair.clock = fair.passes[next]
+	fair.passes[next] += 1 / float64(fair.weight(next))
+	fair.size--
+	return key, true
+}
+
+// len implements pending.
+func (fair *fairQueue) len() int {
+	return fair.size
+}
+
+// keys implements pending. The keys are grouped by tag rather than in the order they will be dispatched.
+func (fair *fairQueue) keys() []string {
+	keys := make([]string, 0, fair.size)
+	for _, class := range fair.classes {
+		keys = append(keys, fair.queues[class].keys()...)
+	}
+	return keys
+}
+
+// weight returns the weight of the tag, nodes without a weighted tag share a weight of one.
+func (fair *fairQueue) weight(class string) int {
+	if weight := fair.weights[class]; weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+// class returns the tag the node is scheduled under by a fairQueue, which is the first of its tags with a weight or the
+// empty string if it has none.
+func (walker *walker) class(weights map[string]int) func(key string) string {
+	return func(key string) string {
+		for _, tag := range walker.nodes[key].meta.Tags {
+			if _, ok := weights[tag]; ok {
+				return tag
+			}
+		}
+		return ""
+	}
+}
@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Weights(t *testing.T) {
+	tcs := map[string]struct {
+		weights map[string]int
+		order   string
+	}{
+		"unweighted": {
+			order: "bbbbbbbbssss",
+		},
+		"equal": {
+			weights: map[string]int{"big": 1, "small": 1},
+			order:   "bsbsbsbsbbbb",
+		},
+		"weighted": {
+			weights: map[string]int{"big": 3, "small": 1},
+			order:   "bsbbbsbbbsbs",
+		},
+		"favoured": {
+			weights: map[string]int{"big": 1, "small": 3},
+			order:   "bsssbsbbbbbb",
+		},
+		"unlisted": {
+			weights: map[string]int{"small": 1},
+			order:   "bsbsbsbsbbbb",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var order strings.Builder
+
+			// Nodes of the big tenant sort first, so they would all run before the small one without weights.
+			g := NewGraph()
+			add := func(tenant string, nodes int) {
+				for ix := 0; ix < nodes; ix++ {
+					g.AddNodeWithMeta(fmt.Sprintf("%s-%02d", tenant, ix), Executable(func(ctx context.Context) error {
+						order.WriteByte(tenant[0])
+						return nil
+					}), Meta{Tags: []string{tenant}})
+				}
+			}
+			add("big", 8)
+			add("small", 4)
+
+			_, err := g.Run(context.Background(), &Opts{Parallelism: 1, Weights: tc.weights, Verify: true})
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(order.String()).Equal(t, tc.order)
+		})
+	}
+}
+
+func TestFairQueue(t *testing.T) {
+	tcs := map[string]struct {
+		weights map[string]int
+		pushed  []string
+		popped  int
+		then    []string
+		order   []string
+	}{
+		"weighted": {
+			weights: map[string]int{"a": 2},
+			pushed:  []string{"a1", "a2", "a3", "a4", "b1", "b2"},
+			order:   []string{"a1", "b1", "a2", "a3", "b2", "a4"},
+		},
+		"no catching up": {
+			// b runs dry while a keeps going, and doesn't get the turns it missed back once it has nodes again.
+			weights: map[string]int{"a": 1, "b": 1},
+			pushed:  []string{"a1", "a2", "a3", "a4", "a5", "a6", "b1"},
+			popped:  5,
+			then:    []string{"b2", "b3"},
+			order:   []string{"a1", "b1", "a2", "a3", "a4", "b2", "a5", "b3", "a6"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			fair := newFairQueue(tc.weights, func(key string) string {
+				return key[:1]
+			})
+			for _, key := range tc.pushed {
+				fair.push(key)
+			}
+			tests.Execute(fair.len()).Equal(t, len(tc.pushed))
+
+			var order []string
+			for len(order) < tc.popped {
+				key, _ := fair.pop()
+				order = append(order, key)
+			}
+			for _, key := range tc.then {
+				fair.push(key)
+			}
+			for {
+				key, ok := fair.pop()
+				if !ok {
+					break
+				}
+				order = append(order, key)
+			}
+			tests.Execute(order).Equal(t, tc.order)
+			tests.Execute(fair.len()).Equal(t, 0)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package graph
+
+import "log/slog"
+
+// FanOut limits a group of sibling nodes that share a tag, in the way maxUnavailable and maxSurge limit a rolling
+// update: only so many of them may be in flight at once, and once too many have failed the rest are never started.
+// The limits are enforced on top of Opts.Parallelism, and apply to nodes added by expansion as well.
+type FanOut struct {
+	// Tag selects the nodes in the group, see Meta.Tags.
+	Tag string
+
+	// MaxInFlight is the maximum number of nodes in the group that may run at the same time. Nodes over the limit wait
+	// without occupying a worker until another node in the group has finished.
+	//
+	// Optional, the group may use every worker if zero.
+	MaxInFlight int
+
+	// MaxFailures is the number of nodes in the group that may fail. Once that many have, nodes in the group that
+	// haven't started are cancelled with the CancelFanOut reason, while those already running are left to finish.
+	//
+	// Optional, every node in the group is started however many fail if zero.
+	MaxFailures int
+}
+
+// fanOuts returns the fan-out groups the node belongs to.
+func (walker *walker) fanOuts(key string, opts *Opts) []FanOut {
+	if len(opts.FanOuts) == 0 {
+		return nil
+	}
+
+	var fanOuts []FanOut
+	for _, tag := range walker.nodes[key].meta.Tags {
+		for _, fanOut := range opts.FanOuts {
+			if fanOut.Tag == tag {
+				fanOuts = append(fanOuts, fanOut)
+			}
+		}
+	}
+	return fanOuts
+}
+
+// fannedOut returns true if the node can't run because of one of its fan-out groups, in which case it has either been
+// cancelled because the group has failed too often or it waits until another node in the group has finished.
+func (walker *walker) fannedOut(key string, opts *Opts) bool {
+	fanOuts := walker.fanOuts(key, opts)
+	for _, fanOut := range fanOuts {
+		if fanOut.MaxFailures > 0 && walker.fanOutFailures[fanOut.Tag] >= fanOut.MaxFailures {
+			walker.tracer.log(slog.LevelDebug, key, "node cancelled before dispatch", slog.String("reason", string(CancelFanOut)))
+			walker.Cancelled(key, CancelFanOut)
+			return true
+		}
+	}
+
+	for _, fanOut := range fanOuts {
+		if fanOut.MaxInFlight <= 0 || walker.inFlight[fanOut.Tag] < fanOut.MaxInFlight {
+			continue
+		}
+
+		walker.tracer.log(slog.LevelDebug, key, "node throttled", slog.String("tag", fanOut.Tag))
+		delete(walker.processing, key)
+		walker.fanOutWaiting[fanOut.Tag] = append(walker.fanOutWaiting[fanOut.Tag], key)
+		return true
+	}
+	return false
+}
+
+// launch records that the node is in flight in every fan-out group it belongs to.
+func (walker *walker) launch(key string, opts *Opts) {
+	for _, fanOut := range walker.fanOuts(key, opts) {
+		walker.inFlight[fanOut.Tag]++
+	}
+}
+
+// land records that the node is no longer in flight, and makes the first node waiting for each of the fan-out groups
+// it belongs to ready again.
+func (walker *walker) land(key string, opts *Opts) {
+	for _, fanOut := range walker.fanOuts(key, opts) {
+		walker.inFlight[fanOut.Tag]--
+		if waiting := walker.fanOutWaiting[fanOut.Tag]; len(waiting) > 0 {
+			walker.fanOutWaiting[fanOut.Tag] = waiting[1:]
+			walker.ready(waiting[0])
+		}
+	}
+}
+
+// breach records that a node failed against every fan-out group it belongs to. Groups that have now failed too often
+// make every node waiting for them ready again, so they are cancelled instead of waiting for nodes that never start.
+func (walker *walker) breach(key string, opts *Opts) {
+	for _, fanOut := range walker.fanOuts(key, opts) {
+		walker.fanOutFailures[fanOut.Tag]++
+		if fanOut.MaxFailures > 0 && walker.fanOutFailures[fanOut.Tag] >= fanOut.MaxFailures {
+			waiting := walker.fanOutWaiting[fanOut.Tag]
+			delete(walker.fanOutWaiting, fanOut.Tag)
+			walker.ready(waiting...)
+		}
+	}
+}
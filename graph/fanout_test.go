@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_FanOut(t *testing.T) {
+	tcs := map[string]struct {
+		fanOut    FanOut
+		failing   map[string]bool
+		peak      int
+		completed int
+		errored   []string
+		cancelled []string
+	}{
+		"unlimited": {
+			fanOut:    FanOut{Tag: "hosts"},
+			peak:      6,
+			completed: 6 + 1,
+		},
+		"in flight": {
+			fanOut:    FanOut{Tag: "hosts", MaxInFlight: 2},
+			peak:      2,
+			completed: 6 + 1,
+		},
+		"failures": {
+			fanOut:    FanOut{Tag: "hosts", MaxInFlight: 1, MaxFailures: 2},
+			failing:   map[string]bool{"host-1": true, "host-2": true},
+			peak:      1,
+			completed: 1 + 1,
+			errored:   []string{"host-1", "host-2"},
+			cancelled: []string{"host-3", "host-4", "host-5"},
+		},
+		"tolerated": {
+			fanOut:    FanOut{Tag: "hosts", MaxInFlight: 3, MaxFailures: 2},
+			failing:   map[string]bool{"host-1": true},
+			peak:      3,
+			completed: 5 + 1,
+			errored:   []string{"host-1"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			running, peak := 0, 0
+
+			g := NewGraph()
+			for ix := 0; ix < 6; ix++ {
+				key := fmt.Sprintf("host-%d", ix)
+				g.AddNodeWithMeta(key, Executable(func(ctx context.Context) error {
+					mutex.Lock()
+					running++
+					peak = max(peak, running)
+					mutex.Unlock()
+
+					time.Sleep(20 * time.Millisecond)
+
+					mutex.Lock()
+					running--
+					mutex.Unlock()
+
+					if tc.failing[key] {
+						return fmt.Errorf("boom")
+					}
+					return nil
+				}), Meta{Tags: []string{"hosts"}})
+			}
+			// Nodes outside the group aren't held back by it.
+			g.AddNode("other", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 8, FanOuts: []FanOut{tc.fanOut}, Verify: true})
+			if len(tc.errored) == 0 {
+				tests.ExecuteE(err).NoError(t)
+			} else {
+				tests.Execute(err != nil).Equal(t, true)
+			}
+			tests.Execute(peak).Equal(t, tc.peak)
+			tests.Execute(len(result.Status(StatusCompleted))).Equal(t, tc.completed)
+			tests.Execute(result.Status(StatusErrored)).Equal(t, tc.errored)
+			tests.Execute(result.Status(StatusCancelled)).Equal(t, tc.cancelled)
+			for _, key := range tc.cancelled {
+				tests.Execute(result.Nodes[key].Reason).Equal(t, CancelFanOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Package gomod builds graphs from Go module dependency data, so the algorithms of the graph package can be applied to
+// module graphs: finding cycles, critical paths or everything that depends on a module.
+//
+// Every module is a node keyed by its path and version, see Key, and every dependency is an edge from the module that
+// is required to the module requiring it, so modules come after everything they depend on. The nodes don't do
+// anything when walked, replace them with graph.Graph.ReplaceNode to attach work to them.
+package gomod
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pasataleo/go-errors/errors"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+var (
+	InvalidInput errors.ErrorCode = "gomod.invalid_input"
+)
+
+const (
+	// LabelPath, LabelVersion, LabelMain and LabelIndirect are the labels set on every node, see graph.Meta.Labels.
+	// LabelMain and LabelIndirect are only set to "true", and only on the main module and on indirect dependencies.
+	LabelPath     = "gomod.path"
+	LabelVersion  = "gomod.version"
+	LabelMain     = "gomod.main"
+	LabelIndirect = "gomod.indirect"
+)
+
+// Module describes a module, as reported by `go list -m -json`.
+type Module struct {
+	Path     string
+	Version  string
+	Main     bool
+	Indirect bool
+}
+
+// Key returns the key of the node for the module, which is its path followed by its version. The main module has no
+// version, so its key is just its path.
+func (module Module) Key() string {
+	if len(module.Version) == 0 {
+		return module.Path
+	}
+	return module.Path + "@" + module.Version
+}
+
+// builder adds modules and their dependencies to a graph, adding each module and edge only once.
+type builder struct {
+	graph graph.Graph
+	edges map[[2]string]bool
+}
+
+func newBuilder() *builder {
+	return &builder{
+		graph: graph.NewGraph(),
+		edges: make(map[[2]string]bool),
+	}
+}
+
+// add adds the module to the graph, unless it is already there.
+func (builder *builder) add(module Module) error {
+	if _, ok := builder.graph.Meta(module.Key()); ok {
+		return nil
+	}
+
+	labels := map[string]string{
+		LabelPath:    module.Path,
+		LabelVersion: module.Version,
+	}
+	if module.Main {
+		labels[LabelMain] = "true"
+	}
+	if module.Indirect {
+		labels[LabelIndirect] = "true"
+	}
+	return builder.graph.AddNodeWithMeta(module.Key(), graph.Executable(func(ctx context.Context) error {
+		return nil
+	}), graph.Meta{Labels: labels})
+}
+
+// require records that the module requires the dependency.
+func (builder *builder) require(module Module, dependency Module) error {
+	edge := [2]string{dependency.Key(), module.Key()}
+	if builder.edges[edge] {
+		return nil
+	}
+	builder.edges[edge] = true
+
+	if err := builder.add(module); err != nil {
+		return err
+	}
+	if err := builder.add(dependency); err != nil {
+		return err
+	}
+	return builder.graph.Connect(edge[0], edge[1])
+}
+
+// ParseGraph builds a graph from the output of `go mod graph`, which lists every requirement of every module in the
+// build list. The first module listed is the main module.
+func ParseGraph(reader io.Reader) (graph.Graph, error) {
+	builder := newBuilder()
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return graph.Graph{}, errors.Newf(nil, InvalidInput, "line %d: expected two modules, found %q", line, text)
+		}
+
+		module, dependency := parseModule(fields[0]), parseModule(fields[1])
+		if dependency.Path == "go" || dependency.Path == "toolchain" {
+			continue // the Go version and toolchain are listed as requirements, but they aren't modules.
+		}
+		module.Main = len(module.Version) == 0
+		if err := builder.require(module, dependency); err != nil {
+			return graph.Graph{}, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return graph.Graph{}, errors.New(err, InvalidInput, "failed to read module graph")
+	}
+	return builder.graph, nil
+}
+
+// parseModule parses a module as written by `go mod graph`, which is its path followed by its version if it has one.
+func parseModule(text string) Module {
+	path, version, _ := strings.Cut(text, "@")
+	return Module{Path: path, Version: version}
+}
+
+// ParseList builds a graph from the output of `go list -m -json all`, which describes every module in the build list
+// but not which modules require which. Every module is connected to the main module, which requires all of them since
+// Go 1.17, and is labelled as indirect if it isn't required directly.
+func ParseList(reader io.Reader) (graph.Graph, error) {
+	var modules []Module
+
+	decoder := json.NewDecoder(reader)
+	for {
+		var module Module
+		if err := decoder.Decode(&module); err == io.EOF {
+			break
+		} else if err != nil {
+			return graph.Graph{}, errors.New(err, InvalidInput, "failed to decode module list")
+		}
+		if module.Main {
+			module.Version = ""
+		}
+		modules = append(modules, module)
+	}
+
+	return build(modules)
+}
+
+// ParseGoMod builds a graph from a go.mod file, containing the module it declares and every module it requires.
+func ParseGoMod(reader io.Reader) (graph.Graph, error) {
+	var modules []Module
+
+	scanner := bufio.NewScanner(reader)
+	block := false
+	for line := 1; scanner.Scan(); line++ {
+		text, comment, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(text)
+
+		switch {
+		case len(fields) == 0:
+			continue
+		case block && fields[0] == ")":
+			block = false
+			continue
+		case block:
+			// Requirements within a block are written without the require keyword.
+		case fields[0] == "module" && len(fields) == 2:
+			modules = append(modules, Module{Path: strings.Trim(fields[1], `"`), Main: true})
+			continue
+		case fields[0] == "require" && len(fields) == 2 && fields[1] == "(":
+			block = true
+			continue
+		case fields[0] == "require":
+			fields = fields[1:]
+		default:
+			continue
+		}
+
+		if len(fields) != 2 {
+			return graph.Graph{}, errors.Newf(nil, InvalidInput, "line %d: expected a module and a version, found %q", line, strings.TrimSpace(text))
+		}
+		modules = append(modules, Module{
+			Path:     strings.Trim(fields[0], `"`),
+			Version:  fields[1],
+			Indirect: strings.TrimSpace(comment) == "indirect",
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return graph.Graph{}, errors.New(err, InvalidInput, "failed to read go.mod")
+	}
+
+	return build(modules)
+}
+
+// build builds a graph in which the main module requires every other module.
+func build(modules []Module) (graph.Graph, error) {
+	builder := newBuilder()
+
+	var main *Module
+	for ix := range modules {
+		if modules[ix].Main {
+			main = &modules[ix]
+			break
+		}
+	}
+	if main == nil {
+		return graph.Graph{}, errors.New(nil, InvalidInput, "no main module")
+	}
+
+	if err := builder.add(*main); err != nil {
+		return graph.Graph{}, err
+	}
+	for _, module := range modules {
+		if module.Main {
+			continue
+		}
+		if err := builder.require(*main, module); err != nil {
+			return graph.Graph{}, err
+		}
+	}
+	return builder.graph, nil
+}
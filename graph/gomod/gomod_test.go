@@ -0,0 +1,117 @@
+package gomod
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+func TestParse(t *testing.T) {
+	tcs := map[string]struct {
+		parse    func(reader *strings.Reader) (graph.Graph, error)
+		input    string
+		edges    map[string][]string
+		indirect []string
+		err      string
+	}{
+		"graph": {
+			parse: func(reader *strings.Reader) (graph.Graph, error) { return ParseGraph(reader) },
+			input: `example.com/app go@1.23
+example.com/app example.com/lib@v1.2.0
+example.com/app golang.org/x/text@v0.3.0
+example.com/lib@v1.2.0 golang.org/x/text@v0.3.0
+`,
+			edges: map[string][]string{
+				"example.com/lib@v1.2.0":   {"example.com/app"},
+				"golang.org/x/text@v0.3.0": {"example.com/app", "example.com/lib@v1.2.0"},
+			},
+		},
+		"graph invalid": {
+			parse: func(reader *strings.Reader) (graph.Graph, error) { return ParseGraph(reader) },
+			input: "example.com/app\n",
+			err:   "line 1: expected two modules, found \"example.com/app\"",
+		},
+		"list": {
+			parse: func(reader *strings.Reader) (graph.Graph, error) { return ParseList(reader) },
+			input: `{"Path": "example.com/app", "Main": true, "Dir": "/src/app"}
+{"Path": "example.com/lib", "Version": "v1.2.0"}
+{"Path": "golang.org/x/text", "Version": "v0.3.0", "Indirect": true}
+`,
+			edges: map[string][]string{
+				"example.com/lib@v1.2.0":   {"example.com/app"},
+				"golang.org/x/text@v0.3.0": {"example.com/app"},
+			},
+			indirect: []string{"golang.org/x/text@v0.3.0"},
+		},
+		"list without main": {
+			parse: func(reader *strings.Reader) (graph.Graph, error) { return ParseList(reader) },
+			input: `{"Path": "example.com/lib", "Version": "v1.2.0"}`,
+			err:   "no main module",
+		},
+		"go.mod": {
+			parse: func(reader *strings.Reader) (graph.Graph, error) { return ParseGoMod(reader) },
+			input: `module example.com/app
+
+go 1.23
+
+require example.com/lib v1.2.0
+
+require (
+	golang.org/x/text v0.3.0 // indirect
+	"golang.org/x/sync" v0.1.0
+)
+
+replace (
+	example.com/lib => ../lib
+)
+`,
+			edges: map[string][]string{
+				"example.com/lib@v1.2.0":   {"example.com/app"},
+				"golang.org/x/sync@v0.1.0": {"example.com/app"},
+				"golang.org/x/text@v0.3.0": {"example.com/app"},
+			},
+			indirect: []string{"golang.org/x/text@v0.3.0"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g, err := tc.parse(strings.NewReader(tc.input))
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+				return
+			}
+			tests.ExecuteE(err).NoError(t)
+			tests.ExecuteE(g.Validate()).NoError(t)
+
+			edges := make(map[string][]string)
+			var indirect []string
+			var keys []string
+			for _, generation := range g.Plan().Generations {
+				keys = append(keys, generation...)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				dependents, err := g.ReverseDependencies(key, false)
+				tests.ExecuteE(err).NoError(t)
+				if len(dependents) > 0 {
+					edges[key] = dependents
+				}
+
+				meta, _ := g.Meta(key)
+				if meta.Labels[LabelIndirect] == "true" {
+					indirect = append(indirect, key)
+				}
+			}
+			tests.Execute(edges).Equal(t, tc.edges)
+			tests.Execute(indirect).Equal(t, tc.indirect)
+
+			meta, _ := g.Meta("example.com/app")
+			tests.Execute(meta.Labels[LabelMain]).Equal(t, "true")
+		})
+	}
+}
@@ -3,6 +3,11 @@ package graph
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/pasataleo/go-errors/errors"
 )
 
 // Graph is a graph data structure.
@@ -15,6 +20,21 @@ type Graph struct {
 
 	// finishers is a map of nodes that have no children.
 	finishers map[string]bool
+
+	// outputs maps node keys to the names and types of the outputs they declare.
+	outputs map[string]map[string]reflect.Type
+
+	// inputs maps node keys to the outputs they consume.
+	inputs map[string][]binding
+
+	// observers are notified of every change made to the graph, see Observe.
+	observers *observers
+
+	// memo remembers things worked out about the graph, such as its Hash, until it is next changed.
+	memo *memo
+
+	// arena allocates the nodes and edges of the graph, if it was created by NewGraphInArena.
+	arena *Arena
 }
 
 // Opts contains options for walking the graph.
@@ -26,7 +46,254 @@ type Opts struct {
 	Parallelism int
 
 	// Callbacks contains callbacks for various events in the graphs.
+	//
+	// Callbacks are kept for compatibility, new code should subscribe to Bus instead.
 	Callbacks Callbacks
+
+	// Bus receives every event published during the walk, if set.
+	Bus *Bus
+
+	// ContextFn is called before each node executes, and the context it returns is handed to the node. It can be used
+	// to inject per-node deadlines, tracing baggage, credentials or tenant values based on the node metadata.
+	//
+	// Optional, the walk context is used unchanged if nil.
+	ContextFn func(ctx context.Context, key string, meta Meta) context.Context
+
+	// Logger is the parent of the loggers handed to each node, see Logger.
+	//
+	// Defaults to slog.Default().
+	Logger *slog.Logger
+
+	// Route decides who owns each node, for example by looking up the team owning a label. The owner is recorded in
+	// every NodeResult and node Event, and WalkResult.FailuresByOwner groups failures by it.
+	//
+	// Defaults to Meta.Owner.
+	Route func(key string, meta Meta) string
+
+	// Authorize is called before each node runs, with the context and metadata the node would run with. If it returns
+	// an error the node doesn't run, and fails with a Forbidden error wrapping the one returned. It lets multi-tenant
+	// services check that whoever triggered the walk is allowed to run each step, typically using a principal stored
+	// in the context.
+	//
+	// Optional, every node is allowed to run if nil.
+	Authorize func(ctx context.Context, key string, meta Meta) error
+
+	// Resolver resolves the environment of each node just before it runs, see Meta.Env.
+	//
+	// Optional, the environment is passed to nodes exactly as it was declared if nil.
+	Resolver Resolver
+
+	// Artifacts is made available to nodes through PutArtifact and GetArtifact.
+	//
+	// Optional, nodes can't exchange artifacts if nil.
+	Artifacts Artifacts
+
+	// Blackboard is made available to nodes through WriteValue and ReadValue.
+	//
+	// Optional, nodes can't share values if nil.
+	Blackboard *Blackboard
+
+	// Roots starts the walk from the given nodes rather than the starters of the graph, to resume from a known stage.
+	// Only the roots and their descendants are walked. Everything else, including the ancestors of the roots, is assumed
+	// to have completed already and doesn't appear in the result, so the outputs of those nodes can't be read.
+	//
+	// Optional, the whole graph is walked if empty.
+	Roots []string
+
+	// Reverse walks the graph against the direction of its edges, so every node only runs once all of its children
+	// have completed. It is the natural order to tear down whatever a graph creates. Subgraphs returned by expandable
+	// nodes are reversed as well, but nodes added through a Stream or an Emitter are added exactly as given. Roots are
+	// also taken in the reversed direction, so their ancestors are walked rather than their descendants.
+	//
+	// Nodes can't read their inputs in a reversed walk, as they run before the nodes producing them.
+	//
+	// Defaults to false.
+	Reverse bool
+
+	// Resume resumes the walk from a checkpoint of an earlier walk of the same graph, see WalkResult.Checkpoint. Nodes
+	// that completed in the checkpoint complete again without executing, and are marked as reused in the result. Nodes
+	// whose Meta.Version has changed since are run again, unless Migrate says they are still valid.
+	//
+	// Optional, every node executes if nil.
+	Resume *Checkpoint
+
+	// Migrate decides whether nodes that completed in the Resume checkpoint with a different version are still valid,
+	// see MigrateFunc.
+	//
+	// Optional, nodes whose version changed always run again if nil.
+	Migrate MigrateFunc
+
+	// Seed controls the order in which nodes that become ready at the same time are dispatched. Walks with the same
+	// seed dispatch nodes in exactly the same order, and with a Parallelism of 1 they also execute in exactly the same
+	// order, so order-dependent bugs can be reproduced.
+	//
+	// Defaults to 0, which dispatches nodes in the order they became ready, and nodes that became ready at the same time
+	// in order of their keys.
+	Seed int64
+
+	// Storage chooses how the walker stores the sets of nodes it keeps track of, see StorageProfile.
+	//
+	// Defaults to StorageDefault.
+	Storage StorageProfile
+
+	// FreeArena frees the arena of the graph once the walk has finished, see NewGraphInArena. The graph must not be used
+	// again afterwards, though the WalkResult can be.
+	//
+	// Unlike every other option, FreeArena modifies the graph, so a graph walked with it must not be walked more than
+	// once, and must not be walked concurrently: the first walk to finish would free the nodes the others still read.
+	// Free the arena with Arena.Free once every walk has finished instead.
+	FreeArena bool
+
+	// FailFast cancels the rest of the walk as soon as any node fails. Nodes that are running have their context
+	// cancelled, and nodes that haven't started are never dispatched. They are all reported as cancelled with the
+	// CancelFailFast reason, rather than as errors.
+	//
+	// Defaults to false, which runs every node that doesn't depend on a failed node.
+	FailFast bool
+
+	// ProfileLabels labels the goroutine running each node with pprof labels naming the node and the walk, see
+	// ProfileLabelNode and ProfileLabelWalk, so CPU profiles of a walk attribute the time spent to the nodes. Goroutines
+	// started by the nodes inherit the labels.
+	//
+	// Defaults to false, as labelling every node has a small cost.
+	ProfileLabels bool
+
+	// SchedulerTrace logs every decision the walker makes, see SchedulerTrace.
+	//
+	// Optional, decisions are not logged if nil.
+	SchedulerTrace *SchedulerTrace
+
+	// Metrics receives measurements of the walker itself, see Metrics. The same measurements are summarised in
+	// WalkResult.Scheduler.
+	//
+	// Optional, measurements are only summarised if nil.
+	Metrics Metrics
+
+	// ResultBuffer is the number of results workers can hand back to the walker without waiting for it to accept them.
+	// A larger buffer frees workers up sooner when many nodes finish at once, at the cost of the walker reacting to
+	// them later. SchedulerStats.Backpressure shows how long workers spent waiting.
+	//
+	// Defaults to 1.
+	ResultBuffer int
+
+	// BatchSize is the maximum number of finished nodes the walker handles before dispatching more, so high throughput
+	// walks work out what is ready and dispatch it once for several nodes instead of once for each of them. Only the
+	// nodes whose results are already buffered are handled together, so it only has an effect along with ResultBuffer.
+	//
+	// Defaults to 1.
+	BatchSize int
+
+	// Deadline is when the walk should finish by. Optional nodes that would push the walk past it are pruned, see
+	// Meta.Optional. The walk itself isn't stopped at the deadline, use a context deadline for that.
+	//
+	// Defaults to the deadline of the walk context, if any.
+	Deadline time.Time
+
+	// Plans caches the plan of the graph, so walking the same graph again doesn't have to work out the critical paths
+	// used to prune optional nodes before the deadline again. See PlanCache.
+	//
+	// Optional, the plan is worked out as the walk needs it if nil.
+	Plans *PlanCache
+
+	// Windows restricts when nodes with the given tags may run, in addition to Meta.Window. A node must be inside every
+	// one of its windows to run.
+	Windows map[string]Window
+
+	// WindowPolicy decides what happens to nodes that become ready outside their windows.
+	//
+	// Defaults to WindowWait.
+	WindowPolicy WindowPolicy
+
+	// Now returns the current time when checking windows.
+	//
+	// Defaults to time.Now.
+	Now func() time.Time
+
+	// Quotas limit how many nodes with each tag may run, see Quota.
+	Quotas []Quota
+
+	// QuotaStore keeps track of the quotas. Pass the same store to several walks to share periodic quotas between them.
+	//
+	// Defaults to a new MemoryQuotaStore for every walk.
+	QuotaStore QuotaStore
+
+	// FanOuts limit how many nodes in each group of nodes sharing a tag may be in flight or fail, see FanOut.
+	FanOuts []FanOut
+
+	// Weights turns on fair scheduling across tags, for walks shared by several tenants such as merged graphs. Ready
+	// nodes are dispatched from each weighted tag in proportion to its weight, so a tenant with twice the weight gets
+	// twice the workers while both have nodes waiting, however many more nodes the other has ready. Nodes are scheduled
+	// under the first of their tags that has a weight, and nodes with none of them share a weight of one.
+	//
+	// Optional, nodes are dispatched in the order they become ready if empty.
+	Weights map[string]int
+
+	// StarvationThreshold is how long a node may be ready without being dispatched before it is reported as starved,
+	// with an EventNodeStarved event and in NodeResult.Starved, along with why it is still waiting. Use it to find nodes
+	// stuck behind saturated workers, parallelism limits, quotas or windows.
+	//
+	// Optional, starvation isn't looked for if zero.
+	StarvationThreshold time.Duration
+
+	// Breakers skip nodes using resources that keep failing, see CircuitBreaker.
+	Breakers []*CircuitBreaker
+
+	// History remembers how every node has fared across walks, see HistoryStore. Every node that executes during the
+	// walk is recorded in it once the walk has finished.
+	//
+	// Optional, nothing is remembered if nil.
+	History HistoryStore
+
+	// Quarantine skips optional nodes whose History says they fail too often, see Quarantine.
+	//
+	// Optional, nodes are never quarantined if nil.
+	Quarantine *Quarantine
+
+	// References resolves references to nodes in other graphs, see Graph.ConnectRef. It is set by RegistryRunner.
+	References ReferenceResolver
+
+	// Completions is shared with other walks, so each node with a Meta.Fingerprint only executes once between all of
+	// them. The others wait for it to finish, and then complete without executing. See CompletionStore.
+	//
+	// Optional, nodes always execute if nil.
+	Completions CompletionStore
+
+	// MaxCost is the budget of the walk. Once the costs reported by the nodes through AddCost reach it, no more nodes
+	// are dispatched. Nodes that are already running are left to finish, the rest are cancelled with CancelBudget and the
+	// walk returns an error with the ExceededBudget code.
+	//
+	// Optional, the walk is unbudgeted if zero.
+	MaxCost float64
+
+	// Speculation starts speculative copies of nodes that run much longer than the rest, see Speculation.
+	//
+	// Optional, nodes are never speculated if nil.
+	Speculation *Speculation
+
+	// Stream adds nodes and edges to the walk while it runs, see Stream. The walk doesn't finish until the stream is
+	// closed.
+	//
+	// Optional, the walk only contains the graph being walked and the nodes they enqueue if nil.
+	Stream *Stream
+
+	// Debugger stops the walk at breakpoints, see Debugger.
+	//
+	// Optional, the walk never stops if nil.
+	Debugger *Debugger
+
+	// StepMode steps through the walk one node at a time: the walk stops before every node, as if every node had a
+	// breakpoint, and only runs it once the Debugger continues. Nothing else runs alongside it, whatever the Parallelism.
+	// It suits demos, teaching and careful production runbooks. It requires a Debugger.
+	//
+	// Defaults to false.
+	StepMode bool
+
+	// Verify makes the walker assert its own invariants as it runs: no node is dispatched before all its parents have
+	// completed, and no node is dispatched or finishes more than once. A violation is a bug in the walker, so it panics
+	// with an InvariantViolation error describing the state of the walk.
+	//
+	// Verify is intended for tests, it adds overhead to every node.
+	Verify bool
 }
 
 // Callbacks contains callbacks for various events in the graphs.
@@ -41,18 +308,33 @@ type Callbacks struct {
 
 	// OnError is called when a node errors.
 	OnError func(key string, err error)
+
+	// OnCancel is called when a node is cancelled.
+	OnCancel func(key string, reason CancelReason)
 }
 
-func (callbacks *Callbacks) validate() {
-	if callbacks.OnError == nil {
-		callbacks.OnError = func(key string, err error) {}
-	}
-	if callbacks.OnExpand == nil {
-		callbacks.OnExpand = func(key string) {}
-	}
-	if callbacks.OnComplete == nil {
-		callbacks.OnComplete = func(key string) {}
-	}
+// Sink adapts the callbacks into a Sink that can be subscribed to a Bus.
+func (callbacks Callbacks) Sink() Sink {
+	return SinkFunc(func(event Event) {
+		switch event.Type {
+		case EventNodeCompleted:
+			if callbacks.OnComplete != nil {
+				callbacks.OnComplete(event.Key)
+			}
+		case EventNodeExpanded:
+			if callbacks.OnExpand != nil {
+				callbacks.OnExpand(event.Key)
+			}
+		case EventNodeErrored:
+			if callbacks.OnError != nil {
+				callbacks.OnError(event.Key, event.Err)
+			}
+		case EventNodeCancelled:
+			if callbacks.OnCancel != nil {
+				callbacks.OnCancel(event.Key, event.Reason)
+			}
+		}
+	})
 }
 
 // NewGraph creates a new graph.
@@ -61,53 +343,284 @@ func NewGraph() Graph {
 		nodes:     make(map[string]*node),
 		starters:  make(map[string]bool),
 		finishers: make(map[string]bool),
+		outputs:   make(map[string]map[string]reflect.Type),
+		inputs:    make(map[string][]binding),
+		observers: &observers{},
+		memo:      &memo{},
 	}
 }
 
 // AddNode adds a node to the graph.
-func (g Graph) AddNode(key string, impl interface{}) {
-	if _, ok := impl.(ExecutableNode); ok {
-		g.nodes[key] = &node{
-			key:  key,
-			impl: impl,
+//
+// AddNode returns an error with the DuplicateNode code if a node with the same key already exists, use UpsertNode or
+// ReplaceNode to change an existing node.
+func (g Graph) AddNode(key string, impl interface{}) error {
+	return g.AddNodeWithMeta(key, impl, Meta{})
+}
+
+// AddNodeWithMeta adds a node to the graph, along with metadata describing it.
+func (g Graph) AddNodeWithMeta(key string, impl interface{}, meta Meta) error {
+	if _, ok := g.nodes[key]; ok {
+		err := errors.Newf(nil, DuplicateNode, "node %q already exists", key)
+		return errors.Embed(err, NodeKey, key)
+	}
+
+	node, err := g.newNode(key, impl, meta)
+	if err != nil {
+		return err
+	}
+
+	key = node.key
+	g.nodes[key] = node
+	g.starters[key] = true
+	g.finishers[key] = true
+	g.memo.reset()
+	g.observers.notify(func(observer Observer) {
+		observer.NodeAdded(key, meta)
+	})
+	return nil
+}
+
+// UpsertNode adds a node to the graph, or replaces the implementation of the node if it already exists. The metadata
+// and all the edges of an existing node are kept.
+//
+// UpsertNode returns an error with the InvalidNode code if impl isn't a valid node implementation.
+func (g Graph) UpsertNode(key string, impl interface{}) error {
+	existing, ok := g.nodes[key]
+	if !ok {
+		return g.AddNode(key, impl)
+	}
+	return g.UpsertNodeWithMeta(key, impl, existing.meta)
+}
+
+// UpsertNodeWithMeta adds a node to the graph, or replaces the implementation and metadata of the node if it already
+// exists. All the edges of an existing node are kept.
+//
+// UpsertNodeWithMeta returns an error with the InvalidNode code if impl isn't a valid node implementation.
+func (g Graph) UpsertNodeWithMeta(key string, impl interface{}, meta Meta) error {
+	existing, ok := g.nodes[key]
+	if !ok {
+		return g.AddNodeWithMeta(key, impl, meta)
+	}
+
+	replacement, err := g.newNode(key, impl, meta)
+	if err != nil {
+		return err
+	}
+
+	replacement.parents = existing.parents
+	replacement.children = existing.children
+	g.nodes[key] = replacement
+	g.memo.reset()
+	g.observers.notify(func(observer Observer) {
+		observer.NodeReplaced(key, meta)
+	})
+	return nil
+}
+
+// ReplaceNode replaces an existing node entirely. The implementation is swapped, the metadata is cleared, and every
+// edge to or from the node is removed along with any inputs and outputs it declared.
+//
+// ReplaceNode returns an error with the MissingNode code if the node does not exist.
+func (g Graph) ReplaceNode(key string, impl interface{}) error {
+	return g.ReplaceNodeWithMeta(key, impl, Meta{})
+}
+
+// ReplaceNodeWithMeta replaces an existing node entirely, exactly like ReplaceNode, along with metadata describing it.
+func (g Graph) ReplaceNodeWithMeta(key string, impl interface{}, meta Meta) error {
+	existing, ok := g.nodes[key]
+	if !ok {
+		err := errors.Newf(nil, MissingNode, "node %q does not exist", key)
+		return errors.Embed(err, NodeKey, key)
+	}
+
+	replacement, err := g.newNode(key, impl, meta) // build it first, so we fail before modifying anything.
+	if err != nil {
+		return err
+	}
+
+	g.detach(existing)
+
+	g.nodes[key] = replacement
+	g.starters[key] = true
+	g.finishers[key] = true
+	g.memo.reset()
+	g.observers.notify(func(observer Observer) {
+		observer.NodeReplaced(key, meta)
+	})
+	return nil
+}
+
+// RemoveNode removes a node from the graph, along with every edge to or from it and any inputs and outputs it
+// declared. Inputs of other nodes bound to its outputs are left in place, and are reported by Validate.
+//
+// RemoveNode returns an error with the MissingNode code if the node does not exist.
+func (g Graph) RemoveNode(key string) error {
+	existing, ok := g.nodes[key]
+	if !ok {
+		err := errors.Newf(nil, MissingNode, "node %q does not exist", key)
+		return errors.Embed(err, NodeKey, key)
+	}
+
+	g.detach(existing)
+	delete(g.nodes, key)
+	delete(g.starters, key)
+	delete(g.finishers, key)
+	g.memo.reset()
+	g.observers.notify(func(observer Observer) {
+		observer.NodeRemoved(key)
+	})
+	return nil
+}
+
+// detach removes every edge to or from the node, and any inputs and outputs it declared.
+func (g Graph) detach(existing *node) {
+	key := existing.key
+	for _, parent := range existing.parents {
+		g.nodes[parent].children = remove(g.nodes[parent].children, key)
+		if len(g.nodes[parent].children) == 0 {
+			g.finishers[parent] = true
+		}
+		g.observers.notify(func(observer Observer) {
+			observer.EdgeRemoved(parent, key)
+		})
+	}
+	for _, child := range existing.children {
+		g.nodes[child].parents = remove(g.nodes[child].parents, key)
+		if len(g.nodes[child].parents) == 0 {
+			g.starters[child] = true
 		}
-		g.starters[key] = true
-		g.finishers[key] = true
-		return
+		g.observers.notify(func(observer Observer) {
+			observer.EdgeRemoved(key, child)
+		})
+	}
+	delete(g.outputs, key)
+	delete(g.inputs, key)
+}
+
+// newNode creates a new node, returning an InvalidNode error if impl isn't a valid node implementation.
+func newNode(key string, impl interface{}, meta Meta) (*node, error) {
+	if err := checkNode(key, impl); err != nil {
+		return nil, err
+	}
+
+	return &node{
+		key:  intern(key),
+		impl: impl,
+		meta: meta,
+	}, nil
+}
+
+// newNode creates a new node exactly like the newNode function, but allocates it from the arena of the graph if it has
+// one.
+func (g Graph) newNode(key string, impl interface{}, meta Meta) (*node, error) {
+	if g.arena == nil {
+		return newNode(key, impl, meta)
 	}
 
-	if _, ok := impl.(ExpandableNode); ok {
-		g.nodes[key] = &node{
-			key:  key,
-			impl: impl,
+	if err := checkNode(key, impl); err != nil {
+		return nil, err
+	}
+
+	node := g.arena.node()
+	node.key = intern(key)
+	node.impl = impl
+	node.meta = meta
+	return node, nil
+}
+
+// checkNode returns an InvalidNode error if impl isn't a valid node implementation.
+func checkNode(key string, impl interface{}) error {
+	_, executable := impl.(ExecutableNode)
+	_, expandable := impl.(ExpandableNode)
+	_, streaming := impl.(StreamingExpandableNode)
+	_, lazy := impl.(*lazyNode)
+	if !executable && !expandable && !streaming && !lazy {
+		err := errors.Newf(nil, InvalidNode, "node %q does not implement ExecutableNode or ExpandableNode", key)
+		return errors.Embed(err, NodeKey, key)
+	}
+	return nil
+}
+
+// remove returns the slice without any occurrences of value.
+func remove(values []string, value string) []string {
+	var kept []string
+	for _, candidate := range values {
+		if candidate != value {
+			kept = append(kept, candidate)
 		}
-		g.starters[key] = true
-		g.finishers[key] = true
-		return
 	}
+	return kept
+}
 
-	panic(fmt.Errorf("node %q does not implement ExecutableNode or ExpandableNode", key))
+// Clone returns a copy of the graph that can be modified without affecting the original. The node implementations
+// themselves are shared.
+func (g Graph) Clone() Graph {
+	clone := NewGraph()
+	for key, n := range g.nodes {
+		clone.nodes[key] = &node{
+			key:      n.key,
+			impl:     n.impl,
+			meta:     n.meta,
+			parents:  append([]string(nil), n.parents...),
+			children: append([]string(nil), n.children...),
+		}
+	}
+	for key := range g.starters {
+		clone.starters[key] = true
+	}
+	for key := range g.finishers {
+		clone.finishers[key] = true
+	}
+	for key, outputs := range g.outputs {
+		clone.outputs[key] = make(map[string]reflect.Type, len(outputs))
+		for name, typ := range outputs {
+			clone.outputs[key][name] = typ
+		}
+	}
+	for key, inputs := range g.inputs {
+		clone.inputs[key] = append([]binding(nil), inputs...)
+	}
+	return clone
 }
 
-// Connect connects two nodes in the graph.
-func (g Graph) Connect(from string, to string) {
-	if from == to {
-		panic(fmt.Errorf("cannot connect node %q to itself", from))
+// Meta returns the metadata of the given node.
+func (g Graph) Meta(key string) (Meta, bool) {
+	node, ok := g.nodes[key]
+	if !ok {
+		return Meta{}, false
 	}
+	return node.meta, true
+}
 
-	if _, ok := g.nodes[from]; !ok {
-		panic(fmt.Errorf("node %q does not exist", from))
+// Connect connects two nodes in the graph, so that to only runs once from has completed.
+//
+// Connect returns an error with the SelfLoop code if from and to are the same node, and an error with the MissingNode
+// code if either node does not exist. The graph is left unchanged if it returns an error.
+func (g Graph) Connect(from string, to string) error {
+	if from == to {
+		err := errors.Newf(nil, SelfLoop, "cannot connect node %q to itself", from)
+		return errors.Embed(err, NodeKey, from)
 	}
 
-	if _, ok := g.nodes[to]; !ok {
-		panic(fmt.Errorf("node %q does not exist", to))
+	for _, key := range []string{from, to} {
+		if _, ok := g.nodes[key]; !ok {
+			err := errors.Newf(nil, MissingNode, "node %q does not exist", key)
+			return errors.Embed(err, NodeKey, key)
+		}
 	}
 
-	g.nodes[from].children = append(g.nodes[from].children, to)
-	g.nodes[to].parents = append(g.nodes[to].parents, from)
+	from, to = intern(from), intern(to)
+	g.nodes[from].children = g.arena.append(g.nodes[from].children, to)
+	g.nodes[to].parents = g.arena.append(g.nodes[to].parents, from)
 
 	delete(g.starters, to)
 	delete(g.finishers, from)
+	g.memo.reset()
+	g.observers.notify(func(observer Observer) {
+		observer.EdgeAdded(from, to)
+	})
+	return nil
 }
 
 // Starters returns the keys of the nodes that have no parents.
@@ -128,20 +641,80 @@ func (g Graph) Finishers() []string {
 	return finishers
 }
 
+// Walk walks the graph, executing and expanding every node once all of its parents have completed.
+//
+// Walking never modifies the graph or the options, so the same graph can be walked any number of times, including
+// concurrently with the same options. Everything a walk tracks is copied into the walk itself, and only the node
+// implementations and anything in the options that is stateful, such as a Stream, are shared. The one exception is
+// Opts.FreeArena, which frees the graph once the walk has finished.
 func (g Graph) Walk(ctx context.Context, opts *Opts) error {
+	_, err := g.Run(ctx, opts)
+	return err
+}
+
+// Run walks the graph exactly like Walk, but also returns a WalkResult describing what happened to every node.
+//
+// The result is returned even if the walk fails, unless the graph could not be prepared for walking at all (for example
+// because a DependencyResolver failed).
+func (g Graph) Run(ctx context.Context, opts *Opts) (*WalkResult, error) {
 	if opts == nil {
 		opts = &Opts{
 			Parallelism: 1,
 		}
 	}
 
+	// The options may be shared with other walks, so fill in the defaults on a copy.
+	copied := *opts
+	opts = &copied
+
 	if opts.Parallelism == 0 {
 		panic(fmt.Errorf("parallelism must be greater than 0"))
 	}
 
-	// make sure all callbacks are set
-	opts.Callbacks.validate()
+	if opts.StepMode && opts.Debugger == nil {
+		panic(fmt.Errorf("step mode requires a debugger"))
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	if opts.ResultBuffer == 0 {
+		opts.ResultBuffer = 1
+	}
+
+	if opts.BatchSize == 0 {
+		opts.BatchSize = 1
+	}
+
+	if opts.FreeArena && g.arena != nil {
+		defer g.arena.Free()
+	}
+
+	g, err := g.resolveDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Reverse {
+		g = g.reversed()
+	}
 
-	var walker walker
-	return walker.Walk(ctx, g, opts)
+	g, err = g.fromRoots(opts.Roots)
+	if err != nil {
+		return nil, err
+	}
+
+	// the callbacks are just another subscriber to the events of this walk.
+	bus := NewBus(opts.Callbacks.Sink())
+	if opts.Bus != nil {
+		bus.Subscribe(opts.Bus)
+	}
+
+	walker := walker{
+		id:  newWalkID(),
+		bus: bus,
+	}
+	err = walker.Walk(ctx, g, opts)
+	return walker.result, err
 }
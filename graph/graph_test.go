@@ -2,9 +2,13 @@ package graph
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/pasataleo/go-errors/errors"
 	"github.com/pasataleo/go-testing/tests"
 )
 
@@ -259,3 +263,365 @@ func TestGraph_Validate_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestGraph_Walk_ContextFn(t *testing.T) {
+	type tenantKey struct{}
+
+	var tenant string
+	g := NewGraph()
+	g.AddNodeWithMeta("a", Executable(func(ctx context.Context) error {
+		tenant = ctx.Value(tenantKey{}).(string)
+		return nil
+	}), Meta{Labels: map[string]string{"tenant": "blue"}})
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{
+		Parallelism: 1,
+		ContextFn: func(ctx context.Context, key string, meta Meta) context.Context {
+			return context.WithValue(ctx, tenantKey{}, meta.Labels["tenant"])
+		},
+	})).NoError(t)
+	tests.Execute(tenant).Equal(t, "blue")
+}
+
+func TestGraph_AddNode_Duplicate(t *testing.T) {
+	g := NewGraph()
+	tests.ExecuteE(g.AddNode("a", Executable(func(ctx context.Context) error {
+		return nil
+	}))).NoError(t)
+	tests.ExecuteE(g.AddNode("a", Executable(func(ctx context.Context) error {
+		return nil
+	}))).MatchesError(t, "node \"a\" already exists")
+}
+
+func TestGraph_UpsertNode(t *testing.T) {
+	var builder strings.Builder
+	write := func(value string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			builder.WriteString(value)
+			return nil
+		})
+	}
+
+	g := NewGraph()
+	g.AddNode("a", write("a"))
+	g.AddNode("b", write("b"))
+	g.Connect("a", "b")
+
+	tests.ExecuteE(g.UpsertNode("a", write("x"))).NoError(t)
+	tests.ExecuteE(g.UpsertNode("c", write("c"))).NoError(t)
+	g.Connect("b", "c")
+
+	// Invalid implementations are rejected whether or not the node exists.
+	tests.Execute(errors.GetErrorCode(g.UpsertNode("a", 42))).Equal(t, InvalidNode)
+	tests.Execute(errors.GetErrorCode(g.UpsertNode("d", 42))).Equal(t, InvalidNode)
+	tests.Execute(errors.GetErrorCode(g.UpsertNodeWithMeta("d", 42, Meta{}))).Equal(t, InvalidNode)
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1})).NoError(t)
+	tests.Execute(builder.String()).Equal(t, "xbc")
+}
+
+func TestGraph_ReplaceNode(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNode("b", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect("a", "b")
+
+	tests.ExecuteE(g.ReplaceNode("b", Executable(func(ctx context.Context) error {
+		return nil
+	}))).NoError(t)
+	tests.ExecuteE(g.ReplaceNode("c", Executable(func(ctx context.Context) error {
+		return nil
+	}))).MatchesError(t, "node \"c\" does not exist")
+
+	starters := g.Starters()
+	sort.Strings(starters)
+	finishers := g.Finishers()
+	sort.Strings(finishers)
+	tests.Execute(starters).Equal(t, []string{"a", "b"})
+	tests.Execute(finishers).Equal(t, []string{"a", "b"})
+}
+
+func TestGraph_RemoveNode(t *testing.T) {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	g := NewGraph()
+	g.AddNode("a", noop)
+	g.AddNode("b", noop)
+	g.AddNode("c", noop)
+	g.Connect("a", "b")
+	g.Connect("b", "c")
+
+	tests.ExecuteE(g.RemoveNode("b")).NoError(t)
+	tests.ExecuteE(g.RemoveNode("b")).MatchesError(t, "node \"b\" does not exist")
+
+	starters := g.Starters()
+	sort.Strings(starters)
+	finishers := g.Finishers()
+	sort.Strings(finishers)
+	tests.Execute(starters).Equal(t, []string{"a", "c"})
+	tests.Execute(finishers).Equal(t, []string{"a", "c"})
+}
+
+func TestGraph_Walk_Seed(t *testing.T) {
+	walk := func(seed int64) string {
+		var builder strings.Builder
+
+		g := NewGraph()
+		for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+			g.AddNode(key, Executable(func(ctx context.Context) error {
+				builder.WriteString(key)
+				return nil
+			}))
+		}
+
+		tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1, Seed: seed})).NoError(t)
+		return builder.String()
+	}
+
+	for seed := int64(1); seed <= 5; seed++ {
+		tests.Execute(walk(seed)).Equal(t, walk(seed))
+	}
+}
+
+// executableExpandable is a node that both executes and expands.
+type executableExpandable struct {
+	execute func(ctx context.Context) error
+	expand  func(ctx context.Context) (Graph, error)
+}
+
+func (node executableExpandable) Execute(ctx context.Context) error {
+	return node.execute(ctx)
+}
+
+func (node executableExpandable) Expand(ctx context.Context) (Graph, error) {
+	return node.expand(ctx)
+}
+
+func TestGraph_Run_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNode("b", executableExpandable{
+		execute: func(ctx context.Context) error {
+			cancel()
+			return nil
+		},
+		expand: func(ctx context.Context) (Graph, error) {
+			t.Error("expected b not to expand after the walk was cancelled")
+			return NewGraph(), nil
+		},
+	})
+	g.AddNode("c", Executable(func(ctx context.Context) error {
+		t.Error("expected c not to execute after the walk was cancelled")
+		return nil
+	}))
+	g.AddNode("d", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect("a", "b")
+	g.Connect("b", "c")
+	g.Connect("c", "d")
+
+	result, err := g.Run(ctx, &Opts{Parallelism: 1})
+	tests.Execute(errors.GetErrorCode(err)).Equal(t, Cancelled)
+	tests.Execute(result.Keys()).Equal(t, []string{"a", "b", "c", "d"})
+	tests.Execute(result.Status(StatusCompleted)).Equal(t, []string{"a"})
+	tests.Execute(result.Status(StatusCancelled)).Equal(t, []string{"b", "c", "d"})
+	tests.Execute(result.Nodes["d"].Reason).Equal(t, CancelContext)
+}
+
+func TestGraph_Run_FailFast(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		return fmt.Errorf("boom")
+	}))
+	g.AddNode("b", Executable(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	g.AddNode("c", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect("b", "c")
+
+	var cancelled []string
+	result, err := g.Run(context.Background(), &Opts{
+		Parallelism: 2,
+		FailFast:    true,
+		Callbacks: Callbacks{
+			OnCancel: func(key string, reason CancelReason) {
+				cancelled = append(cancelled, key+":"+string(reason))
+			},
+		},
+	})
+	tests.ExecuteE(err).MatchesError(t, "a: failed to execute node (boom)")
+	tests.Execute(result.Status(StatusErrored)).Equal(t, []string{"a"})
+	tests.Execute(result.Status(StatusCancelled)).Equal(t, []string{"b", "c"})
+	tests.Execute(cancelled).Equal(t, []string{"b:fail_fast", "c:fail_fast"})
+}
+
+func TestGraph_Run_CancelManual(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		Handle(ctx).Cancel()
+		return nil
+	}))
+	g.AddNode("b", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect("a", "b")
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 1})
+	tests.ExecuteE(err).MatchesError(t, "walk was cancelled (manual) (context canceled)")
+	tests.Execute(result.Status(StatusCompleted)).Equal(t, []string{"a"})
+	tests.Execute(result.Nodes["b"].Status).Equal(t, StatusCancelled)
+	tests.Execute(result.Nodes["b"].Reason).Equal(t, CancelManual)
+}
+
+func TestGraph_Run_Ancestry(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", Expandable(func(ctx context.Context) (Graph, error) {
+		subgraph := NewGraph()
+		subgraph.AddNode("a1", Executable(func(ctx context.Context) error {
+			return fmt.Errorf("boom")
+		}))
+		subgraph.AddNode("a2", Executable(func(ctx context.Context) error {
+			return nil
+		}))
+		subgraph.Connect("a1", "a2")
+		return subgraph, nil
+	}))
+	g.AddNode("b", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNode("c", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect("a", "b")
+	g.Connect("b", "c")
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 1})
+	tests.ExecuteE(err).Error(t)
+	tests.Execute(result.Status(StatusErrored)).Equal(t, []string{"a", "a1"})
+	tests.Execute(result.Status(StatusSkipped)).Equal(t, []string{"a2", "b", "c"})
+
+	tests.Execute(errors.GetErrorCode(result.Nodes["c"].Err)).Equal(t, UpstreamFailed)
+	tests.ExecuteE(result.Nodes["c"].Err).MatchesError(t, "upstream node \"a1\" failed: a1 -> a2 -> a -> b -> c")
+	ancestry, _ := errors.GetEmbeddedData[[]string](result.Nodes["c"].Err, Ancestry)
+	tests.Execute(ancestry).Equal(t, []string{"a1", "a2", "a", "b", "c"})
+
+	ancestry, _ = errors.GetEmbeddedData[[]string](result.Nodes["a"].Err, Ancestry)
+	tests.Execute(ancestry).Equal(t, []string{"a1", "a2", "a"})
+}
+
+func TestGraph_ErrorCodes(t *testing.T) {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	g := NewGraph()
+	g.AddNode("a", noop)
+	g.AddNode("b", noop)
+
+	tests.Execute(errors.GetErrorCode(g.AddNode("a", noop))).Equal(t, DuplicateNode)
+	tests.Execute(errors.GetErrorCode(g.AddNode("c", "not a node"))).Equal(t, InvalidNode)
+	tests.Execute(errors.GetErrorCode(g.Connect("a", "a"))).Equal(t, SelfLoop)
+	tests.Execute(errors.GetErrorCode(g.Connect("a", "c"))).Equal(t, MissingNode)
+	tests.Execute(errors.GetErrorCode(g.ReplaceNode("c", noop))).Equal(t, MissingNode)
+
+	tests.ExecuteE(g.Connect("a", "b")).NoError(t)
+	tests.ExecuteE(g.Connect("b", "a")).NoError(t)
+
+	err := g.Validate()
+	tests.Execute(errors.GetErrorCode(err)).Equal(t, CycleDetected)
+	cycle, _ := errors.GetEmbeddedData[[]string](err, Cycle)
+	tests.Execute(cycle).Equal(t, []string{"a", "b", "a"})
+}
+
+func TestGraph_Run_ExpansionCollision(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNode("b", Expandable(func(ctx context.Context) (Graph, error) {
+		subgraph := NewGraph()
+		subgraph.AddNode("a", Executable(func(ctx context.Context) error {
+			t.Error("expected the colliding node not to execute")
+			return nil
+		}))
+		return subgraph, nil
+	}))
+	g.Connect("a", "b")
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 1})
+	tests.ExecuteE(err).MatchesError(t, "b: node \"b\" expanded into nodes that already exist: [a]")
+	tests.Execute(errors.GetErrorCode(result.Nodes["b"].Err)).Equal(t, ExpansionCollision)
+}
+
+func TestGraph_Run_Concurrent(t *testing.T) {
+	// Every walk expands into the same subgraph, and shares the same options.
+	subgraph := NewGraph()
+	subgraph.AddNode("b/1", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	subgraph.AddNode("b/2", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	subgraph.Connect("b/1", "b/2")
+
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNode("b", Expandable(func(ctx context.Context) (Graph, error) {
+		return subgraph, nil
+	}))
+	g.AddNode("c", StreamingExpandable(func(ctx context.Context, emitter *Emitter) error {
+		if err := emitter.AddNode("c/1", Executable(func(ctx context.Context) error {
+			return nil
+		})); err != nil {
+			return err
+		}
+		return emitter.AddNode("c/2", Executable(func(ctx context.Context) error {
+			return nil
+		}), "c/1")
+	}))
+	g.Connect("a", "b")
+	g.Connect("a", "c")
+
+	opts := &Opts{Parallelism: 2}
+
+	var wg sync.WaitGroup
+	results := make([]*WalkResult, 8)
+	errs := make([]error, len(results))
+	for ix := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[ix], errs[ix] = g.Run(context.Background(), opts)
+		}()
+	}
+	wg.Wait()
+
+	for ix, result := range results {
+		tests.ExecuteE(errs[ix]).NoError(t)
+		tests.Execute(result.Status(StatusCompleted)).Equal(t, []string{"a", "b", "b/1", "b/2", "c", "c/1", "c/2"})
+	}
+
+	// Neither the graph nor the options were touched by any of the walks.
+	tests.Execute(g.sortedKeys()).Equal(t, []string{"a", "b", "c"})
+	finishers := g.Finishers()
+	sort.Strings(finishers)
+	tests.Execute(finishers).Equal(t, []string{"b", "c"})
+	tests.Execute(opts.Logger == nil).Equal(t, true)
+}
@@ -0,0 +1,121 @@
+package graphtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+var _ graph.ExecutableNode = (*FakeNode)(nil)
+
+// FakeNode is an ExecutableNode with scripted outcomes, so retry, timeout and skip logic can be tested without relying
+// on sleeps. By default, a FakeNode succeeds immediately. The zero value is ready to use, and equivalent to NewFakeNode.
+type FakeNode struct {
+	mutex sync.Mutex
+
+	// failures is the number of attempts that fail with err before the node succeeds. If it is negative, every
+	// attempt fails.
+	failures int
+	err      error
+
+	// blocking is true if attempts block until the node is released.
+	blocking bool
+	released chan struct{}
+	once     sync.Once
+
+	// calls counts the attempts so far, and started is closed when the first attempt starts.
+	calls   int
+	started chan struct{}
+}
+
+// NewFakeNode creates a fake node that succeeds immediately.
+func NewFakeNode() *FakeNode {
+	return &FakeNode{}
+}
+
+// channels creates the channels of the node if they don't exist yet, so the zero value can be used. It must be called
+// with the mutex held.
+func (node *FakeNode) channels() {
+	if node.released == nil {
+		node.released = make(chan struct{})
+		node.started = make(chan struct{})
+	}
+}
+
+// SucceedAfter makes the first n attempts fail with the given error, and every later attempt succeed.
+func (node *FakeNode) SucceedAfter(n int, err error) *FakeNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	node.failures = n
+	node.err = err
+	return node
+}
+
+// FailWith makes every attempt fail with the given error.
+func (node *FakeNode) FailWith(err error) *FakeNode {
+	return node.SucceedAfter(-1, err)
+}
+
+// BlockUntilReleased makes every attempt block until Release is called, or the context of the attempt is done.
+func (node *FakeNode) BlockUntilReleased() *FakeNode {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	node.blocking = true
+	return node
+}
+
+// Release unblocks every current and future attempt.
+func (node *FakeNode) Release() {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	node.channels()
+	node.once.Do(func() {
+		close(node.released)
+	})
+}
+
+// Calls returns the number of attempts so far.
+func (node *FakeNode) Calls() int {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	return node.calls
+}
+
+// Started returns a channel that is closed as soon as the first attempt starts.
+func (node *FakeNode) Started() <-chan struct{} {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	node.channels()
+	return node.started
+}
+
+// Execute implements graph.ExecutableNode.
+func (node *FakeNode) Execute(ctx context.Context) error {
+	node.mutex.Lock()
+	node.channels()
+	node.calls++
+	attempt := node.calls
+	if attempt == 1 {
+		close(node.started)
+	}
+	blocking, failures, err, released := node.blocking, node.failures, node.err, node.released
+	node.mutex.Unlock()
+
+	if blocking {
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if failures < 0 || attempt <= failures {
+		return err
+	}
+	return nil
+}
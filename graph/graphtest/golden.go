@@ -0,0 +1,50 @@
+package graphtest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+// update rewrites golden files with the actual output instead of comparing against them, run the tests with
+// -graphtest.update to use it.
+var update = flag.Bool("graphtest.update", false, "update golden files instead of comparing against them")
+
+// Trace returns the recorded events in the canonical trace format, see graph.FormatTrace.
+func (recorder *Recorder) Trace() string {
+	return graph.FormatTrace(recorder.Events())
+}
+
+// AssertGolden asserts that actual matches the contents of the golden file at path.
+//
+// If the tests are run with -graphtest.update, the golden file is written with actual instead.
+func AssertGolden(t testing.TB, path string, actual string) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory for golden file %q: %v", path, err)
+		}
+		if err := os.WriteFile(path, []byte(actual), 0o644); err != nil {
+			t.Fatalf("failed to update golden file %q: %v", path, err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file %q (run with -graphtest.update to create it): %v", path, err)
+	}
+	if string(expected) != actual {
+		t.Errorf("output does not match golden file %q (run with -graphtest.update to update it)\nexpected:\n%s\nactual:\n%s", path, expected, actual)
+	}
+}
+
+// AssertTrace asserts that the trace of the recorded walk matches the golden file at path.
+func AssertTrace(t testing.TB, recorder *Recorder, path string) {
+	t.Helper()
+	AssertGolden(t, path, recorder.Trace())
+}
@@ -0,0 +1,113 @@
+package graphtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+func diamond() graph.Graph {
+	g := graph.NewGraph()
+	for _, key := range []string{"a", "b", "c", "d"} {
+		g.AddNode(key, graph.Executable(func(ctx context.Context) error {
+			return nil
+		}))
+	}
+	g.Connect("a", "b")
+	g.Connect("a", "c")
+	g.Connect("b", "d")
+	g.Connect("c", "d")
+	return g
+}
+
+func TestRecordingWalker(t *testing.T) {
+	recorder, result, err := RecordingWalker{}.Walk(context.Background(), diamond())
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(len(result.Nodes)).Equal(t, 4)
+
+	RanBefore(t, recorder, "a", "b")
+	RanBefore(t, recorder, "a", "c")
+	RanBefore(t, recorder, "b", "d")
+	RanBefore(t, recorder, "c", "d")
+	Completed(t, recorder, "a", "b", "c", "d")
+}
+
+func TestStepWalker(t *testing.T) {
+	walker := NewStepWalker(context.Background(), diamond(), graph.Opts{Parallelism: 2})
+
+	key, ok := walker.Step()
+	tests.Execute(ok).Equal(t, true)
+	tests.Execute(key).Equal(t, "a")
+	Completed(t, walker.Recorder, "a")
+	NotStarted(t, walker.Recorder, "d")
+
+	tests.ExecuteE(walker.StepKey("c")).NoError(t)
+	NotStarted(t, walker.Recorder, "d")
+
+	tests.ExecuteE(walker.StepKey("b")).NoError(t)
+	tests.ExecuteE(walker.StepKey("d")).NoError(t)
+
+	_, err := walker.Wait()
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(walker.Recorder.Completed()).Equal(t, []string{"a", "c", "b", "d"})
+	tests.ExecuteE(walker.StepKey("a")).MatchesError(t, "the walk finished without node \"a\" becoming ready")
+}
+
+func TestFakeNode(t *testing.T) {
+	failure := errors.New("failure")
+
+	node := NewFakeNode().SucceedAfter(2, failure)
+	tests.ExecuteE(node.Execute(context.Background())).MatchesError(t, "failure")
+	tests.ExecuteE(node.Execute(context.Background())).MatchesError(t, "failure")
+	tests.ExecuteE(node.Execute(context.Background())).NoError(t)
+	tests.Execute(node.Calls()).Equal(t, 3)
+
+	node = NewFakeNode().FailWith(failure)
+	for i := 0; i < 3; i++ {
+		tests.ExecuteE(node.Execute(context.Background())).MatchesError(t, "failure")
+	}
+
+	node = NewFakeNode().BlockUntilReleased()
+	done := make(chan error)
+	go func() {
+		done <- node.Execute(context.Background())
+	}()
+	<-node.Started()
+	node.Release()
+	tests.ExecuteE(<-done).NoError(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	node = NewFakeNode().BlockUntilReleased()
+	go func() {
+		done <- node.Execute(ctx)
+	}()
+	<-node.Started()
+	cancel()
+	tests.ExecuteE(<-done).MatchesError(t, "context canceled")
+
+	// The zero value succeeds immediately, and can still be blocked.
+	node = &FakeNode{}
+	tests.ExecuteE(node.Execute(context.Background())).NoError(t)
+	<-node.Started()
+
+	node = (&FakeNode{}).BlockUntilReleased()
+	node.Release()
+	tests.ExecuteE(node.Execute(context.Background())).NoError(t)
+}
+
+func TestAssertTrace(t *testing.T) {
+	g := diamond()
+	g.AddNode("e", graph.Executable(func(ctx context.Context) error {
+		return errors.New("boom")
+	}))
+	g.Connect("d", "e")
+
+	recorder, _, err := RecordingWalker{}.Walk(context.Background(), g)
+	tests.ExecuteE(err).Error(t)
+
+	AssertTrace(t, recorder, "testdata/diamond.trace")
+}
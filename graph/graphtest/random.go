@@ -0,0 +1,277 @@
+package graphtest
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+
+	"github.com/pasataleo/go-errors/errors"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+var (
+	InvariantViolated errors.ErrorCode = "graphtest.invariant_violated"
+)
+
+// RandomOpts controls the shape of the graphs generated by RandomDAG.
+type RandomOpts struct {
+	// Nodes is the number of nodes at the top level of the graph.
+	Nodes int
+
+	// Density is the probability that any two nodes are connected, between 0 and 1.
+	Density float64
+
+	// ExpandProbability is the probability that a node is expandable rather than executable, between 0 and 1.
+	ExpandProbability float64
+
+	// FailProbability is the probability that a node fails, between 0 and 1.
+	FailProbability float64
+
+	// MaxDepth limits how deeply expansions can be nested.
+	//
+	// Defaults to 2.
+	MaxDepth int
+}
+
+// spec describes a generated graph, so the expected behaviour of walking it can be calculated.
+type spec struct {
+	keys  []string
+	edges map[string][]string
+
+	// fails contains the nodes that return an error.
+	fails map[string]bool
+
+	// subgraphs contains the specs of the subgraphs expandable nodes expand into.
+	subgraphs map[string]*spec
+}
+
+// RandomDAG generates a random directed acyclic graph, along with a Tracker that records how it is walked and can check
+// the walk against the invariants of the walker.
+func RandomDAG(rng *rand.Rand, opts RandomOpts) (graph.Graph, *Tracker) {
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = 2
+	}
+
+	tracker := &Tracker{
+		calls: make(map[string][]call),
+	}
+	tracker.spec = randomSpec(rng, opts, "n", opts.Nodes, 0)
+	return tracker.build(tracker.spec), tracker
+}
+
+func randomSpec(rng *rand.Rand, opts RandomOpts, prefix string, nodes int, depth int) *spec {
+	s := &spec{
+		edges:     make(map[string][]string),
+		fails:     make(map[string]bool),
+		subgraphs: make(map[string]*spec),
+	}
+
+	for ix := 0; ix < nodes; ix++ {
+		key := fmt.Sprintf("%s%d", prefix, ix)
+		s.keys = append(s.keys, key)
+
+		if rng.Float64() < opts.FailProbability {
+			s.fails[key] = true
+		}
+
+		if depth < opts.MaxDepth && rng.Float64() < opts.ExpandProbability {
+			s.subgraphs[key] = randomSpec(rng, opts, key+"/", rng.IntN(nodes+1), depth+1)
+		}
+	}
+
+	// Only ever connect earlier nodes to later nodes, which guarantees there are no cycles.
+	for i := 0; i < nodes; i++ {
+		for j := i + 1; j < nodes; j++ {
+			if rng.Float64() < opts.Density {
+				s.edges[s.keys[i]] = append(s.edges[s.keys[i]], s.keys[j])
+			}
+		}
+	}
+	return s
+}
+
+// call records a single execution or expansion of a node, as positions in the global sequence of calls.
+type call struct {
+	start int
+	end   int
+}
+
+// Tracker records every call made to the nodes of a generated graph.
+type Tracker struct {
+	spec *spec
+
+	mutex    sync.Mutex
+	sequence int
+	calls    map[string][]call
+}
+
+func (tracker *Tracker) begin() int {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.sequence++
+	return tracker.sequence
+}
+
+func (tracker *Tracker) end(key string, start int) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.sequence++
+	tracker.calls[key] = append(tracker.calls[key], call{start: start, end: tracker.sequence})
+}
+
+func (tracker *Tracker) build(s *spec) graph.Graph {
+	g := graph.NewGraph()
+	for _, key := range s.keys {
+		key := key
+
+		var err error
+		if s.fails[key] {
+			err = fmt.Errorf("%s failed", key)
+		}
+
+		if subgraph, ok := s.subgraphs[key]; ok {
+			g.AddNode(key, graph.Expandable(func(ctx context.Context) (graph.Graph, error) {
+				start := tracker.begin()
+				defer tracker.end(key, start)
+				if err != nil {
+					return graph.Graph{}, err
+				}
+				return tracker.build(subgraph), nil
+			}))
+			continue
+		}
+
+		g.AddNode(key, graph.Executable(func(ctx context.Context) error {
+			start := tracker.begin()
+			defer tracker.end(key, start)
+			return err
+		}))
+	}
+
+	for _, from := range s.keys {
+		for _, to := range s.edges[from] {
+			g.Connect(from, to)
+		}
+	}
+	return g
+}
+
+// Check verifies the recorded walk against the invariants of the walker:
+//
+//   - every node whose parents all completed ran exactly once, and every other node never ran.
+//   - every node ran after all of its parents (and everything they expanded into) had finished.
+//   - every node added by an expansion ran after the expansion finished.
+func (tracker *Tracker) Check() error {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	var multi error
+	tracker.check(tracker.spec, true, &multi)
+	return multi
+}
+
+// check verifies the spec, and returns whether every node in it completed. reachable is false if the spec belongs to an
+// expansion that never happened.
+func (tracker *Tracker) check(s *spec, reachable bool, multi *error) bool {
+	parents := make(map[string][]string)
+	for _, from := range s.keys {
+		for _, to := range s.edges[from] {
+			parents[to] = append(parents[to], from)
+		}
+	}
+
+	completed := make(map[string]bool)
+	all := true
+	for _, key := range s.keys { // keys are in topological order, as edges only go forward.
+		ready := reachable
+		for _, parent := range parents[key] {
+			ready = ready && completed[parent]
+		}
+
+		calls := tracker.calls[key]
+		switch {
+		case ready && len(calls) != 1:
+			*multi = errors.Append(*multi, errors.Newf(nil, InvariantViolated, "expected %q to run once, but it ran %d times", key, len(calls)))
+		case !ready && len(calls) != 0:
+			*multi = errors.Append(*multi, errors.Newf(nil, InvariantViolated, "expected %q not to run, but it ran %d times", key, len(calls)))
+		}
+
+		ran := ready && len(calls) == 1
+		completed[key] = ran && !s.fails[key]
+		if subgraph, ok := s.subgraphs[key]; ok {
+			completed[key] = tracker.check(subgraph, completed[key], multi) && completed[key]
+		}
+		all = all && completed[key]
+
+		if !ran {
+			continue
+		}
+
+		start := calls[0].start
+		for _, parent := range parents[key] {
+			if end := tracker.lastEnd(parent, s); end > start {
+				*multi = errors.Append(*multi, errors.Newf(nil, InvariantViolated, "expected %q to run after %q finished", key, parent))
+			}
+		}
+		if subgraph, ok := s.subgraphs[key]; ok && completed[key] {
+			for _, member := range subgraph.keys {
+				if first := tracker.firstStart(member, subgraph); first >= 0 && first < calls[0].end {
+					*multi = errors.Append(*multi, errors.Newf(nil, InvariantViolated, "expected %q to run after %q expanded", member, key))
+				}
+			}
+		}
+	}
+	return all
+}
+
+// lastEnd returns the last time the node, or anything it expanded into, finished.
+func (tracker *Tracker) lastEnd(key string, s *spec) int {
+	end := -1
+	for _, call := range tracker.calls[key] {
+		end = max(end, call.end)
+	}
+	if subgraph, ok := s.subgraphs[key]; ok {
+		for _, member := range subgraph.keys {
+			end = max(end, tracker.lastEnd(member, subgraph))
+		}
+	}
+	return end
+}
+
+// firstStart returns the first time the node, or anything it expanded into, started, or -1 if it never did.
+func (tracker *Tracker) firstStart(key string, s *spec) int {
+	start := -1
+	for _, call := range tracker.calls[key] {
+		if start < 0 || call.start < start {
+			start = call.start
+		}
+	}
+	if subgraph, ok := s.subgraphs[key]; ok {
+		for _, member := range subgraph.keys {
+			if first := tracker.firstStart(member, subgraph); first >= 0 && (start < 0 || first < start) {
+				start = first
+			}
+		}
+	}
+	return start
+}
+
+// Keys returns the keys of every node that could appear in the walk, including nodes added by expansions, sorted.
+func (tracker *Tracker) Keys() []string {
+	var keys []string
+	var collect func(s *spec)
+	collect = func(s *spec) {
+		for _, key := range s.keys {
+			keys = append(keys, key)
+			if subgraph, ok := s.subgraphs[key]; ok {
+				collect(subgraph)
+			}
+		}
+	}
+	collect(tracker.spec)
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,31 @@
+package graphtest
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+func FuzzWalk(f *testing.F) {
+	f.Add(uint64(1), uint8(10), uint8(1), uint8(30), uint8(20), uint8(0))
+	f.Add(uint64(2), uint8(25), uint8(4), uint8(10), uint8(30), uint8(10))
+	f.Add(uint64(3), uint8(50), uint8(8), uint8(50), uint8(10), uint8(5))
+
+	f.Fuzz(func(t *testing.T, seed uint64, nodes uint8, parallelism uint8, density uint8, expand uint8, fail uint8) {
+		g, tracker := RandomDAG(rand.New(rand.NewPCG(seed, seed)), RandomOpts{
+			Nodes:             int(nodes % 64),
+			Density:           float64(density%101) / 100,
+			ExpandProbability: float64(expand%101) / 100,
+			FailProbability:   float64(fail%101) / 100,
+		})
+
+		// Errors are expected when nodes fail, the tracker checks the right nodes ran.
+		_ = g.Walk(context.Background(), &graph.Opts{Parallelism: int(parallelism%8) + 1, Verify: true})
+
+		if err := tracker.Check(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
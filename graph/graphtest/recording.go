@@ -0,0 +1,147 @@
+// Package graphtest contains helpers for testing code built on top of graph.
+package graphtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+var _ graph.Sink = (*Recorder)(nil)
+
+// Recorder is a graph.Sink that records every event published during a walk.
+type Recorder struct {
+	mutex  sync.Mutex
+	events []graph.Event
+}
+
+// Handle implements graph.Sink.
+func (recorder *Recorder) Handle(event graph.Event) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.events = append(recorder.events, event)
+}
+
+// Events returns every event recorded so far, in the order they were published.
+func (recorder *Recorder) Events() []graph.Event {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]graph.Event(nil), recorder.events...)
+}
+
+// Started returns the keys of the nodes in the order they were dispatched.
+func (recorder *Recorder) Started() []string {
+	return recorder.keys(graph.EventNodeStarted)
+}
+
+// Completed returns the keys of the nodes in the order they completed.
+func (recorder *Recorder) Completed() []string {
+	return recorder.keys(graph.EventNodeCompleted)
+}
+
+// Errored returns the keys of the nodes in the order they errored.
+func (recorder *Recorder) Errored() []string {
+	return recorder.keys(graph.EventNodeErrored)
+}
+
+// Cancelled returns the keys of the nodes in the order they were cancelled.
+func (recorder *Recorder) Cancelled() []string {
+	return recorder.keys(graph.EventNodeCancelled)
+}
+
+func (recorder *Recorder) keys(t graph.EventType) []string {
+	var keys []string
+	for _, event := range recorder.Events() {
+		if event.Type == t {
+			keys = append(keys, event.Key)
+		}
+	}
+	return keys
+}
+
+// index returns the position of the first event of the given type for the given key, or -1 if there is none.
+func (recorder *Recorder) index(key string, types ...graph.EventType) int {
+	for ix, event := range recorder.Events() {
+		if event.Key != key {
+			continue
+		}
+		for _, t := range types {
+			if event.Type == t {
+				return ix
+			}
+		}
+	}
+	return -1
+}
+
+// RecordingWalker walks graphs while recording everything that happens, so the execution order can be asserted on.
+type RecordingWalker struct {
+	// Opts are used for every walk. Opts.Bus is replaced for the duration of each walk, but any existing bus still
+	// receives all the events.
+	Opts graph.Opts
+}
+
+// Walk walks the graph, returning the recorder that captured the walk along with the result.
+func (walker RecordingWalker) Walk(ctx context.Context, g graph.Graph) (*Recorder, *graph.WalkResult, error) {
+	recorder := new(Recorder)
+
+	opts := walker.Opts
+	if opts.Parallelism == 0 {
+		opts.Parallelism = 1
+	}
+
+	bus := graph.NewBus(recorder)
+	if opts.Bus != nil {
+		bus.Subscribe(opts.Bus)
+	}
+	opts.Bus = bus
+
+	result, err := g.Run(ctx, &opts)
+	return recorder, result, err
+}
+
+// RanBefore asserts that the node first finished (by completing, erroring or expanding) before the node second
+// started.
+func RanBefore(t testing.TB, recorder *Recorder, first string, second string) {
+	t.Helper()
+
+	finished := recorder.index(first, graph.EventNodeCompleted, graph.EventNodeErrored, graph.EventNodeExpanded)
+	if finished < 0 {
+		t.Errorf("expected %q to run before %q, but %q never finished", first, second, first)
+		return
+	}
+
+	started := recorder.index(second, graph.EventNodeStarted)
+	if started < 0 {
+		t.Errorf("expected %q to run before %q, but %q never started", first, second, second)
+		return
+	}
+
+	if finished > started {
+		t.Errorf("expected %q to run before %q, but %q started first", first, second, second)
+	}
+}
+
+// Completed asserts that all the given nodes completed.
+func Completed(t testing.TB, recorder *Recorder, keys ...string) {
+	t.Helper()
+
+	for _, key := range keys {
+		if recorder.index(key, graph.EventNodeCompleted) < 0 {
+			t.Errorf("expected %q to complete, but it did not", key)
+		}
+	}
+}
+
+// NotStarted asserts that none of the given nodes were dispatched.
+func NotStarted(t testing.TB, recorder *Recorder, keys ...string) {
+	t.Helper()
+
+	for _, key := range keys {
+		if recorder.index(key, graph.EventNodeStarted) >= 0 {
+			t.Errorf("expected %q not to start, but it did", key)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package graphtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pasataleo/go-errors/errors"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+var (
+	WalkFinished errors.ErrorCode = "graphtest.walk_finished"
+)
+
+// arrival is a node that is waiting to be allowed to execute.
+type arrival struct {
+	key     string
+	release chan struct{}
+}
+
+// StepWalker walks a graph one node at a time under the control of a test. Every node is held just before it executes
+// until the test releases it with Step or StepKey, and each step waits for the released node to finish.
+type StepWalker struct {
+	// Recorder records everything that happened during the walk.
+	Recorder *Recorder
+
+	arrivals chan arrival
+	finished chan string
+	done     chan struct{}
+
+	// waiting contains the nodes that have arrived but not been released, in arrival order. It is only accessed by
+	// the test goroutine.
+	waiting []arrival
+
+	// released contains the nodes that have been released but haven't finished yet.
+	mutex    sync.Mutex
+	released map[string]bool
+
+	result *graph.WalkResult
+	err    error
+}
+
+// NewStepWalker starts walking the graph in the background, and returns the walker controlling it. Opts.ContextFn and
+// Opts.Bus are wrapped, so any existing values keep working.
+func NewStepWalker(ctx context.Context, g graph.Graph, opts graph.Opts) *StepWalker {
+	walker := &StepWalker{
+		Recorder: new(Recorder),
+		arrivals: make(chan arrival),
+		finished: make(chan string),
+		done:     make(chan struct{}),
+		released: make(map[string]bool),
+	}
+
+	if opts.Parallelism == 0 {
+		opts.Parallelism = 1
+	}
+
+	contextFn := opts.ContextFn
+	opts.ContextFn = func(ctx context.Context, key string, meta graph.Meta) context.Context {
+		if contextFn != nil {
+			ctx = contextFn(ctx, key, meta)
+		}
+
+		release := make(chan struct{})
+		walker.arrivals <- arrival{key: key, release: release}
+		<-release
+		return ctx
+	}
+
+	bus := graph.NewBus(walker.Recorder, graph.SinkFunc(func(event graph.Event) {
+		switch event.Type {
+		case graph.EventNodeCompleted, graph.EventNodeErrored, graph.EventNodeExpanded, graph.EventNodeCancelled:
+			walker.mutex.Lock()
+			released := walker.released[event.Key]
+			delete(walker.released, event.Key)
+			walker.mutex.Unlock()
+
+			if released {
+				walker.finished <- event.Key
+			}
+		}
+	}))
+	if opts.Bus != nil {
+		bus.Subscribe(opts.Bus)
+	}
+	opts.Bus = bus
+
+	go func() {
+		defer close(walker.done)
+		walker.result, walker.err = g.Run(ctx, &opts)
+	}()
+	return walker
+}
+
+// Waiting returns the keys of the nodes that are currently held, in the order they arrived.
+//
+// Nodes that are ready may take a moment to arrive, so Waiting is only a snapshot.
+func (walker *StepWalker) Waiting() []string {
+	walker.collect(false)
+
+	var keys []string
+	for _, arrival := range walker.waiting {
+		keys = append(keys, arrival.key)
+	}
+	return keys
+}
+
+// Step releases the node that has been waiting the longest, and waits for it to finish. It returns false once the walk
+// has finished and there is nothing left to release.
+func (walker *StepWalker) Step() (string, bool) {
+	if len(walker.waiting) == 0 && !walker.collect(true) {
+		return "", false
+	}
+
+	next := walker.waiting[0]
+	walker.waiting = walker.waiting[1:]
+	walker.release(next)
+	return next.key, true
+}
+
+// StepKey waits for the given node to arrive, releases it, and waits for it to finish.
+func (walker *StepWalker) StepKey(key string) error {
+	for {
+		for ix, arrival := range walker.waiting {
+			if arrival.key == key {
+				walker.waiting = append(walker.waiting[:ix], walker.waiting[ix+1:]...)
+				walker.release(arrival)
+				return nil
+			}
+		}
+
+		if !walker.collect(true) {
+			return errors.Newf(nil, WalkFinished, "the walk finished without node %q becoming ready", key)
+		}
+	}
+}
+
+// Wait releases every remaining node, and returns the result of the walk once it has finished.
+func (walker *StepWalker) Wait() (*graph.WalkResult, error) {
+	for {
+		if _, ok := walker.Step(); !ok {
+			return walker.result, walker.err
+		}
+	}
+}
+
+// collect moves arrived nodes into the waiting list. If block is true, it waits for at least one arrival and returns
+// false if the walk finished instead.
+func (walker *StepWalker) collect(block bool) bool {
+	if block {
+		select {
+		case arrival := <-walker.arrivals:
+			walker.waiting = append(walker.waiting, arrival)
+		case <-walker.done:
+			return false
+		}
+	}
+
+	for {
+		select {
+		case arrival := <-walker.arrivals:
+			walker.waiting = append(walker.waiting, arrival)
+		default:
+			return true
+		}
+	}
+}
+
+func (walker *StepWalker) release(arrival arrival) {
+	walker.mutex.Lock()
+	walker.released[arrival.key] = true
+	walker.mutex.Unlock()
+
+	close(arrival.release)
+	<-walker.finished
+}
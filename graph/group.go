@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// groupNode completes a first success group, the work is all done by its members.
+type groupNode struct{}
+
+func (node *groupNode) Execute(ctx context.Context) error {
+	return nil
+}
+
+// AddGroup adds a node that completes as soon as the first of the given members succeeds, and cancels the rest with
+// the CancelRace reason. It suits hedged requests, where the same work is tried in several ways or places at once and
+// only the first answer matters. Connect nodes to the group to run them once there is a winner.
+//
+// The members must already be in the graph, and shouldn't have any other children. A member failing doesn't fail the
+// walk unless every member does. The key of the winner is recorded in NodeResult.Winner for the group, so children of
+// the group can find it through WalkHandle.Parent.
+//
+// AddGroup returns an error with the InvalidNode code if there are no members, and the MissingNode code if any of them
+// don't exist. The graph is left unchanged if it returns an error.
+func (g Graph) AddGroup(key string, members ...string) error {
+	if len(members) == 0 {
+		err := errors.Newf(nil, InvalidNode, "group %q has no members", key)
+		return errors.Embed(err, NodeKey, key)
+	}
+	for _, member := range members {
+		if _, ok := g.nodes[member]; !ok {
+			err := errors.Newf(nil, MissingNode, "node %q does not exist", member)
+			return errors.Embed(err, NodeKey, member)
+		}
+	}
+
+	if err := g.AddNodeWithMeta(key, &groupNode{}, Meta{Readiness: Readiness{Parents: 1, Cancel: true}}); err != nil {
+		return err
+	}
+	for _, member := range members {
+		if err := g.Connect(member, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// group returns the key of the group the node is a member of, or the empty string if it isn't a member of one.
+func (walker *walker) group(key string) string {
+	children := walker.nodes[key].children
+	if len(children) != 1 {
+		return ""
+	}
+	if _, ok := walker.nodes[children[0]].impl.(*groupNode); !ok {
+		return ""
+	}
+	return children[0]
+}
+
+// hedged returns true if the node failed as a member of a group that can still succeed, or already has.
+func (walker *walker) hedged(key string) bool {
+	group := walker.group(key)
+	if len(group) == 0 {
+		return false
+	}
+
+	for _, member := range walker.nodes[group].parents {
+		if _, errored := walker.errored[member]; !errored && !walker.cancelled.has(member) {
+			return true
+		}
+	}
+	return false
+}
+
+// failures returns the errors of every node that failed the walk, leaving out the members of groups and rollouts that
+// succeeded.
+func (walker *walker) failures() map[string]error {
+	failures := make(map[string]error, len(walker.errored))
+	for key, err := range walker.errored {
+		if group := walker.group(key); len(group) > 0 && walker.completed.has(group) {
+			continue
+		}
+		if walker.rolledOut(key) {
+			continue
+		}
+		failures[key] = err
+	}
+	return failures
+}
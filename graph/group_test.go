@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_AddGroup(t *testing.T) {
+	// Members either fail straight away, succeed straight away, or hang until they are cancelled.
+	member := func(behaviour string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			switch behaviour {
+			case "fail":
+				return errors.New("unavailable")
+			case "succeed":
+				return nil
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+
+	tcs := map[string]struct {
+		members     map[string]string
+		parallelism int
+		failFast    bool
+		winner      string
+		statuses    map[string]Status
+		err         string
+	}{
+		"first": {
+			members:     map[string]string{"eu": "succeed", "us": "hang"},
+			parallelism: 2,
+			winner:      "eu",
+			statuses:    map[string]Status{"eu": StatusCompleted, "us": StatusCancelled, "fetch": StatusCompleted, "use": StatusCompleted},
+		},
+		"failed": {
+			// Members are dispatched in order, so eu has failed by the time us succeeds.
+			members:     map[string]string{"eu": "fail", "us": "succeed"},
+			parallelism: 1,
+			failFast:    true,
+			winner:      "us",
+			statuses:    map[string]Status{"eu": StatusErrored, "us": StatusCompleted, "fetch": StatusCompleted, "use": StatusCompleted},
+		},
+		"all": {
+			members:     map[string]string{"eu": "fail", "us": "fail"},
+			parallelism: 1,
+			statuses:    map[string]Status{"eu": StatusErrored, "us": StatusErrored, "fetch": StatusSkipped, "use": StatusSkipped},
+			err:         "eu: failed to execute node (unavailable); us: failed to execute node (unavailable); graph is incomplete",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var winner string
+
+			g := NewGraph()
+			for key, behaviour := range tc.members {
+				g.AddNode(key, member(behaviour))
+			}
+			tests.ExecuteE(g.AddGroup("fetch", "eu", "us")).NoError(t)
+			g.AddNode("use", Executable(func(ctx context.Context) error {
+				result, _ := Handle(ctx).Parent("fetch")
+				winner = result.Winner
+				return nil
+			}))
+			g.Connect("fetch", "use")
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: tc.parallelism, FailFast: tc.failFast, Verify: true})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+			tests.Execute(winner).Equal(t, tc.winner)
+
+			statuses := make(map[string]Status)
+			for key, node := range result.Nodes {
+				statuses[key] = node.Status
+			}
+			tests.Execute(statuses).Equal(t, tc.statuses)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		g := NewGraph()
+		tests.ExecuteE(g.AddGroup("fetch")).MatchesError(t, "group \"fetch\" has no members")
+		tests.ExecuteE(g.AddGroup("fetch", "eu")).MatchesError(t, "node \"eu\" does not exist")
+		tests.Execute(len(g.nodes)).Equal(t, 0)
+	})
+}
@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// WalkHandle gives a node restricted access to the walk it is executing in. It can only be used while the node is
+// executing.
+type WalkHandle struct {
+	// key is the key of the node the handle belongs to.
+	key string
+
+	// parents contains a snapshot of the results of the node's parents, taken when the node was dispatched. It is
+	// sorted by key.
+	parents []NodeResult
+
+	// requests is used to send enqueue requests back to the walker, until finished is closed.
+	requests chan<- enqueueRequest
+	finished <-chan struct{}
+
+	// cancel cancels the walk.
+	cancel context.CancelCauseFunc
+}
+
+// enqueueRequest asks the walker to add a new node to the walk.
+type enqueueRequest struct {
+	node *node
+
+	// expander is set if the node was emitted by a StreamingExpandableNode, and is the key of that node.
+	expander string
+
+	reply chan error
+}
+
+// Handle returns the WalkHandle for the node executing with the given context, or nil if the context doesn't belong to
+// a node.
+func Handle(ctx context.Context) *WalkHandle {
+	if exec := executionFrom(ctx); exec != nil {
+		return &exec.handle
+	}
+	return nil
+}
+
+// Key returns the key of the node the handle belongs to.
+func (handle *WalkHandle) Key() string {
+	return handle.key
+}
+
+// Parents returns the keys of the node's parents, sorted.
+func (handle *WalkHandle) Parents() []string {
+	parents := make([]string, 0, len(handle.parents))
+	for _, parent := range handle.parents {
+		parents = append(parents, parent.Key)
+	}
+	return parents
+}
+
+// Parent returns the result of one of the node's parents.
+func (handle *WalkHandle) Parent(key string) (NodeResult, bool) {
+	ix := sort.Search(len(handle.parents), func(ix int) bool {
+		return handle.parents[ix].Key >= key
+	})
+	if ix < len(handle.parents) && handle.parents[ix].Key == key {
+		return handle.parents[ix], true
+	}
+	return NodeResult{}, false
+}
+
+// Completed returns the keys of the node's parents that had completed when it was dispatched, sorted. They are all of
+// its parents unless the node didn't wait for them, see Readiness.
+func (handle *WalkHandle) Completed() []string {
+	var completed []string
+	for _, parent := range handle.parents {
+		if parent.Status == StatusCompleted {
+			completed = append(completed, parent.Key)
+		}
+	}
+	return completed
+}
+
+// Enqueue adds a new node to the walk. The new node runs once all the given parents have completed, which may include
+// the node calling Enqueue. The key must not already be in use, and all the parents must exist.
+//
+// Enqueued nodes always belong to the top level of the walk, even if the enqueuing node was added by an expansion.
+func (handle *WalkHandle) Enqueue(key string, impl interface{}, parents ...string) error {
+	return handle.EnqueueWithMeta(key, impl, Meta{}, parents...)
+}
+
+// EnqueueWithMeta adds a new node to the walk, exactly like Enqueue, along with metadata describing it.
+//
+// EnqueueWithMeta returns an error with the ClosedHandle code once the walk has finished, or if the node is a
+// speculative copy that lost, see Speculation.
+func (handle *WalkHandle) EnqueueWithMeta(key string, impl interface{}, meta Meta, parents ...string) error {
+	_, executable := impl.(ExecutableNode)
+	_, expandable := impl.(ExpandableNode)
+	_, streaming := impl.(StreamingExpandableNode)
+	if !executable && !expandable && !streaming {
+		err := errors.Newf(nil, InvalidNode, "node %q does not implement ExecutableNode or ExpandableNode", key)
+		return errors.Embed(err, NodeKey, key)
+	}
+
+	reply := make(chan error)
+	request := enqueueRequest{
+		node: &node{
+			key:     key,
+			impl:    impl,
+			meta:    meta,
+			parents: append([]string(nil), parents...),
+		},
+		reply: reply,
+	}
+	select {
+	case handle.requests <- request:
+		return <-reply
+	case <-handle.finished:
+		err := errors.Newf(nil, ClosedHandle, "node %q can no longer add nodes to the walk", handle.key)
+		return errors.Embed(err, NodeKey, handle.key)
+	}
+}
+
+// Cancel cancels the walk. Running nodes have their context cancelled, and nodes that haven't started are never
+// dispatched. They are all reported as cancelled with the CancelManual reason.
+func (handle *WalkHandle) Cancel() {
+	handle.cancel(&cancelCause{reason: CancelManual})
+}
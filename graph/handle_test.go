@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestWalkHandle(t *testing.T) {
+	var builder strings.Builder
+	var duplicate error
+	var parents []string
+
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		builder.WriteString("a")
+		return nil
+	}))
+	g.AddNode("b", Executable(func(ctx context.Context) error {
+		builder.WriteString("b")
+
+		handle := Handle(ctx)
+		parents = handle.Parents()
+		if result, ok := handle.Parent("a"); !ok || result.Status != StatusCompleted {
+			t.Errorf("expected parent a to be completed")
+		}
+
+		duplicate = handle.Enqueue("a", Executable(func(ctx context.Context) error {
+			return nil
+		}))
+		return handle.Enqueue("c", Executable(func(ctx context.Context) error {
+			builder.WriteString("c")
+			return nil
+		}), handle.Key())
+	}))
+	g.Connect("a", "b")
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 1})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(builder.String()).Equal(t, "abc")
+	tests.Execute(parents).Equal(t, []string{"a"})
+	tests.Execute(errors.GetErrorCode(duplicate)).Equal(t, DuplicateNode)
+	tests.Execute(result.Status(StatusCompleted)).Equal(t, []string{"a", "b", "c"})
+
+	// The graph itself must not have been modified.
+	tests.Execute(g.Finishers()).Equal(t, []string{"b"})
+}
@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Impact returns the keys of the nodes that must execute again because the inputs of the given nodes have changed, in
+// an order they could execute in. This is the changed nodes themselves and every node that depends on them, directly
+// or transitively.
+//
+// Nodes with the same Meta.Fingerprint do the same work, so a node sharing its fingerprint with a changed node is
+// treated as changed as well, along with everything depending on it. The result can be passed to Opts.Roots to walk
+// only the part of the graph that is affected.
+//
+// Impact returns an error with the MissingNode code if any of the changed nodes do not exist, and an error with the
+// CycleDetected code if the graph contains a cycle.
+func (g Graph) Impact(changed ...string) ([]string, error) {
+	fingerprints := make(map[string]bool)
+	for _, key := range changed {
+		n, ok := g.nodes[key]
+		if !ok {
+			err := errors.Newf(nil, MissingNode, "node %q does not exist", key)
+			return nil, errors.Embed(err, NodeKey, key)
+		}
+		if len(n.meta.Fingerprint) > 0 {
+			fingerprints[n.meta.Fingerprint] = true
+		}
+	}
+
+	roots := append([]string(nil), changed...)
+	if len(fingerprints) > 0 {
+		for key, n := range g.nodes {
+			if fingerprints[n.meta.Fingerprint] {
+				roots = append(roots, key)
+			}
+		}
+	}
+
+	order, err := g.topologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	impacted := g.descendants(nil, roots...)
+	keys := make([]string, 0, len(impacted))
+	for _, key := range order {
+		if impacted[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
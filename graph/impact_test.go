@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Impact(t *testing.T) {
+	tcs := map[string]struct {
+		changed  []string
+		expected []string
+		err      string
+	}{
+		"none": {
+			expected: []string{},
+		},
+		"root": {
+			changed:  []string{"a"},
+			expected: []string{"a", "b", "f", "d"},
+		},
+		"leaf": {
+			changed:  []string{"d"},
+			expected: []string{"d"},
+		},
+		"fingerprint": {
+			changed:  []string{"c"},
+			expected: []string{"c", "d", "e"},
+		},
+		"overlapping": {
+			changed:  []string{"b", "a"},
+			expected: []string{"a", "b", "f", "d"},
+		},
+		"missing": {
+			changed: []string{"x"},
+			err:     "node \"x\" does not exist",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			noop := Executable(func(ctx context.Context) error {
+				return nil
+			})
+
+			// a -> b -> d, c -> d, b -> f, and e on its own doing the same work as c.
+			g := NewGraph()
+			for _, key := range []string{"a", "b", "d", "f"} {
+				g.AddNode(key, noop)
+			}
+			g.AddNodeWithMeta("c", noop, Meta{Fingerprint: "build"})
+			g.AddNodeWithMeta("e", noop, Meta{Fingerprint: "build"})
+			g.Connect("a", "b")
+			g.Connect("b", "d")
+			g.Connect("c", "d")
+			g.Connect("b", "f")
+
+			impacted, err := g.Impact(tc.changed...)
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+				return
+			}
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(impacted).Equal(t, tc.expected)
+		})
+	}
+}
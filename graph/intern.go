@@ -0,0 +1,28 @@
+package graph
+
+import "unique"
+
+// InternedKey is a node key that has been interned, so every InternedKey made from the same key shares the same
+// storage and comparing two of them is as cheap as comparing pointers. Callers building the same keys over and over, in
+// hot loops for example, can intern them once and compare or store the InternedKey instead.
+//
+// The graph interns every key it is given, so the keys it hands back share storage with each other no matter how they
+// were built.
+type InternedKey struct {
+	handle unique.Handle[string]
+}
+
+// Intern returns the interned version of the key.
+func Intern(key string) InternedKey {
+	return InternedKey{handle: unique.Make(key)}
+}
+
+// String returns the key. Every call returns the same string, sharing the same storage, for the same key.
+func (key InternedKey) String() string {
+	return key.handle.Value()
+}
+
+// intern returns the canonical copy of the key, so the many maps keyed by node keys all share its storage.
+func intern(key string) string {
+	return unique.Make(key).Value()
+}
@@ -0,0 +1,34 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestIntern(t *testing.T) {
+	built := fmt.Sprintf("node-%d", 1)
+	tests.Execute(Intern(built) == Intern("node-1")).Equal(t, true)
+	tests.Execute(Intern("node-1") == Intern("node-2")).Equal(t, false)
+	tests.Execute(Intern(built).String()).Equal(t, "node-1")
+
+	// Keys given to the graph share storage with the interned key, however they were built.
+	g := NewGraph()
+	g.AddNode(strings.Clone("a"), Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNode(strings.Clone("b"), Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect(strings.Clone("a"), strings.Clone("b"))
+
+	same := func(a string, b string) bool {
+		return unsafe.StringData(a) == unsafe.StringData(b)
+	}
+	tests.Execute(same(g.Starters()[0], Intern("a").String())).Equal(t, true)
+	tests.Execute(same(g.nodes["a"].children[0], Intern("b").String())).Equal(t, true)
+}
@@ -0,0 +1,89 @@
+package graph
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/pasataleo/go-graph/graph/backoff"
+)
+
+// ChildErrorAction is what happens to a node added by an expansion after it fails, see ChildErrorHandler.
+type ChildErrorAction string
+
+const (
+	// ChildFail fails the node as if there was no handler.
+	ChildFail ChildErrorAction = "fail"
+
+	// ChildRetry runs the node again. The handler is consulted again if it fails again, so it should give up after a
+	// number of attempts.
+	ChildRetry ChildErrorAction = "retry"
+
+	// ChildSwallow ignores the failure. The node is recorded as completed, with its error kept in its NodeResult, and
+	// the rest of the subgraph carries on as if it had succeeded.
+	ChildSwallow ChildErrorAction = "swallow"
+)
+
+// ChildErrorHandler can be implemented by an ExpandableNode to intercept the failures of the nodes it expanded into,
+// for example to tolerate a failed region in a fan-out deployment. Only the nearest expanded node is consulted, so a
+// node expanded by a node that was itself expanded is handled by its own parent.
+//
+// OnChildError is called from the walker's main loop, so it should return quickly.
+type ChildErrorHandler interface {
+	// OnChildError decides what happens to the child that failed with the given error on the given attempt, starting
+	// from 1.
+	OnChildError(ctx context.Context, child string, attempt int, err error) ChildErrorAction
+}
+
+// ChildRetryBackoff can be implemented alongside ChildErrorHandler to wait between the attempts of the nodes it
+// retries. The retried node is held until its backoff has passed, without occupying a worker.
+type ChildRetryBackoff interface {
+	// RetryBackoff returns the backoff to use when retrying the given child.
+	RetryBackoff(child string) backoff.Backoff
+}
+
+// childError consults the node that expanded into the failed node, if it has a ChildErrorHandler, and returns true if
+// the failure was handled without failing the node.
+func (walker *walker) childError(ctx context.Context, key string, err error) bool {
+	expander, ok := walker.expandedBy[key]
+	if !ok {
+		return false
+	}
+	handler, ok := walker.nodes[expander].impl.(ChildErrorHandler)
+	if !ok {
+		return false
+	}
+
+	attempt := 1
+	if exec, ok := walker.executions[key]; ok {
+		attempt = exec.attempt
+	}
+
+	action := handler.OnChildError(ctx, key, attempt, err)
+	walker.tracer.log(slog.LevelDebug, key, "child error handled",
+		slog.String("expander", expander),
+		slog.String("action", string(action)),
+		slog.Int("attempt", attempt))
+
+	switch action {
+	case ChildRetry:
+		delete(walker.processing, key)
+		delete(walker.executions, key)
+		walker.attempts[key] = attempt + 1
+		walker.previous[key] = err
+		if policy, ok := handler.(ChildRetryBackoff); ok {
+			if delay := policy.RetryBackoff(key).Delay(attempt); delay > 0 {
+				walker.tracer.log(slog.LevelDebug, key, "node held", slog.String("reason", "backoff"), slog.Duration("delay", delay))
+				walker.hold(key, walker.now().Add(delay))
+				return true
+			}
+		}
+		walker.ready(key)
+		return true
+	case ChildSwallow:
+		walker.ready(walker.Completed(key)...)
+		walker.result.Nodes[key].Err = err
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph/backoff"
+)
+
+// region expands into a deploy node per region, and decides what to do when one of them fails.
+type region struct {
+	deploy func(ctx context.Context, region string) error
+	handle func(child string, attempt int, err error) ChildErrorAction
+}
+
+func (node *region) Expand(ctx context.Context) (Graph, error) {
+	g := NewGraph()
+	for _, name := range []string{"eu", "us"} {
+		g.AddNode(name, Executable(func(ctx context.Context) error {
+			return node.deploy(ctx, name)
+		}))
+	}
+	g.AddNode("verify", Executable(func(ctx context.Context) error {
+		return node.deploy(ctx, "verify")
+	}))
+	g.Connect("eu", "verify")
+	g.Connect("us", "verify")
+	return g, nil
+}
+
+func (node *region) OnChildError(ctx context.Context, child string, attempt int, err error) ChildErrorAction {
+	return node.handle(child, attempt, err)
+}
+
+func TestGraph_Walk_OnChildError(t *testing.T) {
+	tcs := map[string]struct {
+		handle func(child string, attempt int, err error) ChildErrorAction
+		ran    []string
+		err    string
+
+		// swallowed is true if the failure of us is swallowed, so it completes with its error.
+		swallowed bool
+	}{
+		"fail": {
+			handle: func(child string, attempt int, err error) ChildErrorAction {
+				return ChildFail
+			},
+			ran: []string{"eu", "us"},
+			err: "us: failed to execute node (us is down); graph is incomplete",
+		},
+		"retry": {
+			handle: func(child string, attempt int, err error) ChildErrorAction {
+				if attempt < 3 {
+					return ChildRetry
+				}
+				return ChildFail
+			},
+			ran: []string{"eu", "us", "us", "us", "verify"},
+		},
+		"give up": {
+			handle: func(child string, attempt int, err error) ChildErrorAction {
+				if attempt < 2 {
+					return ChildRetry
+				}
+				return ChildFail
+			},
+			ran: []string{"eu", "us", "us"},
+			err: "us: failed to execute node (us is down); graph is incomplete",
+		},
+		"swallow": {
+			handle: func(child string, attempt int, err error) ChildErrorAction {
+				return ChildSwallow
+			},
+			ran:       []string{"eu", "us", "verify"},
+			swallowed: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var ran []string
+
+			// us fails twice before it succeeds.
+			g := NewGraph()
+			g.AddNode("deploy", &region{
+				deploy: func(ctx context.Context, region string) error {
+					mutex.Lock()
+					defer mutex.Unlock()
+					ran = append(ran, region)
+
+					count := 0
+					for _, previous := range ran {
+						if previous == "us" {
+							count++
+						}
+					}
+					if region == "us" && count < 3 {
+						return errors.New("us is down")
+					}
+					return nil
+				},
+				handle: tc.handle,
+			})
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 1})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+			tests.Execute(ran).Equal(t, tc.ran)
+			tests.Execute(result.Nodes["us"].Attempts).Equal(t, strings.Count(strings.Join(tc.ran, " "), "us"))
+			if tc.swallowed {
+				tests.Execute(result.Nodes["us"].Status).Equal(t, StatusCompleted)
+				tests.ExecuteE(result.Nodes["us"].Err).MatchesError(t, "failed to execute node (us is down)")
+			}
+		})
+	}
+}
+
+// patient retries the nodes it expanded into with a backoff.
+type patient struct {
+	*region
+	backoff backoff.Backoff
+}
+
+func (node *patient) RetryBackoff(child string) backoff.Backoff {
+	return node.backoff
+}
+
+func TestGraph_Walk_OnChildError_Backoff(t *testing.T) {
+	var mutex sync.Mutex
+	var attempts []time.Time
+
+	g := NewGraph()
+	g.AddNode("deploy", &patient{
+		region: &region{
+			deploy: func(ctx context.Context, region string) error {
+				if region != "us" {
+					return nil
+				}
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				attempts = append(attempts, time.Now())
+				if len(attempts) < 3 {
+					return errors.New("us is down")
+				}
+				return nil
+			},
+			handle: func(child string, attempt int, err error) ChildErrorAction {
+				return ChildRetry
+			},
+		},
+		backoff: backoff.Exponential(10*time.Millisecond, 2),
+	})
+
+	var held []string
+	bus := NewBus(SinkFunc(func(event Event) {
+		if event.Type == EventNodeHeld {
+			held = append(held, event.Key)
+		}
+	}))
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 2, Bus: bus})).NoError(t)
+	tests.Execute(held).Equal(t, []string{"us", "us"})
+	tests.Execute(len(attempts)).Equal(t, 3)
+	tests.Execute(attempts[1].Sub(attempts[0]) >= 10*time.Millisecond).Equal(t, true)
+	tests.Execute(attempts[2].Sub(attempts[1]) >= 20*time.Millisecond).Equal(t, true)
+}
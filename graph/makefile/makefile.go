@@ -0,0 +1,249 @@
+// Package makefile builds graphs from simple make style dependency files, so existing dependency declarations can be
+// run in parallel by the walker.
+//
+// Files consist of rules like
+//
+//	target other: dependency another
+//		command
+//		another command
+//
+// Every target becomes a node running its commands, see Exec, connected to the targets it depends on. Dependencies that
+// aren't targets themselves are assumed to be files that already exist and are ignored. Comments start with #, and
+// lines ending with a backslash are continued on the next line. Variables, pattern rules and everything else make
+// supports are not.
+package makefile
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pasataleo/go-errors/errors"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+var (
+	InvalidInput  errors.ErrorCode = "makefile.invalid_input"
+	FailedCommand errors.ErrorCode = "makefile.failed_command"
+)
+
+// Opts configures how the commands of the targets are run.
+type Opts struct {
+	// Shell is the command each line of a recipe is passed to as its last argument.
+	//
+	// Defaults to sh -c.
+	Shell []string
+
+	// Dir is the directory the commands run in.
+	//
+	// Defaults to the working directory of the process.
+	Dir string
+}
+
+var _ graph.ExecutableNode = (*Exec)(nil)
+
+// Exec is a node that runs shell commands one after the other, failing as soon as one of them does. The output of the
+// commands is captured in the result of the node, see graph.Stdout and graph.Stderr.
+//
+// As in make, commands starting with @ run exactly like any other, and commands starting with - may fail without
+// failing the node.
+type Exec struct {
+	Commands []string
+	Shell    []string
+	Dir      string
+}
+
+// Execute implements graph.ExecutableNode.
+func (node *Exec) Execute(ctx context.Context) error {
+	shell := node.Shell
+	if len(shell) == 0 {
+		shell = []string{"sh", "-c"}
+	}
+
+	for _, command := range node.Commands {
+		command = strings.TrimLeft(command, "@")
+		ignore := strings.HasPrefix(command, "-")
+		command = strings.TrimLeft(command, "-@")
+
+		cmd := exec.CommandContext(ctx, shell[0], append(shell[1:len(shell):len(shell)], command)...)
+		cmd.Dir = node.Dir
+		cmd.Stdout = graph.Stdout(ctx)
+		cmd.Stderr = graph.Stderr(ctx)
+		if err := cmd.Run(); err != nil && !ignore {
+			return errors.Newf(err, FailedCommand, "command %q failed", command)
+		}
+	}
+	return nil
+}
+
+// Rule is a target along with everything the file says about it.
+type Rule struct {
+	Target string
+
+	// Dependencies are the targets the target depends on, in the order they were declared. Dependencies that aren't
+	// targets are left out.
+	Dependencies []string
+
+	// Commands are the commands of the target's recipe.
+	Commands []string
+}
+
+// rule is a Rule as it is being read, along with the line its commands were declared on so a second recipe can be
+// reported.
+type rule struct {
+	Rule
+	recipe int
+}
+
+// Parse builds a graph from a dependency file, with a node for every target.
+func Parse(reader io.Reader, opts *Opts) (graph.Graph, error) {
+	if opts == nil {
+		opts = &Opts{}
+	}
+
+	rules, err := Rules(reader)
+	if err != nil {
+		return graph.Graph{}, err
+	}
+
+	g := graph.NewGraph()
+	for _, rule := range rules {
+		node := &Exec{Commands: rule.Commands, Shell: opts.Shell, Dir: opts.Dir}
+		if err := g.AddNode(rule.Target, node); err != nil {
+			return graph.Graph{}, err
+		}
+	}
+	for _, rule := range rules {
+		for _, dependency := range rule.Dependencies {
+			if err := g.Connect(dependency, rule.Target); err != nil {
+				return graph.Graph{}, err
+			}
+		}
+	}
+	return g, nil
+}
+
+// Rules reads the rules of a dependency file, sorted by target. Targets declared by several rules are merged into one.
+func Rules(reader io.Reader) ([]Rule, error) {
+	rules := make(map[string]*rule)
+	var current []string
+
+	lines, err := read(reader)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line.text, "\t") {
+			if current == nil {
+				return nil, errors.Newf(nil, InvalidInput, "line %d: command outside of a rule", line.number)
+			}
+			command := strings.TrimSpace(line.text)
+			if len(command) == 0 {
+				continue
+			}
+			for _, target := range current {
+				rule := rules[target]
+				if rule.recipe != 0 && rule.recipe != line.rule {
+					return nil, errors.Newf(nil, InvalidInput, "line %d: target %q already has commands from line %d", line.number, target, rule.recipe)
+				}
+				rule.recipe = line.rule
+				rule.Commands = append(rule.Commands, command)
+			}
+			continue
+		}
+
+		text, _, _ := strings.Cut(line.text, "#")
+		if len(strings.TrimSpace(text)) == 0 {
+			continue
+		}
+
+		targets, dependencies, ok := strings.Cut(text, ":")
+		if !ok || strings.ContainsAny(targets, "=%$") || strings.HasPrefix(dependencies, "=") {
+			return nil, errors.Newf(nil, InvalidInput, "line %d: expected a rule, found %q", line.number, strings.TrimSpace(text))
+		}
+
+		current = nil
+		for _, target := range strings.Fields(targets) {
+			if strings.HasPrefix(target, ".") {
+				continue // special targets like .PHONY don't mean anything here.
+			}
+			if _, ok := rules[target]; !ok {
+				rules[target] = &rule{Rule: Rule{Target: target}}
+			}
+			rules[target].Dependencies = append(rules[target].Dependencies, strings.Fields(dependencies)...)
+			current = append(current, target)
+		}
+	}
+
+	targets := make([]string, 0, len(rules))
+	for target := range rules {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	result := make([]Rule, 0, len(targets))
+	for _, target := range targets {
+		rule := rules[target].Rule
+
+		// Dependencies that aren't targets are files that already exist, as far as we are concerned.
+		var dependencies []string
+		for _, dependency := range rule.Dependencies {
+			if _, ok := rules[dependency]; ok && !slices.Contains(dependencies, dependency) {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+		rule.Dependencies = dependencies
+		result = append(result, rule)
+	}
+	return result, nil
+}
+
+// line is a logical line of a dependency file, with continued lines joined together.
+type line struct {
+	text   string
+	number int
+
+	// rule is the number of the line of the rule that commands belong to.
+	rule int
+}
+
+// read splits the file into logical lines, joining lines that end with a backslash to the next one.
+func read(reader io.Reader) ([]line, error) {
+	var lines []line
+	var rule int
+
+	scanner := bufio.NewScanner(reader)
+	var pending *line
+	for number := 1; scanner.Scan(); number++ {
+		text := scanner.Text()
+		if pending != nil {
+			pending.text += " " + strings.TrimSpace(text)
+		} else {
+			pending = &line{text: text, number: number}
+		}
+
+		if strings.HasSuffix(pending.text, "\\") {
+			pending.text = strings.TrimRight(strings.TrimSuffix(pending.text, "\\"), " \t")
+			continue
+		}
+
+		if text, _, _ := strings.Cut(pending.text, "#"); !strings.HasPrefix(text, "\t") && len(strings.TrimSpace(text)) > 0 {
+			rule = pending.number
+		}
+		pending.rule = rule
+		lines = append(lines, *pending)
+		pending = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New(err, InvalidInput, "failed to read dependency file")
+	}
+	if pending != nil {
+		lines = append(lines, *pending)
+	}
+	return lines, nil
+}
@@ -0,0 +1,148 @@
+package makefile
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+func TestParse(t *testing.T) {
+	tcs := map[string]struct {
+		input    string
+		edges    map[string][]string
+		commands map[string][]string // the commands of every target
+		err      string
+	}{
+		"rules": {
+			input: `# Build everything.
+.PHONY: all
+all: test lint
+
+test: build main.go
+	go test ./...
+
+lint: build
+	@golangci-lint run \
+		./...
+	# not a comment for make, the shell ignores it
+
+build:
+	go build ./...
+`,
+			edges: map[string][]string{
+				"build": {"lint", "test"},
+				"lint":  {"all"},
+				"test":  {"all"},
+			},
+			commands: map[string][]string{
+				"all":   nil,
+				"build": {"go build ./..."},
+				"lint":  {"golangci-lint run ./...", "# not a comment for make, the shell ignores it"},
+				"test":  {"go test ./..."},
+			},
+		},
+		"several targets": {
+			input: `a b: c
+	echo $@
+c:
+a: d
+d:
+`,
+			edges: map[string][]string{
+				"c": {"a", "b"},
+				"d": {"a"},
+			},
+			commands: map[string][]string{
+				"a": {"echo $@"},
+				"b": {"echo $@"},
+				"c": nil,
+				"d": nil,
+			},
+		},
+		"command outside rule": {
+			input: "\techo hello\n",
+			err:   "line 1: command outside of a rule",
+		},
+		"variable": {
+			input: "CC := gcc\n",
+			err:   "line 1: expected a rule, found \"CC := gcc\"",
+		},
+		"two recipes": {
+			input: "a:\n\techo one\na:\n\techo two\n",
+			err:   "line 4: target \"a\" already has commands from line 1",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g, err := Parse(strings.NewReader(tc.input), &Opts{Shell: []string{"printf", "%s\\n"}})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+				return
+			}
+			tests.ExecuteE(err).NoError(t)
+
+			edges := make(map[string][]string)
+			for target := range tc.commands {
+				dependents, err := g.ReverseDependencies(target, false)
+				tests.ExecuteE(err).NoError(t)
+				if len(dependents) > 0 {
+					edges[target] = dependents
+				}
+			}
+			tests.Execute(edges).Equal(t, tc.edges)
+
+			// Printing the commands instead of running them shows which commands each target has.
+			result, err := g.Run(context.Background(), &graph.Opts{Parallelism: 1})
+			tests.ExecuteE(err).NoError(t)
+			for target, commands := range tc.commands {
+				var printed []string
+				if stdout := strings.TrimSuffix(string(result.Nodes[target].Stdout), "\n"); len(stdout) > 0 {
+					printed = strings.Split(stdout, "\n")
+				}
+				tests.Execute(printed).Equal(t, commands)
+			}
+		})
+	}
+}
+
+func TestExec(t *testing.T) {
+	tcs := map[string]struct {
+		commands []string
+		stdout   string
+		err      string
+	}{
+		"success": {
+			commands: []string{"echo one", "@echo two"},
+			stdout:   "one\ntwo\n",
+		},
+		"failure": {
+			commands: []string{"echo one", "exit 3", "echo two"},
+			stdout:   "one\n",
+			err:      "node: failed to execute node (command \"exit 3\" failed (exit status 3))",
+		},
+		"ignored": {
+			commands: []string{"-exit 3", "echo two"},
+			stdout:   "two\n",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g := graph.NewGraph()
+			g.AddNode("node", &Exec{Commands: tc.commands})
+
+			result, err := g.Run(context.Background(), &graph.Opts{Parallelism: 1})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+			tests.Execute(string(result.Nodes["node"].Stdout)).Equal(t, tc.stdout)
+		})
+	}
+}
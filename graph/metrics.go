@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"sync"
+	"time"
+)
+
+// Metrics receives measurements of the walker itself, rather than of the nodes it runs, so users can tell when the
+// walker is the bottleneck.
+//
+// Calls are serialised, but they are made from the worker goroutines as well as the goroutine driving the walk, so
+// implementations should return quickly.
+type Metrics interface {
+	// QueueDepth is called whenever the number of nodes that are ready but waiting for a free worker changes.
+	QueueDepth(depth int)
+
+	// WorkersBusy is called whenever the number of workers running a node changes.
+	WorkersBusy(busy int)
+
+	// DispatchLatency is called when a worker starts running a node, with the time since the node became ready.
+	DispatchLatency(key string, latency time.Duration)
+}
+
+// SchedulerStats summarises how the walker itself performed during a walk.
+type SchedulerStats struct {
+	// MaxQueueDepth is the largest number of nodes that were waiting for a free worker at the same time.
+	MaxQueueDepth int
+
+	// MaxWorkersBusy is the largest number of workers that were running nodes at the same time.
+	MaxWorkersBusy int
+
+	// TotalDispatchLatency and MaxDispatchLatency describe the time between nodes becoming ready and a worker starting
+	// to run them.
+	TotalDispatchLatency time.Duration
+	MaxDispatchLatency   time.Duration
+
+	// Backpressure is the total time workers spent waiting for the walker to accept the results of their nodes. A large
+	// value means the walker is slower than the nodes, see Opts.ResultBuffer and Opts.BatchSize.
+	Backpressure time.Duration
+
+	// MaxBatch is the largest number of finished nodes the walker handled before dispatching more, see Opts.BatchSize.
+	MaxBatch int
+}
+
+// scheduler tracks the SchedulerStats of a walk, and reports the measurements to the Metrics if there are any.
+type scheduler struct {
+	mutex sync.Mutex
+
+	// waiting and busy are the current number of nodes waiting for a worker, and the current number of busy workers.
+	waiting int
+	busy    int
+
+	stats   SchedulerStats
+	metrics Metrics
+}
+
+// queued records that nodes became ready, and are waiting for a free worker.
+func (scheduler *scheduler) queued(nodes int) {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	scheduler.waiting += nodes
+	scheduler.stats.MaxQueueDepth = max(scheduler.stats.MaxQueueDepth, scheduler.waiting)
+	if scheduler.metrics != nil {
+		scheduler.metrics.QueueDepth(scheduler.waiting)
+	}
+}
+
+// dropped records that a node that was waiting for a free worker was cancelled instead.
+func (scheduler *scheduler) dropped() {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	scheduler.waiting--
+	if scheduler.metrics != nil {
+		scheduler.metrics.QueueDepth(scheduler.waiting)
+	}
+}
+
+// started records that a worker started running a node that became ready latency ago.
+func (scheduler *scheduler) started(key string, latency time.Duration) {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	scheduler.waiting--
+	scheduler.busy++
+	scheduler.stats.MaxWorkersBusy = max(scheduler.stats.MaxWorkersBusy, scheduler.busy)
+	scheduler.stats.TotalDispatchLatency += latency
+	scheduler.stats.MaxDispatchLatency = max(scheduler.stats.MaxDispatchLatency, latency)
+	if scheduler.metrics != nil {
+		scheduler.metrics.QueueDepth(scheduler.waiting)
+		scheduler.metrics.WorkersBusy(scheduler.busy)
+		scheduler.metrics.DispatchLatency(key, latency)
+	}
+}
+
+// stopped records that a worker finished running a node.
+func (scheduler *scheduler) stopped() {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	scheduler.busy--
+	if scheduler.metrics != nil {
+		scheduler.metrics.WorkersBusy(scheduler.busy)
+	}
+}
+
+// blocked records that a worker waited for the walker to accept a result.
+func (scheduler *scheduler) blocked(wait time.Duration) {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+	scheduler.stats.Backpressure += wait
+}
+
+// batched records that the walker handled a number of finished nodes before dispatching more.
+func (scheduler *scheduler) batched(nodes int) {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	scheduler.stats.MaxBatch = max(scheduler.stats.MaxBatch, nodes)
+}
+
+// snapshot returns the stats recorded so far.
+func (scheduler *scheduler) snapshot() SchedulerStats {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+	return scheduler.stats
+}
@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+type recordingMetrics struct {
+	mutex     sync.Mutex
+	depths    []int
+	busy      []int
+	latencies map[string]time.Duration
+}
+
+func (metrics *recordingMetrics) QueueDepth(depth int) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.depths = append(metrics.depths, depth)
+}
+
+func (metrics *recordingMetrics) WorkersBusy(busy int) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.busy = append(metrics.busy, busy)
+}
+
+func (metrics *recordingMetrics) DispatchLatency(key string, latency time.Duration) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.latencies[key] = latency
+}
+
+func TestMetrics(t *testing.T) {
+	g := NewGraph()
+	for _, key := range []string{"a", "b", "c"} {
+		g.AddNode(key, Executable(func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}))
+	}
+
+	metrics := &recordingMetrics{latencies: make(map[string]time.Duration)}
+	result, err := g.Run(context.Background(), &Opts{
+		Parallelism: 1,
+		Metrics:     metrics,
+	})
+	tests.ExecuteE(err).NoError(t)
+
+	tests.Execute(result.Scheduler.MaxQueueDepth >= 2).Equal(t, true)
+	tests.Execute(result.Scheduler.MaxWorkersBusy).Equal(t, 1)
+	tests.Execute(result.Scheduler.MaxDispatchLatency >= 20*time.Millisecond).Equal(t, true)
+	tests.Execute(result.Nodes["c"].Started.Sub(result.Nodes["c"].Ready) >= 20*time.Millisecond).Equal(t, true)
+
+	tests.Execute(len(metrics.latencies)).Equal(t, 3)
+	tests.Execute(metrics.depths[len(metrics.depths)-1]).Equal(t, 0)
+	tests.Execute(metrics.busy[len(metrics.busy)-1]).Equal(t, 0)
+}
+
+func TestGraph_Walk_BatchSize(t *testing.T) {
+	tcs := map[string]struct {
+		batch    int
+		expected int
+	}{
+		"default": {expected: 1},
+		"batched": {batch: 4, expected: 4},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g := NewGraph()
+			for ix := 0; ix < 8; ix++ {
+				g.AddNode(fmt.Sprintf("node-%d", ix), Executable(func(ctx context.Context) error {
+					return nil
+				}))
+			}
+
+			// Holding up the walker on the first node lets every other node finish and wait in the buffer.
+			var once sync.Once
+			bus := NewBus(SinkFunc(func(event Event) {
+				if event.Type == EventNodeCompleted {
+					once.Do(func() {
+						time.Sleep(50 * time.Millisecond)
+					})
+				}
+			}))
+
+			result, err := g.Run(context.Background(), &Opts{
+				Parallelism:  8,
+				ResultBuffer: 8,
+				BatchSize:    tc.batch,
+				Bus:          bus,
+			})
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(len(result.Status(StatusCompleted))).Equal(t, 8)
+			tests.Execute(result.Scheduler.MaxBatch).Equal(t, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Separator separates the segments of hierarchical keys, such as "deploy/eu/az1".
+const Separator = "/"
+
+// JoinKey joins the given segments into a hierarchical key.
+func JoinKey(segments ...string) string {
+	return strings.Join(segments, Separator)
+}
+
+// SplitKey splits a hierarchical key into its segments.
+func SplitKey(key string) []string {
+	return strings.Split(key, Separator)
+}
+
+// Namespace returns the namespace of a hierarchical key, which is everything before the last separator. Keys without a
+// separator are in the root namespace, which is the empty string.
+func Namespace(key string) string {
+	ix := strings.LastIndex(key, Separator)
+	if ix < 0 {
+		return ""
+	}
+	return key[:ix]
+}
+
+// InNamespace returns true if the key is the namespace itself, or is nested anywhere within it. Every key is within the
+// root namespace.
+func InNamespace(key string, namespace string) bool {
+	if len(namespace) == 0 {
+		return true
+	}
+	return key == namespace || strings.HasPrefix(key, namespace+Separator)
+}
+
+// truncate returns the first depth segments of a key, depth must be at least 1.
+func truncate(key string, depth int) string {
+	segments := SplitKey(key)
+	if len(segments) <= depth {
+		return key
+	}
+	return JoinKey(segments[:depth]...)
+}
+
+// Namespaces returns every namespace used by the keys in the graph, including intermediate namespaces, sorted.
+func (g Graph) Namespaces() []string {
+	namespaces := make(map[string]bool)
+	for key := range g.nodes {
+		for namespace := Namespace(key); len(namespace) > 0; namespace = Namespace(namespace) {
+			namespaces[namespace] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		sorted = append(sorted, namespace)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// Select returns a new graph containing only the nodes within the given namespace, along with the edges between them.
+func (g Graph) Select(namespace string) Graph {
+	selected := NewGraph()
+	for key, node := range g.nodes {
+		if InNamespace(key, namespace) {
+			_ = selected.AddNodeWithMeta(key, node.impl, node.meta)
+		}
+	}
+
+	for key, node := range g.nodes {
+		if !InNamespace(key, namespace) {
+			continue
+		}
+		for _, child := range node.children {
+			if InNamespace(child, namespace) {
+				// Both nodes were added above, and g never holds self loops, so this can't fail.
+				_ = selected.Connect(key, child)
+			}
+		}
+	}
+	return selected
+}
+
+// Collapse returns a new graph where every node is replaced by its namespace truncated to the given depth. Each
+// collapsed node expands into the nodes it contains, so the collapsed graph can still be walked, and edges between the
+// contained nodes are preserved within the expansion. Edges between different collapsed nodes become edges between the
+// collapsed nodes themselves.
+//
+// A collapsed node is keyed by its namespace, unless one of the nodes it contains already has that key, in which case
+// a separator is appended to it: a graph with both "deploy" and "deploy/eu" collapses into "deploy/" at depth 1.
+//
+// Collapse is mostly useful to visualize large graphs at a coarser level. It returns an error with the InvalidDepth code
+// if depth is less than 1, and an error with the CycleDetected code if collapsing the graph joins its nodes into a
+// cycle, for example when a/x depends on b/y which depends on a/z.
+func (g Graph) Collapse(depth int) (Graph, error) {
+	if depth < 1 {
+		return Graph{}, errors.Newf(nil, InvalidDepth, "depth must be at least 1, not %d", depth)
+	}
+
+	groups := make(map[string][]string)
+	for key := range g.nodes {
+		group := truncate(key, depth)
+		groups[group] = append(groups[group], key)
+	}
+
+	collapsed := NewGraph()
+	collapsedTo := make(map[string]string, len(g.nodes))
+	for group, members := range groups {
+		if len(members) == 1 && members[0] == group {
+			// This node isn't collapsed at all, so keep it as it is.
+			collapsedTo[group] = group
+			if err := collapsed.AddNodeWithMeta(group, g.nodes[group].impl, g.nodes[group].meta); err != nil {
+				return Graph{}, err
+			}
+			continue
+		}
+
+		key := group
+		for slices.Contains(members, key) {
+			key += Separator
+		}
+		for _, member := range members {
+			collapsedTo[member] = key
+		}
+
+		subgraph := g.Select(group)
+		if err := collapsed.AddNode(key, Expandable(func(ctx context.Context) (Graph, error) {
+			return subgraph, nil
+		})); err != nil {
+			return Graph{}, err
+		}
+	}
+
+	for key, node := range g.nodes {
+		for _, child := range node.children {
+			from, to := collapsedTo[key], collapsedTo[child]
+			if from == to || collapsed.connected(from, to) {
+				continue
+			}
+			if err := collapsed.Connect(from, to); err != nil {
+				return Graph{}, err
+			}
+		}
+	}
+
+	if _, err := collapsed.topologicalOrder(); err != nil {
+		return Graph{}, err
+	}
+	return collapsed, nil
+}
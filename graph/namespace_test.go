@@ -0,0 +1,168 @@
+package graph
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func namespacedGraph(builder *strings.Builder) Graph {
+	write := func(value string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			builder.WriteString(value + ";")
+			return nil
+		})
+	}
+
+	g := NewGraph()
+	g.AddNode("build", write("build"))
+	g.AddNode("deploy/eu/az1", write("deploy/eu/az1"))
+	g.AddNode("deploy/eu/az2", write("deploy/eu/az2"))
+	g.AddNode("deploy/us/az1", write("deploy/us/az1"))
+	g.Connect("build", "deploy/eu/az1")
+	g.Connect("deploy/eu/az1", "deploy/eu/az2")
+	g.Connect("build", "deploy/us/az1")
+	return g
+}
+
+func TestGraph_Select(t *testing.T) {
+	g := namespacedGraph(new(strings.Builder)).Select("deploy/eu")
+	tests.Execute(g.sortedKeys()).Equal(t, []string{"deploy/eu/az1", "deploy/eu/az2"})
+	tests.Execute(g.Starters()).Equal(t, []string{"deploy/eu/az1"})
+	tests.Execute(namespacedGraph(new(strings.Builder)).Namespaces()).Equal(t, []string{"deploy", "deploy/eu", "deploy/us"})
+}
+
+func TestGraph_Collapse(t *testing.T) {
+	var builder strings.Builder
+	g, err := namespacedGraph(&builder).Collapse(2)
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(g.sortedKeys()).Equal(t, []string{"build", "deploy/eu", "deploy/us"})
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1})).NoError(t)
+
+	executed := strings.Split(strings.TrimSuffix(builder.String(), ";"), ";")
+	sort.Strings(executed)
+	tests.Execute(executed).Equal(t, []string{"build", "deploy/eu/az1", "deploy/eu/az2", "deploy/us/az1"})
+}
+
+func TestGraph_Collapse_Namespace(t *testing.T) {
+	var builder strings.Builder
+	write := func(value string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			builder.WriteString(value + ";")
+			return nil
+		})
+	}
+
+	// deploy is both a node and the namespace of deploy/eu, so the collapsed node needs a key of its own.
+	g := NewGraph()
+	g.AddNode("build", write("build"))
+	g.AddNode("deploy", write("deploy"))
+	g.AddNode("deploy/eu", write("deploy/eu"))
+	g.Connect("build", "deploy")
+	g.Connect("deploy", "deploy/eu")
+
+	collapsed, err := g.Collapse(1)
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(collapsed.sortedKeys()).Equal(t, []string{"build", "deploy/"})
+	tests.Execute(collapsed.Starters()).Equal(t, []string{"build"})
+
+	tests.ExecuteE(collapsed.Walk(context.Background(), &Opts{Parallelism: 1})).NoError(t)
+	tests.Execute(builder.String()).Equal(t, "build;deploy;deploy/eu;")
+}
+
+func TestGraph_WriteDOT(t *testing.T) {
+	var builder strings.Builder
+	tests.ExecuteE(namespacedGraph(new(strings.Builder)).WriteDOT(&builder)).NoError(t)
+	tests.Execute(builder.String()).Equal(t, `digraph {
+	"build" [label="build"];
+	subgraph "cluster_deploy" {
+		label="deploy";
+		subgraph "cluster_deploy/eu" {
+			label="deploy/eu";
+			"deploy/eu/az1" [label="az1"];
+			"deploy/eu/az2" [label="az2"];
+		}
+		subgraph "cluster_deploy/us" {
+			label="deploy/us";
+			"deploy/us/az1" [label="az1"];
+		}
+	}
+	"build" -> "deploy/eu/az1";
+	"build" -> "deploy/us/az1";
+	"deploy/eu/az1" -> "deploy/eu/az2";
+}
+`)
+}
+
+func TestGraph_WritePlantUML(t *testing.T) {
+	var builder strings.Builder
+	tests.ExecuteE(namespacedGraph(new(strings.Builder)).WritePlantUML(&builder)).NoError(t)
+	tests.Execute(builder.String()).Equal(t, `@startuml
+component "build" as n0
+package "deploy" {
+	package "deploy/eu" {
+		component "az1" as n1
+		component "az2" as n2
+	}
+	package "deploy/us" {
+		component "az1" as n3
+	}
+}
+n0 --> n1
+n0 --> n3
+n1 --> n2
+@enduml
+`)
+}
+
+func TestGraph_WritePlantUMLActivity(t *testing.T) {
+	var builder strings.Builder
+	tests.ExecuteE(namespacedGraph(new(strings.Builder)).WritePlantUMLActivity(&builder)).NoError(t)
+	tests.Execute(builder.String()).Equal(t, `@startuml
+start
+:build;
+fork
+	:deploy/eu/az1;
+fork again
+	:deploy/us/az1;
+end fork
+:deploy/eu/az2;
+stop
+@enduml
+`)
+
+	g := namespacedGraph(new(strings.Builder))
+	tests.ExecuteE(g.Connect("deploy/eu/az2", "build")).NoError(t)
+	err := g.WritePlantUMLActivity(new(strings.Builder))
+	tests.Execute(errors.GetErrorCode(err)).Equal(t, CycleDetected)
+}
+
+func TestGraph_Collapse_Error(t *testing.T) {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	g := NewGraph()
+	g.AddNode("a/x", noop)
+	g.AddNode("b/y", noop)
+	g.AddNode("a/z", noop)
+	g.Connect("a/x", "b/y")
+	g.Connect("b/y", "a/z")
+
+	for _, depth := range []int{-1, 0} {
+		_, err := g.Collapse(depth)
+		tests.Execute(errors.GetErrorCode(err)).Equal(t, InvalidDepth)
+	}
+
+	// a/x and a/z collapse into a, which both depends on b and is depended on by it.
+	_, err := g.Collapse(1)
+	tests.Execute(errors.GetErrorCode(err)).Equal(t, CycleDetected)
+
+	_, err = g.Collapse(2)
+	tests.ExecuteE(err).NoError(t)
+}
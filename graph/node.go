@@ -1,6 +1,11 @@
 package graph
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/pasataleo/go-errors/errors"
+)
 
 // node is a node in the graph.
 type node struct {
@@ -10,11 +15,65 @@ type node struct {
 	// impl is the implementation of the node.
 	impl interface{}
 
+	// meta contains the metadata the node was added with.
+	meta Meta
+
 	// parents and children contain the parents and children of the node.
 	parents  []string
 	children []string
 }
 
+// Meta contains optional metadata describing a node.
+//
+// The walker itself doesn't interpret labels or tags, they are passed back to the hooks in Opts so callers can make
+// decisions based on them.
+type Meta struct {
+	// Labels are arbitrary key/value pairs attached to the node.
+	Labels map[string]string
+
+	// Tags group related nodes together.
+	Tags []string
+
+	// Owner is the person or team responsible for the node, failures are grouped by it so they can be routed to the
+	// right people. See Opts.Route.
+	Owner string
+
+	// Estimate is the expected duration of the node, used by Simulate and to decide whether optional nodes fit before
+	// the deadline of a walk.
+	Estimate time.Duration
+
+	// Env is the environment of the node. The values are passed through Opts.Resolver just before the node runs, so
+	// they can be references to secrets or configuration rather than the values themselves, and the resolved values are
+	// available to the node through Env and Environ.
+	Env map[string]string
+
+	// Optional marks the node as one the walk can do without. Optional nodes are pruned instead of dispatched if the
+	// longest path of estimates from the node would finish after the deadline of the walk, or if an optional parent was
+	// pruned. See Opts.Deadline.
+	Optional bool
+
+	// Fingerprint identifies the work the node does. Nodes with the same fingerprint are expected to do the same work,
+	// so walks sharing Opts.Completions only execute one of them. Expandable nodes are never shared.
+	Fingerprint string
+
+	// Window restricts when the node may run, see Window and Opts.WindowPolicy. Windows can also be declared for every
+	// node with a tag through Opts.Windows.
+	Window Window
+
+	// Version is the version of the definition of the node. Nodes that completed in a checkpoint with a different version
+	// are run again when resuming from it, unless Opts.Migrate says otherwise.
+	Version string
+
+	// Readiness decides when the node is ready to run, see Readiness.
+	//
+	// Defaults to once all of its parents have completed.
+	Readiness Readiness
+
+	// Subgraph limits the subgraph the node expands into, see SubgraphLimits. It is ignored for nodes that don't
+	// expand.
+	Subgraph SubgraphLimits
+}
+
 // ExecutableNode is a node that can be executed.
 type ExecutableNode interface {
 	Execute(ctx context.Context) error
@@ -34,10 +93,19 @@ func (e *executable) Execute(ctx context.Context) error {
 }
 
 // ExpandableNode is a node that can be expanded.
+//
+// If Expand could only discover some of the work, it can return the subgraph it did discover along with an error
+// wrapped by Partial. The subgraph is walked as usual, and the node fails with the error once it has finished.
 type ExpandableNode interface {
 	Expand(ctx context.Context) (Graph, error)
 }
 
+// Partial marks an error returned by ExpandableNode.Expand as partial, meaning the subgraph returned alongside it should
+// still be walked.
+func Partial(err error) error {
+	return errors.New(err, PartialExpansion, "expansion is partial")
+}
+
 type expandable struct {
 	fn func(ctx context.Context) (Graph, error)
 }
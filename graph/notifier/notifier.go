@@ -0,0 +1,447 @@
+// Package notifier posts JSON payloads to webhooks when nodes fail or walks complete.
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pasataleo/go-errors/errors"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+var (
+	FailedDelivery errors.ErrorCode = "notifier.failed_delivery"
+)
+
+// Trigger identifies the situations a webhook is notified about.
+type Trigger string
+
+const (
+	// NodeFailed triggers a notification whenever a node errors.
+	NodeFailed Trigger = "node.failed"
+
+	// WalkCompleted triggers a notification when a walk finishes, successfully or not.
+	WalkCompleted Trigger = "walk.completed"
+)
+
+// Notification contains everything known about the event that triggered a notification.
+type Notification struct {
+	// Trigger is the reason for the notification.
+	Trigger Trigger
+
+	// WalkID identifies the walk that triggered the notification.
+	WalkID string
+
+	// Key is the key of the failed node, it is empty for walk notifications.
+	Key string
+
+	// Owner is the owner of the failed node, see graph.Opts.Route. It is empty for walk notifications.
+	Owner string
+
+	// Err is the error of the failed node or walk, if any.
+	Err error
+
+	// Completed contains the keys of all the nodes that have completed in the walk so far.
+	Completed []string
+
+	// Failed contains the keys of all the nodes that have failed in the walk so far.
+	Failed []string
+
+	// FailedByOwner contains the same keys as Failed, grouped by the owner of each node.
+	FailedByOwner map[string][]string
+
+	// Time is the time of the triggering event.
+	Time time.Time
+}
+
+// PayloadFunc builds the JSON payload for a notification. The returned value is marshalled with encoding/json.
+type PayloadFunc func(notification Notification) (interface{}, error)
+
+// Webhook describes a single webhook endpoint.
+type Webhook struct {
+	// URL is the endpoint the payload is posted to.
+	URL string
+
+	// Headers are added to every request, for example to provide authorization.
+	Headers map[string]string
+
+	// Triggers limits the notifications sent to this webhook. Defaults to all triggers.
+	Triggers []Trigger
+
+	// Payload builds the body of the request. Defaults to DefaultPayload.
+	Payload PayloadFunc
+
+	// Owners limits node failure notifications sent to this webhook to nodes with one of the given owners, so each
+	// team can be alerted about just their own nodes. Walk notifications are always sent. Defaults to all owners.
+	Owners []string
+}
+
+// Opts contains options for a Notifier.
+type Opts struct {
+	// Client is used to send requests. Defaults to a client that gives up on requests after 10s.
+	Client *http.Client
+
+	// Retries is the number of times a failed delivery is retried.
+	Retries int
+
+	// Backoff is the delay between retries, it is doubled after every attempt up to MaxBackoff.
+	//
+	// Defaults to 100ms.
+	Backoff time.Duration
+
+	// MaxBackoff caps the delay between retries.
+	//
+	// Defaults to 10s.
+	MaxBackoff time.Duration
+
+	// FlushTimeout is how long a walk waits for its own notifications to be delivered when it finishes. Notifications
+	// still queued after that are delivered once the walk has returned.
+	//
+	// Defaults to 10s.
+	FlushTimeout time.Duration
+
+	// QueueSize is the number of notifications that may be waiting to be delivered. Notifications are dropped, and
+	// reported to OnError, while the queue is full.
+	//
+	// Defaults to 64.
+	QueueSize int
+
+	// OnError is called when a notification could not be delivered after all retries, or was dropped. It is called
+	// from the goroutine delivering the notifications, or from the walker when a notification is dropped.
+	OnError func(webhook Webhook, err error)
+}
+
+var _ graph.Sink = (*Notifier)(nil)
+
+// Notifier is a graph.Sink that sends notifications to webhooks.
+//
+// Notifications are queued and delivered in order by a separate goroutine, so slow or failing webhooks don't hold up
+// the walks. A walk only finishes once its own notifications have been delivered or given up on, or after
+// Opts.FlushTimeout, whichever comes first.
+type Notifier struct {
+	opts     Opts
+	webhooks []Webhook
+
+	mutex sync.Mutex
+
+	// walks tracks the progress of every walk the notifier has seen, by walk id.
+	walks map[string]*progress
+
+	// queue holds the notifications waiting to be delivered. draining is true while a goroutine is delivering them,
+	// and idle is signalled once it has delivered them all.
+	queue    []delivery
+	draining bool
+	idle     *sync.Cond
+
+	// pending counts the queued notifications of each walk, by walk id. flushed holds a channel for each finished walk
+	// still waiting for its notifications, which is closed once its count drops to zero.
+	pending map[string]int
+	flushed map[string]chan struct{}
+}
+
+// delivery is a notification waiting to be delivered to a webhook.
+type delivery struct {
+	webhook      Webhook
+	notification Notification
+}
+
+type progress struct {
+	completed []string
+	failed    []string
+
+	// owners holds the owner of each failed node.
+	owners map[string]string
+}
+
+// New creates a new notifier that delivers to the given webhooks.
+func New(opts Opts, webhooks ...Webhook) *Notifier {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.Backoff == 0 {
+		opts.Backoff = 100 * time.Millisecond
+	}
+	if opts.MaxBackoff == 0 {
+		opts.MaxBackoff = 10 * time.Second
+	}
+	if opts.FlushTimeout == 0 {
+		opts.FlushTimeout = 10 * time.Second
+	}
+	if opts.QueueSize == 0 {
+		opts.QueueSize = 64
+	}
+	notifier := &Notifier{
+		opts:     opts,
+		webhooks: webhooks,
+		walks:    make(map[string]*progress),
+		pending:  make(map[string]int),
+		flushed:  make(map[string]chan struct{}),
+	}
+	notifier.idle = sync.NewCond(&notifier.mutex)
+	return notifier
+}
+
+// DefaultPayload is the payload used when a webhook doesn't specify one.
+func DefaultPayload(notification Notification) (interface{}, error) {
+	payload := map[string]interface{}{
+		"trigger":   notification.Trigger,
+		"walk_id":   notification.WalkID,
+		"completed": len(notification.Completed),
+		"failed":    notification.Failed,
+		"time":      notification.Time,
+	}
+	if len(notification.FailedByOwner) > 0 {
+		payload["failed_by_owner"] = notification.FailedByOwner
+	}
+	if len(notification.Key) > 0 {
+		payload["key"] = notification.Key
+	}
+	if len(notification.Owner) > 0 {
+		payload["owner"] = notification.Owner
+	}
+	if notification.Err != nil {
+		payload["error"] = notification.Err.Error()
+	}
+	return payload, nil
+}
+
+// Handle implements graph.Sink.
+func (notifier *Notifier) Handle(event graph.Event) {
+	notification, ok := notifier.record(event)
+	if !ok {
+		return
+	}
+
+	for _, webhook := range notifier.webhooks {
+		if !webhook.triggeredBy(notification.Trigger) || !webhook.ownedBy(notification) {
+			continue
+		}
+
+		notifier.enqueue(webhook, notification)
+	}
+
+	if event.Type == graph.EventWalkFinished {
+		notifier.wait(event.WalkID)
+	}
+}
+
+// Flush blocks until every queued notification, from any walk, has been delivered or given up on.
+func (notifier *Notifier) Flush() {
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+
+	for notifier.draining {
+		notifier.idle.Wait()
+	}
+}
+
+// wait blocks until the notifications of the walk have been delivered or given up on, or until Opts.FlushTimeout.
+func (notifier *Notifier) wait(walkID string) {
+	notifier.mutex.Lock()
+	if notifier.pending[walkID] == 0 {
+		notifier.mutex.Unlock()
+		return
+	}
+	flushed := make(chan struct{})
+	notifier.flushed[walkID] = flushed
+	notifier.mutex.Unlock()
+
+	timer := time.NewTimer(notifier.opts.FlushTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-flushed:
+	case <-timer.C:
+		notifier.mutex.Lock()
+		delete(notifier.flushed, walkID)
+		notifier.mutex.Unlock()
+	}
+}
+
+// enqueue queues the notification for delivery to the webhook, and starts delivering the queue if it isn't already.
+func (notifier *Notifier) enqueue(webhook Webhook, notification Notification) {
+	notifier.mutex.Lock()
+	if len(notifier.queue) >= notifier.opts.QueueSize {
+		notifier.mutex.Unlock()
+		err := errors.Newf(nil, FailedDelivery, "dropped notification, %d notifications are already queued", notifier.opts.QueueSize)
+		notifier.fail(webhook, errors.Embed(err, "notifier.url", webhook.URL))
+		return
+	}
+
+	notifier.queue = append(notifier.queue, delivery{webhook: webhook, notification: notification})
+	notifier.pending[notification.WalkID]++
+	if !notifier.draining {
+		notifier.draining = true
+		go notifier.drain()
+	}
+	notifier.mutex.Unlock()
+}
+
+// drain delivers the queued notifications in order until the queue is empty.
+func (notifier *Notifier) drain() {
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+
+	for len(notifier.queue) > 0 {
+		next := notifier.queue[0]
+		notifier.queue = notifier.queue[1:]
+
+		notifier.mutex.Unlock()
+		if err := notifier.deliver(next.webhook, next.notification); err != nil {
+			notifier.fail(next.webhook, err)
+		}
+		notifier.mutex.Lock()
+
+		walkID := next.notification.WalkID
+		if notifier.pending[walkID]--; notifier.pending[walkID] == 0 {
+			delete(notifier.pending, walkID)
+			if flushed, ok := notifier.flushed[walkID]; ok {
+				close(flushed)
+				delete(notifier.flushed, walkID)
+			}
+		}
+	}
+
+	notifier.draining = false
+	notifier.idle.Broadcast()
+}
+
+func (notifier *Notifier) fail(webhook Webhook, err error) {
+	if notifier.opts.OnError != nil {
+		notifier.opts.OnError(webhook, err)
+	}
+}
+
+// record updates the progress of the walk and returns the notification for the event, if there is one.
+func (notifier *Notifier) record(event graph.Event) (Notification, bool) {
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+
+	walk, ok := notifier.walks[event.WalkID]
+	if !ok {
+		walk = &progress{owners: make(map[string]string)}
+		notifier.walks[event.WalkID] = walk
+	}
+
+	notification := Notification{
+		WalkID: event.WalkID,
+		Key:    event.Key,
+		Owner:  event.Owner,
+		Err:    event.Err,
+		Time:   event.Time,
+	}
+
+	switch event.Type {
+	case graph.EventNodeCompleted:
+		walk.completed = append(walk.completed, event.Key)
+		return notification, false
+	case graph.EventNodeErrored:
+		walk.failed = append(walk.failed, event.Key)
+		walk.owners[event.Key] = event.Owner
+		notification.Trigger = NodeFailed
+	case graph.EventWalkFinished:
+		delete(notifier.walks, event.WalkID)
+		notification.Trigger = WalkCompleted
+	default:
+		return notification, false
+	}
+
+	notification.Completed = append([]string(nil), walk.completed...)
+	notification.Failed = append([]string(nil), walk.failed...)
+	sort.Strings(notification.Completed)
+	sort.Strings(notification.Failed)
+
+	notification.FailedByOwner = make(map[string][]string)
+	for _, key := range notification.Failed {
+		owner := walk.owners[key]
+		notification.FailedByOwner[owner] = append(notification.FailedByOwner[owner], key)
+	}
+	return notification, true
+}
+
+// deliver posts the notification to the webhook, retrying as configured.
+func (notifier *Notifier) deliver(webhook Webhook, notification Notification) error {
+	build := webhook.Payload
+	if build == nil {
+		build = DefaultPayload
+	}
+
+	payload, err := build(notification)
+	if err != nil {
+		return errors.Wrap(err, "failed to build payload")
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal payload")
+	}
+
+	backoff := notifier.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		err = notifier.post(webhook, body)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= notifier.opts.Retries {
+			return errors.Embed(errors.Newf(err, FailedDelivery, "failed to deliver notification after %d attempts", attempt+1), "notifier.url", webhook.URL)
+		}
+
+		time.Sleep(backoff)
+		backoff = min(backoff*2, notifier.opts.MaxBackoff)
+	}
+}
+
+func (notifier *Notifier) post(webhook Webhook, body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range webhook.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := notifier.opts.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", response.Status)
+	}
+	return nil
+}
+
+func (webhook Webhook) triggeredBy(trigger Trigger) bool {
+	if len(webhook.Triggers) == 0 {
+		return true
+	}
+	for _, candidate := range webhook.Triggers {
+		if candidate == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+func (webhook Webhook) ownedBy(notification Notification) bool {
+	if len(webhook.Owners) == 0 || notification.Trigger != NodeFailed {
+		return true
+	}
+	for _, owner := range webhook.Owners {
+		if owner == notification.Owner {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+func TestNotifier(t *testing.T) {
+	var mutex sync.Mutex
+	var attempts int
+	var payloads []map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		attempts++
+		if attempts == 1 {
+			// fail the first request so the retry logic is exercised.
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var payload map[string]interface{}
+		if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	g := graph.NewGraph()
+	g.AddNode("a", graph.Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNode("b", graph.Executable(func(ctx context.Context) error {
+		return context.DeadlineExceeded
+	}))
+	g.Connect("a", "b")
+
+	notifier := New(Opts{Retries: 1, Backoff: 1}, Webhook{URL: server.URL})
+	tests.ExecuteE(g.Walk(context.Background(), &graph.Opts{Parallelism: 1, Bus: graph.NewBus(notifier)})).Error(t)
+
+	tests.Execute(attempts).Equal(t, 3)
+	tests.Execute(len(payloads)).Equal(t, 2)
+	tests.Execute(payloads[0]["trigger"]).Equal(t, interface{}(string(NodeFailed)))
+	tests.Execute(payloads[0]["key"]).Equal(t, interface{}("b"))
+	tests.Execute(payloads[1]["trigger"]).Equal(t, interface{}(string(WalkCompleted)))
+	tests.Execute(payloads[1]["completed"]).Equal(t, interface{}(float64(1)))
+}
+
+func TestNotifier_Owners(t *testing.T) {
+	var mutex sync.Mutex
+	received := make(map[string][]map[string]interface{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		var payload map[string]interface{}
+		if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		received[request.URL.Path] = append(received[request.URL.Path], payload)
+	}))
+	defer server.Close()
+
+	g := graph.NewGraph()
+	g.AddNodeWithMeta("a", graph.Executable(func(ctx context.Context) error {
+		return context.DeadlineExceeded
+	}), graph.Meta{Owner: "infra"})
+	g.AddNodeWithMeta("b", graph.Executable(func(ctx context.Context) error {
+		return context.DeadlineExceeded
+	}), graph.Meta{Owner: "web"})
+
+	notifier := New(Opts{}, Webhook{URL: server.URL + "/infra", Owners: []string{"infra"}}, Webhook{URL: server.URL + "/all"})
+	tests.ExecuteE(g.Walk(context.Background(), &graph.Opts{Parallelism: 1, Bus: graph.NewBus(notifier)})).Error(t)
+
+	tests.Execute(len(received["/all"])).Equal(t, 3)
+	tests.Execute(len(received["/infra"])).Equal(t, 2)
+	tests.Execute(received["/infra"][0]["key"]).Equal(t, interface{}("a"))
+	tests.Execute(received["/infra"][0]["owner"]).Equal(t, interface{}("infra"))
+	tests.Execute(received["/infra"][1]["failed_by_owner"]).Equal(t, interface{}(map[string]interface{}{
+		"infra": []interface{}{"a"},
+		"web":   []interface{}{"b"},
+	}))
+}
+
+func TestNotifier_Async(t *testing.T) {
+	var mutex sync.Mutex
+	var triggers []string
+	release := make(chan struct{})
+
+	// The webhook doesn't respond until b has run, so the walk would never finish if notifications held it up.
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		<-release
+
+		var payload map[string]interface{}
+		if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		triggers = append(triggers, payload["trigger"].(string))
+	}))
+	defer server.Close()
+
+	g := graph.NewGraph()
+	g.AddNode("a", graph.Executable(func(ctx context.Context) error {
+		return context.DeadlineExceeded
+	}))
+	g.AddNode("b", graph.Executable(func(ctx context.Context) error {
+		close(release)
+		return nil
+	}))
+
+	notifier := New(Opts{}, Webhook{URL: server.URL})
+	tests.ExecuteE(g.Walk(context.Background(), &graph.Opts{Parallelism: 1, Bus: graph.NewBus(notifier)})).Error(t)
+
+	// Every notification has been delivered by the time the walk returns.
+	mutex.Lock()
+	defer mutex.Unlock()
+	tests.Execute(triggers).Equal(t, []string{string(NodeFailed), string(WalkCompleted)})
+}
+
+func TestNotifier_FlushTimeout(t *testing.T) {
+	release := make(chan struct{})
+
+	// The webhook never responds while the walk runs, so the walk only returns because of the flush timeout.
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	g := graph.NewGraph()
+	g.AddNode("a", graph.Executable(func(ctx context.Context) error {
+		return context.DeadlineExceeded
+	}))
+
+	notifier := New(Opts{FlushTimeout: 10 * time.Millisecond}, Webhook{URL: server.URL})
+	tests.ExecuteE(g.Walk(context.Background(), &graph.Opts{Parallelism: 1, Bus: graph.NewBus(notifier)})).Error(t)
+
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+	tests.Execute(len(notifier.flushed)).Equal(t, 0)
+}
@@ -0,0 +1,134 @@
+package graph
+
+import "sync"
+
+// Observer is notified of every change made to a graph it observes, so anything derived from the graph, such as an
+// index or a visualization, can be kept up to date without polling.
+//
+// Observers are called synchronously from whichever goroutine changes the graph, after the change has been made.
+type Observer interface {
+	// NodeAdded is called when a node is added.
+	NodeAdded(key string, meta Meta)
+
+	// NodeReplaced is called when the implementation or metadata of an existing node is replaced. Any edges removed
+	// along with it are reported first.
+	NodeReplaced(key string, meta Meta)
+
+	// NodeRemoved is called when a node is removed. The edges removed along with it are reported first.
+	NodeRemoved(key string)
+
+	// EdgeAdded is called when two nodes are connected.
+	EdgeAdded(from string, to string)
+
+	// EdgeRemoved is called when the edge between two nodes is removed.
+	EdgeRemoved(from string, to string)
+}
+
+var _ Observer = ObserverFuncs{}
+
+// ObserverFuncs adapts simple functions into an Observer. Any of the functions can be nil, in which case the change is
+// ignored.
+type ObserverFuncs struct {
+	OnNodeAdded    func(key string, meta Meta)
+	OnNodeReplaced func(key string, meta Meta)
+	OnNodeRemoved  func(key string)
+	OnEdgeAdded    func(from string, to string)
+	OnEdgeRemoved  func(from string, to string)
+}
+
+// NodeAdded implements Observer.
+func (funcs ObserverFuncs) NodeAdded(key string, meta Meta) {
+	if funcs.OnNodeAdded != nil {
+		funcs.OnNodeAdded(key, meta)
+	}
+}
+
+// NodeReplaced implements Observer.
+func (funcs ObserverFuncs) NodeReplaced(key string, meta Meta) {
+	if funcs.OnNodeReplaced != nil {
+		funcs.OnNodeReplaced(key, meta)
+	}
+}
+
+// NodeRemoved implements Observer.
+func (funcs ObserverFuncs) NodeRemoved(key string) {
+	if funcs.OnNodeRemoved != nil {
+		funcs.OnNodeRemoved(key)
+	}
+}
+
+// EdgeAdded implements Observer.
+func (funcs ObserverFuncs) EdgeAdded(from string, to string) {
+	if funcs.OnEdgeAdded != nil {
+		funcs.OnEdgeAdded(from, to)
+	}
+}
+
+// EdgeRemoved implements Observer.
+func (funcs ObserverFuncs) EdgeRemoved(from string, to string) {
+	if funcs.OnEdgeRemoved != nil {
+		funcs.OnEdgeRemoved(from, to)
+	}
+}
+
+// Observe registers an observer that is notified of every later change to the graph. The returned function removes
+// the observer again.
+//
+// Observers belong to the graph itself, so they aren't carried over by Clone or any other method returning a new graph.
+func (g Graph) Observe(observer Observer) func() {
+	return g.observers.add(observer)
+}
+
+// observers contains the observers registered on a graph. It is shared by every copy of the Graph value.
+type observers struct {
+	mutex sync.RWMutex
+
+	// next is the id that will be assigned to the next observer.
+	next int
+
+	// snapshot contains the registered observers in the order they were registered. It is replaced rather than
+	// modified, so notify can iterate over it without holding the lock.
+	snapshot []registeredObserver
+}
+
+type registeredObserver struct {
+	id       int
+	observer Observer
+}
+
+func (observers *observers) add(observer Observer) func() {
+	observers.mutex.Lock()
+	defer observers.mutex.Unlock()
+
+	id := observers.next
+	observers.next++
+	observers.snapshot = append(append([]registeredObserver(nil), observers.snapshot...), registeredObserver{id, observer})
+
+	return func() {
+		observers.mutex.Lock()
+		defer observers.mutex.Unlock()
+
+		var kept []registeredObserver
+		for _, registered := range observers.snapshot {
+			if registered.id != id {
+				kept = append(kept, registered)
+			}
+		}
+		observers.snapshot = kept
+	}
+}
+
+// notify calls fn for every registered observer. Graphs that weren't created by NewGraph have no observers.
+func (observers *observers) notify(fn func(observer Observer)) {
+	if observers == nil {
+		return
+	}
+
+	observers.mutex.RLock()
+	snapshot := observers.snapshot
+	observers.mutex.RUnlock()
+
+	for _, registered := range snapshot {
+		fn(registered.observer)
+	}
+}
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Observe(t *testing.T) {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	var changes []string
+	observer := ObserverFuncs{
+		OnNodeAdded: func(key string, meta Meta) {
+			changes = append(changes, fmt.Sprintf("+%s", key))
+		},
+		OnNodeReplaced: func(key string, meta Meta) {
+			changes = append(changes, fmt.Sprintf("~%s", key))
+		},
+		OnNodeRemoved: func(key string) {
+			changes = append(changes, fmt.Sprintf("-%s", key))
+		},
+		OnEdgeAdded: func(from string, to string) {
+			changes = append(changes, fmt.Sprintf("+%s->%s", from, to))
+		},
+		OnEdgeRemoved: func(from string, to string) {
+			changes = append(changes, fmt.Sprintf("-%s->%s", from, to))
+		},
+	}
+
+	g := NewGraph()
+	stop := g.Observe(observer)
+
+	g.AddNode("a", noop)
+	g.AddNode("b", noop)
+	g.AddNode("b", noop) // Failed changes aren't reported.
+	g.Connect("a", "b")
+	g.UpsertNode("a", noop)
+	g.ReplaceNode("b", noop)
+	g.RemoveNode("a")
+	tests.Execute(changes).Equal(t, []string{"+a", "+b", "+a->b", "~a", "-a->b", "~b", "-a"})
+
+	// Staged changes are only reported once they are committed.
+	changes = nil
+	tx := g.Begin()
+	tx.AddNode("c", noop)
+	tx.Connect("b", "c")
+	tests.Execute(changes).Equal(t, []string(nil))
+	tests.ExecuteE(tx.Commit()).NoError(t)
+	tests.Execute(changes).Equal(t, []string{"+c", "+b->c"})
+
+	changes = nil
+	tx = g.Begin()
+	tx.RemoveNode("c")
+	tx.Rollback()
+	tests.Execute(changes).Equal(t, []string(nil))
+
+	// Clones don't share observers, and observers can be removed.
+	g.Clone().AddNode("d", noop)
+	stop()
+	g.AddNode("e", noop)
+	tests.Execute(changes).Equal(t, []string(nil))
+}
@@ -0,0 +1,419 @@
+// Package openlineage exports walk events as OpenLineage run events, so walks show up in data-lineage catalogs.
+package openlineage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+const (
+	// Producer identifies this library as the producer of the events.
+	Producer = "https://github.com/pasataleo/go-graph"
+
+	// SchemaURL is the OpenLineage schema the run events conform to.
+	SchemaURL = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/$defs/RunEvent"
+
+	parentFacetSchemaURL       = "https://openlineage.io/spec/facets/1-0-1/ParentRunFacet.json#/$defs/ParentRunFacet"
+	errorMessageFacetSchemaURL = "https://openlineage.io/spec/facets/1-0-1/ErrorMessageRunFacet.json#/$defs/ErrorMessageRunFacet"
+)
+
+// EventType is the OpenLineage run state transition.
+type EventType string
+
+const (
+	Start    EventType = "START"
+	Complete EventType = "COMPLETE"
+	Fail     EventType = "FAIL"
+	Abort    EventType = "ABORT"
+)
+
+// RunEvent is an OpenLineage run event.
+type RunEvent struct {
+	EventType EventType `json:"eventType"`
+	EventTime time.Time `json:"eventTime"`
+	Run       Run       `json:"run"`
+	Job       Job       `json:"job"`
+	Inputs    []Dataset `json:"inputs"`
+	Outputs   []Dataset `json:"outputs"`
+	Producer  string    `json:"producer"`
+	SchemaURL string    `json:"schemaURL"`
+}
+
+// Run identifies a single run of a job.
+type Run struct {
+	RunID  string                 `json:"runId"`
+	Facets map[string]interface{} `json:"facets,omitempty"`
+}
+
+// Job identifies a job.
+type Job struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// Dataset identifies a dataset read or written by a job.
+type Dataset struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// Transport delivers run events to a lineage backend.
+type Transport interface {
+	Emit(ctx context.Context, event RunEvent) error
+}
+
+// HTTPTransport posts run events to the lineage endpoint of an OpenLineage compatible server, such as Marquez.
+type HTTPTransport struct {
+	// URL is the full url of the lineage endpoint, for example http://localhost:5000/api/v1/lineage.
+	URL string
+
+	// APIKey is sent as a bearer token if set.
+	APIKey string
+
+	// Client is used to send requests. Defaults to a client that gives up on requests after 10s.
+	Client *http.Client
+}
+
+// Emit implements Transport.
+func (transport HTTPTransport) Emit(ctx context.Context, event RunEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, transport.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if len(transport.APIKey) > 0 {
+		request.Header.Set("Authorization", "Bearer "+transport.APIKey)
+	}
+
+	client := transport.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", response.Status)
+	}
+	return nil
+}
+
+// WriterTransport writes run events as lines of JSON, which is useful for debugging or for shipping events with a log
+// collector.
+type WriterTransport struct {
+	mutex  sync.Mutex
+	Writer io.Writer
+}
+
+// Emit implements Transport.
+func (transport *WriterTransport) Emit(_ context.Context, event RunEvent) error {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	return json.NewEncoder(transport.Writer).Encode(event)
+}
+
+// Opts contains options for an Exporter.
+type Opts struct {
+	// Namespace is the OpenLineage namespace of the jobs.
+	Namespace string
+
+	// Job is the name of the job representing the walk. Nodes are reported as jobs named "<Job>.<key>".
+	Job string
+
+	// Transport delivers the events.
+	Transport Transport
+
+	// Datasets optionally reports the inputs and outputs of each node.
+	Datasets func(key string) (inputs []Dataset, outputs []Dataset)
+
+	// Timeout bounds the delivery of a single event, it is passed to the Transport through the context.
+	//
+	// Defaults to 10s.
+	Timeout time.Duration
+
+	// QueueSize is the number of events that may be waiting to be delivered. Events are dropped, and reported to
+	// OnError, while the queue is full.
+	//
+	// Defaults to 256.
+	QueueSize int
+
+	// FlushTimeout is how long a walk waits for its own events to be delivered when it finishes. Events still queued
+	// after that are delivered once the walk has returned.
+	//
+	// Defaults to 10s.
+	FlushTimeout time.Duration
+
+	// OnError is called when an event could not be delivered, or was dropped. It is called from the goroutine
+	// delivering the events, or from the walker when an event is dropped.
+	OnError func(event RunEvent, err error)
+}
+
+var _ graph.Sink = (*Exporter)(nil)
+
+// Exporter is a graph.Sink that converts walk events into OpenLineage run events.
+//
+// Events are queued and delivered in order by a separate goroutine, so a slow lineage backend doesn't hold up the
+// walks. A walk only finishes once its own events have been delivered or given up on, or after Opts.FlushTimeout,
+// whichever comes first.
+type Exporter struct {
+	opts Opts
+
+	mutex sync.Mutex
+
+	// runs maps walk ids, and walk ids joined with node keys, to OpenLineage run ids.
+	runs map[string]string
+
+	// queue holds the events waiting to be delivered, and draining is true while a goroutine is delivering them.
+	queue    []queued
+	draining bool
+
+	// pending counts the queued events of each walk, by walk id. flushed holds a channel for each finished walk still
+	// waiting for its events, which is closed once its count drops to zero.
+	pending map[string]int
+	flushed map[string]chan struct{}
+}
+
+// queued is a run event waiting to be delivered.
+type queued struct {
+	walkID string
+	event  RunEvent
+}
+
+// NewExporter creates a new exporter.
+func NewExporter(opts Opts) *Exporter {
+	if opts.Timeout == 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.QueueSize == 0 {
+		opts.QueueSize = 256
+	}
+	if opts.FlushTimeout == 0 {
+		opts.FlushTimeout = 10 * time.Second
+	}
+	return &Exporter{
+		opts:    opts,
+		runs:    make(map[string]string),
+		pending: make(map[string]int),
+		flushed: make(map[string]chan struct{}),
+	}
+}
+
+// Handle implements graph.Sink.
+func (exporter *Exporter) Handle(event graph.Event) {
+	var eventType EventType
+	switch event.Type {
+	case graph.EventWalkStarted, graph.EventNodeStarted:
+		eventType = Start
+	case graph.EventWalkFinished, graph.EventNodeCompleted, graph.EventNodeErrored:
+		eventType = Complete
+		if event.Err != nil {
+			eventType = Fail
+		}
+	case graph.EventNodeCancelled:
+		if !exporter.started(event.WalkID, event.Key) {
+			// The node was cancelled before it was dispatched, so there is no run to abort.
+			return
+		}
+		eventType = Abort
+	default:
+		return
+	}
+
+	run := RunEvent{
+		EventType: eventType,
+		EventTime: event.Time,
+		Run: Run{
+			RunID:  exporter.runID(event.WalkID, event.Key),
+			Facets: make(map[string]interface{}),
+		},
+		Job: Job{
+			Namespace: exporter.opts.Namespace,
+			Name:      exporter.jobName(event.Key),
+		},
+		Inputs:    []Dataset{},
+		Outputs:   []Dataset{},
+		Producer:  Producer,
+		SchemaURL: SchemaURL,
+	}
+
+	if len(event.Key) > 0 {
+		run.Run.Facets["parent"] = map[string]interface{}{
+			"_producer":  Producer,
+			"_schemaURL": parentFacetSchemaURL,
+			"run": map[string]string{
+				"runId": exporter.runID(event.WalkID, ""),
+			},
+			"job": Job{
+				Namespace: exporter.opts.Namespace,
+				Name:      exporter.opts.Job,
+			},
+		}
+
+		if exporter.opts.Datasets != nil {
+			inputs, outputs := exporter.opts.Datasets(event.Key)
+			run.Inputs = append(run.Inputs, inputs...)
+			run.Outputs = append(run.Outputs, outputs...)
+		}
+	}
+
+	if event.Err != nil {
+		run.Run.Facets["errorMessage"] = map[string]interface{}{
+			"_producer":           Producer,
+			"_schemaURL":          errorMessageFacetSchemaURL,
+			"message":             event.Err.Error(),
+			"programmingLanguage": "go",
+		}
+	}
+
+	if eventType != Start {
+		// The run is finished, so we can forget about it once the event has been built.
+		defer exporter.forget(event.WalkID, event.Key)
+	}
+
+	exporter.enqueue(event.WalkID, run)
+	if event.Type == graph.EventWalkFinished {
+		exporter.wait(event.WalkID)
+	}
+}
+
+// enqueue queues the run event for delivery, and starts delivering the queue if it isn't already.
+func (exporter *Exporter) enqueue(walkID string, run RunEvent) {
+	exporter.mutex.Lock()
+	if len(exporter.queue) >= exporter.opts.QueueSize {
+		exporter.mutex.Unlock()
+		exporter.fail(run, fmt.Errorf("dropped event, %d events are already queued", exporter.opts.QueueSize))
+		return
+	}
+
+	exporter.queue = append(exporter.queue, queued{walkID: walkID, event: run})
+	exporter.pending[walkID]++
+	if !exporter.draining {
+		exporter.draining = true
+		go exporter.drain()
+	}
+	exporter.mutex.Unlock()
+}
+
+// drain delivers the queued events in order until the queue is empty.
+func (exporter *Exporter) drain() {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	for len(exporter.queue) > 0 {
+		next := exporter.queue[0]
+		exporter.queue = exporter.queue[1:]
+
+		exporter.mutex.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), exporter.opts.Timeout)
+		if err := exporter.opts.Transport.Emit(ctx, next.event); err != nil {
+			exporter.fail(next.event, err)
+		}
+		cancel()
+		exporter.mutex.Lock()
+
+		if exporter.pending[next.walkID]--; exporter.pending[next.walkID] == 0 {
+			delete(exporter.pending, next.walkID)
+			if flushed, ok := exporter.flushed[next.walkID]; ok {
+				close(flushed)
+				delete(exporter.flushed, next.walkID)
+			}
+		}
+	}
+
+	exporter.draining = false
+}
+
+// wait blocks until the events of the walk have been delivered or given up on, or until Opts.FlushTimeout.
+func (exporter *Exporter) wait(walkID string) {
+	exporter.mutex.Lock()
+	if exporter.pending[walkID] == 0 {
+		exporter.mutex.Unlock()
+		return
+	}
+	flushed := make(chan struct{})
+	exporter.flushed[walkID] = flushed
+	exporter.mutex.Unlock()
+
+	timer := time.NewTimer(exporter.opts.FlushTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-flushed:
+	case <-timer.C:
+		exporter.mutex.Lock()
+		delete(exporter.flushed, walkID)
+		exporter.mutex.Unlock()
+	}
+}
+
+func (exporter *Exporter) fail(run RunEvent, err error) {
+	if exporter.opts.OnError != nil {
+		exporter.opts.OnError(run, err)
+	}
+}
+
+func (exporter *Exporter) jobName(key string) string {
+	if len(key) == 0 {
+		return exporter.opts.Job
+	}
+	return strings.Join([]string{exporter.opts.Job, key}, ".")
+}
+
+// runID returns the run id for the given walk and node, allocating a new one if required.
+func (exporter *Exporter) runID(walk string, key string) string {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	id := walk + "/" + key
+	if run, ok := exporter.runs[id]; ok {
+		return run
+	}
+
+	run := newUUID()
+	exporter.runs[id] = run
+	return run
+}
+
+// started returns true if a run has been started for the given walk and node.
+func (exporter *Exporter) started(walk string, key string) bool {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	_, ok := exporter.runs[walk+"/"+key]
+	return ok
+}
+
+func (exporter *Exporter) forget(walk string, key string) {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	delete(exporter.runs, walk+"/"+key)
+}
+
+// newUUID generates a random (version 4) UUID as required for OpenLineage run ids.
+func newUUID() string {
+	var uuid [16]byte
+	_, _ = rand.Read(uuid[:])
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
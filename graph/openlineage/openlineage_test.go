@@ -0,0 +1,78 @@
+package openlineage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+func TestExporter(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddNode("a", graph.Executable(func(ctx context.Context) error {
+		return nil
+	}))
+
+	var buffer bytes.Buffer
+	exporter := NewExporter(Opts{
+		Namespace: "tests",
+		Job:       "pipeline",
+		Transport: &WriterTransport{Writer: &buffer},
+	})
+	tests.ExecuteE(g.Walk(context.Background(), &graph.Opts{Parallelism: 1, Bus: graph.NewBus(exporter)})).NoError(t)
+
+	var events []RunEvent
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var event RunEvent
+		tests.ExecuteE(json.Unmarshal([]byte(line), &event)).NoError(t)
+		events = append(events, event)
+	}
+
+	tests.Execute(len(events)).Equal(t, 4)
+	tests.Execute(events[0].EventType).Equal(t, Start)
+	tests.Execute(events[0].Job.Name).Equal(t, "pipeline")
+	tests.Execute(events[1].Job.Name).Equal(t, "pipeline.a")
+	tests.Execute(events[2].EventType).Equal(t, Complete)
+	tests.Execute(events[1].Run.RunID).Equal(t, events[2].Run.RunID)
+	tests.Execute(events[3].Run.RunID).Equal(t, events[0].Run.RunID)
+
+	parent := events[1].Run.Facets["parent"].(map[string]interface{})
+	tests.Execute(parent["run"].(map[string]interface{})["runId"]).Equal(t, interface{}(events[0].Run.RunID))
+}
+
+func TestExporter_CancelledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := graph.NewGraph()
+	g.AddNode("a", graph.Executable(func(ctx context.Context) error {
+		cancel()
+		return nil
+	}))
+	g.AddNode("b", graph.Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect("a", "b")
+
+	var buffer bytes.Buffer
+	exporter := NewExporter(Opts{
+		Namespace: "tests",
+		Job:       "pipeline",
+		Transport: &WriterTransport{Writer: &buffer},
+	})
+	tests.ExecuteE(g.Walk(ctx, &graph.Opts{Parallelism: 1, Bus: graph.NewBus(exporter)})).Error(t)
+
+	// b never started, so it isn't reported as aborted.
+	var jobs []string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var event RunEvent
+		tests.ExecuteE(json.Unmarshal([]byte(line), &event)).NoError(t)
+		jobs = append(jobs, string(event.EventType)+" "+event.Job.Name)
+	}
+	tests.Execute(jobs).Equal(t, []string{"START pipeline", "START pipeline.a", "COMPLETE pipeline.a", "FAIL pipeline"})
+}
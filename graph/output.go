@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// output is a buffer that is safe to write to from multiple goroutines.
+type output struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+// Write implements io.Writer.
+func (output *output) Write(data []byte) (int, error) {
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+	return output.buffer.Write(data)
+}
+
+// Bytes returns a copy of everything written so far. It is safe to call on a nil output, which returns nil.
+func (output *output) Bytes() []byte {
+	if output == nil {
+		return nil
+	}
+
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+	return bytes.Clone(output.buffer.Bytes())
+}
+
+// Stdout returns a writer that captures the standard output of the node executing with the given context. Everything
+// written is collected into the Stdout field of the node's NodeResult.
+//
+// If the context doesn't belong to a node, the returned writer discards everything.
+func Stdout(ctx context.Context) io.Writer {
+	if exec := executionFrom(ctx); exec != nil {
+		exec.mutex.Lock()
+		defer exec.mutex.Unlock()
+
+		if exec.stdout == nil {
+			exec.stdout = new(output)
+		}
+		return exec.stdout
+	}
+	return io.Discard
+}
+
+// Stderr returns a writer that captures the standard error of the node executing with the given context. Everything
+// written is collected into the Stderr field of the node's NodeResult.
+//
+// If the context doesn't belong to a node, the returned writer discards everything.
+func Stderr(ctx context.Context) io.Writer {
+	if exec := executionFrom(ctx); exec != nil {
+		exec.mutex.Lock()
+		defer exec.mutex.Unlock()
+
+		if exec.stderr == nil {
+			exec.stderr = new(output)
+		}
+		return exec.stderr
+	}
+	return io.Discard
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestWalkResult_FailuresByOwner(t *testing.T) {
+	fail := Executable(func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+	succeed := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	tcs := map[string]struct {
+		route    func(key string, meta Meta) string
+		expected map[string][]string
+		owner    string // owner of the successful node "e".
+	}{
+		"meta": {
+			expected: map[string][]string{
+				"infra": {"a", "c"},
+				"web":   {"b"},
+				"":      {"d"},
+			},
+			owner: "web",
+		},
+		"route": {
+			route: func(key string, meta Meta) string {
+				return meta.Labels["team"]
+			},
+			expected: map[string][]string{
+				"storage": {"a", "b"},
+				"":        {"c", "d"},
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g := NewGraph()
+			g.AddNodeWithMeta("a", fail, Meta{Owner: "infra", Labels: map[string]string{"team": "storage"}})
+			g.AddNodeWithMeta("b", fail, Meta{Owner: "web", Labels: map[string]string{"team": "storage"}})
+			g.AddNodeWithMeta("c", fail, Meta{Owner: "infra"})
+			g.AddNode("d", fail)
+			g.AddNodeWithMeta("e", succeed, Meta{Owner: "web"})
+
+			var owners []string
+			bus := NewBus(SinkFunc(func(event Event) {
+				if event.Type == EventNodeErrored && event.Key == "a" {
+					owners = append(owners, event.Owner)
+				}
+			}))
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 1, Route: tc.route, Bus: bus})
+			tests.ExecuteE(err).Error(t)
+			tests.Execute(result.FailuresByOwner()).Equal(t, tc.expected)
+			tests.Execute(owners).Equal(t, []string{result.Nodes["a"].Owner})
+			tests.Execute(result.Nodes["e"].Owner).Equal(t, tc.owner)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_PartialExpansion(t *testing.T) {
+	tcs := map[string]struct {
+		err      error
+		nodes    []string
+		expected string
+		ran      []string
+	}{
+		"complete": {
+			nodes: []string{"x", "y"},
+			ran:   []string{"x", "y", "after"},
+		},
+		"partial": {
+			err:      Partial(errors.New("page 3 is missing")),
+			nodes:    []string{"x", "y"},
+			expected: "expand: failed to expand node (expansion is partial (page 3 is missing)); graph is incomplete",
+			ran:      []string{"x", "y"},
+		},
+		"empty": {
+			err:      Partial(errors.New("page 1 is missing")),
+			expected: "expand: failed to expand node (expansion is partial (page 1 is missing)); graph is incomplete",
+		},
+		"failed": {
+			err:      errors.New("no pages"),
+			nodes:    []string{"x", "y"},
+			expected: "expand: failed to expand node (no pages); graph is incomplete",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var ran []string
+			record := func(key string) ExecutableNode {
+				return Executable(func(ctx context.Context) error {
+					mutex.Lock()
+					defer mutex.Unlock()
+					ran = append(ran, key)
+					return nil
+				})
+			}
+
+			g := NewGraph()
+			g.AddNode("expand", Expandable(func(ctx context.Context) (Graph, error) {
+				subgraph := NewGraph()
+				for _, key := range tc.nodes {
+					subgraph.AddNode(key, record(key))
+				}
+				return subgraph, tc.err
+			}))
+			g.AddNode("after", record("after"))
+			g.Connect("expand", "after")
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 1})
+			if len(tc.expected) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.expected)
+				tests.Execute(result.Nodes["expand"].Status).Equal(t, StatusErrored)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+			tests.Execute(ran).Equal(t, tc.ran)
+		})
+	}
+}
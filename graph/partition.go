@@ -0,0 +1,441 @@
+package graph
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Edge is a directed edge between two nodes.
+type Edge struct {
+	From string
+	To   string
+}
+
+// Partition is one part of a graph split by Graph.Partition.
+type Partition struct {
+	// Index is the position of the partition in the slice returned by Partition.
+	Index int
+
+	// Graph contains the nodes in the partition and the edges between them.
+	Graph Graph
+
+	// Imports contains the edges into the partition from nodes in other partitions, and Exports the edges out of the
+	// partition to nodes in other partitions. Both are sorted.
+	Imports []Edge
+	Exports []Edge
+}
+
+// Keys returns the keys of the nodes in the partition, sorted.
+func (partition Partition) Keys() []string {
+	return partition.Graph.sortedKeys()
+}
+
+// Partition splits the graph into n partitions of roughly equal size, keeping as many edges as possible within a
+// partition. The edges that cross partitions are recorded as the Imports and Exports of each partition, these are the
+// only points at which partitions walked separately need to synchronize, see Coordinator.
+//
+// Nodes are assigned greedily, in a depth-first topological order, to the partition holding most of their parents, and
+// then moved between partitions while that reduces the number of cut edges. The result is deterministic, but not
+// guaranteed to be optimal. Inputs and outputs declared with ports are not carried over into the partitions.
+//
+// Partition returns an error with the CycleDetected code if the graph contains a cycle, and panics if n is less than 1.
+func (g Graph) Partition(n int) ([]Partition, error) {
+	if n < 1 {
+		panic("partitions must be greater than 0")
+	}
+
+	order, err := g.topologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := (len(order) + n - 1) / n
+	loads := make([]int, n)
+	assigned := make(map[string]int, len(order))
+
+	// neighbours counts the neighbours of the node that are in each partition.
+	neighbours := func(key string) []int {
+		counts := make([]int, n)
+		for _, parent := range g.nodes[key].parents {
+			if partition, ok := assigned[parent]; ok {
+				counts[partition]++
+			}
+		}
+		for _, child := range g.nodes[key].children {
+			if partition, ok := assigned[child]; ok {
+				counts[partition]++
+			}
+		}
+		return counts
+	}
+
+	for _, key := range order {
+		counts := neighbours(key)
+
+		best := -1
+		for partition := 0; partition < n; partition++ {
+			if loads[partition] >= capacity {
+				continue
+			}
+			if best < 0 || counts[partition] > counts[best] || (counts[partition] == counts[best] && loads[partition] < loads[best]) {
+				best = partition
+			}
+		}
+		assigned[key] = best
+		loads[best]++
+	}
+
+	// Move single nodes to a partition with more of their neighbours, until that no longer helps.
+	for moved := true; moved; {
+		moved = false
+		for _, key := range order {
+			current := assigned[key]
+			counts := neighbours(key)
+			for partition := 0; partition < n; partition++ {
+				if partition == current || loads[partition] >= capacity || counts[partition] <= counts[current] {
+					continue
+				}
+				loads[current]--
+				loads[partition]++
+				assigned[key] = partition
+				current = partition
+				moved = true
+			}
+		}
+	}
+
+	partitions := make([]Partition, n)
+	for ix := range partitions {
+		partitions[ix] = Partition{Index: ix, Graph: NewGraph()}
+	}
+	for _, key := range order {
+		node := g.nodes[key]
+		_ = partitions[assigned[key]].Graph.AddNodeWithMeta(key, node.impl, node.meta)
+	}
+	for _, key := range order {
+		for _, child := range g.sortedChildren(key) {
+			from, to := assigned[key], assigned[child]
+			if from == to {
+				_ = partitions[from].Graph.Connect(key, child)
+				continue
+			}
+			partitions[from].Exports = append(partitions[from].Exports, Edge{From: key, To: child})
+			partitions[to].Imports = append(partitions[to].Imports, Edge{From: key, To: child})
+		}
+	}
+	for _, partition := range partitions {
+		sortEdges(partition.Imports)
+		sortEdges(partition.Exports)
+	}
+	return partitions, nil
+}
+
+// topologicalOrder returns the keys of the graph so that every node comes after all of its parents. Each node is
+// followed by as much of what depends on it as possible, so chains of nodes stay together. It returns an error with the
+// CycleDetected code if there is no such order.
+func (g Graph) topologicalOrder() ([]string, error) {
+	keys := g.sortedKeys()
+
+	visited := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if err := g.dfs(key, visited, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// Reverse post-order of a depth-first search, visiting everything in reverse so the order reads naturally.
+	order := make([]string, 0, len(keys))
+	done := make(map[string]bool, len(keys))
+	var visit func(key string)
+	visit = func(key string) {
+		done[key] = true
+		children := g.sortedChildren(key)
+		for ix := len(children) - 1; ix >= 0; ix-- {
+			if !done[children[ix]] {
+				visit(children[ix])
+			}
+		}
+		order = append(order, key)
+	}
+	for ix := len(keys) - 1; ix >= 0; ix-- {
+		if len(g.nodes[keys[ix]].parents) == 0 && !done[keys[ix]] {
+			visit(keys[ix])
+		}
+	}
+
+	slices.Reverse(order)
+	return order, nil
+}
+
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+}
+
+// Runner walks the graph of a single partition on behalf of a Coordinator. A runner could hand the graph to another
+// process, as long as it only returns once the walk has finished and publishes the events of the walk to opts.Bus.
+type Runner interface {
+	Run(ctx context.Context, g Graph, opts *Opts) (*WalkResult, error)
+}
+
+// RunnerFunc adapts a simple function into a Runner.
+type RunnerFunc func(ctx context.Context, g Graph, opts *Opts) (*WalkResult, error)
+
+// Run implements Runner.
+func (fn RunnerFunc) Run(ctx context.Context, g Graph, opts *Opts) (*WalkResult, error) {
+	return fn(ctx, g, opts)
+}
+
+// LocalRunner is a Runner that walks partitions within the current process.
+var LocalRunner Runner = RunnerFunc(func(ctx context.Context, g Graph, opts *Opts) (*WalkResult, error) {
+	return g.Run(ctx, opts)
+})
+
+// Coordinator walks a graph as separate partitions, each on its own Runner, and only synchronizes the partitions at the
+// edges that cross between them.
+//
+// Within each partition, the parents of imported edges are stood in for by nodes that wait for the real node in the
+// other partition to finish. Waiting nodes hold a worker, so each partition is walked with an extra worker for each node
+// it imports from other partitions on top of Opts.Parallelism.
+type Coordinator struct {
+	// Runners walk the partitions, the graph is split into one partition for each runner.
+	Runners []Runner
+
+	// Opts are used to walk every partition. Events for the nodes standing in for other partitions are not published to
+	// Opts.Bus or Opts.Callbacks.
+	Opts Opts
+}
+
+// signal records when a node that other partitions depend on has finished.
+type signal struct {
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+func (signal *signal) finish(err error) {
+	signal.once.Do(func() {
+		signal.err = err
+		close(signal.done)
+	})
+}
+
+// Run partitions the graph and walks every partition at the same time. The returned result contains every node of the
+// graph as if it was walked in one piece.
+func (coordinator *Coordinator) Run(ctx context.Context, g Graph) (*WalkResult, error) {
+	if len(coordinator.Runners) == 0 {
+		panic("coordinator must have at least one runner")
+	}
+
+	g, err := g.resolveDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions, err := g.Partition(len(coordinator.Runners))
+	if err != nil {
+		return nil, err
+	}
+
+	signals := make(map[string]*signal)
+	for _, partition := range partitions {
+		for _, edge := range partition.Exports {
+			signals[edge.From] = &signal{done: make(chan struct{})}
+		}
+	}
+
+	results := make([]*WalkResult, len(partitions))
+	errs := make([]error, len(partitions))
+
+	var wait sync.WaitGroup
+	for ix, partition := range partitions {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			results[ix], errs[ix] = coordinator.run(ctx, partition, signals)
+		}()
+	}
+	wait.Wait()
+
+	return coordinator.merge(results, errs)
+}
+
+// run walks a single partition, standing in for the nodes it imports from other partitions.
+func (coordinator *Coordinator) run(ctx context.Context, partition Partition, signals map[string]*signal) (*WalkResult, error) {
+	g := partition.Graph.Clone()
+
+	imported := make(map[string]bool)
+	for _, edge := range partition.Imports {
+		if !imported[edge.From] {
+			imported[edge.From] = true
+
+			from := signals[edge.From]
+			_ = g.AddNode(edge.From, Executable(func(ctx context.Context) error {
+				select {
+				case <-from.done:
+					return from.err
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}))
+		}
+		_ = g.Connect(edge.From, edge.To)
+	}
+
+	exported := make(map[string]*signal)
+	for _, edge := range partition.Exports {
+		exported[edge.From] = signals[edge.From]
+	}
+
+	opts := coordinator.Opts
+	if opts.Parallelism == 0 {
+		opts.Parallelism = 1
+	}
+	opts.Parallelism += len(imported)
+
+	// Keep the nodes standing in for other partitions out of the events the caller sees.
+	public := NewBus(opts.Callbacks.Sink())
+	if opts.Bus != nil {
+		public.Subscribe(opts.Bus)
+	}
+	opts.Callbacks = Callbacks{}
+	opts.Bus = NewBus(SinkFunc(func(event Event) {
+		if imported[event.Key] {
+			return
+		}
+
+		if signal, ok := exported[event.Key]; ok {
+			switch event.Type {
+			case EventNodeCompleted:
+				signal.finish(nil)
+			case EventNodeErrored:
+				signal.finish(errors.Newf(event.Err, UpstreamFailed, "node %q failed in partition %d", event.Key, partition.Index))
+			case EventNodeCancelled:
+				signal.finish(errors.Newf(nil, Cancelled, "node %q was cancelled in partition %d", event.Key, partition.Index))
+			}
+		}
+		public.Publish(event)
+	}))
+
+	result, err := coordinator.Runners[partition.Index].Run(ctx, g, &opts)
+
+	// Anything exported that never finished, because it was skipped for example, must not leave other partitions
+	// waiting forever.
+	for key, signal := range exported {
+		signal.finish(errors.Newf(nil, UpstreamFailed, "node %q did not complete in partition %d", key, partition.Index))
+	}
+
+	if result != nil {
+		for key := range imported {
+			delete(result.Nodes, key)
+		}
+	}
+	return result, err
+}
+
+// mergeGraphs combines the graphs walked by each partition back into a single graph. The nodes standing in for other
+// partitions only contribute the edges that crossed between partitions.
+func mergeGraphs(results []*WalkResult) Graph {
+	merged := NewGraph()
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		for key, original := range result.Graph.nodes {
+			if _, owned := result.Nodes[key]; owned {
+				merged.nodes[key] = &node{key: key, impl: original.impl, meta: original.meta}
+			}
+		}
+	}
+
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		for _, key := range result.Graph.sortedKeys() {
+			for _, child := range result.Graph.nodes[key].children {
+				from, to := merged.nodes[key], merged.nodes[child]
+				if from == nil || to == nil || slices.Contains(from.children, child) {
+					continue
+				}
+				from.children = append(from.children, child)
+				to.parents = append(to.parents, key)
+			}
+		}
+	}
+
+	for key, node := range merged.nodes {
+		if len(node.parents) == 0 {
+			merged.starters[key] = true
+		}
+		if len(node.children) == 0 {
+			merged.finishers[key] = true
+		}
+	}
+	return merged
+}
+
+// merge combines the results of walking each partition into the result of walking the whole graph.
+func (coordinator *Coordinator) merge(results []*WalkResult, errs []error) (*WalkResult, error) {
+	merged := &WalkResult{
+		WalkID:     newWalkID(),
+		Nodes:      make(map[string]*NodeResult),
+		Artifacts:  coordinator.Opts.Artifacts,
+		Blackboard: coordinator.Opts.Blackboard,
+	}
+
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+
+		if merged.Started.IsZero() || result.Started.Before(merged.Started) {
+			merged.Started = result.Started
+		}
+		if result.Finished.After(merged.Finished) {
+			merged.Finished = result.Finished
+		}
+		for key, node := range result.Nodes {
+			merged.Nodes[key] = node
+		}
+		merged.Cost += result.Cost
+
+		merged.Scheduler.MaxQueueDepth = max(merged.Scheduler.MaxQueueDepth, result.Scheduler.MaxQueueDepth)
+		merged.Scheduler.MaxWorkersBusy = max(merged.Scheduler.MaxWorkersBusy, result.Scheduler.MaxWorkersBusy)
+		merged.Scheduler.MaxDispatchLatency = max(merged.Scheduler.MaxDispatchLatency, result.Scheduler.MaxDispatchLatency)
+		merged.Scheduler.TotalDispatchLatency += result.Scheduler.TotalDispatchLatency
+		merged.Scheduler.Backpressure += result.Scheduler.Backpressure
+	}
+	if merged.Finished.IsZero() {
+		merged.Finished = time.Now()
+	}
+	merged.Graph = mergeGraphs(results)
+
+	// Nodes standing in for other partitions fail whenever the node they stand in for does, so only the nodes that
+	// failed in their own partition explain what went wrong.
+	failed := make(map[string]error)
+	for key, node := range merged.Nodes {
+		if node.Status == StatusErrored && errors.GetErrorCode(node.Err) != UpstreamFailed {
+			failed[key] = node.Err
+		}
+	}
+	if len(failed) > 0 {
+		return merged, newWalkError(failed, nil)
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return merged, err
+		}
+	}
+	return merged, nil
+}
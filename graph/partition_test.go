@@ -0,0 +1,167 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func noop() ExecutableNode {
+	return Executable(func(ctx context.Context) error {
+		return nil
+	})
+}
+
+// chains returns a graph of count independent chains, each length nodes long.
+func chains(count int, length int) Graph {
+	g := NewGraph()
+	for chain := 0; chain < count; chain++ {
+		for ix := 0; ix < length; ix++ {
+			key := fmt.Sprintf("%d-%d", chain, ix)
+			g.AddNode(key, noop())
+			if ix > 0 {
+				g.Connect(fmt.Sprintf("%d-%d", chain, ix-1), key)
+			}
+		}
+	}
+	return g
+}
+
+func TestGraph_Partition(t *testing.T) {
+	tcs := map[string]struct {
+		graph      Graph
+		partitions int
+		sizes      []int
+		cut        int
+	}{
+		"chains": {
+			graph:      chains(2, 5),
+			partitions: 2,
+			sizes:      []int{5, 5},
+			cut:        0,
+		},
+		"uneven chains": {
+			graph:      chains(3, 4),
+			partitions: 2,
+			sizes:      []int{6, 6},
+			cut:        1,
+		},
+		"wide": {
+			graph:      wide(10),
+			partitions: 3,
+			sizes:      []int{4, 4, 4},
+			cut:        14,
+		},
+		"single": {
+			graph:      chains(1, 3),
+			partitions: 1,
+			sizes:      []int{3},
+			cut:        0,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			partitions, err := tc.graph.Partition(tc.partitions)
+			tests.ExecuteE(err).NoError(t)
+
+			var sizes []int
+			seen := make(map[string]bool)
+			cut := 0
+			for ix, partition := range partitions {
+				tests.Execute(partition.Index).Equal(t, ix)
+				sizes = append(sizes, len(partition.Keys()))
+				for _, key := range partition.Keys() {
+					tests.Execute(seen[key]).Equal(t, false)
+					seen[key] = true
+				}
+				cut += len(partition.Exports)
+			}
+			tests.Execute(sizes).Equal(t, tc.sizes)
+			tests.Execute(len(seen)).Equal(t, len(tc.graph.nodes))
+			tests.Execute(cut).Equal(t, tc.cut)
+
+			var imports int
+			for _, partition := range partitions {
+				imports += len(partition.Imports)
+			}
+			tests.Execute(imports).Equal(t, cut)
+		})
+	}
+}
+
+func TestGraph_Partition_Cycle(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", noop())
+	g.AddNode("b", noop())
+	g.Connect("a", "b")
+	g.Connect("b", "a")
+
+	_, err := g.Partition(2)
+	tests.Execute(errors.GetErrorCode(err)).Equal(t, CycleDetected)
+}
+
+func TestCoordinator(t *testing.T) {
+	g := chains(3, 4)
+
+	// Make the chains depend on each other, so the partitions have to wait for each other.
+	g.Connect("0-1", "1-2")
+	g.Connect("1-1", "2-2")
+	g.Connect("2-3", "0-3")
+
+	coordinator := &Coordinator{
+		Runners: []Runner{LocalRunner, LocalRunner},
+		Opts:    Opts{Parallelism: 1, Verify: true},
+	}
+	result, err := coordinator.Run(context.Background(), g)
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(len(result.Nodes)).Equal(t, 12)
+	tests.Execute(len(result.Status(StatusCompleted))).Equal(t, 12)
+
+	for key, node := range g.nodes {
+		for _, child := range node.children {
+			if result.Nodes[key].Finished.After(result.Nodes[child].Started) {
+				t.Errorf("expected %q to finish before %q started", key, child)
+			}
+		}
+	}
+
+	// The partitions are stitched back together into the graph that was walked.
+	tests.Execute(result.Graph.sortedKeys()).Equal(t, g.sortedKeys())
+	for key := range g.nodes {
+		tests.Execute(result.Graph.sortedChildren(key)).Equal(t, g.sortedChildren(key))
+	}
+}
+
+func TestCoordinator_Failure(t *testing.T) {
+	g := chains(2, 3)
+	g.ReplaceNode("0-0", Executable(func(ctx context.Context) error {
+		return fmt.Errorf("boom")
+	}))
+	g.Connect("0-0", "0-1")
+	g.Connect("0-1", "1-2")
+
+	var errored []string
+	coordinator := &Coordinator{
+		Runners: []Runner{LocalRunner, LocalRunner},
+		Opts: Opts{
+			Parallelism: 1,
+			Callbacks: Callbacks{
+				OnError: func(key string, err error) {
+					errored = append(errored, key)
+				},
+			},
+		},
+	}
+	result, err := coordinator.Run(context.Background(), g)
+	tests.ExecuteE(err).MatchesError(t, "0-0: failed to execute node (boom)")
+	tests.Execute(errors.GetErrorCode(err)).Equal(t, FailedNode)
+	tests.Execute(errored).Equal(t, []string{"0-0"})
+
+	tests.Execute(result.Status(StatusErrored)).Equal(t, []string{"0-0"})
+	tests.Execute(result.Status(StatusSkipped)).Equal(t, []string{"0-1", "0-2", "1-2"})
+	tests.Execute(result.Status(StatusCompleted)).Equal(t, []string{"1-0", "1-1"})
+}
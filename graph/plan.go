@@ -0,0 +1,218 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Plan contains everything that can be worked out about a graph before walking it. Working it out visits every node
+// and edge, so plans for large graphs that are walked repeatedly are worth keeping in a PlanCache.
+type Plan struct {
+	// Hash is the structural hash of the graph the plan was made for, see Graph.Hash.
+	Hash string
+
+	// Generations groups the nodes by how many nodes must complete before them along their longest path from a
+	// starter, so every node in a generation can run at the same time once the previous generations have completed.
+	// The keys in each generation are sorted. It is empty if the graph contains a cycle.
+	Generations [][]string
+
+	// CriticalPath is the path through the graph with the largest sum of Meta.Estimate, from a starter to a finisher.
+	// It is empty if the graph contains a cycle.
+	CriticalPath []string
+
+	// Err is the error returned by Graph.Validate, if any.
+	Err error
+
+	// paths maps every node to the sum of the estimates along the longest path from it to the end of the graph.
+	paths map[string]time.Duration
+}
+
+// Plan works out the plan for the graph.
+func (g Graph) Plan() *Plan {
+	plan := &Plan{
+		Hash: g.Hash(),
+		Err:  g.Validate(),
+	}
+
+	order, err := g.topologicalOrder()
+	if err != nil {
+		return plan
+	}
+
+	generation := make(map[string]int, len(order))
+	for _, key := range order {
+		for _, parent := range g.nodes[key].parents {
+			generation[key] = max(generation[key], generation[parent]+1)
+		}
+		for len(plan.Generations) <= generation[key] {
+			plan.Generations = append(plan.Generations, nil)
+		}
+		plan.Generations[generation[key]] = append(plan.Generations[generation[key]], key)
+	}
+	for _, keys := range plan.Generations {
+		sort.Strings(keys)
+	}
+
+	// Working backwards through the order means every child has its path by the time its parents need it.
+	plan.paths = make(map[string]time.Duration, len(order))
+	for ix := len(order) - 1; ix >= 0; ix-- {
+		var longest time.Duration
+		for _, child := range g.nodes[order[ix]].children {
+			longest = max(longest, plan.paths[child])
+		}
+		plan.paths[order[ix]] = g.nodes[order[ix]].meta.Estimate + longest
+	}
+
+	starters := g.Starters()
+	sort.Strings(starters)
+	for next := starters; len(next) > 0; {
+		longest := next[0]
+		for _, key := range next[1:] {
+			if plan.paths[key] > plan.paths[longest] {
+				longest = key
+			}
+		}
+		plan.CriticalPath = append(plan.CriticalPath, longest)
+		next = g.sortedChildren(longest)
+	}
+	return plan
+}
+
+// Hash returns a hash of the structure of the graph, covering the key of every node, the edges between them and the
+// estimates of every node. Two graphs with the same hash produce the same Plan.
+//
+// The hash is remembered until the graph is next changed, so it is only worked out again after a change.
+func (g Graph) Hash() string {
+	if g.memo == nil {
+		return g.hash()
+	}
+
+	g.memo.mutex.Lock()
+	defer g.memo.mutex.Unlock()
+	if len(g.memo.hash) == 0 {
+		g.memo.hash = g.hash()
+	}
+	return g.memo.hash
+}
+
+func (g Graph) hash() string {
+	hash := sha256.New()
+	write := func(value string) {
+		_ = binary.Write(hash, binary.LittleEndian, uint64(len(value)))
+		hash.Write([]byte(value))
+	}
+	for _, key := range g.sortedKeys() {
+		write(key)
+		_ = binary.Write(hash, binary.LittleEndian, int64(g.nodes[key].meta.Estimate))
+		children := g.sortedChildren(key)
+		_ = binary.Write(hash, binary.LittleEndian, uint64(len(children)))
+		for _, child := range children {
+			write(child)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// memo remembers things worked out about a graph until it is next changed. It is shared by every copy of the Graph
+// value.
+type memo struct {
+	mutex sync.Mutex
+	hash  string
+}
+
+// reset forgets everything remembered about the graph, it must be called whenever the graph changes. Graphs that
+// weren't created by NewGraph remember nothing.
+func (memo *memo) reset() {
+	if memo == nil {
+		return
+	}
+
+	memo.mutex.Lock()
+	defer memo.mutex.Unlock()
+	memo.hash = ""
+}
+
+// PlanCache keeps the plans of the graphs walked with it, keyed by their structural hash, so walking the same graph
+// again skips planning it. Changing a graph changes its hash, so a stale plan is never used.
+//
+// A PlanCache is safe for concurrent use, and can be shared between walks through Opts.Plans.
+type PlanCache struct {
+	mutex sync.Mutex
+
+	// limit is the maximum number of plans kept.
+	limit int
+
+	// plans contains the cached plans by hash, and order records the hashes from least to most recently used.
+	plans map[string]*Plan
+	order []string
+}
+
+// NewPlanCache returns a new, empty PlanCache that keeps up to limit plans, forgetting the least recently used ones
+// first. If limit is zero or less, every plan is kept.
+func NewPlanCache(limit int) *PlanCache {
+	return &PlanCache{
+		limit: limit,
+		plans: make(map[string]*Plan),
+	}
+}
+
+// Plan returns the plan for the graph, working it out only if no plan for a graph with the same hash is cached.
+func (cache *PlanCache) Plan(g Graph) *Plan {
+	hash := g.Hash()
+
+	cache.mutex.Lock()
+	plan, ok := cache.plans[hash]
+	if ok {
+		cache.touch(hash)
+	}
+	cache.mutex.Unlock()
+	if ok {
+		return plan
+	}
+
+	plan = g.Plan()
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if _, ok := cache.plans[hash]; !ok {
+		cache.order = append(cache.order, hash)
+	} else {
+		cache.touch(hash)
+	}
+	cache.plans[hash] = plan
+	for cache.limit > 0 && len(cache.order) > cache.limit {
+		delete(cache.plans, cache.order[0])
+		cache.order = cache.order[1:]
+	}
+	return plan
+}
+
+// Len returns the number of plans in the cache.
+func (cache *PlanCache) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return len(cache.plans)
+}
+
+// touch marks the plan with the given hash as the most recently used. Callers must hold the lock.
+func (cache *PlanCache) touch(hash string) {
+	for ix, candidate := range cache.order {
+		if candidate == hash {
+			cache.order = append(append(cache.order[:ix:ix], cache.order[ix+1:]...), hash)
+			return
+		}
+	}
+}
+
+// plan seeds the walk with the plan for the graph from Opts.Plans, if set.
+func (walker *walker) plan(graph Graph, opts *Opts) {
+	if opts.Plans == nil {
+		return
+	}
+	walker.paths = maps.Clone(opts.Plans.Plan(graph).paths)
+}
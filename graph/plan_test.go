@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Plan(t *testing.T) {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	// a -> b -> d, c -> d, b -> f, and e on its own.
+	build := func() Graph {
+		g := NewGraph()
+		g.AddNodeWithMeta("a", noop, Meta{Estimate: time.Second})
+		g.AddNodeWithMeta("b", noop, Meta{Estimate: time.Second})
+		g.AddNodeWithMeta("c", noop, Meta{Estimate: time.Minute})
+		g.AddNodeWithMeta("d", noop, Meta{Estimate: time.Second})
+		g.AddNodeWithMeta("e", noop, Meta{Estimate: time.Second})
+		g.AddNodeWithMeta("f", noop, Meta{Estimate: time.Minute})
+		g.Connect("a", "b")
+		g.Connect("b", "d")
+		g.Connect("c", "d")
+		g.Connect("b", "f")
+		return g
+	}
+
+	g := build()
+	plan := g.Plan()
+	tests.Execute(plan.Hash).Equal(t, build().Hash())
+	tests.Execute(plan.Generations).Equal(t, [][]string{{"a", "c", "e"}, {"b"}, {"d", "f"}})
+	tests.Execute(plan.CriticalPath).Equal(t, []string{"a", "b", "f"})
+	tests.ExecuteE(plan.Err).NoError(t)
+
+	// The hash changes whenever the graph does.
+	hash := g.Hash()
+	g.Connect("e", "f")
+	tests.Execute(g.Hash() == hash).Equal(t, false)
+	g.RemoveNode("e")
+	g.AddNodeWithMeta("e", noop, Meta{Estimate: time.Second})
+	tests.Execute(g.Hash()).Equal(t, hash)
+
+	g.Connect("d", "a")
+	plan = g.Plan()
+	tests.ExecuteE(plan.Err).MatchesError(t, "found cycle in graph: a -> b -> d -> a")
+	tests.Execute(len(plan.Generations)).Equal(t, 0)
+}
+
+func TestPlanCache(t *testing.T) {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	build := func(keys ...string) Graph {
+		g := NewGraph()
+		for _, key := range keys {
+			g.AddNode(key, noop)
+		}
+		return g
+	}
+
+	cache := NewPlanCache(2)
+	a := cache.Plan(build("a"))
+	tests.Execute(cache.Plan(build("a")) == a).Equal(t, true)
+	cache.Plan(build("b"))
+	cache.Plan(build("a"))
+	cache.Plan(build("c"))
+
+	// b was used least recently, so it is the one forgotten.
+	tests.Execute(cache.Len()).Equal(t, 2)
+	tests.Execute(cache.Plan(build("a")) == a).Equal(t, true)
+	tests.Execute(cache.Len()).Equal(t, 2)
+}
+
+func TestGraph_Walk_Plans(t *testing.T) {
+	g := NewGraph()
+	g.AddNodeWithMeta("a", Executable(func(ctx context.Context) error {
+		return nil
+	}), Meta{Optional: true, Estimate: time.Millisecond})
+	g.AddNodeWithMeta("b", Executable(func(ctx context.Context) error {
+		return nil
+	}), Meta{Estimate: time.Hour})
+	g.Connect("a", "b")
+
+	cache := NewPlanCache(0)
+	for ix := 0; ix < 2; ix++ {
+		result, err := g.Run(context.Background(), &Opts{
+			Parallelism: 1,
+			Deadline:    time.Now().Add(time.Minute),
+			Plans:       cache,
+		})
+		tests.ExecuteE(err).NoError(t)
+		tests.Execute(result.Status(StatusPruned)).Equal(t, []string{"a"})
+	}
+	tests.Execute(cache.Len()).Equal(t, 1)
+}
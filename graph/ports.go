@@ -0,0 +1,219 @@
+package graph
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// binding records that a node consumes a named output of another node.
+type binding struct {
+	producer string
+	name     string
+	typ      reflect.Type
+}
+
+// Output is a handle to a typed value produced by a node.
+type Output[T any] struct {
+	key  string
+	name string
+}
+
+// NewOutput declares that the node with the given key produces a value of type T under the given name. Observers are
+// told the node was replaced, as its ports have changed.
+//
+// NewOutput returns an error with the MissingNode code if the node does not exist.
+func NewOutput[T any](g Graph, key string, name string) (Output[T], error) {
+	existing, ok := g.nodes[key]
+	if !ok {
+		err := errors.Newf(nil, MissingNode, "node %q does not exist", key)
+		return Output[T]{}, errors.Embed(err, NodeKey, key)
+	}
+
+	if _, ok := g.outputs[key]; !ok {
+		g.outputs[key] = make(map[string]reflect.Type)
+	}
+	g.outputs[key][name] = reflect.TypeFor[T]()
+	g.memo.reset()
+	g.observers.notify(func(observer Observer) {
+		observer.NodeReplaced(key, existing.meta)
+	})
+
+	return Output[T]{
+		key:  key,
+		name: name,
+	}, nil
+}
+
+// Key returns the key of the node producing the output.
+func (output Output[T]) Key() string {
+	return output.key
+}
+
+// Name returns the name of the output.
+func (output Output[T]) Name() string {
+	return output.name
+}
+
+// Set records the value of the output. It must be called from the context of the producing node.
+func (output Output[T]) Set(ctx context.Context, value T) error {
+	exec := executionFrom(ctx)
+	if exec == nil || exec.key != output.key {
+		return errors.Newf(nil, WrongProducer, "output %q can only be set by node %q", output.name, output.key)
+	}
+
+	exec.values.set(output.key, output.name, value)
+	return nil
+}
+
+// Input is a handle to a typed value consumed by a node.
+type Input[T any] struct {
+	producer string
+	name     string
+}
+
+// NewInput declares that the node with the given key consumes the named output of the producer, and connects the
+// producer to the node so the value is always available by the time the node executes.
+//
+// Validate reports inputs whose producer doesn't exist or doesn't produce a value of type T under the given name.
+// NewInput returns an error with the MissingNode code if the node does not exist, or the error connecting it to the
+// producer.
+func NewInput[T any](g Graph, key string, producer string, name string) (Input[T], error) {
+	if _, ok := g.nodes[key]; !ok {
+		err := errors.Newf(nil, MissingNode, "node %q does not exist", key)
+		return Input[T]{}, errors.Embed(err, NodeKey, key)
+	}
+
+	if _, ok := g.nodes[producer]; ok && !g.connected(producer, key) {
+		if err := g.Connect(producer, key); err != nil {
+			return Input[T]{}, errors.Embed(err, NodeKey, key)
+		}
+	}
+
+	g.inputs[key] = append(g.inputs[key], binding{
+		producer: producer,
+		name:     name,
+		typ:      reflect.TypeFor[T](),
+	})
+	g.memo.reset()
+
+	return Input[T]{
+		producer: producer,
+		name:     name,
+	}, nil
+}
+
+// Bind is a convenience for NewInput that consumes the given output.
+func Bind[T any](g Graph, key string, output Output[T]) (Input[T], error) {
+	return NewInput[T](g, key, output.key, output.name)
+}
+
+// Get returns the value of the input. The producer must have set the value before it completed.
+func (input Input[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+
+	exec := executionFrom(ctx)
+	if exec == nil {
+		return zero, errors.New(nil, MissingOutput, "inputs can only be read from within a node")
+	}
+
+	value, ok := exec.values.get(input.producer, input.name)
+	if !ok {
+		err := errors.Newf(nil, MissingOutput, "node %q did not set output %q", input.producer, input.name)
+		return zero, errors.Embed(err, NodeKey, exec.key)
+	}
+
+	typed, ok := value.(T)
+	if !ok {
+		err := errors.Newf(nil, TypeMismatch, "output %q of node %q has type %T, not %T", input.name, input.producer, value, zero)
+		return zero, errors.Embed(err, NodeKey, exec.key)
+	}
+	return typed, nil
+}
+
+// validatePorts checks that every input is bound to a matching output.
+func (g Graph) validatePorts() error {
+	var keys []string
+	for key := range g.inputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, input := range g.inputs[key] {
+			if _, ok := g.nodes[input.producer]; !ok {
+				err := errors.Newf(nil, MissingProducer, "input %q of node %q refers to node %q, which does not exist", input.name, key, input.producer)
+				return errors.Embed(err, NodeKey, key)
+			}
+
+			typ, ok := g.outputs[input.producer][input.name]
+			if !ok {
+				err := errors.Newf(nil, MissingProducer, "input %q of node %q refers to an output node %q does not declare", input.name, key, input.producer)
+				return errors.Embed(err, NodeKey, key)
+			}
+
+			if typ != input.typ {
+				err := errors.Newf(nil, TypeMismatch, "input %q of node %q expects %s, but node %q produces %s", input.name, key, input.typ, input.producer, typ)
+				return errors.Embed(err, NodeKey, key)
+			}
+
+			if !g.connected(input.producer, key) {
+				err := errors.Newf(nil, MissingProducer, "input %q of node %q was bound before node %q existed, so they are not connected", input.name, key, input.producer)
+				return errors.Embed(err, NodeKey, key)
+			}
+		}
+	}
+	return nil
+}
+
+// connected returns true if there is an edge directly between from and to.
+func (g Graph) connected(from string, to string) bool {
+	for _, child := range g.nodes[from].children {
+		if child == to {
+			return true
+		}
+	}
+	return false
+}
+
+// values holds the outputs set by the nodes of a walk.
+type values struct {
+	mutex  sync.RWMutex
+	values map[[2]string]interface{}
+}
+
+func newValues() *values {
+	return &values{
+		values: make(map[[2]string]interface{}),
+	}
+}
+
+func (values *values) set(key string, name string, value interface{}) {
+	values.mutex.Lock()
+	defer values.mutex.Unlock()
+	values.values[[2]string{key, name}] = value
+}
+
+// copy sets every output of the node with the given key that was set in from.
+func (values *values) copy(from *values, key string) {
+	from.mutex.RLock()
+	defer from.mutex.RUnlock()
+	values.mutex.Lock()
+	defer values.mutex.Unlock()
+
+	for id, value := range from.values {
+		if id[0] == key {
+			values.values[id] = value
+		}
+	}
+}
+
+func (values *values) get(key string, name string) (interface{}, bool) {
+	values.mutex.RLock()
+	defer values.mutex.RUnlock()
+	value, ok := values.values[[2]string{key, name}]
+	return value, ok
+}
@@ -0,0 +1,116 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestPorts(t *testing.T) {
+	var received string
+
+	var output Output[string]
+	g := NewGraph()
+	g.AddNode("producer", Executable(func(ctx context.Context) error {
+		return output.Set(ctx, "hello")
+	}))
+	output, err := NewOutput[string](g, "producer", "greeting")
+	tests.ExecuteE(err).NoError(t)
+
+	var input Input[string]
+	g.AddNode("consumer", Executable(func(ctx context.Context) error {
+		value, err := input.Get(ctx)
+		received = value
+		return err
+	}))
+	input, err = Bind(g, "consumer", output)
+	tests.ExecuteE(err).NoError(t)
+
+	tests.ExecuteE(g.Validate()).NoError(t)
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1})).NoError(t)
+	tests.Execute(received).Equal(t, "hello")
+}
+
+func TestPorts_Validate_Error(t *testing.T) {
+	tcs := []struct {
+		graph       func(g Graph) Graph
+		expectedErr string
+	}{
+		{
+			graph: func(g Graph) Graph {
+				g.AddNode("b", Executable(func(ctx context.Context) error {
+					return nil
+				}))
+				_, _ = NewInput[int](g, "b", "a", "count")
+				return g
+			},
+			expectedErr: "input \"count\" of node \"b\" refers to node \"a\", which does not exist",
+		},
+		{
+			graph: func(g Graph) Graph {
+				g.AddNode("a", Executable(func(ctx context.Context) error {
+					return nil
+				}))
+				g.AddNode("b", Executable(func(ctx context.Context) error {
+					return nil
+				}))
+				_, _ = NewOutput[string](g, "a", "count")
+				_, _ = NewInput[int](g, "b", "a", "count")
+				return g
+			},
+			expectedErr: "input \"count\" of node \"b\" expects int, but node \"a\" produces string",
+		},
+		{
+			graph: func(g Graph) Graph {
+				g.AddNode("a", Executable(func(ctx context.Context) error {
+					return nil
+				}))
+				g.AddNode("b", Executable(func(ctx context.Context) error {
+					return nil
+				}))
+				_, _ = NewInput[int](g, "b", "a", "count")
+				return g
+			},
+			expectedErr: "input \"count\" of node \"b\" refers to an output node \"a\" does not declare",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.expectedErr, func(t *testing.T) {
+			tests.ExecuteE(tc.graph(NewGraph()).Validate()).
+				MatchesError(t, tc.expectedErr)
+		})
+	}
+}
+
+func TestPorts_MissingNode(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+
+	_, err := NewOutput[string](g, "missing", "count")
+	tests.Execute(errors.GetErrorCode(err)).Equal(t, MissingNode)
+
+	_, err = NewInput[string](g, "missing", "a", "count")
+	tests.Execute(errors.GetErrorCode(err)).Equal(t, MissingNode)
+}
+
+func TestPorts_NewOutput_Observed(t *testing.T) {
+	g := NewGraph()
+	g.AddNodeWithMeta("a", Executable(func(ctx context.Context) error {
+		return nil
+	}), Meta{Owner: "infra"})
+
+	var replaced []string
+	g.Observe(ObserverFuncs{
+		OnNodeReplaced: func(key string, meta Meta) {
+			replaced = append(replaced, key+" "+meta.Owner)
+		},
+	})
+
+	_, err := NewOutput[string](g, "a", "count")
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(replaced).Equal(t, []string{"a infra"})
+}
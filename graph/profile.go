@@ -0,0 +1,22 @@
+package graph
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+const (
+	// ProfileLabelNode and ProfileLabelWalk are the pprof labels set on the goroutines running nodes when
+	// Opts.ProfileLabels is set, naming the node and the ID of the walk respectively.
+	ProfileLabelNode = "graph.node"
+	ProfileLabelWalk = "graph.walk"
+)
+
+// profile calls fn for the node, labelling the goroutine with the node and the walk if Opts.ProfileLabels is set.
+func (walker *walker) profile(ctx context.Context, key string, opts *Opts, fn func(ctx context.Context)) {
+	if !opts.ProfileLabels {
+		fn(ctx)
+		return
+	}
+	pprof.Do(ctx, pprof.Labels(ProfileLabelNode, key, ProfileLabelWalk, walker.id), fn)
+}
@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_ProfileLabels(t *testing.T) {
+	tcs := map[string]struct {
+		enabled  bool
+		expected map[string]string
+	}{
+		"disabled": {
+			expected: map[string]string{"a": "", "b": ""},
+		},
+		"enabled": {
+			enabled:  true,
+			expected: map[string]string{"a": "a", "b": "b"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			labels := make(map[string]string)
+			walks := make(map[string]bool)
+
+			g := NewGraph()
+			for _, key := range []string{"a", "b"} {
+				g.AddNode(key, Executable(func(ctx context.Context) error {
+					mutex.Lock()
+					defer mutex.Unlock()
+					labels[key], _ = pprof.Label(ctx, ProfileLabelNode)
+					walk, _ := pprof.Label(ctx, ProfileLabelWalk)
+					walks[walk] = true
+					return nil
+				}))
+			}
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 2, ProfileLabels: tc.enabled})
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(labels).Equal(t, tc.expected)
+			if tc.enabled {
+				tests.Execute(walks).Equal(t, map[string]bool{result.WalkID: true})
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// deadline returns when the walk should finish by, if it has a deadline at all.
+func deadline(ctx context.Context, opts *Opts) (time.Time, bool) {
+	if !opts.Deadline.IsZero() {
+		return opts.Deadline, true
+	}
+	return ctx.Deadline()
+}
+
+// prune returns true if the node is optional and should be skipped, either because it belongs to a branch that has
+// already been pruned or because the work still to do along its longest path wouldn't finish before the deadline.
+func (walker *walker) prune(key string) bool {
+	node := walker.nodes[key]
+	if !node.meta.Optional {
+		return false
+	}
+
+	for _, parent := range node.parents {
+		if walker.pruned.has(parent) {
+			walker.tracer.log(slog.LevelDebug, key, "node pruned", slog.String("parent", parent))
+			return true
+		}
+	}
+
+	if walker.deadline.IsZero() {
+		return false
+	}
+
+	path := walker.criticalPath(key)
+	if time.Now().Add(path).After(walker.deadline) {
+		walker.tracer.log(slog.LevelDebug, key, "node pruned",
+			slog.Duration("critical_path", path),
+			slog.Time("deadline", walker.deadline))
+		return true
+	}
+	return false
+}
+
+// criticalPath returns the sum of the estimates along the longest path from the node to the end of the walk, including
+// the node itself. Paths through subgraph finishers continue through the children of the node that expanded into them.
+func (walker *walker) criticalPath(key string) time.Duration {
+	if path, ok := walker.paths[key]; ok {
+		return path
+	}
+
+	var longest time.Duration
+	next := walker.nodes[key].children
+	if starter, ok := walker.subgraphFinishers[key]; ok {
+		next = append(append([]string(nil), next...), walker.nodes[starter].children...)
+	}
+	for _, child := range next {
+		longest = max(longest, walker.criticalPath(child))
+	}
+
+	if walker.paths == nil {
+		walker.paths = make(map[string]time.Duration)
+	}
+	walker.paths[key] = walker.nodes[key].meta.Estimate + longest
+	return walker.paths[key]
+}
+
+// Pruned records that an optional node was skipped rather than dispatched, and returns the children that are now ready.
+// Anything that depends on a pruned node still runs, unless it is optional itself.
+func (walker *walker) Pruned(key string) []string {
+	walker.pruned.add(key)
+	walker.finish(key, StatusPruned, nil)
+	walker.publish(EventNodePruned, key, nil)
+	return walker.resolved(key)
+}
@@ -0,0 +1,130 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Prune(t *testing.T) {
+	type spec struct {
+		meta    Meta
+		parents []string
+	}
+
+	tcs := map[string]struct {
+		nodes    map[string]spec
+		deadline time.Duration
+		pruned   []string
+		ran      []string
+	}{
+		"no deadline": {
+			nodes: map[string]spec{
+				"a": {meta: Meta{Optional: true, Estimate: time.Hour}},
+			},
+			ran: []string{"a"},
+		},
+		"fits": {
+			nodes: map[string]spec{
+				"a": {},
+				"b": {meta: Meta{Optional: true, Estimate: time.Millisecond}, parents: []string{"a"}},
+			},
+			deadline: time.Hour,
+			ran:      []string{"a", "b"},
+		},
+		"too long": {
+			nodes: map[string]spec{
+				"a": {},
+				"b": {meta: Meta{Optional: true, Estimate: time.Hour}, parents: []string{"a"}},
+				"c": {parents: []string{"b"}},
+			},
+			deadline: time.Minute,
+			pruned:   []string{"b"},
+			ran:      []string{"a", "c"},
+		},
+		"critical path": {
+			nodes: map[string]spec{
+				"a": {meta: Meta{Optional: true, Estimate: time.Millisecond}},
+				"b": {meta: Meta{Estimate: time.Hour}, parents: []string{"a"}},
+			},
+			deadline: time.Minute,
+			pruned:   []string{"a"},
+			ran:      []string{"b"},
+		},
+		"branch": {
+			nodes: map[string]spec{
+				"a": {meta: Meta{Optional: true, Estimate: time.Hour}},
+				"b": {meta: Meta{Optional: true}, parents: []string{"a"}},
+				"c": {parents: []string{"b"}},
+			},
+			deadline: time.Minute,
+			pruned:   []string{"a", "b"},
+			ran:      []string{"c"},
+		},
+		"required": {
+			nodes: map[string]spec{
+				"a": {meta: Meta{Estimate: time.Hour}},
+			},
+			deadline: time.Minute,
+			ran:      []string{"a"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var ran []string
+
+			g := NewGraph()
+			for key, spec := range tc.nodes {
+				g.AddNodeWithMeta(key, Executable(func(ctx context.Context) error {
+					mutex.Lock()
+					defer mutex.Unlock()
+					ran = append(ran, key)
+					return nil
+				}), spec.meta)
+			}
+			for key, spec := range tc.nodes {
+				for _, parent := range spec.parents {
+					g.Connect(parent, key)
+				}
+			}
+
+			opts := &Opts{Parallelism: 1, Verify: true}
+			if tc.deadline > 0 {
+				opts.Deadline = time.Now().Add(tc.deadline)
+			}
+
+			result, err := g.Run(context.Background(), opts)
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(result.Status(StatusPruned)).Equal(t, tc.pruned)
+			tests.Execute(result.Status(StatusCompleted)).Equal(t, tc.ran)
+			tests.Execute(ran).Equal(t, tc.ran)
+		})
+	}
+}
+
+func TestGraph_Walk_Prune_ContextDeadline(t *testing.T) {
+	g := NewGraph()
+	g.AddNodeWithMeta("a", Executable(func(ctx context.Context) error {
+		return nil
+	}), Meta{Optional: true, Estimate: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var events []EventType
+	bus := NewBus(SinkFunc(func(event Event) {
+		if event.Key == "a" {
+			events = append(events, event.Type)
+		}
+	}))
+
+	result, err := g.Run(ctx, &Opts{Parallelism: 1, Bus: bus})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(result.Status(StatusPruned)).Equal(t, []string{"a"})
+	tests.Execute(events).Equal(t, []EventType{EventNodePruned})
+}
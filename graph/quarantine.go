@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// NodeStats summarises how a node has fared across walks, see HistoryStore.
+type NodeStats struct {
+	// Runs is how many times the node executed, and Failures how many of those times it failed.
+	Runs     int
+	Failures int
+}
+
+// FailureRate returns the fraction of runs that failed, or zero if the node has never run.
+func (stats NodeStats) FailureRate() float64 {
+	if stats.Runs == 0 {
+		return 0
+	}
+	return float64(stats.Failures) / float64(stats.Runs)
+}
+
+// HistoryStore remembers how every node has fared across walks, so chronically failing nodes can be quarantined. See
+// Opts.History.
+type HistoryStore interface {
+	// Record records that the node with the given key executed, and whether it failed.
+	Record(ctx context.Context, key string, failed bool) error
+
+	// Stats returns how the node with the given key has fared so far.
+	Stats(ctx context.Context, key string) (NodeStats, error)
+}
+
+var _ HistoryStore = (*MemoryHistoryStore)(nil)
+
+// MemoryHistoryStore is a HistoryStore that keeps the history of every node in memory. It only remembers the most
+// recent runs of each node, so nodes that have been fixed stop counting as failing eventually.
+type MemoryHistoryStore struct {
+	mutex sync.Mutex
+
+	// limit is how many runs are remembered, and runs records whether each of the remembered runs failed, oldest first.
+	limit int
+	runs  map[string][]bool
+}
+
+// NewMemoryHistoryStore returns a new, empty MemoryHistoryStore that remembers up to limit runs of every node, or every
+// run if limit is zero.
+func NewMemoryHistoryStore(limit int) *MemoryHistoryStore {
+	return &MemoryHistoryStore{
+		limit: limit,
+		runs:  make(map[string][]bool),
+	}
+}
+
+// Record implements HistoryStore.
+func (store *MemoryHistoryStore) Record(ctx context.Context, key string, failed bool) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	runs := append(store.runs[key], failed)
+	if store.limit > 0 && len(runs) > store.limit {
+		runs = runs[len(runs)-store.limit:]
+	}
+	store.runs[key] = runs
+	return nil
+}
+
+// Stats implements HistoryStore.
+func (store *MemoryHistoryStore) Stats(ctx context.Context, key string) (NodeStats, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	stats := NodeStats{Runs: len(store.runs[key])}
+	for _, failed := range store.runs[key] {
+		if failed {
+			stats.Failures++
+		}
+	}
+	return stats, nil
+}
+
+// Forget clears the history of the node with the given key, for example once it has been fixed. Quarantined nodes
+// never run, so they stay quarantined until their history is forgotten.
+func (store *MemoryHistoryStore) Forget(key string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.runs, key)
+}
+
+// Quarantine decides which optional nodes are failing too often to be worth running, see Opts.Quarantine. Quarantined
+// nodes are skipped with a warning instead of being dispatched, and the nodes that depend on them still run.
+type Quarantine struct {
+	// MinRuns is how many runs a node needs before it can be quarantined, so a single unlucky failure doesn't quarantine
+	// a new node.
+	MinRuns int
+
+	// FailureRate is the rate of failure at or above which nodes are quarantined, between 0 and 1.
+	FailureRate float64
+}
+
+// quarantined returns true if the node is optional and its history says it should be quarantined, in which case it has
+// been skipped.
+func (walker *walker) quarantined(ctx context.Context, key string, opts *Opts) bool {
+	node := walker.nodes[key]
+	if opts.Quarantine == nil || opts.History == nil || !node.meta.Optional {
+		return false
+	}
+
+	stats, err := opts.History.Stats(ctx, key)
+	if err != nil {
+		opts.Logger.Warn("failed to read node history", slog.String("key", key), slog.String("error", err.Error()))
+		return false
+	}
+	if stats.Runs < max(opts.Quarantine.MinRuns, 1) || stats.FailureRate() < opts.Quarantine.FailureRate {
+		return false
+	}
+
+	opts.Logger.Warn("node quarantined",
+		slog.String("key", key),
+		slog.Int("runs", stats.Runs),
+		slog.Float64("failure_rate", stats.FailureRate()))
+	ready := walker.Skipped(key)
+
+	err = errors.Newf(nil, Quarantined, "node %q is quarantined after failing %d of its last %d runs", key, stats.Failures, stats.Runs)
+	walker.result.Nodes[key].Err = errors.Embed(err, NodeKey, key)
+
+	walker.ready(ready...)
+	return true
+}
+
+// remember records how every node that executed during the walk fared in Opts.History.
+func (walker *walker) remember(ctx context.Context, opts *Opts) {
+	if opts.History == nil {
+		return
+	}
+
+	for _, key := range (Graph{nodes: walker.nodes}).sortedKeys() {
+		result := walker.result.Nodes[key]
+		if result.Started.IsZero() || result.Reused {
+			continue // the node never executed.
+		}
+		if result.Status != StatusCompleted && result.Status != StatusErrored {
+			continue
+		}
+
+		if err := opts.History.Record(ctx, key, result.Status == StatusErrored); err != nil {
+			opts.Logger.Warn("failed to record node history", slog.String("key", key), slog.String("error", err.Error()))
+		}
+	}
+}
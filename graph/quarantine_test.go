@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestMemoryHistoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryHistoryStore(3)
+
+	for _, failed := range []bool{true, true, false, false} {
+		tests.ExecuteE(store.Record(ctx, "a", failed)).NoError(t)
+	}
+
+	// Only the last three runs are remembered.
+	stats, err := store.Stats(ctx, "a")
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(stats).Equal(t, NodeStats{Runs: 3, Failures: 1})
+
+	store.Forget("a")
+	stats, err = store.Stats(ctx, "a")
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(stats).Equal(t, NodeStats{})
+	tests.Execute(stats.FailureRate()).Equal(t, float64(0))
+}
+
+func TestGraph_Walk_Quarantine(t *testing.T) {
+	ctx := context.Background()
+
+	// Both flaky and critical have failed two of their last three runs, and new has only ever failed once.
+	history := NewMemoryHistoryStore(0)
+	for _, key := range []string{"flaky", "critical"} {
+		for _, failed := range []bool{true, false, true} {
+			history.Record(ctx, key, failed)
+		}
+	}
+	history.Record(ctx, "new", true)
+
+	var ran []string
+	record := func(key string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			ran = append(ran, key)
+			return nil
+		})
+	}
+
+	g := NewGraph()
+	g.AddNodeWithMeta("flaky", record("flaky"), Meta{Optional: true})
+	g.AddNodeWithMeta("new", record("new"), Meta{Optional: true})
+	g.AddNode("critical", record("critical"))
+	g.AddNode("report", record("report"))
+	g.Connect("flaky", "report")
+
+	var logs bytes.Buffer
+	result, err := g.Run(ctx, &Opts{
+		Parallelism: 1,
+		Logger:      slog.New(slog.NewTextHandler(&logs, nil)),
+		History:     history,
+		Quarantine:  &Quarantine{MinRuns: 3, FailureRate: 0.5},
+	})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(ran).Equal(t, []string{"critical", "new", "report"})
+	tests.Execute(result.Status(StatusSkipped)).Equal(t, []string{"flaky"})
+	tests.ExecuteE(result.Nodes["flaky"].Err).MatchesError(t, "node \"flaky\" is quarantined after failing 2 of its last 3 runs")
+	tests.Execute(strings.Contains(logs.String(), "level=WARN msg=\"node quarantined\" key=flaky")).Equal(t, true)
+
+	// The nodes that ran were recorded, but the quarantined node wasn't.
+	for key, expected := range map[string]NodeStats{
+		"flaky":    {Runs: 3, Failures: 2},
+		"critical": {Runs: 4, Failures: 2},
+		"new":      {Runs: 2, Failures: 1},
+		"report":   {Runs: 1},
+	} {
+		stats, err := history.Stats(ctx, key)
+		tests.ExecuteE(err).NoError(t)
+		tests.Execute(stats).Equal(t, expected)
+	}
+}
@@ -0,0 +1,53 @@
+package graph
+
+// queue is a FIFO queue of node keys backed by a ring buffer that grows as needed. Pushing never blocks, so a single
+// node can release any number of children at once.
+type queue struct {
+	items []string
+	head  int
+	size  int
+}
+
+// push adds a key to the back of the queue.
+func (queue *queue) push(key string) {
+	if queue.size == len(queue.items) {
+		queue.grow()
+	}
+	queue.items[(queue.head+queue.size)%len(queue.items)] = key
+	queue.size++
+}
+
+// pop removes and returns the key at the front of the queue.
+func (queue *queue) pop() (string, bool) {
+	if queue.size == 0 {
+		return "", false
+	}
+
+	key := queue.items[queue.head]
+	queue.items[queue.head] = ""
+	queue.head = (queue.head + 1) % len(queue.items)
+	queue.size--
+	return key, true
+}
+
+// len returns the number of keys in the queue.
+func (queue *queue) len() int {
+	return queue.size
+}
+
+// keys returns the keys in the queue, from front to back.
+func (queue *queue) keys() []string {
+	keys := make([]string, 0, queue.size)
+	for ix := 0; ix < queue.size; ix++ {
+		keys = append(keys, queue.items[(queue.head+ix)%len(queue.items)])
+	}
+	return keys
+}
+
+// grow doubles the capacity of the queue, moving the keys to the front of the new buffer.
+func (queue *queue) grow() {
+	items := make([]string, max(2*len(queue.items), 16))
+	copy(items, queue.keys())
+	queue.items = items
+	queue.head = 0
+}
@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestQueue(t *testing.T) {
+	var q queue
+	for ix := 0; ix < 40; ix++ {
+		q.push(fmt.Sprint(ix))
+		if ix%3 == 0 {
+			q.pop()
+		}
+	}
+	tests.Execute(q.len()).Equal(t, 26)
+	tests.Execute(q.keys()[0]).Equal(t, "14")
+
+	key, ok := q.pop()
+	tests.Execute(ok).Equal(t, true)
+	tests.Execute(key).Equal(t, "14")
+}
+
+func TestGraph_Walk_HighFanOut(t *testing.T) {
+	width := 10000
+	if testing.Short() {
+		width = 1000
+	}
+
+	var executed atomic.Int64
+	noop := Executable(func(ctx context.Context) error {
+		executed.Add(1)
+		return nil
+	})
+
+	g := NewGraph()
+	g.AddNode("root", noop)
+	g.AddNode("sink", noop)
+	for ix := 0; ix < width; ix++ {
+		key := fmt.Sprintf("child-%d", ix)
+		g.AddNode(key, noop)
+		g.Connect("root", key)
+		g.Connect(key, "sink")
+	}
+
+	g.AddNode("expander", Expandable(func(ctx context.Context) (Graph, error) {
+		subgraph := NewGraph()
+		for ix := 0; ix < width; ix++ {
+			subgraph.AddNode(fmt.Sprintf("expanded-%d", ix), noop)
+		}
+		return subgraph, nil
+	}))
+	g.Connect("root", "expander")
+	g.Connect("expander", "sink")
+
+	for _, parallelism := range []int{1, 64} {
+		executed.Store(0)
+		tests.ExecuteE(g.Walk(context.Background(), &Opts{
+			Parallelism: parallelism,
+			Verify:      true,
+		})).NoError(t)
+		tests.Execute(executed.Load()).Equal(t, int64(2*width+2))
+	}
+}
@@ -0,0 +1,172 @@
+package graph
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Quota limits how many nodes with a tag may run, to bound the use of a shared resource even when the nodes using it
+// are added by expansion. Nodes that would exceed a quota are held without occupying a worker until it has room again.
+type Quota struct {
+	// Tag selects the nodes the quota applies to, see Meta.Tags.
+	Tag string
+
+	// Limit is the maximum number of nodes with the tag that may start.
+	Limit int
+
+	// Period is the sliding window the limit applies to. If zero, the limit applies to the whole walk and nodes that
+	// would exceed it are cancelled with CancelQuota instead of being held.
+	//
+	// Quotas with a period are shared with every other walk using the same QuotaStore.
+	Period time.Duration
+}
+
+// QuotaStore keeps track of how much of every quota has been used. Stores are called from the walker's main loop, so
+// they should answer quickly.
+type QuotaStore interface {
+	// Take records that a node is starting against every one of the given quotas, but only if all of them have room.
+	// If any of them doesn't, nothing is recorded and Take returns false along with when to try again, or the zero time
+	// if there won't ever be room during the walk.
+	Take(ctx context.Context, walkID string, quotas []Quota, now time.Time) (bool, time.Time, error)
+
+	// Finish is called when a walk finishes, so the store can forget the quotas that only applied to it.
+	Finish(ctx context.Context, walkID string) error
+}
+
+var _ QuotaStore = (*MemoryQuotaStore)(nil)
+
+// MemoryQuotaStore is a QuotaStore that keeps track of quotas in memory. It can be shared between walks in the same
+// process.
+type MemoryQuotaStore struct {
+	mutex sync.Mutex
+
+	// walks counts the nodes started by each walk for every tag with a per walk quota.
+	walks map[string]map[string]int
+
+	// periods records when the nodes with every tag with a periodic quota started, oldest first.
+	periods map[string][]time.Time
+}
+
+// NewMemoryQuotaStore returns a new, empty MemoryQuotaStore.
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{
+		walks:   make(map[string]map[string]int),
+		periods: make(map[string][]time.Time),
+	}
+}
+
+// Take implements QuotaStore.
+func (store *MemoryQuotaStore) Take(ctx context.Context, walkID string, quotas []Quota, now time.Time) (bool, time.Time, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var retry time.Time
+	full := false
+	for _, quota := range quotas {
+		if quota.Limit <= 0 {
+			return false, time.Time{}, nil
+		}
+
+		if quota.Period == 0 {
+			if store.walks[walkID][quota.Tag] >= quota.Limit {
+				// This quota won't have room again, so there's no point waiting for the others.
+				return false, time.Time{}, nil
+			}
+			continue
+		}
+
+		started := store.expire(quota, now)
+		if len(started) >= quota.Limit {
+			full = true
+
+			// There's room again once enough of the nodes that started have left the period.
+			at := started[len(started)-quota.Limit].Add(quota.Period)
+			if at.After(retry) {
+				retry = at
+			}
+		}
+	}
+	if full {
+		return false, retry, nil
+	}
+
+	for _, quota := range quotas {
+		if quota.Period == 0 {
+			if store.walks[walkID] == nil {
+				store.walks[walkID] = make(map[string]int)
+			}
+			store.walks[walkID][quota.Tag]++
+			continue
+		}
+		store.periods[quota.Tag] = append(store.periods[quota.Tag], now)
+	}
+	return true, time.Time{}, nil
+}
+
+// expire forgets the nodes that started before the period of the quota, and returns the ones that are left.
+func (store *MemoryQuotaStore) expire(quota Quota, now time.Time) []time.Time {
+	started := store.periods[quota.Tag]
+	for len(started) > 0 && !started[0].After(now.Add(-quota.Period)) {
+		started = started[1:]
+	}
+	store.periods[quota.Tag] = started
+	return started
+}
+
+// Finish implements QuotaStore.
+func (store *MemoryQuotaStore) Finish(ctx context.Context, walkID string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.walks, walkID)
+	return nil
+}
+
+// quotas returns the quotas that apply to the node.
+func (walker *walker) quotas(key string, opts *Opts) []Quota {
+	if len(opts.Quotas) == 0 {
+		return nil
+	}
+
+	var quotas []Quota
+	for _, tag := range walker.nodes[key].meta.Tags {
+		for _, quota := range opts.Quotas {
+			if quota.Tag == tag {
+				quotas = append(quotas, quota)
+			}
+		}
+	}
+	return quotas
+}
+
+// overQuota returns true if the node can't run because one of its quotas is full, in which case it has been held,
+// cancelled or failed.
+func (walker *walker) overQuota(ctx context.Context, key string, opts *Opts) bool {
+	quotas := walker.quotas(key, opts)
+	if len(quotas) == 0 {
+		return false
+	}
+
+	ok, retry, err := walker.quotaStore.Take(ctx, walker.id, quotas, walker.now())
+	if err != nil {
+		err = errors.Embed(errors.New(err, FailedNode, "failed to take quota"), NodeKey, key)
+		walker.fail(key, err, opts)
+		return true
+	}
+	if ok {
+		return false
+	}
+
+	if retry.IsZero() {
+		walker.tracer.log(slog.LevelDebug, key, "node cancelled before dispatch", slog.String("reason", string(CancelQuota)))
+		walker.Cancelled(key, CancelQuota)
+		return true
+	}
+
+	walker.tracer.log(slog.LevelDebug, key, "node held", slog.String("reason", "quota"), slog.Time("retry", retry))
+	walker.hold(key, retry)
+	return true
+}
@@ -0,0 +1,115 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestMemoryQuotaStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.January, 3, 13, 0, 0, 0, time.UTC)
+	walk := Quota{Tag: "expensive", Limit: 1}
+	periodic := Quota{Tag: "api", Limit: 2, Period: time.Minute}
+
+	type taken struct {
+		Ok    bool
+		Retry time.Time
+	}
+
+	store := NewMemoryQuotaStore()
+	take := func(walkID string, at time.Time, quotas ...Quota) taken {
+		ok, retry, err := store.Take(ctx, walkID, quotas, at)
+		tests.ExecuteE(err).NoError(t)
+		return taken{ok, retry}
+	}
+
+	// Per walk quotas are counted separately for every walk, and never have room again once they are full.
+	tests.Execute(take("one", now, walk)).Equal(t, taken{true, time.Time{}})
+	tests.Execute(take("one", now, walk)).Equal(t, taken{false, time.Time{}})
+	tests.Execute(take("two", now, walk)).Equal(t, taken{true, time.Time{}})
+
+	// Periodic quotas are shared, and have room again once the oldest nodes leave the period.
+	tests.Execute(take("one", now, periodic)).Equal(t, taken{true, time.Time{}})
+	tests.Execute(take("two", now.Add(time.Second), periodic)).Equal(t, taken{true, time.Time{}})
+	tests.Execute(take("one", now.Add(2*time.Second), periodic)).Equal(t, taken{false, now.Add(time.Minute)})
+	tests.Execute(take("one", now.Add(time.Minute), periodic)).Equal(t, taken{true, time.Time{}})
+
+	// Nothing is taken unless every quota has room.
+	tests.Execute(take("three", now.Add(time.Minute), walk, periodic)).Equal(t, taken{false, now.Add(time.Minute + time.Second)})
+	tests.Execute(take("three", now.Add(time.Minute), walk)).Equal(t, taken{true, time.Time{}})
+
+	// Finished walks are forgotten.
+	tests.ExecuteE(store.Finish(ctx, "one")).NoError(t)
+	tests.Execute(take("one", now, walk)).Equal(t, taken{true, time.Time{}})
+}
+
+func TestGraph_Walk_Quotas(t *testing.T) {
+	t.Run("walk", func(t *testing.T) {
+		var mutex sync.Mutex
+		var ran []string
+
+		// The quota applies to nodes added by expansion as well.
+		g := NewGraph()
+		g.AddNode("expand", Expandable(func(ctx context.Context) (Graph, error) {
+			subgraph := NewGraph()
+			for ix := 0; ix < 3; ix++ {
+				key := fmt.Sprintf("expanded-%d", ix)
+				subgraph.AddNodeWithMeta(key, Executable(func(ctx context.Context) error {
+					mutex.Lock()
+					defer mutex.Unlock()
+					ran = append(ran, key)
+					return nil
+				}), Meta{Tags: []string{"expensive"}})
+			}
+			return subgraph, nil
+		}))
+
+		result, err := g.Run(context.Background(), &Opts{
+			Parallelism: 1,
+			Quotas:      []Quota{{Tag: "expensive", Limit: 2}},
+		})
+		tests.ExecuteE(err).MatchesError(t, "graph is incomplete")
+		tests.Execute(ran).Equal(t, []string{"expanded-0", "expanded-1"})
+		tests.Execute(result.Nodes["expanded-2"].Status).Equal(t, StatusCancelled)
+		tests.Execute(result.Nodes["expanded-2"].Reason).Equal(t, CancelQuota)
+	})
+
+	t.Run("period", func(t *testing.T) {
+		var mutex sync.Mutex
+		var started []time.Time
+
+		g := NewGraph()
+		for _, key := range []string{"a", "b", "c"} {
+			g.AddNodeWithMeta(key, Executable(func(ctx context.Context) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				started = append(started, time.Now())
+				return nil
+			}), Meta{Tags: []string{"api"}})
+		}
+
+		var held []string
+		bus := NewBus(SinkFunc(func(event Event) {
+			if event.Type == EventNodeHeld {
+				held = append(held, event.Key)
+			}
+		}))
+
+		// The quotas are taken just before the nodes start, so the period is measured from before the walk.
+		start := time.Now()
+		_, err := g.Run(context.Background(), &Opts{
+			Parallelism: 3,
+			Bus:         bus,
+			Quotas:      []Quota{{Tag: "api", Limit: 2, Period: 50 * time.Millisecond}},
+		})
+		tests.ExecuteE(err).NoError(t)
+		tests.Execute(held).Equal(t, []string{"c"})
+		tests.Execute(len(started)).Equal(t, 3)
+		tests.Execute(started[2].Sub(start) >= 50*time.Millisecond).Equal(t, true)
+	})
+}
@@ -0,0 +1,150 @@
+package graph
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Readiness decides when a node is ready to run, see Meta.Readiness. By default a node waits for all of its parents to
+// complete, a readiness lets it run once only some of them have so racing or hedged parents don't hold it up.
+type Readiness struct {
+	// Parents is how many of the parents of the node must complete before it is ready. The node runs as soon as that
+	// many have, whether or not the rest ever do, and edges added once the walk has started don't change it.
+	//
+	// Defaults to all of them.
+	Parents int
+
+	// Cancel cancels the parents that haven't finished once the node is ready, with the CancelRace reason. Running
+	// parents have their context cancelled, and the rest are never dispatched. Parents that other nodes are still
+	// waiting for are left to finish.
+	//
+	// Defaults to false, which leaves the remaining parents to finish without the node waiting for them.
+	Cancel bool
+
+	// Timeout makes the node ready once that long has passed since the first of its parents completed, even if it is
+	// still waiting for others, so aggregation steps don't wait forever on stragglers or parents that failed. The node
+	// can find out which parents made it in time through WalkHandle.Completed.
+	//
+	// Defaults to zero, which waits for as long as it takes.
+	Timeout time.Duration
+}
+
+// AnyParent returns a readiness that runs the node as soon as any one of its parents completes.
+func AnyParent() Readiness {
+	return Readiness{Parents: 1}
+}
+
+// Quorum returns a readiness that runs the node once n of its parents have completed.
+func Quorum(n int) Readiness {
+	return Readiness{Parents: n}
+}
+
+// Join returns a readiness that runs the node once all of its parents have completed, or once the timeout has passed
+// since the first of them completed.
+func Join(timeout time.Duration) Readiness {
+	return Readiness{Timeout: timeout}
+}
+
+// required returns how many of the parents of the node must complete before it is ready.
+func (node *node) required() int {
+	if parents := node.meta.Readiness.Parents; parents > 0 && parents < len(node.parents) {
+		return parents
+	}
+	return len(node.parents)
+}
+
+// race cancels the parents of a node that is ready but didn't wait for all of them, if its readiness says to.
+func (walker *walker) race(key string) {
+	node := walker.nodes[key]
+	if !node.meta.Readiness.Cancel {
+		return
+	}
+
+	for _, parent := range node.parents {
+		if walker.finished(parent) || walker.lost[parent] || walker.needed(parent, key) {
+			continue
+		}
+
+		walker.tracer.log(slog.LevelDebug, parent, "node lost", slog.String("child", key))
+		walker.lost[parent] = true
+		if exec, ok := walker.executions[parent]; ok && exec.cancel != nil {
+			exec.cancel(&cancelCause{reason: CancelRace})
+		}
+	}
+}
+
+// finished returns true if the node has completed, errored or been cancelled.
+func (walker *walker) finished(key string) bool {
+	_, errored := walker.errored[key]
+	return walker.completed.has(key) || errored || walker.cancelled.has(key)
+}
+
+// needed returns true if any child of the node other than the given one is still waiting for its parents.
+func (walker *walker) needed(key string, except string) bool {
+	for _, child := range walker.nodes[key].children {
+		if child != except && walker.remaining[child] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// racing returns true if the node could lose a race, in which case it runs with a context of its own so it can be
+// cancelled without cancelling the rest of the walk.
+func (walker *walker) racing(key string) bool {
+	for _, child := range walker.nodes[key].children {
+		if walker.nodes[child].meta.Readiness.Cancel {
+			return true
+		}
+	}
+	return false
+}
+
+// Lost records that the node was cancelled because it lost a race, see Readiness.Cancel.
+func (walker *walker) Lost(key string) {
+	walker.Cancelled(key, CancelRace)
+}
+
+// settled returns how many nodes have finished in a way that doesn't leave the graph incomplete, which is every node
+// that completed or errored, and every node that was cancelled because it lost a race.
+func (walker *walker) settled() int {
+	settled := walker.completed.len() + len(walker.errored)
+	for key := range walker.lost {
+		if walker.cancelled.has(key) {
+			settled++
+		}
+	}
+	return settled
+}
+
+// join starts the timeout of a node that joins its parents with one, see Readiness.Timeout. It is called whenever a
+// parent of the node completes, but only the first one starts the timeout.
+func (walker *walker) join(key string) {
+	timeout := walker.nodes[key].meta.Readiness.Timeout
+	if _, ok := walker.joins[key]; ok || timeout <= 0 {
+		return
+	}
+
+	walker.tracer.log(LevelTrace, key, "node joining", slog.Duration("timeout", timeout))
+	walker.joins[key] = walker.now().Add(timeout)
+	walker.arm()
+}
+
+// Joined marks every node whose join has timed out as ready, and returns them.
+func (walker *walker) Joined() []string {
+	now := walker.now()
+
+	var ready []string
+	for key, at := range walker.joins {
+		if at.After(now) {
+			continue
+		}
+
+		walker.tracer.log(slog.LevelDebug, key, "node ready", slog.String("reason", "join timed out"))
+		delete(walker.joins, key)
+		walker.remaining[key] = 0
+		walker.race(key)
+		ready = append(ready, key)
+	}
+	return ready
+}
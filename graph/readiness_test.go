@@ -0,0 +1,228 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Readiness(t *testing.T) {
+	// slow only finishes once it is released, or cancelled.
+	slow := func(release <-chan struct{}) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+	fail := Executable(func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+
+	tcs := map[string]struct {
+		parallelism int
+		build       func(release chan struct{}) Graph
+		statuses    map[string]Status
+		reasons     map[string]CancelReason
+		err         string
+	}{
+		"any": {
+			parallelism: 2,
+			build: func(release chan struct{}) Graph {
+				g := NewGraph()
+				g.AddNode("fast", noop)
+				g.AddNode("slow", slow(release))
+				g.AddNodeWithMeta("child", Executable(func(ctx context.Context) error {
+					close(release)
+					return nil
+				}), Meta{Readiness: AnyParent()})
+				g.Connect("fast", "child")
+				g.Connect("slow", "child")
+				return g
+			},
+			statuses: map[string]Status{"fast": StatusCompleted, "slow": StatusCompleted, "child": StatusCompleted},
+		},
+		"cancel": {
+			parallelism: 2,
+			build: func(release chan struct{}) Graph {
+				g := NewGraph()
+				g.AddNode("fast", noop)
+				g.AddNode("slow", slow(release))
+				g.AddNodeWithMeta("child", noop, Meta{Readiness: Readiness{Parents: 1, Cancel: true}})
+				g.Connect("fast", "child")
+				g.Connect("slow", "child")
+				return g
+			},
+			statuses: map[string]Status{"fast": StatusCompleted, "slow": StatusCancelled, "child": StatusCompleted},
+			reasons:  map[string]CancelReason{"slow": CancelRace},
+		},
+		"undispatched": {
+			parallelism: 1,
+			build: func(release chan struct{}) Graph {
+				g := NewGraph()
+				g.AddNode("a", noop)
+				g.AddNode("b", noop)
+				g.AddNodeWithMeta("child", noop, Meta{Readiness: Readiness{Parents: 1, Cancel: true}})
+				g.Connect("a", "child")
+				g.Connect("b", "child")
+				return g
+			},
+			statuses: map[string]Status{"a": StatusCompleted, "b": StatusCancelled, "child": StatusCompleted},
+			reasons:  map[string]CancelReason{"b": CancelRace},
+		},
+		"needed": {
+			parallelism: 2,
+			build: func(release chan struct{}) Graph {
+				g := NewGraph()
+				g.AddNode("fast", noop)
+				g.AddNode("slow", slow(release))
+				g.AddNodeWithMeta("child", Executable(func(ctx context.Context) error {
+					close(release)
+					return nil
+				}), Meta{Readiness: Readiness{Parents: 1, Cancel: true}})
+				g.AddNode("other", noop)
+				g.Connect("fast", "child")
+				g.Connect("slow", "child")
+				g.Connect("slow", "other")
+				return g
+			},
+			statuses: map[string]Status{"fast": StatusCompleted, "slow": StatusCompleted, "child": StatusCompleted, "other": StatusCompleted},
+		},
+		"quorum": {
+			parallelism: 3,
+			build: func(release chan struct{}) Graph {
+				g := NewGraph()
+				g.AddNode("a", noop)
+				g.AddNode("b", noop)
+				g.AddNode("c", fail)
+				g.AddNodeWithMeta("child", noop, Meta{Readiness: Quorum(2)})
+				g.Connect("a", "child")
+				g.Connect("b", "child")
+				g.Connect("c", "child")
+				return g
+			},
+			statuses: map[string]Status{"a": StatusCompleted, "b": StatusCompleted, "c": StatusErrored, "child": StatusCompleted},
+			err:      "c: failed to execute node (boom)",
+		},
+		"unmet": {
+			parallelism: 3,
+			build: func(release chan struct{}) Graph {
+				g := NewGraph()
+				g.AddNode("a", noop)
+				g.AddNode("b", fail)
+				g.AddNode("c", fail)
+				g.AddNodeWithMeta("child", noop, Meta{Readiness: Quorum(2)})
+				g.Connect("a", "child")
+				g.Connect("b", "child")
+				g.Connect("c", "child")
+				return g
+			},
+			statuses: map[string]Status{"a": StatusCompleted, "b": StatusErrored, "c": StatusErrored, "child": StatusSkipped},
+			err:      "b: failed to execute node (boom); c: failed to execute node (boom); graph is incomplete",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g := tc.build(make(chan struct{}))
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: tc.parallelism, Verify: true})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+
+			statuses := make(map[string]Status)
+			reasons := make(map[string]CancelReason)
+			for key, node := range result.Nodes {
+				statuses[key] = node.Status
+				if len(node.Reason) > 0 {
+					reasons[key] = node.Reason
+				}
+			}
+			tests.Execute(statuses).Equal(t, tc.statuses)
+			if tc.reasons == nil {
+				tc.reasons = map[string]CancelReason{}
+			}
+			tests.Execute(reasons).Equal(t, tc.reasons)
+		})
+	}
+}
+
+func TestGraph_Walk_Join(t *testing.T) {
+	tcs := map[string]struct {
+		readiness Readiness
+		completed []string
+		statuses  map[string]Status
+	}{
+		"timeout": {
+			readiness: Join(20 * time.Millisecond),
+			completed: []string{"a", "b"},
+			statuses:  map[string]Status{"a": StatusCompleted, "b": StatusCompleted, "straggler": StatusCompleted, "aggregate": StatusCompleted},
+		},
+		"cancel": {
+			readiness: Readiness{Timeout: 20 * time.Millisecond, Cancel: true},
+			completed: []string{"a", "b"},
+			statuses:  map[string]Status{"a": StatusCompleted, "b": StatusCompleted, "straggler": StatusCancelled, "aggregate": StatusCompleted},
+		},
+		"all": {
+			readiness: Join(time.Minute),
+			completed: []string{"a", "b", "straggler"},
+			statuses:  map[string]Status{"a": StatusCompleted, "b": StatusCompleted, "straggler": StatusCompleted, "aggregate": StatusCompleted},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			release := make(chan struct{})
+			var completed []string
+
+			g := NewGraph()
+			g.AddNode("a", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			g.AddNode("b", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			g.AddNode("straggler", Executable(func(ctx context.Context) error {
+				if tc.readiness.Timeout == time.Minute {
+					return nil // this one isn't late.
+				}
+				select {
+				case <-release:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}))
+			g.AddNodeWithMeta("aggregate", Executable(func(ctx context.Context) error {
+				completed = Handle(ctx).Completed()
+				close(release)
+				return nil
+			}), Meta{Readiness: tc.readiness})
+			for _, parent := range []string{"a", "b", "straggler"} {
+				g.Connect(parent, "aggregate")
+			}
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 4, Verify: true})
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(completed).Equal(t, tc.completed)
+
+			statuses := make(map[string]Status)
+			for key, node := range result.Nodes {
+				statuses[key] = node.Status
+			}
+			tests.Execute(statuses).Equal(t, tc.statuses)
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package graph
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Ref refers to a node in another graph registered in a Registry, by the name of the graph and the key of the node.
+type Ref struct {
+	Graph string
+	Key   string
+}
+
+// String returns the key of the node that stands in for the referenced node in the graph referring to it.
+func (ref Ref) String() string {
+	return ref.Graph + "#" + ref.Key
+}
+
+// ReferenceResolver resolves references to nodes in other graphs, see Graph.ConnectRef.
+type ReferenceResolver interface {
+	// Wait blocks until the referenced node has completed, and returns an error if it failed or never will complete.
+	Wait(ctx context.Context, ref Ref) error
+}
+
+// refNode stands in for a node in another graph, it completes once the node it refers to does.
+type refNode struct {
+	ref Ref
+}
+
+func (node *refNode) Execute(ctx context.Context) error {
+	exec := executionFrom(ctx)
+	if exec == nil || exec.references == nil {
+		err := errors.Newf(nil, UnresolvedRef, "no resolver for reference to node %q in graph %q", node.ref.Key, node.ref.Graph)
+		return errors.Embed(err, GraphName, node.ref.Graph)
+	}
+	return exec.references.Wait(ctx, node.ref)
+}
+
+// ConnectRef adds an edge from a node in another graph, so that to only runs once the referenced node has completed in
+// whichever walk is running the other graph. This lets giant graphs be broken up into several smaller ones.
+//
+// The referenced node is stood in for by a node keyed by Ref.String, which waits on Opts.References when the walk
+// reaches it. ConnectRef returns an error with the MissingNode code if to doesn't exist.
+func (g Graph) ConnectRef(ref Ref, to string) error {
+	if _, ok := g.nodes[to]; !ok {
+		err := errors.Newf(nil, MissingNode, "node %q does not exist", to)
+		return errors.Embed(err, NodeKey, to)
+	}
+
+	key := ref.String()
+	if _, ok := g.nodes[key]; !ok {
+		if err := g.AddNode(key, &refNode{ref: ref}); err != nil {
+			return err
+		}
+	}
+	return g.Connect(key, to)
+}
+
+var _ ReferenceResolver = (*RegistryRunner)(nil)
+
+// RegistryRunner walks graphs from a Registry by name, and resolves the references between them. A walk waiting on a
+// reference blocks until the referenced graph has been run and the node has completed, so graphs referring to each other
+// can be run at the same time in any order.
+type RegistryRunner struct {
+	registry *Registry
+
+	mutex sync.Mutex
+
+	// signals tracks every referenced node by graph name and key. They are created by whichever comes first, the node
+	// finishing or a walk waiting for it.
+	signals map[string]map[string]*signal
+
+	// running counts the walks in progress by graph name.
+	running map[string]int
+}
+
+// NewRegistryRunner returns a RegistryRunner for the graphs in the given registry.
+func NewRegistryRunner(registry *Registry) *RegistryRunner {
+	return &RegistryRunner{
+		registry: registry,
+		signals:  make(map[string]map[string]*signal),
+		running:  make(map[string]int),
+	}
+}
+
+// signal returns the signal for the given node, creating it if it doesn't exist yet. If fresh is true, a signal that
+// already finished with an error is replaced, because the graph is being run again.
+func (runner *RegistryRunner) signal(name string, key string, fresh bool) *signal {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+
+	signals, ok := runner.signals[name]
+	if !ok {
+		signals = make(map[string]*signal)
+		runner.signals[name] = signals
+	}
+
+	existing, ok := signals[key]
+	if ok && fresh {
+		select {
+		case <-existing.done:
+			if existing.err != nil {
+				ok = false
+			}
+		default:
+		}
+	}
+	if !ok {
+		existing = &signal{done: make(chan struct{})}
+		signals[key] = existing
+	}
+	return existing
+}
+
+// Wait implements ReferenceResolver.
+func (runner *RegistryRunner) Wait(ctx context.Context, ref Ref) error {
+	signal := runner.signal(ref.Graph, ref.Key, false)
+	select {
+	case <-signal.done:
+		return signal.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run builds the latest version of the named graph with the given parameters and walks it, exactly like Graph.Run.
+// Opts.References is set to the runner, so the graph can refer to nodes in other graphs run by it.
+func (runner *RegistryRunner) Run(ctx context.Context, name string, params map[string]string, opts *Opts) (*WalkResult, error) {
+	g, err := runner.registry.Instantiate(name, params)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := Opts{Parallelism: 1}
+	if opts != nil {
+		copied = *opts
+	}
+	copied.References = runner
+
+	runner.mutex.Lock()
+	runner.running[name]++
+	runner.mutex.Unlock()
+
+	// started holds the signals of the nodes this walk started, which are the only ones it may finish while other walks
+	// of the same graph are still running.
+	started := make(map[string]*signal)
+
+	bus := NewBus(SinkFunc(func(event Event) {
+		switch event.Type {
+		case EventNodeStarted:
+			started[event.Key] = runner.signal(name, event.Key, true)
+		case EventNodeCompleted:
+			runner.signal(name, event.Key, false).finish(nil)
+		case EventNodeErrored:
+			err := errors.Newf(event.Err, UpstreamFailed, "node %q failed in graph %q", event.Key, name)
+			runner.signal(name, event.Key, false).finish(errors.Embed(err, GraphName, name))
+		case EventNodeCancelled:
+			err := errors.Newf(nil, Cancelled, "node %q was cancelled in graph %q", event.Key, name)
+			runner.signal(name, event.Key, false).finish(errors.Embed(err, GraphName, name))
+		}
+	}))
+	if copied.Bus != nil {
+		bus.Subscribe(copied.Bus)
+	}
+	copied.Bus = bus
+
+	result, err := g.Run(ctx, &copied)
+	runner.finish(name, started)
+	return result, err
+}
+
+// finish fails the signals of the nodes the walk started but never finished. Anything that no walk started, because it
+// was skipped for example, is failed once the last walk of the graph has finished so other walks aren't left waiting
+// forever.
+func (runner *RegistryRunner) finish(name string, started map[string]*signal) {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+
+	signals := started
+	if runner.running[name]--; runner.running[name] == 0 {
+		delete(runner.running, name)
+		signals = runner.signals[name]
+	}
+
+	for key, signal := range signals {
+		err := errors.Newf(nil, UpstreamFailed, "node %q did not complete in graph %q", key, name)
+		signal.finish(errors.Embed(err, GraphName, name))
+	}
+}
+
+// Combined builds the latest version of every registered graph, with the parameters given for its name, and combines
+// them into a single graph so the dependencies between pipelines can be visualized and checked as a whole.
+//
+// The keys of every graph are prefixed with its name, see WithPrefix, and the nodes standing in for references are
+// replaced by edges from the nodes they refer to. Combined returns an error with the UnknownGraph or MissingNode code if
+// a reference can't be resolved, and an error with the CycleDetected code if the graphs depend on each other in a cycle.
+func (registry *Registry) Combined(params map[string]map[string]string) (Graph, error) {
+	combined := NewGraph()
+	refs := make(map[string]Ref)
+	for _, name := range registry.Names() {
+		g, err := registry.Instantiate(name, params[name])
+		if err != nil {
+			return Graph{}, err
+		}
+
+		prefixed := g.WithPrefix(name)
+		for key, node := range prefixed.nodes {
+			if ref, ok := node.impl.(*refNode); ok {
+				refs[key] = ref.ref
+			}
+			combined.nodes[key] = node
+		}
+		maps.Copy(combined.outputs, prefixed.outputs)
+		maps.Copy(combined.inputs, prefixed.inputs)
+	}
+
+	// Only now that every graph is in place can the references be swapped for the nodes they refer to.
+	for key, ref := range refs {
+		if _, ok := registry.Latest(ref.Graph); !ok {
+			err := errors.Newf(nil, UnknownGraph, "graph %q is not registered", ref.Graph)
+			return Graph{}, errors.Embed(err, GraphName, ref.Graph)
+		}
+
+		target := JoinKey(ref.Graph, ref.Key)
+		if _, ok := combined.nodes[target]; !ok {
+			err := errors.Newf(nil, MissingNode, "graph %q has no node %q", ref.Graph, ref.Key)
+			return Graph{}, errors.Embed(errors.Embed(err, GraphName, ref.Graph), NodeKey, ref.Key)
+		}
+
+		for _, child := range combined.nodes[key].children {
+			parents := combined.nodes[child].parents
+			combined.nodes[child].parents = slices.DeleteFunc(parents, func(parent string) bool {
+				return parent == key
+			})
+			combined.nodes[target].children = append(combined.nodes[target].children, child)
+			combined.nodes[child].parents = append(combined.nodes[child].parents, target)
+		}
+		delete(combined.nodes, key)
+	}
+
+	for key, node := range combined.nodes {
+		if len(node.parents) == 0 {
+			combined.starters[key] = true
+		}
+		if len(node.children) == 0 {
+			combined.finishers[key] = true
+		}
+	}
+
+	if _, err := combined.topologicalOrder(); err != nil {
+		return Graph{}, err
+	}
+	return combined, nil
+}
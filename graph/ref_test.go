@@ -0,0 +1,207 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestRegistryRunner(t *testing.T) {
+	var mutex sync.Mutex
+	var ran []string
+	record := func(key string, err error) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			ran = append(ran, key)
+			return err
+		})
+	}
+
+	tcs := map[string]struct {
+		err      error
+		expected string
+		ran      []string
+	}{
+		"completed": {
+			ran: []string{"network", "app"},
+		},
+		"failed": {
+			err:      errors.New("boom"),
+			expected: "infra#network: failed to execute node (node \"network\" failed in graph \"infra\" (failed to execute node (boom))); graph is incomplete",
+			ran:      []string{"network"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			ran = nil
+
+			registry := NewRegistry()
+			registry.Register("infra", "v1", func(params map[string]string) (Graph, error) {
+				g := NewGraph()
+				g.AddNode("network", record("network", tc.err))
+				return g, nil
+			})
+			registry.Register("app", "v1", func(params map[string]string) (Graph, error) {
+				g := NewGraph()
+				g.AddNode("app", record("app", nil))
+				return g, g.ConnectRef(Ref{Graph: "infra", Key: "network"}, "app")
+			})
+
+			runner := NewRegistryRunner(registry)
+
+			// The app is started first, and waits for the infrastructure to be run.
+			done := make(chan error)
+			go func() {
+				_, err := runner.Run(context.Background(), "app", nil, &Opts{Parallelism: 1})
+				done <- err
+			}()
+
+			_, _ = runner.Run(context.Background(), "infra", nil, &Opts{Parallelism: 1})
+			err := <-done
+			if len(tc.expected) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.expected)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+			tests.Execute(ran).Equal(t, tc.ran)
+		})
+	}
+}
+
+func TestGraph_ConnectRef_Unresolved(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("app", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	tests.ExecuteE(g.ConnectRef(Ref{Graph: "infra", Key: "network"}, "missing")).MatchesError(t, "node \"missing\" does not exist")
+	tests.ExecuteE(g.ConnectRef(Ref{Graph: "infra", Key: "network"}, "app")).NoError(t)
+
+	tests.ExecuteE(g.Walk(context.Background(), nil)).MatchesError(t, "infra#network: failed to execute node (no resolver for reference to node \"network\" in graph \"infra\"); graph is incomplete")
+}
+
+func TestRegistry_Combined(t *testing.T) {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	tcs := map[string]struct {
+		infra   *Ref
+		app     Ref
+		parents map[string][]string
+		err     string
+	}{
+		"combined": {
+			app: Ref{Graph: "infra", Key: "network"},
+			parents: map[string][]string{
+				"app/app":        {"infra/network"},
+				"infra/database": {"infra/network"},
+				"infra/network":  nil,
+			},
+		},
+		"cycle": {
+			infra: &Ref{Graph: "app", Key: "app"},
+			app:   Ref{Graph: "infra", Key: "database"},
+			err:   "found cycle in graph: app/app -> infra/network -> infra/database -> app/app",
+		},
+		"missing": {
+			app: Ref{Graph: "infra", Key: "cache"},
+			err: "graph \"infra\" has no node \"cache\"",
+		},
+		"unknown": {
+			app: Ref{Graph: "billing", Key: "ledger"},
+			err: "graph \"billing\" is not registered",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			registry := NewRegistry()
+			registry.Register("infra", "v1", func(params map[string]string) (Graph, error) {
+				g := NewGraph()
+				g.AddNode("network", noop)
+				g.AddNode("database", noop)
+				g.Connect("network", "database")
+				if tc.infra != nil {
+					return g, g.ConnectRef(*tc.infra, "network")
+				}
+				return g, nil
+			})
+			registry.Register("app", "v1", func(params map[string]string) (Graph, error) {
+				g := NewGraph()
+				g.AddNode("app", noop)
+				return g, g.ConnectRef(tc.app, "app")
+			})
+
+			combined, err := registry.Combined(nil)
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+				return
+			}
+			tests.ExecuteE(err).NoError(t)
+
+			parents := make(map[string][]string)
+			for key, node := range combined.nodes {
+				parents[key] = node.parents
+			}
+			tests.Execute(parents).Equal(t, tc.parents)
+			tests.Execute(combined.Starters()).Equal(t, []string{"infra/network"})
+		})
+	}
+}
+
+func TestRegistryRunner_Concurrent(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	registry := NewRegistry()
+	registry.Register("infra", "v1", func(params map[string]string) (Graph, error) {
+		g := NewGraph()
+		if params["network"] != "true" {
+			g.AddNode("dns", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			return g, nil
+		}
+		g.AddNode("network", Executable(func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		}))
+		return g, nil
+	})
+	registry.Register("app", "v1", func(params map[string]string) (Graph, error) {
+		g := NewGraph()
+		g.AddNode("app", Executable(func(ctx context.Context) error {
+			return nil
+		}))
+		return g, g.ConnectRef(Ref{Graph: "infra", Key: "network"}, "app")
+	})
+
+	runner := NewRegistryRunner(registry)
+
+	app := make(chan error)
+	go func() {
+		_, err := runner.Run(context.Background(), "app", nil, &Opts{Parallelism: 1})
+		app <- err
+	}()
+
+	infra := make(chan error)
+	go func() {
+		_, err := runner.Run(context.Background(), "infra", map[string]string{"network": "true"}, &Opts{Parallelism: 1})
+		infra <- err
+	}()
+	<-started
+
+	// Another walk of the same graph finishing doesn't fail the network, which is still running in the first walk.
+	_, err := runner.Run(context.Background(), "infra", nil, &Opts{Parallelism: 1})
+	tests.ExecuteE(err).NoError(t)
+
+	close(release)
+	tests.ExecuteE(<-infra).NoError(t)
+	tests.ExecuteE(<-app).NoError(t)
+}
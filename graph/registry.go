@@ -0,0 +1,124 @@
+package graph
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// BuildFunc builds a graph from a set of parameters.
+type BuildFunc func(params map[string]string) (Graph, error)
+
+// Registry holds named graph builders, so services orchestrating many pipeline shapes can manage them centrally.
+//
+// Each name can be registered multiple times with different versions. The most recently registered version is the
+// latest, and is used unless a specific version is requested.
+type Registry struct {
+	mutex sync.RWMutex
+
+	// graphs maps names to their registered versions, in registration order.
+	graphs map[string][]registration
+}
+
+type registration struct {
+	version string
+	build   BuildFunc
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		graphs: make(map[string][]registration),
+	}
+}
+
+// Register adds a new version of the named graph. Registering the same name and version twice is an error.
+func (registry *Registry) Register(name string, version string, build BuildFunc) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for _, existing := range registry.graphs[name] {
+		if existing.version == version {
+			err := errors.Newf(nil, DuplicateGraph, "graph %q already has a version %q", name, version)
+			return errors.Embed(errors.Embed(err, GraphName, name), GraphVersion, version)
+		}
+	}
+
+	registry.graphs[name] = append(registry.graphs[name], registration{
+		version: version,
+		build:   build,
+	})
+	return nil
+}
+
+// Names returns the names of all the registered graphs, sorted.
+func (registry *Registry) Names() []string {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	names := make([]string, 0, len(registry.graphs))
+	for name := range registry.graphs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Versions returns the registered versions of the named graph, in the order they were registered.
+func (registry *Registry) Versions(name string) []string {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	var versions []string
+	for _, registration := range registry.graphs[name] {
+		versions = append(versions, registration.version)
+	}
+	return versions
+}
+
+// Latest returns the latest version of the named graph.
+func (registry *Registry) Latest(name string) (string, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	registrations := registry.graphs[name]
+	if len(registrations) == 0 {
+		return "", false
+	}
+	return registrations[len(registrations)-1].version, true
+}
+
+// Instantiate builds the latest version of the named graph with the given parameters.
+func (registry *Registry) Instantiate(name string, params map[string]string) (Graph, error) {
+	version, ok := registry.Latest(name)
+	if !ok {
+		err := errors.Newf(nil, UnknownGraph, "graph %q is not registered", name)
+		return Graph{}, errors.Embed(err, GraphName, name)
+	}
+	return registry.InstantiateVersion(name, version, params)
+}
+
+// InstantiateVersion builds a specific version of the named graph with the given parameters.
+func (registry *Registry) InstantiateVersion(name string, version string, params map[string]string) (Graph, error) {
+	registry.mutex.RLock()
+	var build BuildFunc
+	for _, registration := range registry.graphs[name] {
+		if registration.version == version {
+			build = registration.build
+		}
+	}
+	registry.mutex.RUnlock()
+
+	if build == nil {
+		err := errors.Newf(nil, UnknownGraph, "graph %q has no version %q", name, version)
+		return Graph{}, errors.Embed(errors.Embed(err, GraphName, name), GraphVersion, version)
+	}
+
+	g, err := build(params)
+	if err != nil {
+		err = errors.Newf(err, FailedBuild, "failed to build graph %q", name)
+		return Graph{}, errors.Embed(errors.Embed(err, GraphName, name), GraphVersion, version)
+	}
+	return g, nil
+}
@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestRegistry(t *testing.T) {
+	build := func(suffix string) BuildFunc {
+		return func(params map[string]string) (Graph, error) {
+			g := NewGraph()
+			g.AddNode(params["region"]+suffix, Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			return g, nil
+		}
+	}
+
+	registry := NewRegistry()
+	tests.ExecuteE(registry.Register("deploy", "v1", build("-v1"))).NoError(t)
+	tests.ExecuteE(registry.Register("deploy", "v2", build("-v2"))).NoError(t)
+	tests.ExecuteE(registry.Register("deploy", "v2", build("-v2"))).MatchesError(t, "graph \"deploy\" already has a version \"v2\"")
+
+	tests.Execute(registry.Names()).Equal(t, []string{"deploy"})
+	tests.Execute(registry.Versions("deploy")).Equal(t, []string{"v1", "v2"})
+
+	g, err := registry.Instantiate("deploy", map[string]string{"region": "eu"})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(g.Starters()).Equal(t, []string{"eu-v2"})
+
+	g, err = registry.InstantiateVersion("deploy", "v1", map[string]string{"region": "us"})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(g.Starters()).Equal(t, []string{"us-v1"})
+
+	_, err = registry.Instantiate("missing", nil)
+	tests.ExecuteE(err).MatchesError(t, "graph \"missing\" is not registered")
+}
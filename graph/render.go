@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// RenderStyle chooses the characters Render draws the tree with.
+type RenderStyle int
+
+const (
+	// RenderUnicode draws the tree with box drawing characters.
+	RenderUnicode RenderStyle = iota
+
+	// RenderASCII draws the tree with plain ASCII characters, for terminals and logs that can't show anything else.
+	RenderASCII
+)
+
+// branches returns the prefixes a style draws the tree with: for a child with more siblings after it, for the last
+// child, and to continue the lines of each under their own children.
+func (style RenderStyle) branches() (branch, last, through, after string) {
+	if style == RenderASCII {
+		return "|-- ", "`-- ", "|   ", "    "
+	}
+	return "├── ", "└── ", "│   ", "    "
+}
+
+// String renders the graph as an indented tree, see Render.
+func (g Graph) String() string {
+	var builder strings.Builder
+	_ = g.Render(&builder, RenderUnicode)
+	return builder.String()
+}
+
+// Render writes the graph as an indented tree for quick inspection in a terminal, with every starter at the top level
+// and the children of each node below it. A node with more than one parent is drawn in full under the first of them
+// only, and is annotated with "(see above)" everywhere else. An edge back to a node that is already on the path from
+// the top is annotated with "(cycle)" instead of being followed. Nodes that can't be reached from a starter because
+// they are part of a cycle are drawn at the top level after the starters.
+func (g Graph) Render(writer io.Writer, style RenderStyle) error {
+	branch, last, through, after := style.branches()
+
+	var builder strings.Builder
+	drawn := make(map[string]bool, len(g.nodes))
+	path := make(map[string]bool)
+
+	var draw func(key string, prefix string)
+	draw = func(key string, prefix string) {
+		drawn[key] = true
+		path[key] = true
+		defer delete(path, key)
+
+		children := g.sortedChildren(key)
+		for ix, child := range children {
+			connector, indent := branch, through
+			if ix == len(children)-1 {
+				connector, indent = last, after
+			}
+
+			builder.WriteString(prefix + connector + child)
+			switch {
+			case path[child]:
+				builder.WriteString(" (cycle)\n")
+			case drawn[child]:
+				builder.WriteString(" (see above)\n")
+			default:
+				builder.WriteString("\n")
+				draw(child, prefix+indent)
+			}
+		}
+	}
+
+	roots := g.Starters()
+	sort.Strings(roots)
+	for _, key := range append(roots, g.sortedKeys()...) {
+		if drawn[key] {
+			continue
+		}
+		builder.WriteString(key + "\n")
+		draw(key, "")
+	}
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
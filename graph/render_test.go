@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Render(t *testing.T) {
+	tcs := map[string]struct {
+		nodes []string
+		edges [][2]string
+		style RenderStyle
+		want  string
+	}{
+		"chain": {
+			nodes: []string{"a", "b", "c"},
+			edges: [][2]string{{"a", "b"}, {"b", "c"}},
+			want: `a
+└── b
+    └── c
+`,
+		},
+		"diamond": {
+			nodes: []string{"a", "b", "c", "d", "e"},
+			edges: [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}, {"d", "e"}},
+			want: `a
+├── b
+│   └── d
+│       └── e
+└── c
+    └── d (see above)
+`,
+		},
+		"ascii": {
+			nodes: []string{"a", "b", "c", "d"},
+			edges: [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}},
+			style: RenderASCII,
+			want: `a
+|-- b
+|   ` + "`-- d" + `
+` + "`-- c" + `
+`,
+		},
+		"cycle": {
+			nodes: []string{"a", "b", "c", "x", "y"},
+			edges: [][2]string{{"a", "b"}, {"b", "c"}, {"c", "b"}, {"x", "y"}, {"y", "x"}},
+			want: `a
+└── b
+    └── c
+        └── b (cycle)
+x
+└── y
+    └── x (cycle)
+`,
+		},
+		"starters": {
+			nodes: []string{"b", "a", "c"},
+			edges: [][2]string{{"b", "c"}, {"a", "c"}},
+			want: `a
+└── c
+b
+└── c (see above)
+`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g := NewGraph()
+			for _, key := range tc.nodes {
+				g.AddNode(key, Executable(func(ctx context.Context) error {
+					return nil
+				}))
+			}
+			for _, edge := range tc.edges {
+				tests.ExecuteE(g.Connect(edge[0], edge[1])).NoError(t)
+			}
+
+			var builder strings.Builder
+			tests.ExecuteE(g.Render(&builder, tc.style)).NoError(t)
+			tests.Execute(builder.String()).Equal(t, tc.want)
+			if tc.style == RenderUnicode {
+				tests.Execute(g.String()).Equal(t, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package report
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+// dialect formats the commands a CI system reads from the output of a build.
+type dialect interface {
+	// failure returns the command reporting that the node failed with the given message.
+	failure(key string, message string) string
+
+	// warning and notice return the commands reporting a warning or a note about the node.
+	warning(key string, message string) string
+	notice(key string, message string) string
+
+	// open and close return the commands starting and ending a collapsible block of output.
+	open(name string) string
+	close(name string) string
+
+	// quote returns the output of a node with any commands it contains neutralised, so a node can't inject commands
+	// into the build just by printing them.
+	quote(output string) string
+}
+
+var _ graph.Sink = (*Annotator)(nil)
+
+// Annotator writes the commands that CI systems read from the output of a build, so tools built on the walker can report
+// failed nodes as build errors and fold the output of every node away.
+//
+// An Annotator is a graph.Sink: subscribe it to the bus of a walk to report every node that fails as soon as it does.
+// Once the walk has finished, Write adds the annotations of every node and its output, each in a block of its own.
+type Annotator struct {
+	mutex   sync.Mutex
+	writer  io.Writer
+	dialect dialect
+}
+
+// GitHubActions returns an Annotator that writes GitHub Actions workflow commands.
+func GitHubActions(writer io.Writer) *Annotator {
+	return &Annotator{writer: writer, dialect: githubActions{token: randomToken}}
+}
+
+// TeamCity returns an Annotator that writes TeamCity service messages.
+func TeamCity(writer io.Writer) *Annotator {
+	return &Annotator{writer: writer, dialect: teamCity{}}
+}
+
+// Handle implements graph.Sink.
+func (annotator *Annotator) Handle(event graph.Event) {
+	if event.Type != graph.EventNodeErrored {
+		return
+	}
+
+	annotator.mutex.Lock()
+	defer annotator.mutex.Unlock()
+	_, _ = io.WriteString(annotator.writer, annotator.dialect.failure(event.Key, fmt.Sprint(event.Err))+"\n")
+}
+
+// Write writes the annotations of every node in the walk as warnings and notices, followed by the output of every node
+// that wrote any, in order of their keys and each in a block named after the node. Any commands the nodes printed are
+// neutralised, so they are shown in the output rather than run by the CI system.
+func (annotator *Annotator) Write(result *graph.WalkResult) error {
+	annotator.mutex.Lock()
+	defer annotator.mutex.Unlock()
+
+	var builder strings.Builder
+	for _, key := range result.Keys() {
+		for _, annotation := range result.Nodes[key].Annotations {
+			switch annotation.Kind {
+			case graph.AnnotationWarning:
+				builder.WriteString(annotator.dialect.warning(key, annotation.Value) + "\n")
+			default:
+				builder.WriteString(annotator.dialect.notice(key, annotation.Name+": "+annotation.Value) + "\n")
+			}
+		}
+	}
+
+	for _, key := range result.Keys() {
+		node := result.Nodes[key]
+		if len(node.Stdout) == 0 && len(node.Stderr) == 0 {
+			continue
+		}
+
+		var output strings.Builder
+		for _, stream := range [][]byte{node.Stdout, node.Stderr} {
+			output.Write(stream)
+			if len(stream) > 0 && stream[len(stream)-1] != '\n' {
+				output.WriteString("\n")
+			}
+		}
+
+		builder.WriteString(annotator.dialect.open(key) + "\n")
+		builder.WriteString(annotator.dialect.quote(output.String()))
+		builder.WriteString(annotator.dialect.close(key) + "\n")
+	}
+
+	_, err := io.WriteString(annotator.writer, builder.String())
+	return err
+}
+
+// githubActions formats workflow commands, see
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions.
+type githubActions struct {
+	// token returns the token that stops and resumes the processing of commands around the output of a node.
+	token func() string
+}
+
+var (
+	// githubData escapes the data of a workflow command, and githubProperty escapes the values of its properties.
+	githubData     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func (githubActions) failure(key string, message string) string {
+	return fmt.Sprintf("::error title=%s::%s", githubProperty.Replace(key), githubData.Replace(message))
+}
+
+func (githubActions) warning(key string, message string) string {
+	return fmt.Sprintf("::warning title=%s::%s", githubProperty.Replace(key), githubData.Replace(message))
+}
+
+func (githubActions) notice(key string, message string) string {
+	return fmt.Sprintf("::notice title=%s::%s", githubProperty.Replace(key), githubData.Replace(message))
+}
+
+func (githubActions) open(name string) string {
+	return "::group::" + githubData.Replace(name)
+}
+
+func (githubActions) close(name string) string {
+	return "::endgroup::"
+}
+
+func (actions githubActions) quote(output string) string {
+	token := actions.token()
+	return "::stop-commands::" + token + "\n" + output + "::" + token + "::\n"
+}
+
+// randomToken returns a token that the output of a node can't guess.
+func randomToken() string {
+	var token [16]byte
+	_, _ = rand.Read(token[:])
+	return hex.EncodeToString(token[:])
+}
+
+// teamCity formats service messages, see https://www.jetbrains.com/help/teamcity/service-messages.html.
+type teamCity struct{}
+
+// teamCityValue escapes the values of service messages.
+var teamCityValue = strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]")
+
+// teamCityOutput breaks up service messages in the output of a node so TeamCity doesn't read them.
+var teamCityOutput = strings.NewReplacer("##teamcity[", "# #teamcity[")
+
+func (teamCity) failure(key string, message string) string {
+	return fmt.Sprintf("##teamcity[buildProblem description='%s']", teamCityValue.Replace(key+": "+message))
+}
+
+func (teamCity) warning(key string, message string) string {
+	return fmt.Sprintf("##teamcity[message text='%s' status='WARNING']", teamCityValue.Replace(key+": "+message))
+}
+
+func (teamCity) notice(key string, message string) string {
+	return fmt.Sprintf("##teamcity[message text='%s']", teamCityValue.Replace(key+": "+message))
+}
+
+func (teamCity) open(name string) string {
+	return fmt.Sprintf("##teamcity[blockOpened name='%s']", teamCityValue.Replace(name))
+}
+
+func (teamCity) close(name string) string {
+	return fmt.Sprintf("##teamcity[blockClosed name='%s']", teamCityValue.Replace(name))
+}
+
+func (teamCity) quote(output string) string {
+	return teamCityOutput.Replace(output)
+}
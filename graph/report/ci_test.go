@@ -0,0 +1,89 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+func TestAnnotator(t *testing.T) {
+	tcs := map[string]struct {
+		annotator func(writer io.Writer) *Annotator
+
+		// injected is a command the deploy node prints, which the CI system mustn't run.
+		injected string
+		want     string
+	}{
+		"github actions": {
+			annotator: func(writer io.Writer) *Annotator {
+				return &Annotator{writer: writer, dialect: githubActions{token: func() string { return "token" }}}
+			},
+			injected: "::error::injected",
+			want: "::error title=deploy%3Aeu::failed to execute node (100%25 down%0Aretry later)\n" +
+				"::notice title=build::version: 1.2.3\n" +
+				"::warning title=deploy%3Aeu::disk 90%25 full\n" +
+				"::group::build\n" +
+				"::stop-commands::token\n" +
+				"compiling\n" +
+				"::token::\n" +
+				"::endgroup::\n" +
+				"::group::deploy:eu\n" +
+				"::stop-commands::token\n" +
+				"deploying\n" +
+				"::error::injected\n" +
+				"warning\n" +
+				"::token::\n" +
+				"::endgroup::\n",
+		},
+		"teamcity": {
+			annotator: TeamCity,
+			injected:  "##teamcity[buildStatus status='SUCCESS']",
+			want: "##teamcity[buildProblem description='deploy:eu: failed to execute node (100% down|nretry later)']\n" +
+				"##teamcity[message text='build: version: 1.2.3']\n" +
+				"##teamcity[message text='deploy:eu: disk 90% full' status='WARNING']\n" +
+				"##teamcity[blockOpened name='build']\n" +
+				"compiling\n" +
+				"##teamcity[blockClosed name='build']\n" +
+				"##teamcity[blockOpened name='deploy:eu']\n" +
+				"deploying\n" +
+				"# #teamcity[buildStatus status='SUCCESS']\n" +
+				"warning\n" +
+				"##teamcity[blockClosed name='deploy:eu']\n",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g := graph.NewGraph()
+			g.AddNode("build", graph.Executable(func(ctx context.Context) error {
+				fmt.Fprintln(graph.Stdout(ctx), "compiling")
+				graph.Annotate(ctx, "version", "1.2.3")
+				return nil
+			}))
+			g.AddNode("deploy:eu", graph.Executable(func(ctx context.Context) error {
+				fmt.Fprintln(graph.Stdout(ctx), "deploying")
+				fmt.Fprint(graph.Stdout(ctx), tc.injected)
+				fmt.Fprint(graph.Stderr(ctx), "warning")
+				graph.AnnotateWarning(ctx, "disk 90% full")
+				return fmt.Errorf("100%% down\nretry later")
+			}))
+			g.AddNode("verify", graph.Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			g.Connect("build", "deploy:eu")
+			g.Connect("deploy:eu", "verify")
+
+			var builder strings.Builder
+			annotator := tc.annotator(&builder)
+			result, _ := g.Run(context.Background(), &graph.Opts{Parallelism: 1, Bus: graph.NewBus(annotator)})
+			tests.ExecuteE(annotator.Write(result)).NoError(t)
+			tests.Execute(builder.String()).Equal(t, tc.want)
+		})
+	}
+}
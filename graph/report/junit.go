@@ -0,0 +1,134 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+// junitSuites, junitSuite and junitCase are how JUnit writes a walk.
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name       string           `xml:"name,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitMessage    `xml:"failure,omitempty"`
+	Skipped    *junitMessage    `xml:"skipped,omitempty"`
+	Stdout     string           `xml:"system-out,omitempty"`
+	Stderr     string           `xml:"system-err,omitempty"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit writes the walk as a JUnit XML report, so CI systems can show it in their test views. The walk is a single test
+// suite named after the walk, and every node is a test case named after its key, with its namespace as the class name,
+// see graph.Namespace. Nodes that errored are failures, and nodes that never completed for any other reason are
+// skipped with their status as the message. The output of every node is attached to its test case, and its annotations
+// are its properties: notes by name, links by title and warnings named "warning". See graph.Annotate.
+func JUnit(writer io.Writer, result *graph.WalkResult) error {
+	suite := junitSuite{
+		Name: result.WalkID,
+		Time: seconds(result.Duration().Seconds()),
+	}
+
+	for _, key := range result.Keys() {
+		node := result.Nodes[key]
+
+		testcase := junitCase{
+			Name:      key,
+			ClassName: graph.Namespace(key),
+			Time:      seconds(node.Duration().Seconds()),
+			Stdout:    string(node.Stdout),
+			Stderr:    string(node.Stderr),
+		}
+		if len(testcase.ClassName) == 0 {
+			testcase.ClassName = result.WalkID
+		}
+		for _, annotation := range node.Annotations {
+			if testcase.Properties == nil {
+				testcase.Properties = &junitProperties{}
+			}
+
+			name := annotation.Name
+			if annotation.Kind == graph.AnnotationWarning {
+				name = string(graph.AnnotationWarning)
+			}
+			testcase.Properties.Properties = append(testcase.Properties.Properties, junitProperty{Name: name, Value: annotation.Value})
+		}
+
+		switch node.Status {
+		case graph.StatusCompleted:
+		case graph.StatusErrored:
+			suite.Failures++
+			testcase.Failure = &junitMessage{Message: first(node.Err), Text: fmt.Sprint(node.Err)}
+		default:
+			suite.Skipped++
+			message := string(node.Status)
+			if len(node.Reason) > 0 {
+				message = fmt.Sprintf("%s (%s)", node.Status, node.Reason)
+			}
+			testcase.Skipped = &junitMessage{Message: message}
+			if node.Err != nil {
+				testcase.Skipped.Text = node.Err.Error()
+			}
+		}
+
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testcase)
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "\t")
+	if err := encoder.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
+
+// seconds formats a duration in seconds the way JUnit reports expect it.
+func seconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+// first returns the first line of the error, or nothing if there isn't one.
+func first(err error) string {
+	if err == nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(err.Error(), "\n")
+	return line
+}
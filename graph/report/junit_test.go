@@ -0,0 +1,65 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+func TestJUnit(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	result := &graph.WalkResult{
+		WalkID:   "walk",
+		Started:  start,
+		Finished: start.Add(3 * time.Second),
+		Nodes: map[string]*graph.NodeResult{
+			"build": {
+				Status:   graph.StatusCompleted,
+				Started:  start,
+				Finished: start.Add(time.Second),
+				Stdout:   []byte("ok\n"),
+				Annotations: []graph.Annotation{
+					{Kind: graph.AnnotationNote, Name: "version", Value: "1.2.3"},
+					{Kind: graph.AnnotationWarning, Value: "slow"},
+				},
+			},
+			"deploy/eu": {
+				Status:   graph.StatusErrored,
+				Started:  start.Add(time.Second),
+				Finished: start.Add(2500 * time.Millisecond),
+				Err:      fmt.Errorf("exit status 1\n<details>"),
+			},
+			"deploy/us": {
+				Status: graph.StatusCancelled,
+				Reason: graph.CancelFailFast,
+			},
+		},
+	}
+
+	var builder strings.Builder
+	tests.ExecuteE(JUnit(&builder, result)).NoError(t)
+	tests.Execute(builder.String()).Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+	<testsuite name="walk" tests="3" failures="1" errors="0" skipped="1" time="3.000">
+		<testcase name="build" classname="walk" time="1.000">
+			<properties>
+				<property name="version" value="1.2.3"></property>
+				<property name="warning" value="slow"></property>
+			</properties>
+			<system-out>ok&#xA;</system-out>
+		</testcase>
+		<testcase name="deploy/eu" classname="deploy" time="1.500">
+			<failure message="exit status 1">exit status 1&#xA;&lt;details&gt;</failure>
+		</testcase>
+		<testcase name="deploy/us" classname="deploy" time="0.000">
+			<skipped message="cancelled (fail_fast)"></skipped>
+		</testcase>
+	</testsuite>
+</testsuites>
+`)
+}
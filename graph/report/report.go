@@ -0,0 +1,113 @@
+// Package report renders the results of walks as reports for CI systems, for example job summaries, pull request
+// comments or test reports.
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+// excerpt is how many characters of an error the summary table shows.
+const excerpt = 120
+
+// statuses lists every status in the order Markdown counts them.
+var statuses = []graph.Status{
+	graph.StatusCompleted,
+	graph.StatusErrored,
+	graph.StatusSkipped,
+	graph.StatusPruned,
+	graph.StatusCancelled,
+	graph.StatusHeld,
+	graph.StatusRunning,
+	graph.StatusPending,
+}
+
+// Markdown returns a summary of the walk as GitHub flavoured Markdown: a line counting the nodes by status and the
+// warnings they raised, followed by a table listing the status, duration, attempts and the first line of the error of
+// every node, in order of their keys. The annotations of the nodes are listed after the table, see graph.Annotate.
+func Markdown(result *graph.WalkResult) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "### Walk %s\n\n", result.WalkID)
+
+	var counts []string
+	for _, status := range statuses {
+		if keys := result.Status(status); len(keys) > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", len(keys), status))
+		}
+	}
+	if len(counts) == 0 {
+		counts = append(counts, "no nodes")
+	}
+	switch result.Warnings {
+	case 0:
+	case 1:
+		counts = append(counts, "1 warning")
+	default:
+		counts = append(counts, fmt.Sprintf("%d warnings", result.Warnings))
+	}
+	fmt.Fprintf(&builder, "%s in %s.\n\n", strings.Join(counts, ", "), result.Duration().Round(time.Millisecond))
+
+	if len(result.Nodes) == 0 {
+		return builder.String()
+	}
+
+	builder.WriteString("| Node | Status | Duration | Attempts | Error |\n")
+	builder.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, key := range result.Keys() {
+		node := result.Nodes[key]
+
+		duration := "-"
+		if node.Duration() > 0 {
+			duration = node.Duration().Round(time.Millisecond).String()
+		}
+
+		attempts := "-"
+		if node.Attempts > 0 {
+			attempts = fmt.Sprint(node.Attempts)
+		}
+
+		var message string
+		if node.Err != nil {
+			message = "`" + cell(node.Err.Error()) + "`"
+		}
+
+		fmt.Fprintf(&builder, "| `%s` | %s | %s | %s | %s |\n", cell(key), node.Status, duration, attempts, message)
+	}
+
+	annotated := false
+	for _, key := range result.Keys() {
+		for _, annotation := range result.Nodes[key].Annotations {
+			if !annotated {
+				builder.WriteString("\n#### Annotations\n\n")
+				annotated = true
+			}
+
+			switch annotation.Kind {
+			case graph.AnnotationLink:
+				fmt.Fprintf(&builder, "- `%s`: [%s](%s)\n", cell(key), annotation.Name, annotation.Value)
+			case graph.AnnotationWarning:
+				fmt.Fprintf(&builder, "- `%s`: **warning** %s\n", cell(key), annotation.Value)
+			default:
+				fmt.Fprintf(&builder, "- `%s`: %s = %s\n", cell(key), annotation.Name, annotation.Value)
+			}
+		}
+	}
+	return builder.String()
+}
+
+// cell makes the text fit in a single cell of a table, by keeping only the start of its first line and escaping the
+// characters that would end the cell or its code span.
+func cell(text string) string {
+	text, _, truncated := strings.Cut(text, "\n")
+	if runes := []rune(text); len(runes) > excerpt {
+		text, truncated = string(runes[:excerpt]), true
+	}
+	text = strings.NewReplacer("|", "\\|", "`", "'").Replace(text)
+	if truncated {
+		text += "…"
+	}
+	return text
+}
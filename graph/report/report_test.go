@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+func TestMarkdown(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tcs := map[string]struct {
+		result *graph.WalkResult
+		want   string
+	}{
+		"empty": {
+			result: &graph.WalkResult{WalkID: "walk", Started: start, Finished: start},
+			want:   "### Walk walk\n\nno nodes in 0s.\n\n",
+		},
+		"nodes": {
+			result: &graph.WalkResult{
+				WalkID:   "walk",
+				Started:  start,
+				Finished: start.Add(3 * time.Second),
+				Warnings: 1,
+				Nodes: map[string]*graph.NodeResult{
+					"build": {
+						Status:   graph.StatusCompleted,
+						Started:  start,
+						Finished: start.Add(time.Second),
+						Attempts: 1,
+						Annotations: []graph.Annotation{
+							{Kind: graph.AnnotationNote, Name: "version", Value: "1.2.3"},
+							{Kind: graph.AnnotationLink, Name: "logs", Value: "https://example.com/build"},
+						},
+					},
+					"test": {
+						Status:   graph.StatusErrored,
+						Started:  start.Add(time.Second),
+						Finished: start.Add(2500 * time.Millisecond),
+						Attempts: 3,
+						Err:      fmt.Errorf("exit status 1 | `go test`\nmore output"),
+						Annotations: []graph.Annotation{
+							{Kind: graph.AnnotationWarning, Value: "flaky"},
+						},
+					},
+					"deploy": {
+						Status: graph.StatusSkipped,
+						Err:    fmt.Errorf("%s", strings.Repeat("x", 130)),
+					},
+				},
+			},
+			want: "### Walk walk\n\n" +
+				"1 completed, 1 errored, 1 skipped, 1 warning in 3s.\n\n" +
+				"| Node | Status | Duration | Attempts | Error |\n" +
+				"| --- | --- | --- | --- | --- |\n" +
+				"| `build` | completed | 1s | 1 |  |\n" +
+				"| `deploy` | skipped | - | - | `" + strings.Repeat("x", 120) + "…` |\n" +
+				"| `test` | errored | 1.5s | 3 | `exit status 1 \\| 'go test'…` |\n" +
+				"\n#### Annotations\n\n" +
+				"- `build`: version = 1.2.3\n" +
+				"- `build`: [logs](https://example.com/build)\n" +
+				"- `test`: **warning** flaky\n",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			tests.Execute(Markdown(tc.result)).Equal(t, tc.want)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/pasataleo/go-errors/errors"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+// sarifLog and the types below are the parts of SARIF 2.1.0 that SARIF writes, see
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// SARIF writes the errors returned by graph.Graph.Validate as a SARIF log, so problems with a graph can be shown in code
+// scanning tools. Every error is reported as a result whose rule is its error code, located at the node it is about if
+// it names one. Several errors appended together with errors.Append are reported as separate results.
+//
+// The tool is reported under the given name, which should name whatever built the graph.
+func SARIF(writer io.Writer, tool string, err error) error {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: tool, Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]bool)
+	for _, err := range errors.Expand(err) {
+		code := string(errors.GetErrorCode(err))
+		rules[code] = true
+
+		result := sarifResult{
+			RuleID:  code,
+			Level:   "error",
+			Message: sarifMessage{Text: err.Error()},
+		}
+		if key, ok := errors.GetEmbeddedData[string](err, graph.NodeKey); ok {
+			result.Locations = []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{Name: key, Kind: "node"}},
+			}}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	for rule := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: rule})
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+func TestSARIF(t *testing.T) {
+	noop := graph.Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	cyclic := graph.NewGraph()
+	cyclic.AddNode("a", noop)
+	cyclic.AddNode("b", noop)
+	cyclic.Connect("a", "b")
+	cyclic.Connect("b", "a")
+
+	tcs := map[string]struct {
+		err       error
+		rules     []string
+		results   []string
+		locations []string
+	}{
+		"valid": {},
+		"cycle": {
+			err:       cyclic.Validate(),
+			rules:     []string{"graph.cycle_detected"},
+			results:   []string{"found cycle in graph: a -> b -> a"},
+			locations: []string{"a"},
+		},
+		"several": {
+			err: errors.Append(
+				errors.New(nil, graph.MissingNode, "node \"x\" does not exist"),
+				cyclic.Validate(),
+			),
+			rules:     []string{"graph.cycle_detected", "graph.missing_node"},
+			results:   []string{"node \"x\" does not exist", "found cycle in graph: a -> b -> a"},
+			locations: []string{"", "a"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var builder strings.Builder
+			tests.ExecuteE(SARIF(&builder, "deployer", tc.err)).NoError(t)
+
+			var log struct {
+				Version string
+				Runs    []struct {
+					Tool struct {
+						Driver struct {
+							Name  string
+							Rules []struct{ ID string }
+						}
+					}
+					Results []struct {
+						RuleID    string
+						Message   struct{ Text string }
+						Locations []struct {
+							LogicalLocations []struct{ Name string }
+						}
+					}
+				}
+			}
+			tests.ExecuteE(json.Unmarshal([]byte(builder.String()), &log)).NoError(t)
+			tests.Execute(log.Version).Equal(t, "2.1.0")
+			tests.Execute(log.Runs[0].Tool.Driver.Name).Equal(t, "deployer")
+
+			var rules, results, locations []string
+			for _, rule := range log.Runs[0].Tool.Driver.Rules {
+				rules = append(rules, rule.ID)
+			}
+			for _, result := range log.Runs[0].Results {
+				results = append(results, result.Message.Text)
+
+				var location string
+				if len(result.Locations) > 0 {
+					location = result.Locations[0].LogicalLocations[0].Name
+				}
+				locations = append(locations, location)
+			}
+			tests.Execute(rules).Equal(t, tc.rules)
+			tests.Execute(results).Equal(t, tc.results)
+			tests.Execute(locations).Equal(t, tc.locations)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// DependencyResolver is implemented by nodes whose parents are only known at runtime, for example because they are
+// discovered by parsing files. Dependencies is called just before the walk starts (or just after the subgraph
+// containing the node is expanded) and each returned key is connected as a parent of the node.
+//
+// DependencyResolver is implemented in addition to ExecutableNode or ExpandableNode.
+type DependencyResolver interface {
+	Dependencies(ctx context.Context) ([]string, error)
+}
+
+// resolveDependencies returns a copy of the graph with the edges returned by every DependencyResolver connected. The
+// graph itself is returned unchanged if no node implements DependencyResolver.
+func (g Graph) resolveDependencies(ctx context.Context) (Graph, error) {
+	var keys []string
+	for key, node := range g.nodes {
+		if _, ok := node.impl.(DependencyResolver); ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return g, nil
+	}
+	sort.Strings(keys)
+
+	resolved := g.Clone()
+	for _, key := range keys {
+		dependencies, err := g.nodes[key].impl.(DependencyResolver).Dependencies(ctx)
+		if err != nil {
+			return Graph{}, errors.Embed(errors.New(err, FailedNode, "failed to resolve dependencies"), NodeKey, key)
+		}
+
+		for _, dependency := range dependencies {
+			if _, ok := resolved.nodes[dependency]; !ok {
+				err := errors.Newf(nil, MissingNode, "node %q depends on node %q, which does not exist", key, dependency)
+				return Graph{}, errors.Embed(err, NodeKey, key)
+			}
+
+			if resolved.connected(dependency, key) {
+				continue
+			}
+			if err := resolved.Connect(dependency, key); err != nil {
+				return Graph{}, err
+			}
+		}
+	}
+
+	if err := resolved.Validate(); err != nil {
+		return Graph{}, err
+	}
+	return resolved, nil
+}
@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+type resolvingNode struct {
+	ExecutableNode
+	dependencies []string
+}
+
+func (node resolvingNode) Dependencies(ctx context.Context) ([]string, error) {
+	return node.dependencies, nil
+}
+
+func TestGraph_Walk_DependencyResolver(t *testing.T) {
+	var builder strings.Builder
+	write := func(value string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			builder.WriteString(value)
+			return nil
+		})
+	}
+
+	g := NewGraph()
+	g.AddNode("c", resolvingNode{ExecutableNode: write("c"), dependencies: []string{"b"}})
+	g.AddNode("b", resolvingNode{ExecutableNode: write("b"), dependencies: []string{"a"}})
+	g.AddNode("a", write("a"))
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1})).NoError(t)
+	tests.Execute(builder.String()).Equal(t, "abc")
+
+	// The original graph must not have been modified.
+	tests.Execute(len(g.Starters())).Equal(t, 3)
+}
+
+func TestGraph_Walk_DependencyResolver_Error(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", resolvingNode{ExecutableNode: Executable(func(ctx context.Context) error {
+		return nil
+	}), dependencies: []string{"missing"}})
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 1})).
+		MatchesError(t, "node \"a\" depends on node \"missing\", which does not exist")
+}
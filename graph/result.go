@@ -0,0 +1,190 @@
+package graph
+
+import (
+	"sort"
+	"time"
+)
+
+// Status describes the state of a node within a walk.
+type Status string
+
+const (
+	// StatusPending means the node was never dispatched.
+	StatusPending Status = "pending"
+
+	// StatusRunning means the node has been dispatched, but hasn't finished yet.
+	StatusRunning Status = "running"
+
+	// StatusHeld means the node is ready, but is waiting for its window to open or for room in one of its quotas. See
+	// Window and Quota.
+	StatusHeld Status = "held"
+
+	// StatusCompleted means the node, and any subgraph it expanded into, completed successfully.
+	StatusCompleted Status = "completed"
+
+	// StatusErrored means the node returned an error.
+	StatusErrored Status = "errored"
+
+	// StatusSkipped means the node was never dispatched because a node it depends on failed. The error of the node
+	// describes the chain of nodes from the failed one.
+	//
+	// Nodes skipped because they were outside their window, their circuit was open or a debugger skipped them are skipped
+	// as well, but the nodes that depend on them still run. See WindowSkip, CircuitBreaker and Debugger.
+	StatusSkipped Status = "skipped"
+
+	// StatusPruned means the node was optional, and was never dispatched because the walk wouldn't have finished before
+	// its deadline otherwise. Nodes that depend on a pruned node still run, unless they are optional too.
+	StatusPruned Status = "pruned"
+
+	// StatusCancelled means the walk was cancelled before the node could finish, or before it was dispatched at all.
+	StatusCancelled Status = "cancelled"
+)
+
+// NodeResult describes what happened to a single node during a walk.
+type NodeResult struct {
+	// Key is the key of the node.
+	Key string
+
+	// Owner is who owns the node, see Opts.Route.
+	Owner string
+
+	// ExpandedBy is the key of the node that expanded into this node, it is empty for nodes that weren't added by an
+	// expansion.
+	ExpandedBy string
+
+	// Status is the final status of the node.
+	Status Status
+
+	// Err is the error returned by the node, if any. Nodes that were skipped, or expanded nodes that failed because
+	// their subgraph did, have an UpstreamFailed error with the chain of keys from the node that originally failed
+	// embedded under Ancestry. Nodes whose failure was swallowed by a ChildErrorHandler are completed, but keep their
+	// error here.
+	Err error
+
+	// Reason explains why the node was cancelled, it is only set if Status is StatusCancelled.
+	Reason CancelReason
+
+	// Winner is the key of the member that succeeded first, it is only set for groups. See Graph.AddGroup.
+	Winner string
+
+	// Ready, Started and Finished record when the node became ready to run, when a worker started running it and when
+	// it finished. They are zero if the node never got that far. The time between Ready and Started is the dispatch
+	// latency, which is spent waiting for a free worker.
+	Ready    time.Time
+	Started  time.Time
+	Finished time.Time
+
+	// Attempts is how many times the node was dispatched, which is more than once if it was retried. It is zero if the
+	// node was never dispatched.
+	Attempts int
+
+	// Cost is the cost the node reported through AddCost.
+	Cost float64
+
+	// Reused is true if the node didn't execute because a node with the same fingerprint had already completed, see
+	// Opts.Completions, or because it was resumed from a checkpoint, see Opts.Resume.
+	Reused bool
+
+	// Starved explains why the node waited longer than Opts.StarvationThreshold to be dispatched after it became ready,
+	// it is empty if it didn't.
+	Starved StarveReason
+
+	// Speculated is true if a speculative copy of the node was started because it ran for too long, see Speculation.
+	Speculated bool
+
+	// Stdout and Stderr contain everything the node wrote to the writers returned by Stdout and Stderr.
+	Stdout []byte
+	Stderr []byte
+
+	// Artifacts contains the names of the artifacts the node put into the artifact store.
+	Artifacts []string
+
+	// Annotations contains the notes, links and warnings the node attached to its result as it executed, in the order
+	// it added them. Only the annotations of the last attempt are kept. See Annotate.
+	Annotations []Annotation
+
+	// Warnings contains the warnings the node raised through Warn as it executed, in the order it raised them. Only the
+	// warnings of the last attempt are kept.
+	Warnings []error
+}
+
+// Duration returns how long the node took, or zero if it never finished.
+func (result *NodeResult) Duration() time.Duration {
+	if result.Started.IsZero() || result.Finished.IsZero() {
+		return 0
+	}
+	return result.Finished.Sub(result.Started)
+}
+
+// WalkResult describes what happened during a walk.
+type WalkResult struct {
+	// WalkID identifies the walk, and matches the id in any published events.
+	WalkID string
+
+	// Scheduler describes how the walker itself performed.
+	Scheduler SchedulerStats
+
+	// Started and Finished record when the walk started and finished.
+	Started  time.Time
+	Finished time.Time
+
+	// Cost is the total cost reported by the nodes through AddCost, see Opts.MaxCost.
+	Cost float64
+
+	// Warnings is the number of warnings raised by the nodes through Warn, see NodeResult.Warnings. A walk that succeeded
+	// with warnings completed every node, but something is worth looking at.
+	Warnings int
+
+	// Nodes contains the result of every node in the walk, including nodes added by expansion.
+	Nodes map[string]*NodeResult
+
+	// Graph is the graph as it was walked, with every subgraph merged in and every node added while the walk ran. Use
+	// NodeResult.ExpandedBy to find where nodes came from, and WriteDOT or WriteJSON to inspect it.
+	Graph Graph
+
+	// Artifacts is the artifact store the nodes wrote to, if one was configured.
+	Artifacts Artifacts
+
+	// Blackboard contains the values the nodes wrote, if a blackboard was configured.
+	Blackboard *Blackboard
+
+	// values contains the typed outputs the nodes set, so checkpoints can carry them to the walks resuming from them.
+	values *values
+}
+
+// Keys returns the keys of all the nodes in the result, sorted.
+func (result *WalkResult) Keys() []string {
+	keys := make([]string, 0, len(result.Nodes))
+	for key := range result.Nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Status returns the keys of all the nodes that finished with the given status, sorted.
+func (result *WalkResult) Status(status Status) []string {
+	var keys []string
+	for _, key := range result.Keys() {
+		if result.Nodes[key].Status == status {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// FailuresByOwner returns the keys of the nodes that errored, sorted and grouped by their owner. Nodes without an owner
+// are grouped under the empty string.
+func (result *WalkResult) FailuresByOwner() map[string][]string {
+	failures := make(map[string][]string)
+	for _, key := range result.Status(StatusErrored) {
+		owner := result.Nodes[key].Owner
+		failures[owner] = append(failures[owner], key)
+	}
+	return failures
+}
+
+// Duration returns how long the walk took.
+func (result *WalkResult) Duration() time.Duration {
+	return result.Finished.Sub(result.Started)
+}
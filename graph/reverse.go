@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// reversed returns a copy of the graph with the direction of every edge reversed, so children run before their
+// parents. See Opts.Reverse.
+//
+// Outputs and inputs are dropped, as a consumer can't run before the node producing its inputs.
+func (g Graph) reversed() Graph {
+	reversed := NewGraph()
+	for key, n := range g.nodes {
+		reversed.nodes[key] = &node{
+			key:      n.key,
+			impl:     n.impl,
+			meta:     n.meta,
+			parents:  append([]string(nil), n.children...),
+			children: append([]string(nil), n.parents...),
+		}
+	}
+	for key := range g.finishers {
+		reversed.starters[key] = true
+	}
+	for key := range g.starters {
+		reversed.finishers[key] = true
+	}
+	return reversed
+}
+
+// Inverse returns a new graph with the direction of every edge reversed, and the implementation of every node swapped
+// for the one returned by fn. It derives a teardown graph from the graph provisioning the same things: fn maps each
+// create step to its destroy step, and every node is destroyed before anything it depended on.
+//
+// fn is called with the key, implementation and metadata of every node, and the metadata is kept. The implementation is
+// nil for lazy nodes, as it isn't known until they run. Unlike Opts.Reverse, the subgraphs of expandable nodes returned
+// by fn are not reversed, so fn should return nodes that expand into teardown subgraphs directly.
+//
+// Inverse returns an error with the FailedNode code if fn fails, and the InvalidNode code if it returns something that
+// isn't a node.
+func (g Graph) Inverse(fn func(key string, impl interface{}, meta Meta) (interface{}, error)) (Graph, error) {
+	inverse := g.reversed()
+	for _, key := range inverse.sortedKeys() {
+		node := inverse.nodes[key]
+
+		impl := node.impl
+		if _, ok := impl.(*lazyNode); ok {
+			impl = nil
+		}
+
+		mapped, err := fn(key, impl, node.meta)
+		if err != nil {
+			return Graph{}, errors.Embed(errors.New(err, FailedNode, "failed to invert node"), NodeKey, key)
+		}
+
+		if _, err := newNode(key, mapped, node.meta); err != nil {
+			return Graph{}, err
+		}
+		node.impl = mapped
+	}
+	return inverse, nil
+}
@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Reverse(t *testing.T) {
+	tcs := map[string]struct {
+		reverse bool
+		roots   []string
+		ran     []string
+	}{
+		"forward": {
+			ran: []string{"network", "database", "app", "workers/1", "workers/2"},
+		},
+		"reverse": {
+			reverse: true,
+			ran:     []string{"app", "database", "workers/2", "workers/1", "network"},
+		},
+		"roots": {
+			reverse: true,
+			roots:   []string{"database"},
+			ran:     []string{"database", "network"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var ran []string
+			record := func(key string) ExecutableNode {
+				return Executable(func(ctx context.Context) error {
+					ran = append(ran, key)
+					return nil
+				})
+			}
+
+			g := NewGraph()
+			g.AddNode("network", record("network"))
+			g.AddNode("database", record("database"))
+			g.AddNode("app", record("app"))
+			g.AddNode("workers", Expandable(func(ctx context.Context) (Graph, error) {
+				subgraph := NewGraph()
+				subgraph.AddNode("workers/1", record("workers/1"))
+				subgraph.AddNode("workers/2", record("workers/2"))
+				return subgraph, subgraph.Connect("workers/1", "workers/2")
+			}))
+			g.Connect("network", "database")
+			g.Connect("database", "app")
+			g.Connect("network", "workers")
+
+			err := g.Walk(context.Background(), &Opts{Parallelism: 1, Reverse: tc.reverse, Roots: tc.roots})
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(ran).Equal(t, tc.ran)
+		})
+	}
+}
+
+func TestGraph_Inverse(t *testing.T) {
+	tcs := map[string]struct {
+		fail      string
+		ran       []string
+		err       string
+		errorCode errors.ErrorCode
+	}{
+		"inverse": {
+			ran: []string{"destroy app", "destroy database", "destroy network"},
+		},
+		"failed": {
+			fail:      "database",
+			err:       "failed to invert node (no destroy step)",
+			errorCode: FailedNode,
+		},
+		"invalid": {
+			fail:      "network",
+			err:       "node \"network\" does not implement ExecutableNode or ExpandableNode",
+			errorCode: InvalidNode,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var ran []string
+			create := Executable(func(ctx context.Context) error {
+				t.Error("expected no create step to execute")
+				return nil
+			})
+
+			g := NewGraph()
+			g.AddNodeWithMeta("network", create, Meta{Labels: map[string]string{"resource": "network"}})
+			g.AddNodeWithMeta("database", create, Meta{Labels: map[string]string{"resource": "database"}})
+			g.AddNodeWithMeta("app", create, Meta{Labels: map[string]string{"resource": "app"}})
+			g.Connect("network", "database")
+			g.Connect("database", "app")
+
+			inverse, err := g.Inverse(func(key string, impl interface{}, meta Meta) (interface{}, error) {
+				switch {
+				case key == tc.fail && key == "network":
+					return "not a node", nil
+				case key == tc.fail:
+					return nil, stderrors.New("no destroy step")
+				}
+				return Executable(func(ctx context.Context) error {
+					ran = append(ran, "destroy "+meta.Labels["resource"])
+					return nil
+				}), nil
+			})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+				tests.Execute(errors.GetErrorCode(err)).Equal(t, tc.errorCode)
+				return
+			}
+			tests.ExecuteE(err).NoError(t)
+
+			tests.ExecuteE(inverse.Walk(context.Background(), &Opts{Parallelism: 1})).NoError(t)
+			tests.Execute(ran).Equal(t, tc.ran)
+
+			// The graph being inverted is left as it was.
+			tests.Execute(g.Starters()).Equal(t, []string{"network"})
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"context"
+	"log/slog"
+)
+
+// RunWithRetries walks the graph, and walks it again up to n more times for as long as the walk fails, which suits
+// pipelines running in flaky environments. Every walk resumes from the nodes that completed in the walks before it, see
+// Opts.Resume, so only the nodes that failed or never ran are run again. The outputs set by the resumed nodes are
+// carried over, and so is the content of Opts.Blackboard and Opts.Artifacts as every walk shares them, so the nodes
+// that run again can read everything their ancestors produced. Walks that were cancelled aren't retried.
+//
+// The returned result merges the results of every walk: each node has the result of the last walk that ran it, with
+// the attempts of every walk added up, and the walk as a whole spans all of them. The returned error is the error of the
+// last walk.
+//
+// The options are used for every walk, except Opts.Stream and Opts.Debugger, which can only be used by a single walk and
+// so are only used by the first.
+func RunWithRetries(ctx context.Context, g Graph, opts *Opts, n int) (*WalkResult, error) {
+	if opts == nil {
+		opts = &Opts{Parallelism: 1}
+	}
+
+	// The options may be shared with other walks, so resume from the checkpoints on a copy.
+	copied := *opts
+	opts = &copied
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	var merged *WalkResult
+	for walk := 0; ; walk++ {
+		result, err := g.Run(ctx, opts)
+		if result == nil {
+			// The graph couldn't be walked at all, so there is nothing to merge or retry.
+			return merged, err
+		}
+		merged = mergeResults(merged, result)
+
+		if err == nil || walk >= n || ctx.Err() != nil || result.Outcome() == OutcomeAborted {
+			return merged, err
+		}
+
+		checkpoint := result.Checkpoint()
+		if resume := opts.Resume; resume != nil {
+			for key, version := range resume.Nodes {
+				if _, ok := checkpoint.Nodes[key]; ok {
+					continue
+				}
+				checkpoint.Nodes[key] = version
+				if resume.values != nil {
+					checkpoint.values.copy(resume.values, key)
+				}
+			}
+		}
+		opts.Resume, opts.Stream, opts.Debugger = &checkpoint, nil, nil
+
+		logger.Info("retrying walk",
+			slog.String("walk_id", result.WalkID),
+			slog.Int("retry", walk+1),
+			slog.Int("retries", n),
+			slog.String("error", err.Error()))
+	}
+}
+
+// mergeResults merges the result of a walk that retried an earlier one into the merged results of the walks before it.
+func mergeResults(merged *WalkResult, result *WalkResult) *WalkResult {
+	if merged == nil {
+		return result
+	}
+
+	nodes := make(map[string]*NodeResult, len(result.Nodes))
+	for key, node := range result.Nodes {
+		previous, ok := merged.Nodes[key]
+		if !ok {
+			nodes[key] = node
+			continue
+		}
+		if node.Reused && node.Attempts == 0 {
+			// The node was resumed from the walks before, so keep the result of the walk that ran it.
+			nodes[key] = previous
+			continue
+		}
+
+		node.Attempts += previous.Attempts
+		nodes[key] = node
+	}
+
+	result.Nodes = nodes
+	result.Started = merged.Started
+	result.Cost += merged.Cost
+	result.Warnings = 0
+	for _, node := range nodes {
+		result.Warnings += len(node.Warnings)
+	}
+	return result
+}
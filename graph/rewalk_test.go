@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestRunWithRetries(t *testing.T) {
+	tcs := map[string]struct {
+		retries  int
+		cancel   bool
+		err      string
+		retried  int
+		runs     map[string]int
+		attempts int
+	}{
+		"recovers": {
+			retries:  3,
+			retried:  2,
+			runs:     map[string]int{"a": 1, "b": 3, "c": 1, "d": 1},
+			attempts: 3,
+		},
+		"exhausted": {
+			retries:  1,
+			retried:  1,
+			err:      "b: failed to execute node (flaky); graph is incomplete",
+			runs:     map[string]int{"a": 1, "b": 2, "c": 1},
+			attempts: 2,
+		},
+		"cancelled": {
+			retries:  3,
+			cancel:   true,
+			err:      "b: failed to execute node (flaky); walk was cancelled (context) (context canceled)",
+			runs:     map[string]int{"a": 1, "b": 1},
+			attempts: 1,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mutex sync.Mutex
+			runs := make(map[string]int)
+			node := func(key string) ExecutableNode {
+				return Executable(func(ctx context.Context) error {
+					mutex.Lock()
+					runs[key]++
+					run := runs[key]
+					mutex.Unlock()
+
+					if key == "b" && run < 3 {
+						if tc.cancel {
+							cancel()
+						}
+						return fmt.Errorf("flaky")
+					}
+					return nil
+				})
+			}
+
+			g := NewGraph()
+			for _, key := range []string{"a", "b", "c", "d"} {
+				g.AddNode(key, node(key))
+			}
+			g.Connect("a", "b")
+			g.Connect("a", "c")
+			g.Connect("b", "d")
+
+			var logs bytes.Buffer
+			opts := &Opts{Parallelism: 1, Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+			result, err := RunWithRetries(ctx, g, opts, tc.retries)
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+
+			tests.Execute(strings.Count(logs.String(), "retrying walk")).Equal(t, tc.retried)
+			tests.Execute(opts.Resume == nil).Equal(t, true)
+			tests.Execute(runs).Equal(t, tc.runs)
+			tests.Execute(result.Nodes["b"].Attempts).Equal(t, tc.attempts)
+			tests.Execute(result.Nodes["a"].Reused).Equal(t, false)
+		})
+	}
+}
+
+func TestRunWithRetries_CarriesValues(t *testing.T) {
+	var (
+		output Output[string]
+		input  Input[string]
+		runs   = make(map[string]int)
+		read   string
+	)
+
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		runs["a"]++
+		if err := WriteValue(ctx, "x", "written"); err != nil {
+			return err
+		}
+		return output.Set(ctx, "set")
+	}))
+	g.AddNode("b", Executable(func(ctx context.Context) error {
+		runs["b"]++
+		if runs["b"] == 1 {
+			return fmt.Errorf("flaky")
+		}
+
+		value, err := input.Get(ctx)
+		if err != nil {
+			return err
+		}
+		written, err := ReadValueAs[string](ctx, "x")
+		if err != nil {
+			return err
+		}
+		read = value + " " + written
+		return nil
+	}))
+	output, err := NewOutput[string](g, "a", "v")
+	tests.ExecuteE(err).NoError(t)
+	input, err = Bind(g, "b", output)
+	tests.ExecuteE(err).NoError(t)
+
+	opts := &Opts{Parallelism: 1, Blackboard: NewBlackboard(), Logger: slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))}
+	result, err := RunWithRetries(context.Background(), g, opts, 1)
+	tests.ExecuteE(err).NoError(t)
+
+	// a only ran in the first walk, but b could still read what it produced when it ran again.
+	tests.Execute(runs).Equal(t, map[string]int{"a": 1, "b": 2})
+	tests.Execute(read).Equal(t, "set written")
+	tests.Execute(result.Nodes["a"].Reused).Equal(t, false)
+	tests.Execute(result.Nodes["b"].Status).Equal(t, StatusCompleted)
+}
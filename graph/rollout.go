@@ -0,0 +1,183 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// RolloutStage is a stage of a Rollout. By the end of the stage, the larger of Nodes and Fraction of the members of the
+// rollout have run.
+type RolloutStage struct {
+	Nodes    int
+	Fraction float64
+}
+
+// Canary returns the stages of a canary rollout, which runs a single member, then a tenth of them, then the rest.
+func Canary() []RolloutStage {
+	return []RolloutStage{{Nodes: 1}, {Fraction: 0.1}}
+}
+
+// Rollout describes how AddRollout runs its members.
+type Rollout struct {
+	// Stages are run one after the other, the members are all run in a final stage once they have.
+	//
+	// Defaults to Canary.
+	Stages []RolloutStage
+
+	// MaxFailures is how many members may fail before the rollout is aborted. The members that haven't run by the end
+	// of the stage that exceeded it never run.
+	//
+	// Defaults to zero, which aborts the rollout as soon as a stage finishes with a failure.
+	MaxFailures int
+
+	// Pause is called after every stage but the last, once it has finished without exceeding MaxFailures, with the
+	// number of stages that have finished so far and the keys of the members that have completed. The next stage only
+	// starts once it returns, so it can wait to see how the members are doing, and it aborts the rollout if it returns
+	// an error.
+	//
+	// Optional, every stage starts as soon as the previous one has finished if nil.
+	Pause func(ctx context.Context, stage int, completed []string) error
+}
+
+// rolloutGate runs between the stages of a rollout, and decides whether the rollout carries on. Its parents are every
+// member that has run so far, and the walker lets it run once they have all finished whether they failed or not.
+type rolloutGate struct {
+	rollout Rollout
+
+	// key is the key of the rollout, and stage is the number of the stage that has just finished. final is true for the
+	// gate after the last stage, which is keyed by the rollout itself.
+	key   string
+	stage int
+	final bool
+}
+
+func (gate *rolloutGate) Execute(ctx context.Context) error {
+	handle := Handle(ctx)
+
+	var failed []string
+	for _, key := range handle.Parents() {
+		if result, _ := handle.Parent(key); result.Status == StatusErrored {
+			failed = append(failed, key)
+		}
+	}
+	if len(failed) > gate.rollout.MaxFailures {
+		err := errors.Newf(nil, AbortedRollout, "rollout %q was aborted after %d of its members failed: %v", gate.key, len(failed), failed)
+		return errors.Embed(err, NodeKey, gate.key)
+	}
+
+	if gate.final || gate.rollout.Pause == nil {
+		return nil
+	}
+	return gate.rollout.Pause(ctx, gate.stage, handle.Completed())
+}
+
+// AddRollout adds a node that runs the given members in stages, so a change can be rolled out to a few of them and
+// checked before it reaches the rest. Each stage waits for the previous one to finish, and the rollout node completes
+// once the last stage has. Connect nodes to the rollout to run them once it has completed.
+//
+// The members must already be in the graph. Between stages the rollout checks how many members have failed, and if
+// it's more than Rollout.MaxFailures the rollout node fails and the members that haven't run never do. Members that
+// fail without aborting the rollout don't fail the walk. The stages are run by nodes keyed by JoinKey(key, "stage-N").
+//
+// AddRollout returns an error with the InvalidNode code if there are no members, and the MissingNode code if any of
+// them don't exist.
+func (g Graph) AddRollout(key string, rollout Rollout, members ...string) error {
+	if len(members) == 0 {
+		err := errors.Newf(nil, InvalidNode, "rollout %q has no members", key)
+		return errors.Embed(err, NodeKey, key)
+	}
+	for _, member := range members {
+		if _, ok := g.nodes[member]; !ok {
+			err := errors.Newf(nil, MissingNode, "node %q does not exist", member)
+			return errors.Embed(err, NodeKey, member)
+		}
+	}
+
+	if rollout.Stages == nil {
+		rollout.Stages = Canary()
+	}
+
+	// Work out where each stage ends, skipping stages that wouldn't run anything new.
+	var ends []int
+	for _, stage := range rollout.Stages {
+		end := max(stage.Nodes, int(math.Ceil(stage.Fraction*float64(len(members)))))
+		if end >= len(members) {
+			break
+		}
+		if len(ends) == 0 || end > ends[len(ends)-1] {
+			ends = append(ends, end)
+		}
+	}
+	ends = append(ends, len(members))
+
+	if err := g.AddNode(key, &rolloutGate{rollout: rollout, key: key, stage: len(ends), final: true}); err != nil {
+		return err
+	}
+
+	start := 0
+	previous := ""
+	for ix, end := range ends {
+		gate := key
+		if ix < len(ends)-1 {
+			gate = JoinKey(key, fmt.Sprintf("stage-%d", ix+1))
+			if err := g.AddNode(gate, &rolloutGate{rollout: rollout, key: key, stage: ix + 1}); err != nil {
+				return err
+			}
+		}
+
+		for _, member := range members[start:end] {
+			if len(previous) > 0 {
+				if err := g.Connect(previous, member); err != nil {
+					return err
+				}
+			}
+		}
+		// Every gate waits on every member that has run so far, so it can count all of the failures.
+		for _, member := range members[:end] {
+			if err := g.Connect(member, gate); err != nil {
+				return err
+			}
+		}
+		start, previous = end, gate
+	}
+	return nil
+}
+
+// gated returns the rollout gates waiting on the node, if it is a member of a rollout.
+func (walker *walker) gated(key string) []string {
+	var gates []string
+	for _, child := range walker.nodes[key].children {
+		if _, ok := walker.nodes[child].impl.(*rolloutGate); ok {
+			gates = append(gates, child)
+		}
+	}
+	return gates
+}
+
+// tolerated lets the rollout gates waiting on a member that failed run without it, as they decide whether the failure
+// aborts the rollout. It returns the gates that are now ready.
+func (walker *walker) tolerated(key string) []string {
+	var ready []string
+	for _, gate := range walker.gated(key) {
+		walker.remaining[gate]--
+		if walker.remaining[gate] == 0 {
+			walker.tracer.log(slog.LevelDebug, gate, "node ready", slog.String("reason", "member failed"), slog.String("parent", key))
+			ready = append(ready, gate)
+		}
+	}
+	return ready
+}
+
+// rolledOut returns true if the node is a member of a rollout that completed, so its failure was tolerated.
+func (walker *walker) rolledOut(key string) bool {
+	for _, gate := range walker.gated(key) {
+		if walker.nodes[gate].impl.(*rolloutGate).final && walker.completed.has(gate) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_AddRollout(t *testing.T) {
+	tcs := map[string]struct {
+		rollout Rollout
+		failing map[string]bool
+		stages  []string
+		ran     []string
+		err     string
+	}{
+		"canary": {
+			stages: []string{"1: [region-00]", "2: [region-00 region-01]"},
+			ran:    []string{"region-00", "region-01", "region-02", "region-03", "region-04", "region-05", "region-06", "region-07", "region-08", "region-09", "region-10", "region-11", "after"},
+		},
+		"stages": {
+			rollout: Rollout{Stages: []RolloutStage{{Nodes: 2}, {Nodes: 1}, {Fraction: 0.5}}},
+			stages:  []string{"1: [region-00 region-01]", "2: [region-00 region-01 region-02 region-03 region-04 region-05]"},
+			ran:     []string{"region-00", "region-01", "region-02", "region-03", "region-04", "region-05", "region-06", "region-07", "region-08", "region-09", "region-10", "region-11", "after"},
+		},
+		"aborted": {
+			failing: map[string]bool{"region-01": true},
+			stages:  []string{"1: [region-00]"},
+			ran:     []string{"region-00", "region-01"},
+			err:     "region-01: failed to execute node (failed); rollout/stage-2: failed to execute node (rollout \"rollout\" was aborted after 1 of its members failed: [region-01])",
+		},
+		"tolerated": {
+			rollout: Rollout{MaxFailures: 1},
+			failing: map[string]bool{"region-01": true},
+			stages:  []string{"1: [region-00]", "2: [region-00]"},
+			ran:     []string{"region-00", "region-01", "region-02", "region-03", "region-04", "region-05", "region-06", "region-07", "region-08", "region-09", "region-10", "region-11", "after"},
+		},
+		"paused": {
+			rollout: Rollout{Pause: func(ctx context.Context, stage int, completed []string) error {
+				if stage == 2 {
+					return fmt.Errorf("alerts firing")
+				}
+				return nil
+			}},
+			stages: []string{"1: [region-00]", "2: [region-00 region-01]"},
+			ran:    []string{"region-00", "region-01"},
+			err:    "rollout/stage-2: failed to execute node (alerts firing)",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var ran, stages []string
+
+			g := NewGraph()
+			var members []string
+			for ix := 0; ix < 12; ix++ {
+				key := fmt.Sprintf("region-%02d", ix)
+				g.AddNode(key, Executable(func(ctx context.Context) error {
+					mutex.Lock()
+					defer mutex.Unlock()
+					ran = append(ran, key)
+					if tc.failing[key] {
+						return fmt.Errorf("failed")
+					}
+					return nil
+				}))
+				members = append(members, key)
+			}
+			g.AddNode("after", Executable(func(ctx context.Context) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				ran = append(ran, "after")
+				return nil
+			}))
+
+			rollout := tc.rollout
+			pause := rollout.Pause
+			rollout.Pause = func(ctx context.Context, stage int, completed []string) error {
+				stages = append(stages, fmt.Sprintf("%d: %v", stage, completed))
+				if pause != nil {
+					return pause(ctx, stage, completed)
+				}
+				return nil
+			}
+			tests.ExecuteE(g.AddRollout("rollout", rollout, members...)).NoError(t)
+			g.Connect("rollout", "after")
+
+			_, err := g.Run(context.Background(), &Opts{Parallelism: 1, Verify: true, FailFast: true})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+			tests.Execute(stages).Equal(t, tc.stages)
+			tests.Execute(ran).Equal(t, tc.ran)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		g := NewGraph()
+		tests.ExecuteE(g.AddRollout("rollout", Rollout{})).MatchesError(t, "rollout \"rollout\" has no members")
+		tests.ExecuteE(g.AddRollout("rollout", Rollout{}, "missing")).MatchesError(t, "node \"missing\" does not exist")
+	})
+}
@@ -0,0 +1,108 @@
+package graph
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// fromRoots returns a copy of the graph containing only the given roots and their descendants, see Opts.Roots. The
+// graph itself is returned unchanged if there are no roots.
+func (g Graph) fromRoots(roots []string) (Graph, error) {
+	if len(roots) == 0 {
+		return g, nil
+	}
+
+	for _, root := range roots {
+		if _, ok := g.nodes[root]; !ok {
+			err := errors.Newf(nil, MissingNode, "root %q does not exist", root)
+			return Graph{}, errors.Embed(err, NodeKey, root)
+		}
+	}
+	walked := g.descendants(nil, roots...)
+
+	// Edges from nodes that aren't walked are dropped, as those nodes are assumed to have completed.
+	selected := NewGraph()
+	for key := range walked {
+		n := g.nodes[key]
+		clone := &node{
+			key:      n.key,
+			impl:     n.impl,
+			meta:     n.meta,
+			children: append([]string(nil), n.children...),
+		}
+		for _, parent := range n.parents {
+			if walked[parent] {
+				clone.parents = append(clone.parents, parent)
+			}
+		}
+		selected.nodes[key] = clone
+
+		if len(clone.parents) == 0 {
+			selected.starters[key] = true
+		}
+		if len(clone.children) == 0 {
+			selected.finishers[key] = true
+		}
+		if outputs, ok := g.outputs[key]; ok {
+			selected.outputs[key] = outputs
+		}
+		if inputs, ok := g.inputs[key]; ok {
+			selected.inputs[key] = inputs
+		}
+	}
+	return selected, nil
+}
+
+// descendants returns the given nodes along with every node that depends on them, directly or transitively. The nodes
+// in expansions depend on the node that expanded into them, see WalkResult.expansions.
+func (g Graph) descendants(expansions map[string][]string, keys ...string) map[string]bool {
+	walked := make(map[string]bool)
+	next := append([]string(nil), keys...)
+	for len(next) > 0 {
+		key := next[len(next)-1]
+		next = next[:len(next)-1]
+		if walked[key] {
+			continue
+		}
+		walked[key] = true
+		next = append(next, g.nodes[key].children...)
+		next = append(next, expansions[key]...)
+	}
+	return walked
+}
+
+// ReverseDependencies returns the keys of the nodes that depend on the given node, in sorted order. If transitive is
+// false only the nodes connected directly to it are returned, otherwise every node that can't run until it has
+// completed is.
+//
+// Every node keeps track of the nodes depending on it, so the lookup only visits the nodes it returns.
+//
+// ReverseDependencies returns an error with the MissingNode code if the node does not exist.
+func (g Graph) ReverseDependencies(key string, transitive bool) ([]string, error) {
+	return g.reverseDependencies(key, transitive, nil)
+}
+
+// ReverseDependencies returns the keys of the nodes that depend on the given node in the graph as it was walked,
+// exactly like Graph.ReverseDependencies. The nodes a node expanded into depend on it as well.
+func (result *WalkResult) ReverseDependencies(key string, transitive bool) ([]string, error) {
+	return result.Graph.reverseDependencies(key, transitive, result.expansions())
+}
+
+func (g Graph) reverseDependencies(key string, transitive bool, expansions map[string][]string) ([]string, error) {
+	n, ok := g.nodes[key]
+	if !ok {
+		err := errors.Newf(nil, MissingNode, "node %q does not exist", key)
+		return nil, errors.Embed(err, NodeKey, key)
+	}
+
+	direct := append(slices.Clone(n.children), expansions[key]...)
+	if !transitive {
+		slices.Sort(direct)
+		return slices.Compact(direct), nil
+	}
+
+	walked := g.descendants(expansions, direct...)
+	return slices.Sorted(maps.Keys(walked)), nil
+}
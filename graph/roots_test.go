@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Roots(t *testing.T) {
+	tcs := map[string]struct {
+		roots    []string
+		ran      []string
+		starters []string
+		err      string
+	}{
+		"none": {
+			ran:      []string{"a", "b", "c", "d", "e", "f"},
+			starters: []string{"a", "c", "e"},
+		},
+		"single": {
+			roots:    []string{"b"},
+			ran:      []string{"b", "d", "f"},
+			starters: []string{"b"},
+		},
+		"multiple": {
+			roots:    []string{"b", "c"},
+			ran:      []string{"b", "c", "d", "f"},
+			starters: []string{"b", "c"},
+		},
+		"descendant": {
+			roots:    []string{"a", "d"},
+			ran:      []string{"a", "b", "d", "f"},
+			starters: []string{"a"},
+		},
+		"missing": {
+			roots: []string{"x"},
+			err:   "root \"x\" does not exist",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var ran []string
+
+			// a -> b -> d, c -> d, b -> f, and e on its own.
+			g := NewGraph()
+			for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+				g.AddNode(key, Executable(func(ctx context.Context) error {
+					mutex.Lock()
+					defer mutex.Unlock()
+					ran = append(ran, key)
+					return nil
+				}))
+			}
+			g.Connect("a", "b")
+			g.Connect("b", "d")
+			g.Connect("c", "d")
+			g.Connect("b", "f")
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 2, Roots: tc.roots})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+				return
+			}
+			tests.ExecuteE(err).NoError(t)
+
+			sort.Strings(ran)
+			tests.Execute(ran).Equal(t, tc.ran)
+			tests.Execute(result.Status(StatusCompleted)).Equal(t, tc.ran)
+
+			starters := result.Graph.Starters()
+			sort.Strings(starters)
+			tests.Execute(starters).Equal(t, tc.starters)
+		})
+	}
+}
+
+func TestGraph_ReverseDependencies(t *testing.T) {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	// a -> b -> d, c -> d, b -> f, and e on its own.
+	g := NewGraph()
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		g.AddNode(key, noop)
+	}
+	g.Connect("a", "b")
+	g.Connect("b", "d")
+	g.Connect("c", "d")
+	g.Connect("b", "f")
+
+	tcs := map[string]struct {
+		key        string
+		transitive bool
+		expected   []string
+		err        string
+	}{
+		"direct":     {key: "a", expected: []string{"b"}},
+		"transitive": {key: "a", transitive: true, expected: []string{"b", "d", "f"}},
+		"leaf":       {key: "d", transitive: true},
+		"missing":    {key: "x", err: "node \"x\" does not exist"},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			dependents, err := g.ReverseDependencies(tc.key, tc.transitive)
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+				return
+			}
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(dependents).Equal(t, tc.expected)
+		})
+	}
+
+	t.Run("expanded", func(t *testing.T) {
+		g := NewGraph()
+		g.AddNode("a", noop)
+		g.AddNode("expand", Expandable(func(ctx context.Context) (Graph, error) {
+			subgraph := NewGraph()
+			subgraph.AddNode("x", noop)
+			subgraph.AddNode("y", noop)
+			subgraph.Connect("x", "y")
+			return subgraph, nil
+		}))
+		g.Connect("a", "expand")
+
+		result, err := g.Run(context.Background(), &Opts{Parallelism: 1})
+		tests.ExecuteE(err).NoError(t)
+
+		dependents, err := result.ReverseDependencies("a", true)
+		tests.ExecuteE(err).NoError(t)
+		tests.Execute(dependents).Equal(t, []string{"expand", "x", "y"})
+
+		dependents, err = result.ReverseDependencies("expand", false)
+		tests.ExecuteE(err).NoError(t)
+		tests.Execute(dependents).Equal(t, []string{"x", "y"})
+	})
+}
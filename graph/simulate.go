@@ -0,0 +1,165 @@
+package graph
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// SimulateOpts contains options for simulating a walk.
+type SimulateOpts struct {
+	// Parallelism is the number of workers to simulate.
+	//
+	// Defaults to 1.
+	Parallelism int
+
+	// Cost returns the estimated duration of a node.
+	//
+	// Defaults to the Estimate in the metadata of each node.
+	Cost func(key string, meta Meta) time.Duration
+}
+
+// SimulatedNode describes when a node ran during a simulated walk.
+type SimulatedNode struct {
+	// Start and End are offsets from the start of the walk.
+	Start time.Duration
+	End   time.Duration
+
+	// Worker is the index of the worker the node ran on.
+	Worker int
+}
+
+// Wave is a set of nodes that were dispatched at the same time in a simulated walk.
+type Wave struct {
+	// Time is the offset from the start of the walk at which the wave was dispatched.
+	Time time.Duration
+
+	// Keys contains the keys of the nodes dispatched, sorted.
+	Keys []string
+}
+
+// Simulation is the predicted outcome of a walk.
+type Simulation struct {
+	// Parallelism is the number of workers that were simulated.
+	Parallelism int
+
+	// Makespan is the predicted duration of the whole walk.
+	Makespan time.Duration
+
+	// Waves contains the nodes dispatched at each point in time, in order.
+	Waves []Wave
+
+	// Nodes records when each node ran.
+	Nodes map[string]SimulatedNode
+
+	// Utilization is the fraction of the available worker time that was spent running nodes, between 0 and 1.
+	Utilization float64
+}
+
+// Simulate predicts the outcome of walking the graph without executing any node code. A virtual clock is advanced
+// using the estimated cost of each node, and nodes are dispatched to workers exactly as the walker would (in key order
+// when several are ready at once).
+//
+// Expandable nodes can't be expanded without running them, so they are simulated as a single node with their own cost.
+func (g Graph) Simulate(opts SimulateOpts) (*Simulation, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+
+	cost := opts.Cost
+	if cost == nil {
+		cost = func(key string, meta Meta) time.Duration {
+			return meta.Estimate
+		}
+	}
+
+	simulation := &Simulation{
+		Parallelism: parallelism,
+		Nodes:       make(map[string]SimulatedNode, len(g.nodes)),
+	}
+
+	remaining := make(map[string]int, len(g.nodes))
+	var ready []string
+	for key, node := range g.nodes {
+		remaining[key] = node.required()
+		if len(node.parents) == 0 {
+			ready = append(ready, key)
+		}
+	}
+
+	// workers records the key each worker is running, or the empty string if it is idle.
+	workers := make([]string, parallelism)
+
+	var now, busy time.Duration
+	for completed := 0; completed < len(g.nodes); {
+		// First, hand out as much ready work as we have idle workers.
+		sort.Strings(ready)
+		var wave []string
+		for worker := range workers {
+			if len(ready) == 0 {
+				break
+			}
+			if len(workers[worker]) > 0 {
+				continue
+			}
+
+			key := ready[0]
+			ready = ready[1:]
+
+			duration := cost(key, g.nodes[key].meta)
+			if duration < 0 {
+				return nil, errors.Newf(nil, InvalidEstimate, "node %q has a negative cost", key)
+			}
+
+			workers[worker] = key
+			busy += duration
+			simulation.Nodes[key] = SimulatedNode{
+				Start:  now,
+				End:    now + duration,
+				Worker: worker,
+			}
+			wave = append(wave, key)
+		}
+		if len(wave) > 0 {
+			sort.Strings(wave)
+			simulation.Waves = append(simulation.Waves, Wave{Time: now, Keys: wave})
+		}
+
+		// Then, advance the clock to the next time a node finishes.
+		next := time.Duration(-1)
+		for _, key := range workers {
+			if len(key) > 0 && (next < 0 || simulation.Nodes[key].End < next) {
+				next = simulation.Nodes[key].End
+			}
+		}
+		now = next
+
+		// Finally, complete everything that finished at that time.
+		for worker, key := range workers {
+			if len(key) == 0 || simulation.Nodes[key].End != now {
+				continue
+			}
+
+			workers[worker] = ""
+			completed++
+			for _, child := range g.nodes[key].children {
+				remaining[child]--
+				if remaining[child] == 0 {
+					ready = append(ready, child)
+				}
+			}
+		}
+	}
+
+	simulation.Makespan = now
+	if now > 0 {
+		simulation.Utilization = float64(busy) / (float64(now) * float64(parallelism))
+	}
+	return simulation, nil
+}
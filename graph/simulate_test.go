@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Simulate(t *testing.T) {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	g := NewGraph()
+	g.AddNodeWithMeta("a", noop, Meta{Estimate: time.Second})
+	g.AddNodeWithMeta("b", noop, Meta{Estimate: 2 * time.Second})
+	g.AddNodeWithMeta("c", noop, Meta{Estimate: 3 * time.Second})
+	g.AddNodeWithMeta("d", noop, Meta{Estimate: time.Second})
+	g.Connect("a", "b")
+	g.Connect("a", "c")
+	g.Connect("b", "d")
+	g.Connect("c", "d")
+
+	serial, err := g.Simulate(SimulateOpts{Parallelism: 1})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(serial.Makespan).Equal(t, 7*time.Second)
+	tests.Execute(serial.Utilization).Equal(t, 1.0)
+
+	parallel, err := g.Simulate(SimulateOpts{Parallelism: 2})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(parallel.Makespan).Equal(t, 5*time.Second)
+	tests.Execute(parallel.Waves).Equal(t, []Wave{
+		{Time: 0, Keys: []string{"a"}},
+		{Time: time.Second, Keys: []string{"b", "c"}},
+		{Time: 4 * time.Second, Keys: []string{"d"}},
+	})
+	tests.Execute(parallel.Utilization).Equal(t, 0.7)
+}
@@ -0,0 +1,201 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// eventJSON is the wire format used by the JSONL and webhook sinks.
+type eventJSON struct {
+	Type   EventType `json:"type"`
+	WalkID string    `json:"walk_id"`
+	Key    string    `json:"key,omitempty"`
+	Owner  string    `json:"owner,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+func marshalEvent(event Event) eventJSON {
+	data := eventJSON{
+		Type:   event.Type,
+		WalkID: event.WalkID,
+		Key:    event.Key,
+		Owner:  event.Owner,
+		Reason: string(event.Reason),
+		Time:   event.Time,
+	}
+	if event.Err != nil {
+		data.Error = event.Err.Error()
+	}
+	return data
+}
+
+// SlogSink returns a sink that writes every event to the given logger. Error events are logged at error level, and
+// everything else at info level.
+func SlogSink(logger *slog.Logger) Sink {
+	return SinkFunc(func(event Event) {
+		attrs := []slog.Attr{
+			slog.String("walk_id", event.WalkID),
+		}
+		if len(event.Key) > 0 {
+			attrs = append(attrs, slog.String("key", event.Key))
+		}
+		if len(event.Owner) > 0 {
+			attrs = append(attrs, slog.String("owner", event.Owner))
+		}
+		if len(event.Reason) > 0 {
+			attrs = append(attrs, slog.String("reason", string(event.Reason)))
+		}
+
+		level := slog.LevelInfo
+		if event.Err != nil {
+			level = slog.LevelError
+			attrs = append(attrs, slog.String("error", event.Err.Error()))
+		}
+
+		logger.LogAttrs(context.Background(), level, string(event.Type), attrs...)
+	})
+}
+
+// JSONLSink returns a sink that writes every event as a single line of JSON to the given writer.
+//
+// Write errors are ignored, callers that care should wrap the writer.
+func JSONLSink(writer io.Writer) Sink {
+	var mutex sync.Mutex
+	encoder := json.NewEncoder(writer)
+	return SinkFunc(func(event Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		_ = encoder.Encode(marshalEvent(event))
+	})
+}
+
+// WebhookSink returns a sink that posts every event as JSON to the given url.
+//
+// Events are posted in order by a separate goroutine, so a slow endpoint doesn't hold up the walk, and a walk only
+// finishes once its own events have been posted, or after 10 seconds, whichever comes first. Up to 256 events may wait
+// to be posted, further events are dropped until the endpoint catches up. Failed requests are dropped.
+//
+// If client is nil, a client that gives up on requests after 10 seconds is used.
+func WebhookSink(url string, client *http.Client) Sink {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &webhookSink{
+		url:     url,
+		client:  client,
+		pending: make(map[string]int),
+		flushed: make(map[string]chan struct{}),
+	}
+}
+
+const (
+	// webhookSinkQueue is the number of events a webhook sink queues before dropping them.
+	webhookSinkQueue = 256
+
+	// webhookSinkFlush is how long a finishing walk waits for its events to be posted.
+	webhookSinkFlush = 10 * time.Second
+)
+
+type webhookSink struct {
+	url    string
+	client *http.Client
+
+	mutex sync.Mutex
+
+	// queue holds the events waiting to be posted, and draining is true while a goroutine is posting them.
+	queue    []webhookEvent
+	draining bool
+
+	// pending counts the queued events of each walk, by walk id. flushed holds a channel for each finished walk still
+	// waiting for its events, which is closed once its count drops to zero.
+	pending map[string]int
+	flushed map[string]chan struct{}
+}
+
+// webhookEvent is an event waiting to be posted.
+type webhookEvent struct {
+	walkID string
+	body   []byte
+}
+
+func (sink *webhookSink) Handle(event Event) {
+	body, err := json.Marshal(marshalEvent(event))
+	if err != nil {
+		return
+	}
+
+	sink.mutex.Lock()
+	if len(sink.queue) < webhookSinkQueue {
+		sink.queue = append(sink.queue, webhookEvent{walkID: event.WalkID, body: body})
+		sink.pending[event.WalkID]++
+		if !sink.draining {
+			sink.draining = true
+			go sink.drain()
+		}
+	}
+
+	if event.Type != EventWalkFinished || sink.pending[event.WalkID] == 0 {
+		sink.mutex.Unlock()
+		return
+	}
+	flushed := make(chan struct{})
+	sink.flushed[event.WalkID] = flushed
+	sink.mutex.Unlock()
+
+	timer := time.NewTimer(webhookSinkFlush)
+	defer timer.Stop()
+
+	select {
+	case <-flushed:
+	case <-timer.C:
+		sink.mutex.Lock()
+		delete(sink.flushed, event.WalkID)
+		sink.mutex.Unlock()
+	}
+}
+
+// drain posts the queued events in order until the queue is empty.
+func (sink *webhookSink) drain() {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	for len(sink.queue) > 0 {
+		next := sink.queue[0]
+		sink.queue = sink.queue[1:]
+
+		sink.mutex.Unlock()
+		response, err := sink.client.Post(sink.url, "application/json", bytes.NewReader(next.body))
+		if err == nil {
+			_, _ = io.Copy(io.Discard, response.Body)
+			_ = response.Body.Close()
+		}
+		sink.mutex.Lock()
+
+		if sink.pending[next.walkID]--; sink.pending[next.walkID] == 0 {
+			delete(sink.pending, next.walkID)
+			if flushed, ok := sink.flushed[next.walkID]; ok {
+				close(flushed)
+				delete(sink.flushed, next.walkID)
+			}
+		}
+	}
+
+	sink.draining = false
+}
+
+// ChannelSink returns a sink that sends every event on the given channel.
+//
+// Sends are blocking, so the channel must be drained for the walk to make progress.
+func ChannelSink(ch chan<- Event) Sink {
+	return SinkFunc(func(event Event) {
+		ch <- event
+	})
+}
@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// snapshot returns the graph as it was walked, including every node added by expansion, by a WalkHandle or by a
+// Stream. The nodes are copied, so the snapshot can be changed without affecting the graph that was walked.
+func (walker *walker) snapshot() Graph {
+	g := NewGraph()
+	for key, original := range walker.nodes {
+		g.nodes[key] = &node{
+			key:      original.key,
+			impl:     original.impl,
+			meta:     original.meta,
+			parents:  append([]string(nil), original.parents...),
+			children: append([]string(nil), original.children...),
+		}
+		if len(original.parents) == 0 {
+			g.starters[key] = true
+		}
+		if len(original.children) == 0 {
+			g.finishers[key] = true
+		}
+	}
+	return g
+}
+
+// expansions returns the keys of the nodes each node expanded into, for every node that expanded.
+func (result *WalkResult) expansions() map[string][]string {
+	expansions := make(map[string][]string)
+	for _, key := range result.Graph.sortedKeys() {
+		if expander := result.Nodes[key].ExpandedBy; len(expander) > 0 {
+			expansions[expander] = append(expansions[expander], key)
+		}
+	}
+	return expansions
+}
+
+// WriteDOT writes the graph as it was walked in the Graphviz DOT language, exactly like Graph.WriteDOT. Every node that
+// expanded is connected to the nodes it expanded into with a dashed edge.
+func (result *WalkResult) WriteDOT(writer io.Writer) error {
+	return result.Graph.writeDOT(writer, result.expansions())
+}
+
+// snapshotNode is how a node is written by WalkResult.WriteJSON.
+type snapshotNode struct {
+	Key        string   `json:"key"`
+	Status     Status   `json:"status"`
+	ExpandedBy string   `json:"expanded_by,omitempty"`
+	Parents    []string `json:"parents,omitempty"`
+	Children   []string `json:"children,omitempty"`
+}
+
+// WriteJSON writes the graph as it was walked as a JSON document, listing every node in order of their keys along with
+// its status, its edges and the node it was expanded by.
+func (result *WalkResult) WriteJSON(writer io.Writer) error {
+	document := struct {
+		WalkID string         `json:"walk_id"`
+		Nodes  []snapshotNode `json:"nodes"`
+	}{
+		WalkID: result.WalkID,
+		Nodes:  make([]snapshotNode, 0, len(result.Graph.nodes)),
+	}
+
+	for _, key := range result.Graph.sortedKeys() {
+		node := result.Graph.nodes[key]
+		parents := append([]string(nil), node.parents...)
+		sort.Strings(parents)
+		document.Nodes = append(document.Nodes, snapshotNode{
+			Key:        key,
+			Status:     result.Nodes[key].Status,
+			ExpandedBy: result.Nodes[key].ExpandedBy,
+			Parents:    parents,
+			Children:   result.Graph.sortedChildren(key),
+		})
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
@@ -0,0 +1,97 @@
+package graph
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func expandingGraph() Graph {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	g := NewGraph()
+	g.AddNode("deploy", Expandable(func(ctx context.Context) (Graph, error) {
+		subgraph := NewGraph()
+		subgraph.AddNode("eu", noop)
+		subgraph.AddNode("us", noop)
+		subgraph.Connect("eu", "us")
+		return subgraph, nil
+	}))
+	g.AddNode("verify", noop)
+	g.Connect("deploy", "verify")
+	return g
+}
+
+func TestWalkResult_Graph(t *testing.T) {
+	g := expandingGraph()
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 1})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(result.Graph.sortedKeys()).Equal(t, []string{"deploy", "eu", "us", "verify"})
+	starters := result.Graph.Starters()
+	sort.Strings(starters)
+	tests.Execute(starters).Equal(t, []string{"deploy", "eu"})
+	tests.Execute(result.Nodes["eu"].ExpandedBy).Equal(t, "deploy")
+	tests.Execute(result.Nodes["verify"].ExpandedBy).Equal(t, "")
+
+	// The graph that was walked is left as it was.
+	tests.Execute(g.sortedKeys()).Equal(t, []string{"deploy", "verify"})
+
+	var dot strings.Builder
+	tests.ExecuteE(result.WriteDOT(&dot)).NoError(t)
+	tests.Execute(dot.String()).Equal(t, `digraph {
+	"deploy" [label="deploy"];
+	"eu" [label="eu"];
+	"us" [label="us"];
+	"verify" [label="verify"];
+	"deploy" -> "verify";
+	"deploy" -> "eu" [style=dashed];
+	"deploy" -> "us" [style=dashed];
+	"eu" -> "us";
+}
+`)
+
+	var json strings.Builder
+	tests.ExecuteE(result.WriteJSON(&json)).NoError(t)
+	tests.Execute(json.String()).Equal(t, `{
+  "walk_id": "`+result.WalkID+`",
+  "nodes": [
+    {
+      "key": "deploy",
+      "status": "completed",
+      "children": [
+        "verify"
+      ]
+    },
+    {
+      "key": "eu",
+      "status": "completed",
+      "expanded_by": "deploy",
+      "children": [
+        "us"
+      ]
+    },
+    {
+      "key": "us",
+      "status": "completed",
+      "expanded_by": "deploy",
+      "parents": [
+        "eu"
+      ]
+    },
+    {
+      "key": "verify",
+      "status": "completed",
+      "parents": [
+        "deploy"
+      ]
+    }
+  ]
+}
+`)
+}
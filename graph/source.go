@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// NodeSource materializes node implementations from a backing store on demand, so very large graphs can be walked
+// without holding every implementation in memory. The topology of the graph is still built upfront with AddLazyNode and
+// Connect, only the implementations are deferred.
+type NodeSource interface {
+	// Load returns the implementation of the given node, which must implement ExecutableNode or ExpandableNode. It is
+	// called by a worker just before the node runs, so at most Opts.Parallelism nodes are loaded at once.
+	Load(ctx context.Context, key string) (interface{}, error)
+
+	// Release is called once the node has finished with the implementation returned by Load, whether or not it
+	// succeeded. The walker keeps no reference to the implementation afterwards.
+	Release(key string)
+}
+
+// lazyNode stands in for the implementation of a node that is loaded from a NodeSource when it runs.
+type lazyNode struct {
+	source NodeSource
+}
+
+// AddLazyNode adds a node to the graph whose implementation is loaded from the source when the node runs, and released
+// again as soon as it finishes.
+//
+// Lazy nodes can't implement DependencyResolver, as their implementation isn't known until they run. Their edges must be
+// added with Connect.
+func (g Graph) AddLazyNode(key string, source NodeSource) error {
+	return g.AddLazyNodeWithMeta(key, source, Meta{})
+}
+
+// AddLazyNodeWithMeta adds a lazy node to the graph exactly like AddLazyNode, along with metadata describing it.
+func (g Graph) AddLazyNodeWithMeta(key string, source NodeSource, meta Meta) error {
+	if source == nil {
+		err := errors.Newf(nil, InvalidNode, "node %q has no source", key)
+		return errors.Embed(err, NodeKey, key)
+	}
+	return g.AddNodeWithMeta(key, &lazyNode{source: source}, meta)
+}
+
+// materialize returns the implementation of the node, loading it from its source if the node is lazy. The returned
+// function must be called once the implementation is no longer needed.
+func materialize(ctx context.Context, node *node) (interface{}, func(), error) {
+	lazy, ok := node.impl.(*lazyNode)
+	if !ok {
+		return node.impl, func() {}, nil
+	}
+
+	impl, err := lazy.source.Load(ctx, node.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	release := func() { lazy.source.Release(node.key) }
+
+	_, executable := impl.(ExecutableNode)
+	_, expandable := impl.(ExpandableNode)
+	_, streaming := impl.(StreamingExpandableNode)
+	if !executable && !expandable && !streaming {
+		release()
+		err := errors.Newf(nil, InvalidNode, "source loaded node %q that does not implement ExecutableNode or ExpandableNode", node.key)
+		return nil, nil, errors.Embed(err, NodeKey, node.key)
+	}
+	return impl, release, nil
+}
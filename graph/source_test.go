@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+// countingSource materializes no-op nodes, recording how many are loaded at once.
+type countingSource struct {
+	mutex    sync.Mutex
+	loaded   map[string]bool
+	maxLive  int
+	loads    int
+	releases int
+
+	// impl is returned instead of a no-op node, if set.
+	impl interface{}
+
+	// err is returned by Load, if set.
+	err error
+}
+
+func (source *countingSource) Load(ctx context.Context, key string) (interface{}, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.err != nil {
+		return nil, source.err
+	}
+
+	source.loads++
+	source.loaded[key] = true
+	if len(source.loaded) > source.maxLive {
+		source.maxLive = len(source.loaded)
+	}
+
+	if source.impl != nil {
+		return source.impl, nil
+	}
+	return Executable(func(ctx context.Context) error {
+		return nil
+	}), nil
+}
+
+func (source *countingSource) Release(key string) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.releases++
+	delete(source.loaded, key)
+}
+
+func TestGraph_AddLazyNode(t *testing.T) {
+	source := &countingSource{loaded: make(map[string]bool)}
+
+	g := NewGraph()
+	tests.ExecuteE(g.AddLazyNode("root", source)).NoError(t)
+	for ix := 0; ix < 100; ix++ {
+		key := fmt.Sprintf("node-%d", ix)
+		tests.ExecuteE(g.AddLazyNode(key, source)).NoError(t)
+		tests.ExecuteE(g.Connect("root", key)).NoError(t)
+	}
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 4})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(len(result.Nodes)).Equal(t, 101)
+
+	tests.Execute(source.loads).Equal(t, 101)
+	tests.Execute(source.releases).Equal(t, 101)
+	tests.Execute(len(source.loaded)).Equal(t, 0)
+	tests.Execute(source.maxLive <= 4).Equal(t, true)
+}
+
+func TestGraph_AddLazyNode_Errors(t *testing.T) {
+	tcs := map[string]struct {
+		source *countingSource
+		err    string
+	}{
+		"load": {
+			source: &countingSource{err: fmt.Errorf("store unavailable")},
+			err:    "a: failed to load node (store unavailable)",
+		},
+		"invalid": {
+			source: &countingSource{impl: "not a node"},
+			err:    "a: failed to load node (source loaded node \"a\" that does not implement ExecutableNode or ExpandableNode)",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			tc.source.loaded = make(map[string]bool)
+
+			g := NewGraph()
+			tests.ExecuteE(g.AddLazyNode("a", tc.source)).NoError(t)
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 1})
+			tests.ExecuteE(err).MatchesError(t, tc.err)
+			tests.Execute(errors.GetErrorCode(err)).Equal(t, FailedNode)
+			tests.Execute(result.Nodes["a"].Status).Equal(t, StatusErrored)
+			tests.Execute(len(tc.source.loaded)).Equal(t, 0)
+		})
+	}
+
+	tests.Execute(errors.GetErrorCode(NewGraph().AddLazyNode("a", nil))).Equal(t, InvalidNode)
+}
@@ -0,0 +1,224 @@
+package graph
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Speculation starts a second, speculative copy of nodes that run much longer than the nodes before them, and takes
+// whichever copy succeeds first. The node only fails once both copies have failed, with the error of the first to fail.
+// It mitigates stragglers, such as nodes that landed on a slow remote worker, in graphs with a large fan-out of similar
+// nodes.
+//
+// Both copies may run to completion, so only nodes that are safe to run twice at the same time should be speculated.
+// The copy that loses has its context cancelled and its result discarded. From then on it can't affect the walk: its
+// outputs, logs, annotations and other results are discarded, and it can't add nodes to the walk or cancel it.
+// Expandable nodes are never speculated.
+type Speculation struct {
+	// Percentile of the durations of the nodes that already completed in the walk, between 0 and 1. A node still running
+	// after this percentile multiplied by Multiplier gets a speculative candidate.
+	//
+	// Defaults to 0.95.
+	Percentile float64
+
+	// Multiplier scales the percentile into the threshold after which a node is considered a straggler.
+	//
+	// Defaults to 1.5.
+	Multiplier float64
+
+	// MinSamples is the number of nodes that must have completed before any node is speculated, so the threshold means
+	// something.
+	//
+	// Defaults to 10.
+	MinSamples int
+
+	// Filter decides whether a node may be speculated.
+	//
+	// Optional, every executable node may be speculated if nil.
+	Filter func(key string, meta Meta) bool
+
+	// Execute runs the speculative copy of a node. It can hand the node to a different class of worker than the one
+	// that is running the original, which is the point of speculating.
+	//
+	// Defaults to calling Execute on the node in the current process.
+	Execute func(ctx context.Context, key string, node ExecutableNode) error
+}
+
+// samples is the number of recent node durations a speculator keeps to work out its threshold.
+const samples = 1024
+
+// speculator records how long nodes take, and runs stragglers speculatively.
+type speculator struct {
+	opts Speculation
+
+	mutex     sync.Mutex
+	durations []time.Duration // a ring buffer of the most recent durations.
+	next      int
+}
+
+func newSpeculator(opts *Speculation) *speculator {
+	if opts == nil {
+		return nil
+	}
+
+	speculator := &speculator{opts: *opts}
+	if speculator.opts.Percentile == 0 {
+		speculator.opts.Percentile = 0.95
+	}
+	if speculator.opts.Multiplier == 0 {
+		speculator.opts.Multiplier = 1.5
+	}
+	if speculator.opts.MinSamples == 0 {
+		speculator.opts.MinSamples = 10
+	}
+	if speculator.opts.Execute == nil {
+		speculator.opts.Execute = func(ctx context.Context, key string, node ExecutableNode) error {
+			return node.Execute(ctx)
+		}
+	}
+	return speculator
+}
+
+// record adds the duration of a node that completed.
+func (speculator *speculator) record(duration time.Duration) {
+	speculator.mutex.Lock()
+	defer speculator.mutex.Unlock()
+
+	if len(speculator.durations) < samples {
+		speculator.durations = append(speculator.durations, duration)
+		return
+	}
+	speculator.durations[speculator.next] = duration
+	speculator.next = (speculator.next + 1) % samples
+}
+
+// threshold returns how long a node can run before it is speculated, or false if not enough nodes have completed.
+func (speculator *speculator) threshold() (time.Duration, bool) {
+	speculator.mutex.Lock()
+	durations := slices.Clone(speculator.durations)
+	speculator.mutex.Unlock()
+
+	if len(durations) < speculator.opts.MinSamples {
+		return 0, false
+	}
+
+	slices.Sort(durations)
+	ix := int(math.Ceil(speculator.opts.Percentile*float64(len(durations)))) - 1
+	ix = min(max(ix, 0), len(durations)-1)
+	return time.Duration(float64(durations[ix]) * speculator.opts.Multiplier), true
+}
+
+// execute runs the node, starting a speculative copy if it runs past the threshold. The result of whichever copy
+// succeeds first is returned, and an error only once every copy has failed.
+func (speculator *speculator) execute(ctx context.Context, node *node, executor ExecutableNode) error {
+	start := time.Now()
+
+	threshold, ok := speculator.threshold()
+	if !ok || (speculator.opts.Filter != nil && !speculator.opts.Filter(node.key, node.meta)) {
+		err := executor.Execute(ctx)
+		if err == nil {
+			speculator.record(time.Since(start))
+		}
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops whichever copy lost.
+
+	type result struct {
+		candidate *speculativeCopy
+		err       error
+	}
+	results := make(chan result, 2)
+	run := func(execute func(ctx context.Context) error) *speculativeCopy {
+		candidate := &speculativeCopy{}
+		go func() {
+			results <- result{candidate: candidate, err: execute(context.WithValue(ctx, speculativeCopyKey, candidate))}
+		}()
+		return candidate
+	}
+	copies := []*speculativeCopy{run(executor.Execute)}
+
+	timer := time.NewTimer(threshold)
+	defer timer.Stop()
+
+	var failed []error
+	for len(failed) < len(copies) {
+		select {
+		case result := <-results:
+			if result.err != nil {
+				failed = append(failed, result.err)
+				continue
+			}
+
+			// Whichever copy is still running lost, so it can't affect the walk any more.
+			for _, candidate := range copies {
+				if candidate != result.candidate {
+					candidate.lose()
+				}
+			}
+			speculator.record(time.Since(start))
+			return nil
+		case <-timer.C:
+			Logger(ctx).Debug("node speculated", slog.Duration("threshold", threshold))
+			if exec := executionFrom(ctx); exec != nil {
+				exec.mutex.Lock()
+				exec.speculated = true
+				exec.mutex.Unlock()
+			}
+			copies = append(copies, run(func(ctx context.Context) error {
+				return speculator.opts.Execute(ctx, node.key, executor)
+			}))
+		}
+	}
+	return failed[0]
+}
+
+// speculativeCopy is stored in the context of each copy of a speculated node. Both copies share the execution of the
+// node until one of them wins, after which the other is handed an execution of its own, so nothing it does afterwards
+// reaches the walk.
+type speculativeCopy struct {
+	mutex    sync.Mutex
+	lost     bool
+	detached *execution
+}
+
+// lose records that the other copy won.
+func (speculative *speculativeCopy) lose() {
+	speculative.mutex.Lock()
+	defer speculative.mutex.Unlock()
+	speculative.lost = true
+}
+
+// execution returns the execution the copy should use: the execution of the node, unless it lost.
+func (speculative *speculativeCopy) execution(exec *execution) *execution {
+	speculative.mutex.Lock()
+	defer speculative.mutex.Unlock()
+
+	if !speculative.lost {
+		return exec
+	}
+	if speculative.detached == nil {
+		finished := make(chan struct{})
+		close(finished)
+		speculative.detached = &execution{
+			key:        exec.key,
+			walkID:     exec.walkID,
+			attempt:    exec.attempt,
+			previous:   exec.previous,
+			baseLogger: exec.baseLogger,
+			values:     newValues(),
+			handle: WalkHandle{
+				key:      exec.handle.key,
+				parents:  exec.handle.parents,
+				finished: finished,
+				cancel:   func(error) {},
+			},
+		}
+	}
+	return speculative.detached
+}
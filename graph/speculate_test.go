@@ -0,0 +1,200 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+// straggler returns a node that is slow the first time it runs, and fast every time after that.
+func straggler(calls *atomic.Int32, cancelled chan<- struct{}) ExecutableNode {
+	return Executable(func(ctx context.Context) error {
+		if calls.Add(1) > 1 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			close(cancelled)
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		}
+	})
+}
+
+func TestGraph_Walk_Speculation(t *testing.T) {
+	var speculated []string
+	tcs := map[string]struct {
+		speculation *Speculation
+		speculated  bool
+		calls       int32
+		hooked      []string
+	}{
+		"disabled": {
+			calls: 1,
+		},
+		"default": {
+			speculation: &Speculation{},
+			speculated:  true,
+			calls:       2,
+		},
+		"hook": {
+			speculation: &Speculation{
+				Execute: func(ctx context.Context, key string, node ExecutableNode) error {
+					speculated = append(speculated, key)
+					return node.Execute(ctx)
+				},
+			},
+			speculated: true,
+			calls:      2,
+			hooked:     []string{"slow"},
+		},
+		"filtered": {
+			speculation: &Speculation{
+				Filter: func(key string, meta Meta) bool {
+					return key != "slow"
+				},
+			},
+			calls: 1,
+		},
+		"not enough samples": {
+			speculation: &Speculation{MinSamples: 20},
+			calls:       1,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			speculated = nil
+
+			var calls atomic.Int32
+			cancelled := make(chan struct{})
+
+			g := NewGraph()
+			for ix := 0; ix < 10; ix++ {
+				g.AddNode(fmt.Sprintf("fast-%d", ix), Executable(func(ctx context.Context) error {
+					return nil
+				}))
+			}
+			g.AddNode("slow", straggler(&calls, cancelled))
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 1, Speculation: tc.speculation})
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(result.Nodes["slow"].Speculated).Equal(t, tc.speculated)
+			tests.Execute(result.Nodes["fast-0"].Speculated).Equal(t, false)
+			tests.Execute(calls.Load()).Equal(t, tc.calls)
+			tests.Execute(speculated).Equal(t, tc.hooked)
+
+			if tc.speculated {
+				// The original copy lost, so it must have been cancelled.
+				<-cancelled
+			}
+		})
+	}
+}
+
+func TestGraph_Walk_Speculation_Failures(t *testing.T) {
+	tcs := map[string]struct {
+		original    func(ctx context.Context) error
+		speculative func(ctx context.Context) error
+		err         string
+	}{
+		"original fails": {
+			original: func(ctx context.Context) error {
+				time.Sleep(10 * time.Millisecond)
+				return fmt.Errorf("original")
+			},
+			speculative: func(ctx context.Context) error {
+				time.Sleep(30 * time.Millisecond)
+				return nil
+			},
+		},
+		"speculative fails": {
+			original: func(ctx context.Context) error {
+				time.Sleep(30 * time.Millisecond)
+				return nil
+			},
+			speculative: func(ctx context.Context) error {
+				return fmt.Errorf("speculative")
+			},
+		},
+		"both fail": {
+			original: func(ctx context.Context) error {
+				time.Sleep(10 * time.Millisecond)
+				return fmt.Errorf("original")
+			},
+			speculative: func(ctx context.Context) error {
+				time.Sleep(20 * time.Millisecond)
+				return fmt.Errorf("speculative")
+			},
+			err: "slow: failed to execute node (original)",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+
+			g := NewGraph()
+			for ix := 0; ix < 10; ix++ {
+				g.AddNode(fmt.Sprintf("fast-%d", ix), Executable(func(ctx context.Context) error {
+					return nil
+				}))
+			}
+			g.AddNode("slow", Executable(func(ctx context.Context) error {
+				if calls.Add(1) == 1 {
+					return tc.original(ctx)
+				}
+				return tc.speculative(ctx)
+			}))
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 1, Speculation: &Speculation{}})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+			tests.Execute(result.Nodes["slow"].Speculated).Equal(t, true)
+			tests.Execute(calls.Load()).Equal(t, int32(2))
+		})
+	}
+}
+
+func TestGraph_Walk_Speculation_Loser(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	enqueued := make(chan error)
+
+	g := NewGraph()
+	for ix := 0; ix < 10; ix++ {
+		g.AddNode(fmt.Sprintf("fast-%d", ix), Executable(func(ctx context.Context) error {
+			return nil
+		}))
+	}
+	g.AddNode("slow", Executable(func(ctx context.Context) error {
+		if calls.Add(1) > 1 {
+			return nil
+		}
+
+		// The original ignores its context, and only carries on once the walk is over.
+		<-release
+		enqueued <- Handle(ctx).Enqueue("late", Executable(func(ctx context.Context) error {
+			return nil
+		}))
+		return nil
+	}))
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 1, Speculation: &Speculation{}})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(result.Nodes["slow"].Speculated).Equal(t, true)
+
+	// The original lost, so it can't add nodes to a walk that has finished.
+	close(release)
+	tests.Execute(errors.GetErrorCode(<-enqueued)).Equal(t, ClosedHandle)
+}
@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// StarveReason explains why a ready node waited too long to be dispatched, see Opts.StarvationThreshold.
+type StarveReason string
+
+const (
+	// StarveParallelism means every worker was busy, see Opts.Parallelism.
+	StarveParallelism StarveReason = "parallelism"
+
+	// StarveLimited means a subgraph or fan-out group the node belongs to already had as many nodes running as it
+	// allows, see SubgraphLimits.Parallelism and FanOut.MaxInFlight.
+	StarveLimited StarveReason = "limited"
+
+	// StarveHeld means the node was held, because its window hadn't opened, one of its quotas was full or it was
+	// backing off before a retry.
+	StarveHeld StarveReason = "held"
+)
+
+// waiting records when the node first became ready, unless it already has. It is only needed when looking for starved
+// nodes.
+func (walker *walker) waiting(key string, now time.Time) {
+	if walker.watchdog == nil {
+		return
+	}
+	if _, ok := walker.readySince[key]; !ok {
+		walker.readySince[key] = now
+	}
+}
+
+// starving returns a channel that fires whenever the walker should look for starved nodes, or nil if it shouldn't.
+func (walker *walker) starving() <-chan time.Time {
+	if walker.watchdog == nil {
+		return nil
+	}
+	return walker.watchdog.C
+}
+
+// Starved reports every node that has been ready for longer than the threshold without being dispatched, and hasn't
+// been reported already. Nodes are reported in key order, once each.
+func (walker *walker) Starved(threshold time.Duration) {
+	now := time.Now()
+
+	var starved []string
+	for key, since := range walker.readySince {
+		if walker.processing[key] || walker.finished(key) {
+			delete(walker.readySince, key)
+			continue
+		}
+		if _, ok := walker.starved[key]; !ok && now.Sub(since) >= threshold {
+			starved = append(starved, key)
+		}
+	}
+	slices.Sort(starved)
+
+	for _, key := range starved {
+		reason := walker.starveReason(key)
+		walker.tracer.log(slog.LevelWarn, key, "node starved",
+			slog.String("reason", string(reason)), slog.Duration("waited", now.Sub(walker.readySince[key])))
+		walker.starved[key] = reason
+		walker.bus.Publish(Event{
+			Type:    EventNodeStarved,
+			WalkID:  walker.id,
+			Key:     key,
+			Owner:   walker.owner(key),
+			Starved: reason,
+			Time:    now,
+		})
+	}
+}
+
+// starveReason works out why the node hasn't been dispatched yet.
+func (walker *walker) starveReason(key string) StarveReason {
+	if _, ok := walker.held[key]; ok {
+		return StarveHeld
+	}
+	for _, waiting := range walker.throttles {
+		if slices.Contains(waiting, key) {
+			return StarveLimited
+		}
+	}
+	for _, waiting := range walker.fanOutWaiting {
+		if slices.Contains(waiting, key) {
+			return StarveLimited
+		}
+	}
+	return StarveParallelism
+}
+
+// Starved returns the keys of all the nodes that waited longer than Opts.StarvationThreshold to be dispatched after they
+// became ready, sorted. NodeResult.Starved explains why each of them waited.
+func (result *WalkResult) Starved() []string {
+	var keys []string
+	for _, key := range result.Keys() {
+		if len(result.Nodes[key].Starved) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Starvation(t *testing.T) {
+	tcs := map[string]struct {
+		opts    Opts
+		tags    []string
+		starved map[string]StarveReason
+	}{
+		"none": {
+			opts: Opts{Parallelism: 2},
+		},
+		"parallelism": {
+			opts:    Opts{Parallelism: 1},
+			starved: map[string]StarveReason{"b": StarveParallelism},
+		},
+		"limited": {
+			opts:    Opts{Parallelism: 2, FanOuts: []FanOut{{Tag: "hosts", MaxInFlight: 1}}},
+			tags:    []string{"hosts"},
+			starved: map[string]StarveReason{"b": StarveLimited},
+		},
+		"held": {
+			opts:    Opts{Parallelism: 2, Quotas: []Quota{{Tag: "hosts", Limit: 1, Period: 100 * time.Millisecond}}},
+			tags:    []string{"hosts"},
+			starved: map[string]StarveReason{"b": StarveHeld},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g := NewGraph()
+			for _, key := range []string{"a", "b"} {
+				g.AddNodeWithMeta(key, Executable(func(ctx context.Context) error {
+					time.Sleep(100 * time.Millisecond)
+					return nil
+				}), Meta{Tags: tc.tags})
+			}
+
+			events := make(map[string]StarveReason)
+			opts := tc.opts
+			opts.StarvationThreshold = 40 * time.Millisecond
+			opts.Bus = NewBus(SinkFunc(func(event Event) {
+				if event.Type == EventNodeStarved {
+					events[event.Key] = event.Starved
+				}
+			}))
+
+			result, err := g.Run(context.Background(), &opts)
+			tests.ExecuteE(err).NoError(t)
+
+			var starved []string
+			for key := range tc.starved {
+				starved = append(starved, key)
+			}
+			tests.Execute(result.Starved()).Equal(t, starved)
+			for key, reason := range tc.starved {
+				tests.Execute(result.Nodes[key].Starved).Equal(t, reason)
+			}
+			tests.Execute(len(events)).Equal(t, len(tc.starved))
+			for key, reason := range tc.starved {
+				tests.Execute(events[key]).Equal(t, reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// StorageProfile chooses how the walker stores the sets of nodes it keeps track of, such as the nodes that have
+// completed, to suit the shape of the graph being walked.
+type StorageProfile int
+
+const (
+	// StorageDefault stores sets of nodes in hash maps, which suits most graphs.
+	StorageDefault StorageProfile = iota
+
+	// StorageFewNodes stores sets of nodes in sorted slices. It suits graphs of a few, long running nodes, where the
+	// sets are small and the overhead of a hash map outweighs searching them.
+	StorageFewNodes
+
+	// StorageManyNodes stores sets of nodes as bitmaps, numbering the nodes as they are first added to any set. It
+	// suits graphs with millions of tiny nodes, where every set would otherwise hold a hash map entry for most of them.
+	StorageManyNodes
+)
+
+// keySet is a set of node keys.
+type keySet interface {
+	add(key string)
+	has(key string) bool
+	len() int
+
+	// keys returns the keys in the set, in sorted order.
+	keys() []string
+}
+
+// newKeySet returns a new, empty set stored as the profile says. Sets stored as bitmaps number their nodes with the
+// given ordinals, so sets sharing them can be compared cheaply.
+func newKeySet(profile StorageProfile, ordinals *ordinals) keySet {
+	switch profile {
+	case StorageFewNodes:
+		return &sortedSet{}
+	case StorageManyNodes:
+		return &bitmapSet{ordinals: ordinals}
+	default:
+		return make(mapSet)
+	}
+}
+
+var _ keySet = mapSet(nil)
+
+type mapSet map[string]bool
+
+func (set mapSet) add(key string)      { set[key] = true }
+func (set mapSet) has(key string) bool { return set[key] }
+func (set mapSet) len() int            { return len(set) }
+
+func (set mapSet) keys() []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+var _ keySet = (*sortedSet)(nil)
+
+type sortedSet struct {
+	sorted []string
+}
+
+func (set *sortedSet) add(key string) {
+	if ix, ok := slices.BinarySearch(set.sorted, key); !ok {
+		set.sorted = slices.Insert(set.sorted, ix, key)
+	}
+}
+
+func (set *sortedSet) has(key string) bool {
+	_, ok := slices.BinarySearch(set.sorted, key)
+	return ok
+}
+
+func (set *sortedSet) len() int       { return len(set.sorted) }
+func (set *sortedSet) keys() []string { return slices.Clone(set.sorted) }
+
+// ordinals numbers node keys, so they can be stored in a bitmap.
+type ordinals struct {
+	numbers map[string]int
+	keys    []string
+}
+
+func newOrdinals() *ordinals {
+	return &ordinals{numbers: make(map[string]int)}
+}
+
+// number returns the number of the key, numbering it if it doesn't have one yet.
+func (ordinals *ordinals) number(key string) int {
+	number, ok := ordinals.numbers[key]
+	if !ok {
+		number = len(ordinals.keys)
+		ordinals.numbers[key] = number
+		ordinals.keys = append(ordinals.keys, key)
+	}
+	return number
+}
+
+var _ keySet = (*bitmapSet)(nil)
+
+type bitmapSet struct {
+	ordinals *ordinals
+	words    []uint64
+	count    int
+}
+
+func (set *bitmapSet) add(key string) {
+	number := set.ordinals.number(key)
+	word, bit := number/64, uint64(1)<<(number%64)
+	if word >= len(set.words) {
+		set.words = append(set.words, make([]uint64, word+1-len(set.words))...)
+	}
+	if set.words[word]&bit == 0 {
+		set.words[word] |= bit
+		set.count++
+	}
+}
+
+func (set *bitmapSet) has(key string) bool {
+	number, ok := set.ordinals.numbers[key]
+	if !ok || number/64 >= len(set.words) {
+		return false
+	}
+	return set.words[number/64]&(1<<(number%64)) != 0
+}
+
+func (set *bitmapSet) len() int { return set.count }
+
+func (set *bitmapSet) keys() []string {
+	keys := make([]string, 0, set.count)
+	for ix, word := range set.words {
+		for word != 0 {
+			bit := bits.TrailingZeros64(word)
+			keys = append(keys, set.ordinals.keys[ix*64+bit])
+			word &= word - 1
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
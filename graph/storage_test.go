@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestKeySet(t *testing.T) {
+	tcs := map[string]StorageProfile{
+		"default": StorageDefault,
+		"few":     StorageFewNodes,
+		"many":    StorageManyNodes,
+	}
+
+	for name, profile := range tcs {
+		t.Run(name, func(t *testing.T) {
+			ordinals := newOrdinals()
+			set := newKeySet(profile, ordinals)
+			other := newKeySet(profile, ordinals)
+
+			for ix := 99; ix >= 0; ix -= 3 {
+				set.add(fmt.Sprintf("node-%02d", ix))
+			}
+			set.add("node-99")
+			other.add("node-00")
+
+			tests.Execute(set.len()).Equal(t, 34)
+			tests.Execute(set.has("node-00")).Equal(t, true)
+			tests.Execute(set.has("node-01")).Equal(t, false)
+			tests.Execute(set.has("missing")).Equal(t, false)
+			tests.Execute(set.keys()[:3]).Equal(t, []string{"node-00", "node-03", "node-06"})
+			tests.Execute(other.keys()).Equal(t, []string{"node-00"})
+		})
+	}
+}
+
+func TestGraph_Walk_Storage(t *testing.T) {
+	for _, profile := range []StorageProfile{StorageDefault, StorageFewNodes, StorageManyNodes} {
+		t.Run(fmt.Sprint(profile), func(t *testing.T) {
+			g := wide(200)
+			g.AddNode("failing", Executable(func(ctx context.Context) error {
+				return fmt.Errorf("failed")
+			}))
+			g.AddNode("after", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			g.Connect("failing", "after")
+
+			result, err := g.Run(context.Background(), &Opts{Parallelism: 4, Storage: profile, Verify: true})
+			tests.ExecuteE(err).MatchesError(t, "failing: failed to execute node (failed); graph is incomplete")
+			tests.Execute(len(result.Status(StatusCompleted))).Equal(t, 202)
+			tests.Execute(result.Status(StatusSkipped)).Equal(t, []string{"after"})
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package graph
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Stream adds nodes and edges to a walk while it runs, so a walk can start before the whole graph is known. This suits
+// crawl-style workloads, where finishing one piece of work reveals the next.
+//
+// Pass the stream to a walk through Opts.Stream. The walk keeps running until the stream is closed, even if every node
+// it knows about has finished. Calls to AddNode and Connect block until the walk has started and accepted them, so they
+// are usually made from a separate goroutine. A Stream can only be used by a single walk.
+type Stream struct {
+	mutex  sync.Mutex
+	closed bool
+
+	// nodes and edges carry requests to the walker.
+	nodes chan enqueueRequest
+	edges chan connectRequest
+
+	// done is closed by Close, and finished is closed by the walker once the walk is over.
+	done         chan struct{}
+	finished     chan struct{}
+	finishedOnce sync.Once
+}
+
+// connectRequest asks the walker to add an edge between two nodes that are already part of the walk.
+type connectRequest struct {
+	from string
+	to   string
+
+	// expander is set if the edge was emitted by a StreamingExpandableNode, and is the key of that node.
+	expander string
+
+	reply chan error
+}
+
+// NewStream creates a new stream.
+func NewStream() *Stream {
+	return &Stream{
+		nodes:    make(chan enqueueRequest),
+		edges:    make(chan connectRequest),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+}
+
+// AddNode adds a new node to the walk. The new node runs once all the given parents have completed, and immediately if
+// it has none. The key must not already be in use, and all the parents must exist.
+//
+// AddNode returns an error with the ClosedStream code if the stream was closed or the walk is over.
+func (stream *Stream) AddNode(key string, impl interface{}, parents ...string) error {
+	return stream.AddNodeWithMeta(key, impl, Meta{}, parents...)
+}
+
+// AddNodeWithMeta adds a new node to the walk, exactly like AddNode, along with metadata describing it.
+func (stream *Stream) AddNodeWithMeta(key string, impl interface{}, meta Meta, parents ...string) error {
+	node, err := newNode(key, impl, meta)
+	if err != nil {
+		return err
+	}
+	node.parents = append([]string(nil), parents...)
+
+	if err := stream.open(); err != nil {
+		return err
+	}
+
+	reply := make(chan error)
+	select {
+	case stream.nodes <- enqueueRequest{node: node, reply: reply}:
+		return <-reply
+	case <-stream.finished:
+		return stream.closedError()
+	}
+}
+
+// Connect adds an edge between two nodes that are already part of the walk, so that to only runs once from has
+// completed. Edges can only point forward: to must still be waiting for at least one of its parents, as a node that
+// is ready or has started can't wait for anything else.
+//
+// Connect returns an error with the StartedNode code if to is no longer waiting, the CycleDetected code if to is an
+// ancestor of from, and the ClosedStream code if the stream was closed or the walk is over.
+func (stream *Stream) Connect(from string, to string) error {
+	if err := stream.open(); err != nil {
+		return err
+	}
+
+	reply := make(chan error)
+	select {
+	case stream.edges <- connectRequest{from: from, to: to, reply: reply}:
+		return <-reply
+	case <-stream.finished:
+		return stream.closedError()
+	}
+}
+
+// Close tells the walk no more nodes will be added. The walk finishes once all the nodes it knows about have.
+func (stream *Stream) Close() {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	if !stream.closed {
+		stream.closed = true
+		close(stream.done)
+	}
+}
+
+// open returns an error if the stream has been closed.
+func (stream *Stream) open() error {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	if stream.closed {
+		return stream.closedError()
+	}
+	return nil
+}
+
+// finish is called by the walker once the walk is over, so any pending requests fail instead of blocking forever.
+func (stream *Stream) finish() {
+	stream.finishedOnce.Do(func() {
+		close(stream.finished)
+	})
+}
+
+func (stream *Stream) closedError() error {
+	return errors.New(nil, ClosedStream, "stream is closed")
+}
+
+// Connect adds an edge requested through a Stream to the walk.
+func (walker *walker) Connect(request connectRequest) error {
+	from, to := request.from, request.to
+	if len(request.expander) > 0 {
+		if err := walker.emitted(request.expander, from, to); err != nil {
+			return err
+		}
+	}
+
+	if from == to {
+		err := errors.Newf(nil, SelfLoop, "cannot connect node %q to itself", from)
+		return errors.Embed(err, NodeKey, from)
+	}
+
+	for _, key := range []string{from, to} {
+		if _, ok := walker.nodes[key]; !ok {
+			err := errors.Newf(nil, MissingNode, "node %q does not exist", key)
+			return errors.Embed(err, NodeKey, key)
+		}
+	}
+
+	if walker.remaining[to] <= 0 {
+		err := errors.Newf(nil, StartedNode, "node %q is no longer waiting for its parents", to)
+		return errors.Embed(err, NodeKey, to)
+	}
+
+	if path := walker.path(to, from); path != nil {
+		cycle := append(path, to)
+		err := errors.Newf(nil, CycleDetected, "found cycle in graph: %s", strings.Join(cycle, " -> "))
+		err = errors.Embed(err, NodeKey, to)
+		return errors.Embed(err, Cycle, cycle)
+	}
+
+	// The nodes may be shared with the graph being walked, so copy them before adding the edge.
+	original := walker.nodes[from]
+	walker.nodes[from] = &node{
+		key:      original.key,
+		impl:     original.impl,
+		meta:     original.meta,
+		parents:  original.parents,
+		children: append(append([]string(nil), original.children...), to),
+	}
+	original = walker.nodes[to]
+	walker.nodes[to] = &node{
+		key:      original.key,
+		impl:     original.impl,
+		meta:     original.meta,
+		parents:  append(append([]string(nil), original.parents...), from),
+		children: original.children,
+	}
+
+	if !walker.completed.has(from) && walker.nodes[to].meta.Readiness.Parents == 0 {
+		walker.remaining[to]++
+	}
+	walker.paths = nil
+	return nil
+}
+
+// path returns the chain of nodes from ancestor down to key, following parents and the nodes that expanded into
+// subgraphs, or nil if ancestor isn't an ancestor of key.
+func (walker *walker) path(ancestor string, key string) []string {
+	next := map[string]string{key: ""}
+
+	queue := []string{key}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current == ancestor {
+			var path []string
+			for ; current != ""; current = next[current] {
+				path = append(path, current)
+			}
+			return path
+		}
+
+		parents := walker.nodes[current].parents
+		if expander, ok := walker.expandedBy[current]; ok {
+			parents = append(append([]string(nil), parents...), expander)
+		}
+		for _, parent := range parents {
+			if _, seen := next[parent]; !seen {
+				next[parent] = current
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestStream(t *testing.T) {
+	var mutex sync.Mutex
+	var order []string
+	record := func(key string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			order = append(order, key)
+			return nil
+		})
+	}
+
+	release := make(chan struct{})
+	g := NewGraph()
+	g.AddNode("gate", Executable(func(ctx context.Context) error {
+		<-release
+		return nil
+	}))
+
+	stream := NewStream()
+	done := make(chan struct{})
+	var result *WalkResult
+	var err error
+	go func() {
+		defer close(done)
+		result, err = g.Run(context.Background(), &Opts{Parallelism: 2, Stream: stream})
+	}()
+
+	tests.ExecuteE(stream.AddNode("b", record("b"), "gate")).NoError(t)
+	tests.ExecuteE(stream.AddNode("c", record("c"), "b")).NoError(t)
+	tests.ExecuteE(stream.AddNode("d", record("d"), "gate")).NoError(t)
+	tests.ExecuteE(stream.Connect("c", "d")).NoError(t)
+
+	tests.Execute(errors.GetErrorCode(stream.Connect("b", "gate"))).Equal(t, StartedNode)
+	tests.Execute(errors.GetErrorCode(stream.AddNode("b", record("b")))).Equal(t, DuplicateNode)
+	tests.Execute(errors.GetErrorCode(stream.AddNode("e", record("e"), "missing"))).Equal(t, MissingNode)
+	tests.Execute(errors.GetErrorCode(stream.AddNode("e", "not a node"))).Equal(t, InvalidNode)
+	tests.ExecuteE(stream.Connect("c", "b")).MatchesError(t, "found cycle in graph: b -> c -> b")
+
+	close(release)
+	stream.Close()
+	<-done
+
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(len(result.Nodes)).Equal(t, 4)
+	tests.Execute(order).Equal(t, []string{"b", "c", "d"})
+
+	tests.Execute(errors.GetErrorCode(stream.AddNode("e", record("e")))).Equal(t, ClosedStream)
+	tests.Execute(errors.GetErrorCode(stream.Connect("c", "d"))).Equal(t, ClosedStream)
+}
+
+func TestStream_Crawl(t *testing.T) {
+	links := map[string][]string{
+		"a": {"b", "c"},
+		"b": {"d"},
+		"c": {"e"},
+	}
+
+	// Each node reports the links it found, and the crawler adds them to the walk as children of the node.
+	type page struct {
+		key   string
+		links []string
+	}
+	found := make(chan page, len(links))
+	crawl := func(key string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			found <- page{key: key, links: links[key]}
+			return nil
+		})
+	}
+
+	stream := NewStream()
+	go func() {
+		defer stream.Close()
+
+		stream.AddNode("a", crawl("a"))
+		for outstanding := 1; outstanding > 0; outstanding-- {
+			page := <-found
+			for _, link := range page.links {
+				stream.AddNode(link, crawl(link), page.key)
+				outstanding++
+			}
+		}
+	}()
+
+	result, err := NewGraph().Run(context.Background(), &Opts{Parallelism: 2, Stream: stream})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(len(result.Nodes)).Equal(t, 5)
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		tests.Execute(result.Nodes[key].Status).Equal(t, StatusCompleted)
+	}
+}
+
+func TestStream_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The stream is never closed, but the walk must still finish once it has been cancelled.
+	_, err := NewGraph().Run(ctx, &Opts{Parallelism: 1, Stream: NewStream()})
+	tests.Execute(errors.GetErrorCode(err)).Equal(t, Cancelled)
+}
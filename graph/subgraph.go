@@ -0,0 +1,141 @@
+package graph
+
+import (
+	"context"
+	stderrors "errors"
+	"log/slog"
+	"time"
+)
+
+// SubgraphLimits limits the subgraph an ExpandableNode expands into, so a runaway generated subgraph can't take over
+// the rest of the walk. The limits apply to every node in the subgraph, including the subgraphs of nodes within it.
+type SubgraphLimits struct {
+	// Timeout is how long the subgraph may take, measured from when the node expands. Nodes in the subgraph that are
+	// still running when it runs out have their contexts cancelled, and nodes that haven't started are never dispatched.
+	// Either way, they are reported as cancelled with the CancelDeadline reason.
+	//
+	// Optional, the subgraph may take as long as the walk does if zero.
+	Timeout time.Duration
+
+	// Fraction is the share of the time left before the deadline of the walk, see Opts.Deadline, that the subgraph may
+	// take. If Timeout is set as well, whichever runs out first applies.
+	//
+	// Optional, ignored if zero or if the walk has no deadline.
+	Fraction float64
+
+	// Parallelism is the maximum number of nodes in the subgraph that may run at the same time, for example to deploy
+	// to at most two regions at once. Nodes over the limit wait without occupying a worker until another node in the
+	// subgraph has finished. The limit applies on top of Opts.Parallelism.
+	//
+	// Optional, the subgraph may use every worker if zero.
+	Parallelism int
+}
+
+// bound works out when the subgraph of the node that has just expanded must finish by, see SubgraphLimits. A subgraph
+// within another subgraph must finish by the time the outer one does.
+func (walker *walker) bound(key string) {
+	deadline, bounded := walker.subgraphDeadlines[walker.expandedBy[key]]
+
+	limits := walker.nodes[key].meta.Subgraph
+	now := time.Now()
+	if limits.Timeout > 0 {
+		if at := now.Add(limits.Timeout); !bounded || at.Before(deadline) {
+			deadline, bounded = at, true
+		}
+	}
+	if limits.Fraction > 0 && !walker.deadline.IsZero() {
+		share := time.Duration(float64(walker.deadline.Sub(now)) * limits.Fraction)
+		if at := now.Add(share); !bounded || at.Before(deadline) {
+			deadline, bounded = at, true
+		}
+	}
+
+	if bounded {
+		walker.subgraphDeadlines[key] = deadline
+	}
+}
+
+// subgraphDeadline returns when the subgraph the node belongs to must finish by, if it has to at all.
+func (walker *walker) subgraphDeadline(key string) (time.Time, bool) {
+	expander, ok := walker.expandedBy[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	deadline, ok := walker.subgraphDeadlines[expander]
+	return deadline, ok
+}
+
+// overdue returns true if the subgraph the node belongs to has run out of time, in which case the node has been
+// cancelled instead of dispatched.
+func (walker *walker) overdue(key string) bool {
+	deadline, ok := walker.subgraphDeadline(key)
+	if !ok || time.Now().Before(deadline) {
+		return false
+	}
+
+	walker.tracer.log(slog.LevelDebug, key, "node cancelled before dispatch", slog.String("reason", string(CancelDeadline)))
+	walker.Cancelled(key, CancelDeadline)
+	return true
+}
+
+// timedOut returns true if the node failed because the subgraph it belongs to ran out of time while it was running.
+func (walker *walker) timedOut(key string, err error) bool {
+	deadline, ok := walker.subgraphDeadline(key)
+	return ok && !time.Now().Before(deadline) && stderrors.Is(err, context.DeadlineExceeded)
+}
+
+// bounded returns the context for the node, with the deadline of the subgraph it belongs to if it has one. The returned
+// function must be called once the node has finished.
+func (walker *walker) bounded(ctx context.Context, key string) (context.Context, context.CancelFunc) {
+	deadline, ok := walker.subgraphDeadline(key)
+	if !ok {
+		return ctx, func() {}
+	}
+	return context.WithDeadlineCause(ctx, deadline, &cancelCause{reason: CancelDeadline})
+}
+
+// limiters returns the nodes whose subgraphs the node belongs to that limit their parallelism, from the innermost out.
+func (walker *walker) limiters(key string) []string {
+	var limiters []string
+	for expander, ok := walker.expandedBy[key]; ok; expander, ok = walker.expandedBy[expander] {
+		if walker.nodes[expander].meta.Subgraph.Parallelism > 0 {
+			limiters = append(limiters, expander)
+		}
+	}
+	return limiters
+}
+
+// throttled returns true if the node can't run because one of the subgraphs it belongs to already has as many nodes
+// running as it allows, in which case it waits until one of them has finished.
+func (walker *walker) throttled(key string) bool {
+	for _, limiter := range walker.limiters(key) {
+		if walker.running[limiter] < walker.nodes[limiter].meta.Subgraph.Parallelism {
+			continue
+		}
+
+		walker.tracer.log(slog.LevelDebug, key, "node throttled", slog.String("subgraph", limiter))
+		delete(walker.processing, key)
+		walker.throttles[limiter] = append(walker.throttles[limiter], key)
+		return true
+	}
+	return false
+}
+
+// occupy records that the node is running in every subgraph it belongs to that limits its parallelism.
+func (walker *walker) occupy(key string) {
+	for _, limiter := range walker.limiters(key) {
+		walker.running[limiter]++
+	}
+}
+
+// vacate records that the node has finished running, and makes the first node waiting for each of the subgraphs it
+// belongs to ready again.
+func (walker *walker) vacate(key string) {
+	for _, limiter := range walker.limiters(key) {
+		walker.running[limiter]--
+		if waiting := walker.throttles[limiter]; len(waiting) > 0 {
+			walker.throttles[limiter] = waiting[1:]
+			walker.ready(waiting[0])
+		}
+	}
+}
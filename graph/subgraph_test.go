@@ -0,0 +1,141 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_SubgraphLimits(t *testing.T) {
+	tcs := map[string]struct {
+		limits   SubgraphLimits
+		deadline time.Duration
+		nested   bool
+	}{
+		"timeout": {
+			limits: SubgraphLimits{Timeout: 50 * time.Millisecond},
+		},
+		"fraction": {
+			limits:   SubgraphLimits{Fraction: 0.05},
+			deadline: time.Second,
+		},
+		"nested": {
+			limits: SubgraphLimits{Timeout: 50 * time.Millisecond},
+			nested: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			// slow never finishes by itself, and late only becomes ready once the subgraph has run out of time.
+			subgraph := NewGraph()
+			subgraph.AddNode("slow", Executable(func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}))
+			subgraph.AddNode("sleepy", Executable(func(ctx context.Context) error {
+				time.Sleep(100 * time.Millisecond)
+				return nil
+			}))
+			subgraph.AddNode("late", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			subgraph.Connect("sleepy", "late")
+
+			expand := Expandable(func(ctx context.Context) (Graph, error) {
+				return subgraph, nil
+			})
+			meta := Meta{Subgraph: tc.limits}
+			if tc.nested {
+				// The inner expansion has no limits of its own, so it inherits the outer one.
+				inner := expand
+				expand = Expandable(func(ctx context.Context) (Graph, error) {
+					g := NewGraph()
+					g.AddNode("inner", inner)
+					return g, nil
+				})
+			}
+
+			g := NewGraph()
+			g.AddNodeWithMeta("expand", expand, meta)
+			g.AddNode("outside", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+
+			opts := &Opts{Parallelism: 3}
+			if tc.deadline > 0 {
+				opts.Deadline = time.Now().Add(tc.deadline)
+			}
+
+			start := time.Now()
+			result, err := g.Run(context.Background(), opts)
+			tests.ExecuteE(err).MatchesError(t, "graph is incomplete")
+			tests.Execute(time.Since(start) < 500*time.Millisecond).Equal(t, true)
+			tests.Execute(result.Nodes["outside"].Status).Equal(t, StatusCompleted)
+			tests.Execute(result.Nodes["slow"].Status).Equal(t, StatusCancelled)
+			tests.Execute(result.Nodes["slow"].Reason).Equal(t, CancelDeadline)
+			tests.Execute(result.Nodes["sleepy"].Status).Equal(t, StatusCompleted)
+			tests.Execute(result.Nodes["late"].Status).Equal(t, StatusCancelled)
+			tests.Execute(result.Nodes["late"].Reason).Equal(t, CancelDeadline)
+		})
+	}
+}
+
+func TestGraph_Walk_SubgraphParallelism(t *testing.T) {
+	var mutex sync.Mutex
+	running := make(map[string]int)
+	peak := make(map[string]int)
+
+	// Each node records how many nodes in the same group are running alongside it.
+	node := func(group string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			mutex.Lock()
+			running[group]++
+			peak[group] = max(peak[group], running[group])
+			mutex.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mutex.Lock()
+			running[group]--
+			mutex.Unlock()
+			return nil
+		})
+	}
+
+	expand := func(group string, nodes int, nested Meta) ExpandableNode {
+		return Expandable(func(ctx context.Context) (Graph, error) {
+			subgraph := NewGraph()
+			for ix := 0; ix < nodes; ix++ {
+				subgraph.AddNode(fmt.Sprintf("%s-%d", group, ix), node(group))
+			}
+			if nested.Subgraph.Parallelism > 0 {
+				// The nested subgraph counts towards the limit of this one as well as its own.
+				subgraph.AddNodeWithMeta(group+"-nested", Expandable(func(ctx context.Context) (Graph, error) {
+					inner := NewGraph()
+					for ix := 0; ix < nodes; ix++ {
+						inner.AddNode(fmt.Sprintf("%s-nested-%d", group, ix), node(group))
+					}
+					return inner, nil
+				}), nested)
+			}
+			return subgraph, nil
+		})
+	}
+
+	g := NewGraph()
+	g.AddNodeWithMeta("regions", expand("regions", 6, Meta{}), Meta{Subgraph: SubgraphLimits{Parallelism: 2}})
+	g.AddNodeWithMeta("outer", expand("outer", 4, Meta{Subgraph: SubgraphLimits{Parallelism: 1}}), Meta{Subgraph: SubgraphLimits{Parallelism: 3}})
+	g.AddNode("free", expand("free", 4, Meta{}))
+
+	result, err := g.Run(context.Background(), &Opts{Parallelism: 16})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(len(result.Status(StatusCompleted))).Equal(t, 6+4+4+4+1+3)
+	tests.Execute(peak["regions"]).Equal(t, 2)
+	tests.Execute(peak["outer"]).Equal(t, 3)
+	tests.Execute(peak["free"]).Equal(t, 4)
+}
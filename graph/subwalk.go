@@ -0,0 +1,30 @@
+package graph
+
+import "context"
+
+// subwalk is a node that walks a whole graph of its own.
+type subwalk struct {
+	graph Graph
+	opts  *Opts
+}
+
+// AsNode returns a node that walks the given graph when it executes, so a pre-built graph can be nested inside another
+// as a single node. Unlike a node that expands, the graph is walked in isolation: it has its own parallelism and its own
+// options, and its nodes never appear in the outer walk. The node fails with the error of the inner walk.
+//
+// The options are copied every time the node executes, so the node can be run more than once. If opts doesn't set a
+// logger, the inner walk logs through the logger of the node. A nil opts walks the graph serially.
+func AsNode(g Graph, opts *Opts) ExecutableNode {
+	return &subwalk{graph: g, opts: opts}
+}
+
+func (node *subwalk) Execute(ctx context.Context) error {
+	opts := Opts{Parallelism: 1}
+	if node.opts != nil {
+		opts = *node.opts
+	}
+	if opts.Logger == nil {
+		opts.Logger = Logger(ctx)
+	}
+	return node.graph.Walk(ctx, &opts)
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestAsNode(t *testing.T) {
+	var mutex sync.Mutex
+	var ran []string
+	record := func(key string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			ran = append(ran, key)
+			return nil
+		})
+	}
+
+	// The inner graph runs its two nodes at the same time even though the outer walk is serial, neither of them can
+	// finish until both have started.
+	var running atomic.Int32
+	barrier := make(chan struct{})
+	inner := NewGraph()
+	for _, key := range []string{"x", "y"} {
+		inner.AddNode(key, Executable(func(ctx context.Context) error {
+			if running.Add(1) == 2 {
+				close(barrier)
+			}
+			<-barrier
+			return record(key).Execute(ctx)
+		}))
+	}
+
+	outer := NewGraph()
+	outer.AddNode("a", record("a"))
+	outer.AddNode("inner", AsNode(inner, &Opts{Parallelism: 2}))
+	outer.AddNode("b", record("b"))
+	outer.Connect("a", "inner")
+	outer.Connect("inner", "b")
+
+	result, err := outer.Run(context.Background(), &Opts{Parallelism: 1})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(ran[0]).Equal(t, "a")
+	tests.Execute(ran[3]).Equal(t, "b")
+
+	// The inner nodes are isolated from the outer walk.
+	tests.Execute(result.Keys()).Equal(t, []string{"a", "b", "inner"})
+}
+
+func TestAsNode_Failed(t *testing.T) {
+	inner := NewGraph()
+	inner.AddNode("x", Executable(func(ctx context.Context) error {
+		return errors.New("boom")
+	}))
+
+	outer := NewGraph()
+	outer.AddNode("inner", AsNode(inner, nil))
+
+	tests.ExecuteE(outer.Walk(context.Background(), nil)).MatchesError(t, "inner: failed to execute node (x: failed to execute node (boom))")
+}
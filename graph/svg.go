@@ -0,0 +1,328 @@
+package graph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// svgMargin, svgNodeHeight, svgNodeGap and svgLayerGap set the spacing of an SVG drawing, in pixels.
+	svgMargin     = 20
+	svgNodeHeight = 30
+	svgNodeGap    = 20
+	svgLayerGap   = 50
+
+	// svgCharWidth is roughly how wide a character of a label is, since there's no way to measure text without a font.
+	svgCharWidth = 7
+)
+
+// slot is a position in one layer of a layeredLayout, taken by a node or by a dummy that an edge spanning several
+// layers passes through.
+type slot struct {
+	// key is the key of the node in the slot, it is empty for dummies.
+	key string
+
+	layer, order int
+	above, below []*slot
+
+	// x and y are the center of the slot, and width is how wide it is.
+	x, y, width int
+}
+
+// route is the path an edge takes through a layeredLayout, from the parent to the child.
+type route struct {
+	slots  []*slot
+	dashed bool
+}
+
+// layeredLayout positions the nodes of a graph in layers so every edge points down, using the Sugiyama method: cycles
+// are broken by reversing the edges that close them, nodes are put in the layer below their lowest parent, edges
+// spanning several layers are split by dummy slots, and the order of each layer is swept by the barycenter of the
+// neighbouring layers to reduce crossings.
+type layeredLayout struct {
+	layers [][]*slot
+	slots  map[string]*slot
+	routes []route
+
+	width, height int
+}
+
+// layered lays out the graph, along with a dashed edge from every node in expansions to each of the nodes it expanded
+// into.
+func (g Graph) layered(expansions map[string][]string) *layeredLayout {
+	type edge struct {
+		from, to         string
+		reversed, dashed bool
+	}
+
+	// Break cycles by reversing every edge that leads back to a node that is still being visited, starting from the
+	// starters so the edges that are reversed are the ones that point back up the graph.
+	var edges []edge
+	visiting, visited := make(map[string]bool), make(map[string]bool)
+	var visit func(key string)
+	visit = func(key string) {
+		visiting[key] = true
+		children := g.sortedChildren(key)
+		for ix, child := range append(children, expansions[key]...) {
+			dashed := ix >= len(children)
+			switch {
+			case child == key:
+				// Self loops can't be drawn between layers, so they're left out.
+			case visiting[child]:
+				edges = append(edges, edge{from: child, to: key, reversed: true, dashed: dashed})
+			default:
+				edges = append(edges, edge{from: key, to: child, dashed: dashed})
+				if !visited[child] {
+					visit(child)
+				}
+			}
+		}
+		visiting[key] = false
+		visited[key] = true
+	}
+	keys := g.sortedKeys()
+	for _, key := range keys {
+		if len(g.nodes[key].parents) == 0 && !visited[key] {
+			visit(key)
+		}
+	}
+	for _, key := range keys {
+		if !visited[key] {
+			visit(key)
+		}
+	}
+
+	// Put every node in the layer below its lowest parent, visiting them in topological order.
+	incoming, outgoing := make(map[string]int), make(map[string][]string)
+	for _, edge := range edges {
+		incoming[edge.to]++
+		outgoing[edge.from] = append(outgoing[edge.from], edge.to)
+	}
+	layers := make(map[string]int, len(keys))
+	var ready []string
+	for _, key := range keys {
+		if incoming[key] == 0 {
+			ready = append(ready, key)
+		}
+	}
+	for len(ready) > 0 {
+		key := ready[0]
+		ready = ready[1:]
+		for _, child := range outgoing[key] {
+			layers[child] = max(layers[child], layers[key]+1)
+			if incoming[child]--; incoming[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+
+	layout := &layeredLayout{slots: make(map[string]*slot, len(keys))}
+	place := func(slot *slot) {
+		for len(layout.layers) <= slot.layer {
+			layout.layers = append(layout.layers, nil)
+		}
+		slot.order = len(layout.layers[slot.layer])
+		layout.layers[slot.layer] = append(layout.layers[slot.layer], slot)
+	}
+	for _, key := range keys {
+		slot := &slot{key: key, layer: layers[key], width: len(key)*svgCharWidth + 2*svgNodeGap}
+		layout.slots[key] = slot
+		place(slot)
+	}
+
+	for _, edge := range edges {
+		from := layout.slots[edge.from]
+		slots := []*slot{from}
+		for layer := from.layer + 1; layer < layers[edge.to]; layer++ {
+			dummy := &slot{layer: layer, width: svgNodeGap}
+			place(dummy)
+			slots = append(slots, dummy)
+		}
+		slots = append(slots, layout.slots[edge.to])
+		for ix := 1; ix < len(slots); ix++ {
+			slots[ix-1].below = append(slots[ix-1].below, slots[ix])
+			slots[ix].above = append(slots[ix].above, slots[ix-1])
+		}
+
+		if edge.reversed {
+			slices.Reverse(slots)
+		}
+		layout.routes = append(layout.routes, route{slots: slots, dashed: edge.dashed})
+	}
+
+	layout.order()
+	layout.position()
+	return layout
+}
+
+// order reduces the number of crossing edges by sorting every layer by the average position of the neighbours of each
+// slot, sweeping down the layers and back up a few times.
+func (layout *layeredLayout) order() {
+	sweep := func(layer []*slot, neighbours func(slot *slot) []*slot) {
+		barycenters := make(map[*slot]float64, len(layer))
+		for _, slot := range layer {
+			barycenters[slot] = float64(slot.order)
+			if adjacent := neighbours(slot); len(adjacent) > 0 {
+				var sum int
+				for _, neighbour := range adjacent {
+					sum += neighbour.order
+				}
+				barycenters[slot] = float64(sum) / float64(len(adjacent))
+			}
+		}
+		sort.SliceStable(layer, func(i, j int) bool {
+			return barycenters[layer[i]] < barycenters[layer[j]]
+		})
+		for ix, slot := range layer {
+			slot.order = ix
+		}
+	}
+
+	for range 4 {
+		for ix := 1; ix < len(layout.layers); ix++ {
+			sweep(layout.layers[ix], func(slot *slot) []*slot { return slot.above })
+		}
+		for ix := len(layout.layers) - 2; ix >= 0; ix-- {
+			sweep(layout.layers[ix], func(slot *slot) []*slot { return slot.below })
+		}
+	}
+}
+
+// position works out the coordinates of every slot, centering each layer under the widest one.
+func (layout *layeredLayout) position() {
+	widths := make([]int, len(layout.layers))
+	for ix, layer := range layout.layers {
+		for _, slot := range layer {
+			widths[ix] += slot.width
+		}
+		widths[ix] += svgNodeGap * max(len(layer)-1, 0)
+		layout.width = max(layout.width, widths[ix])
+	}
+
+	for ix, layer := range layout.layers {
+		x := svgMargin + (layout.width-widths[ix])/2
+		for _, slot := range layer {
+			slot.x = x + slot.width/2
+			slot.y = svgMargin + ix*(svgNodeHeight+svgLayerGap) + svgNodeHeight/2
+			x += slot.width + svgNodeGap
+		}
+	}
+
+	layout.width += 2 * svgMargin
+	layout.height = 2*svgMargin + len(layout.layers)*svgNodeHeight + max(len(layout.layers)-1, 0)*svgLayerGap
+}
+
+// crossings counts the pairs of edges between adjacent layers that cross each other.
+func (layout *layeredLayout) crossings() int {
+	var crossings int
+	for _, layer := range layout.layers {
+		var segments [][2]int
+		for _, slot := range layer {
+			for _, below := range slot.below {
+				segments = append(segments, [2]int{slot.order, below.order})
+			}
+		}
+		for i := range segments {
+			for j := i + 1; j < len(segments); j++ {
+				if (segments[i][0]-segments[j][0])*(segments[i][1]-segments[j][1]) < 0 {
+					crossings++
+				}
+			}
+		}
+	}
+	return crossings
+}
+
+// svgColors maps the status of a node to the color it is filled with.
+var svgColors = map[Status]string{
+	StatusPending:   "#ffffff",
+	StatusRunning:   "#fff2a8",
+	StatusHeld:      "#fff2a8",
+	StatusCompleted: "#b8e0a8",
+	StatusErrored:   "#f2a7a7",
+	StatusSkipped:   "#e0e0e0",
+	StatusPruned:    "#e0e0e0",
+	StatusCancelled: "#c8c8c8",
+}
+
+// WriteSVG writes the graph as an SVG image. The graph is laid out in layers without needing Graphviz, so it can be
+// drawn anywhere.
+func (g Graph) WriteSVG(writer io.Writer) error {
+	return g.writeSVG(writer, nil, nil)
+}
+
+// WriteSVG writes the graph as it was walked as an SVG image, exactly like Graph.WriteSVG. Every node is filled with the
+// color of its status, and every node that expanded is connected to the nodes it expanded into with a dashed edge.
+func (result *WalkResult) WriteSVG(writer io.Writer) error {
+	return result.Graph.writeSVG(writer, result.expansions(), result.Nodes)
+}
+
+// writeSVG writes the graph as an SVG image, coloring the nodes that are in results by their status.
+func (g Graph) writeSVG(writer io.Writer, expansions map[string][]string, results map[string]*NodeResult) error {
+	layout := g.layered(expansions)
+
+	escape := func(value string) string {
+		var builder strings.Builder
+		_ = xml.EscapeText(&builder, []byte(value))
+		return builder.String()
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"monospace\" font-size=\"12\">\n",
+		layout.width, layout.height, layout.width, layout.height)
+	builder.WriteString("\t<defs>\n")
+	builder.WriteString("\t\t<marker id=\"arrow\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"6\" markerHeight=\"6\" orient=\"auto\">\n")
+	builder.WriteString("\t\t\t<path d=\"M 0 0 L 10 5 L 0 10 z\" fill=\"#555555\"/>\n")
+	builder.WriteString("\t\t</marker>\n")
+	builder.WriteString("\t</defs>\n")
+
+	for _, route := range layout.routes {
+		points := make([]string, len(route.slots))
+		for ix, slot := range route.slots {
+			y := slot.y
+			if len(slot.key) > 0 {
+				// Edges start and end at the top or bottom of a node, whichever faces the next slot along the route.
+				next := ix + 1
+				if next == len(route.slots) {
+					next = ix - 1
+				}
+				if route.slots[next].layer > slot.layer {
+					y += svgNodeHeight / 2
+				} else {
+					y -= svgNodeHeight / 2
+				}
+			}
+			points[ix] = fmt.Sprintf("%d,%d", slot.x, y)
+		}
+
+		dash := ""
+		if route.dashed {
+			dash = " stroke-dasharray=\"4 4\""
+		}
+		fmt.Fprintf(&builder, "\t<polyline points=\"%s\" fill=\"none\" stroke=\"#555555\"%s marker-end=\"url(#arrow)\"/>\n", strings.Join(points, " "), dash)
+	}
+
+	for _, key := range g.sortedKeys() {
+		slot := layout.slots[key]
+		fill, title := svgColors[StatusPending], key
+		if result, ok := results[key]; ok {
+			fill, title = svgColors[result.Status], fmt.Sprintf("%s (%s)", key, result.Status)
+		}
+
+		builder.WriteString("\t<g>\n")
+		fmt.Fprintf(&builder, "\t\t<title>%s</title>\n", escape(title))
+		fmt.Fprintf(&builder, "\t\t<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"4\" fill=\"%s\" stroke=\"#333333\"/>\n",
+			slot.x-slot.width/2, slot.y-svgNodeHeight/2, slot.width, svgNodeHeight, fill)
+		fmt.Fprintf(&builder, "\t\t<text x=\"%d\" y=\"%d\" text-anchor=\"middle\" dominant-baseline=\"middle\">%s</text>\n",
+			slot.x, slot.y, escape(key))
+		builder.WriteString("\t</g>\n")
+	}
+
+	builder.WriteString("</svg>\n")
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Layered(t *testing.T) {
+	tcs := map[string]struct {
+		nodes     []string
+		edges     [][2]string
+		layers    map[string]int
+		slots     []int
+		crossings int
+	}{
+		"diamond": {
+			nodes:  []string{"a", "b", "c", "d"},
+			edges:  [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}, {"a", "d"}},
+			layers: map[string]int{"a": 0, "b": 1, "c": 1, "d": 2},
+			// The edge from a to d passes through a dummy in the middle layer.
+			slots: []int{1, 3, 1},
+		},
+		"crossing": {
+			nodes:  []string{"a", "b", "x", "y"},
+			edges:  [][2]string{{"a", "y"}, {"b", "x"}},
+			layers: map[string]int{"a": 0, "b": 0, "x": 1, "y": 1},
+			slots:  []int{2, 2},
+		},
+		"cycle": {
+			nodes:  []string{"a", "b", "c"},
+			edges:  [][2]string{{"a", "b"}, {"b", "c"}, {"c", "b"}},
+			layers: map[string]int{"a": 0, "b": 1, "c": 2},
+			slots:  []int{1, 1, 1},
+		},
+		"disconnected": {
+			nodes:  []string{"a", "b"},
+			layers: map[string]int{"a": 0, "b": 0},
+			slots:  []int{2},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			g := NewGraph()
+			for _, key := range tc.nodes {
+				g.AddNode(key, Executable(func(ctx context.Context) error {
+					return nil
+				}))
+			}
+			for _, edge := range tc.edges {
+				tests.ExecuteE(g.Connect(edge[0], edge[1])).NoError(t)
+			}
+
+			layout := g.layered(nil)
+			layers := make(map[string]int)
+			for key, slot := range layout.slots {
+				layers[key] = slot.layer
+			}
+			tests.Execute(layers).Equal(t, tc.layers)
+
+			var slots []int
+			for _, layer := range layout.layers {
+				slots = append(slots, len(layer))
+			}
+			tests.Execute(slots).Equal(t, tc.slots)
+			tests.Execute(layout.crossings()).Equal(t, tc.crossings)
+			tests.Execute(len(layout.routes)).Equal(t, len(tc.edges))
+		})
+	}
+}
+
+func TestWalkResult_WriteSVG(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("build", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.AddNode("test & lint", Executable(func(ctx context.Context) error {
+		return fmt.Errorf("failed")
+	}))
+	g.AddNode("deploy", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect("build", "test & lint")
+	g.Connect("test & lint", "deploy")
+
+	result, _ := g.Run(context.Background(), &Opts{Parallelism: 1})
+
+	var builder strings.Builder
+	tests.ExecuteE(result.WriteSVG(&builder)).NoError(t)
+
+	// The image must be well formed XML, and describe every node along with its status.
+	fills := make(map[string]string)
+	var titles []string
+	decoder := xml.NewDecoder(strings.NewReader(builder.String()))
+	var title bool
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		tests.ExecuteE(err).NoError(t)
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			title = token.Name.Local == "title"
+			if token.Name.Local == "rect" {
+				for _, attr := range token.Attr {
+					if attr.Name.Local == "fill" {
+						fills[titles[len(titles)-1]] = attr.Value
+					}
+				}
+			}
+		case xml.CharData:
+			if title {
+				titles = append(titles, string(token))
+				title = false
+			}
+		}
+	}
+
+	tests.Execute(titles).Equal(t, []string{"build (completed)", "deploy (skipped)", "test & lint (errored)"})
+	tests.Execute(fills["build (completed)"]).Equal(t, svgColors[StatusCompleted])
+	tests.Execute(fills["deploy (skipped)"]).Equal(t, svgColors[StatusSkipped])
+	tests.Execute(fills["test & lint (errored)"]).Equal(t, svgColors[StatusErrored])
+	tests.Execute(strings.Count(builder.String(), "<polyline")).Equal(t, 2)
+}
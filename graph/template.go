@@ -0,0 +1,141 @@
+package graph
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// placeholder matches the parameter placeholders in a template prefix, such as "{region}".
+var placeholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// Template defines a graph once in terms of parameters, so concrete graphs can be instantiated for different values
+// (such as regions or environments) with the keys of each instance kept apart by a prefix.
+type Template struct {
+	// Params names every parameter the template requires. Instantiate fails unless all of them are bound, and no
+	// others are.
+	Params []string
+
+	// Prefix is prepended to every key in the instantiated graph, as a namespace. Parameters can be referenced as
+	// "{name}", for example "deploy/{region}".
+	//
+	// Nodes of prefixed graphs can't exchange values through ports, see WithPrefix, so Instantiate fails if the built
+	// graph declares any. Use the blackboard or artifacts instead.
+	//
+	// Optional, the keys are left unchanged if empty.
+	Prefix string
+
+	// Build builds the graph from the bound parameters.
+	Build BuildFunc
+}
+
+// Instantiate validates the parameters, builds the graph and applies the prefix to every key.
+func (template Template) Instantiate(params map[string]string) (Graph, error) {
+	if err := template.validate(params); err != nil {
+		return Graph{}, err
+	}
+
+	g, err := template.Build(params)
+	if err != nil {
+		return Graph{}, errors.New(err, FailedBuild, "failed to build template")
+	}
+
+	prefix := placeholder.ReplaceAllStringFunc(template.Prefix, func(match string) string {
+		return params[match[1:len(match)-1]]
+	})
+	if len(prefix) > 0 && (len(g.outputs) > 0 || len(g.inputs) > 0) {
+		return Graph{}, errors.Newf(nil, PrefixedPorts, "template declares ports, which can't be used with the prefix %q", prefix)
+	}
+	return g.WithPrefix(prefix), nil
+}
+
+// BuildFunc returns a BuildFunc that instantiates the template, so it can be added to a Registry.
+func (template Template) BuildFunc() BuildFunc {
+	return template.Instantiate
+}
+
+func (template Template) validate(params map[string]string) error {
+	declared := make(map[string]bool, len(template.Params))
+	for _, param := range template.Params {
+		declared[param] = true
+	}
+
+	for _, match := range placeholder.FindAllStringSubmatch(template.Prefix, -1) {
+		if !declared[match[1]] {
+			return errors.Newf(nil, UnknownParameter, "prefix refers to parameter %q, which is not declared", match[1])
+		}
+	}
+
+	var missing []string
+	for _, param := range template.Params {
+		if _, ok := params[param]; !ok {
+			missing = append(missing, param)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Newf(nil, UnboundParameter, "parameters are not bound: %v", missing)
+	}
+
+	var unknown []string
+	for param := range params {
+		if !declared[param] {
+			unknown = append(unknown, param)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return errors.Newf(nil, UnknownParameter, "parameters are not declared: %v", unknown)
+	}
+	return nil
+}
+
+// WithPrefix returns a copy of the graph with the given namespace prepended to every key. The graph is returned
+// unchanged if the prefix is empty.
+//
+// Output and Input handles keep the keys they were created with, so the nodes of the copy can't set or read the ports
+// declared for the graph: setting an output fails with a WrongProducer error, and reading an input with a
+// MissingOutput error.
+func (g Graph) WithPrefix(prefix string) Graph {
+	if len(prefix) == 0 {
+		return g
+	}
+	return g.rename(func(key string) string {
+		return JoinKey(prefix, key)
+	})
+}
+
+// rename returns a copy of the graph with every key passed through the given function.
+func (g Graph) rename(fn func(key string) string) Graph {
+	renamed := NewGraph()
+	for key, n := range g.nodes {
+		clone := &node{
+			key:  fn(key),
+			impl: n.impl,
+			meta: n.meta,
+		}
+		for _, parent := range n.parents {
+			clone.parents = append(clone.parents, fn(parent))
+		}
+		for _, child := range n.children {
+			clone.children = append(clone.children, fn(child))
+		}
+		renamed.nodes[clone.key] = clone
+	}
+	for key := range g.starters {
+		renamed.starters[fn(key)] = true
+	}
+	for key := range g.finishers {
+		renamed.finishers[fn(key)] = true
+	}
+	for key, outputs := range g.outputs {
+		renamed.outputs[fn(key)] = outputs
+	}
+	for key, inputs := range g.inputs {
+		for _, input := range inputs {
+			input.producer = fn(input.producer)
+			renamed.inputs[fn(key)] = append(renamed.inputs[fn(key)], input)
+		}
+	}
+	return renamed
+}
@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestTemplate(t *testing.T) {
+	template := Template{
+		Params: []string{"region", "environment"},
+		Prefix: "{environment}/{region}",
+		Build: func(params map[string]string) (Graph, error) {
+			g := NewGraph()
+			g.AddNode("plan", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			g.AddNode("apply", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			g.Connect("plan", "apply")
+			return g, nil
+		},
+	}
+
+	g, err := template.Instantiate(map[string]string{"region": "eu", "environment": "prod"})
+	tests.ExecuteE(err).NoError(t)
+	tests.Execute(g.sortedKeys()).Equal(t, []string{"prod/eu/apply", "prod/eu/plan"})
+	tests.Execute(g.Starters()).Equal(t, []string{"prod/eu/plan"})
+	tests.Execute(g.Finishers()).Equal(t, []string{"prod/eu/apply"})
+
+	_, err = template.Instantiate(map[string]string{"region": "eu"})
+	tests.ExecuteE(err).MatchesError(t, "parameters are not bound: [environment]")
+
+	_, err = template.Instantiate(map[string]string{"region": "eu", "environment": "prod", "zone": "a"})
+	tests.ExecuteE(err).MatchesError(t, "parameters are not declared: [zone]")
+}
+
+func TestTemplate_Ports(t *testing.T) {
+	template := Template{
+		Params: []string{"region"},
+		Prefix: "{region}",
+		Build: func(params map[string]string) (Graph, error) {
+			g := NewGraph()
+			g.AddNode("plan", Executable(func(ctx context.Context) error {
+				return nil
+			}))
+			_, err := NewOutput[string](g, "plan", "path")
+			return g, err
+		},
+	}
+
+	_, err := template.Instantiate(map[string]string{"region": "eu"})
+	tests.ExecuteE(err).MatchesError(t, `template declares ports, which can't be used with the prefix "eu"`)
+
+	// Without a prefix the handles still match the keys.
+	template.Prefix = ""
+	template.Params = nil
+	_, err = template.Instantiate(nil)
+	tests.ExecuteE(err).NoError(t)
+}
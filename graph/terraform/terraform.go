@@ -0,0 +1,265 @@
+// Package terraform builds graphs from Terraform plans, so infrastructure dependency graphs can be analysed and
+// visualised with the graph package: their critical paths, what depends on a resource, or which resources a change
+// affects.
+//
+// Every resource in the configuration is a node keyed by its address, such as aws_instance.web or
+// module.network.aws_vpc.main, and every dependency is an edge from the resource that is depended on to the resource
+// depending on it. The nodes don't do anything when walked, replace them with graph.Graph.ReplaceNode to attach work
+// to them.
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pasataleo/go-errors/errors"
+
+	"github.com/pasataleo/go-graph/graph"
+)
+
+var (
+	InvalidInput errors.ErrorCode = "terraform.invalid_input"
+)
+
+const (
+	// LabelType and LabelMode are set on every node to the type of the resource and whether it is a managed or a data
+	// resource, see graph.Meta.Labels.
+	LabelType = "terraform.type"
+	LabelMode = "terraform.mode"
+
+	// LabelActions is set to the actions the plan takes on the instances of the resource, such as "create" or
+	// "create,delete", sorted and separated by commas. It is only set for resources the plan changes.
+	LabelActions = "terraform.actions"
+)
+
+// plan is the part of the output of `terraform show -json` that ParsePlan reads.
+type plan struct {
+	Configuration struct {
+		RootModule module `json:"root_module"`
+	} `json:"configuration"`
+	ResourceChanges []struct {
+		ModuleAddress string `json:"module_address"`
+		Mode          string `json:"mode"`
+		Type          string `json:"type"`
+		Name          string `json:"name"`
+		Change        struct {
+			Actions []string `json:"actions"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+type module struct {
+	Resources []struct {
+		Address     string                 `json:"address"`
+		Mode        string                 `json:"mode"`
+		Type        string                 `json:"type"`
+		DependsOn   []string               `json:"depends_on"`
+		Expressions map[string]interface{} `json:"expressions"`
+	} `json:"resources"`
+	ModuleCalls map[string]struct {
+		Expressions map[string]interface{} `json:"expressions"`
+		Module      module                 `json:"module"`
+	} `json:"module_calls"`
+}
+
+// resource is a resource in the configuration, along with the addresses it refers to relative to its module.
+type resource struct {
+	address    string
+	module     string
+	mode       string
+	typ        string
+	references []string
+}
+
+// call is a module call, along with the addresses each of its input variables refers to relative to the calling
+// module.
+type call struct {
+	caller string
+	inputs map[string][]string
+}
+
+// ParsePlan builds a graph from the JSON representation of a plan, as written by `terraform show -json`. Resources
+// depend on the resources named by their depends_on arguments and referred to by their expressions. Referring to a
+// module, for example to one of its outputs, depends on every resource within it, and referring to an input variable of
+// a module depends on the resources the module call sets it from.
+func ParsePlan(reader io.Reader) (graph.Graph, error) {
+	var plan plan
+	if err := json.NewDecoder(reader).Decode(&plan); err != nil {
+		return graph.Graph{}, errors.New(err, InvalidInput, "failed to decode plan")
+	}
+
+	var resources []resource
+	calls := make(map[string]call)
+	collect(plan.Configuration.RootModule, "", &resources, calls)
+
+	actions := make(map[string]map[string]bool)
+	for _, change := range plan.ResourceChanges {
+		address := join(change.ModuleAddress, change.Type+"."+change.Name)
+		if change.Mode == "data" {
+			address = join(change.ModuleAddress, "data."+change.Type+"."+change.Name)
+		}
+		if actions[address] == nil {
+			actions[address] = make(map[string]bool)
+		}
+		for _, action := range change.Change.Actions {
+			if action != "no-op" {
+				actions[address][action] = true
+			}
+		}
+	}
+
+	g := graph.NewGraph()
+	for _, resource := range resources {
+		labels := map[string]string{
+			LabelType: resource.typ,
+			LabelMode: resource.mode,
+		}
+		if len(actions[resource.address]) > 0 {
+			var planned []string
+			for action := range actions[resource.address] {
+				planned = append(planned, action)
+			}
+			sort.Strings(planned)
+			labels[LabelActions] = strings.Join(planned, ",")
+		}
+
+		if err := g.AddNodeWithMeta(resource.address, graph.Executable(func(ctx context.Context) error {
+			return nil
+		}), graph.Meta{Labels: labels}); err != nil {
+			return graph.Graph{}, err
+		}
+	}
+
+	for _, resource := range resources {
+		connected := make(map[string]bool)
+		for _, reference := range resource.references {
+			for _, dependency := range resolve(resources, calls, resource.module, reference) {
+				if dependency == resource.address || connected[dependency] {
+					continue
+				}
+				connected[dependency] = true
+				if err := g.Connect(dependency, resource.address); err != nil {
+					return graph.Graph{}, err
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+// collect adds the resources of the module, and of every module it calls, to resources, and every module call to calls.
+func collect(module module, prefix string, resources *[]resource, calls map[string]call) {
+	for _, config := range module.Resources {
+		resource := resource{
+			address: join(prefix, config.Address),
+			module:  prefix,
+			mode:    config.Mode,
+			typ:     config.Type,
+		}
+		resource.references = append(resource.references, config.DependsOn...)
+		gather(config.Expressions, &resource.references)
+		*resources = append(*resources, resource)
+	}
+
+	names := make([]string, 0, len(module.ModuleCalls))
+	for name := range module.ModuleCalls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		config := module.ModuleCalls[name]
+		address := join(prefix, "module."+name)
+
+		call := call{caller: prefix, inputs: make(map[string][]string)}
+		for variable, expression := range config.Expressions {
+			var references []string
+			gather(expression, &references)
+			call.inputs[variable] = references
+		}
+		calls[address] = call
+
+		collect(config.Module, address, resources, calls)
+	}
+}
+
+// gather adds every reference within the expressions to references. Expressions are nested within blocks, so every
+// value is searched.
+func gather(value interface{}, references *[]string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if found, ok := value["references"].([]interface{}); ok {
+			for _, reference := range found {
+				if reference, ok := reference.(string); ok {
+					*references = append(*references, reference)
+				}
+			}
+		}
+		for _, nested := range value {
+			gather(nested, references)
+		}
+	case []interface{}:
+		for _, nested := range value {
+			gather(nested, references)
+		}
+	}
+}
+
+// resolve returns the addresses of the resources a reference made within the given module refers to.
+func resolve(resources []resource, calls map[string]call, module string, reference string) []string {
+	parts := strings.Split(reference, ".")
+	switch {
+	case parts[0] == "var" && len(parts) >= 2:
+		// Input variables of a module refer to whatever the module call sets them from, in the calling module.
+		call, ok := calls[module]
+		if !ok {
+			return nil
+		}
+
+		var addresses []string
+		for _, reference := range call.inputs[parts[1]] {
+			addresses = append(addresses, resolve(resources, calls, call.caller, reference)...)
+		}
+		return addresses
+	case parts[0] == "module" && len(parts) >= 2:
+		// Strip any index from the module call, module.name[0] refers to the same resources as module.name.
+		name, _, _ := strings.Cut(parts[1], "[")
+		within := join(module, "module."+name) + "."
+
+		var addresses []string
+		for _, resource := range resources {
+			if strings.HasPrefix(resource.address, within) {
+				addresses = append(addresses, resource.address)
+			}
+		}
+		return addresses
+	case parts[0] == "data" && len(parts) >= 3:
+		return exists(resources, join(module, strings.Join(parts[:3], ".")))
+	case len(parts) >= 2:
+		// Variables, locals and the like have two part names as well, but no resources with the same address.
+		name, _, _ := strings.Cut(parts[1], "[")
+		return exists(resources, join(module, parts[0]+"."+name))
+	}
+	return nil
+}
+
+// exists returns the address if there is a resource with it.
+func exists(resources []resource, address string) []string {
+	address, _, _ = strings.Cut(address, "[")
+	for _, resource := range resources {
+		if resource.address == address {
+			return []string{address}
+		}
+	}
+	return nil
+}
+
+// join returns the address within the module.
+func join(module string, address string) string {
+	if len(module) == 0 {
+		return address
+	}
+	return module + "." + address
+}
@@ -0,0 +1,129 @@
+package terraform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+const example = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_instance.web[0]", "mode": "managed", "type": "aws_instance", "name": "web", "change": {"actions": ["create"]}},
+    {"address": "aws_instance.web[1]", "mode": "managed", "type": "aws_instance", "name": "web", "change": {"actions": ["delete", "create"]}},
+    {"address": "aws_security_group.web", "mode": "managed", "type": "aws_security_group", "name": "web", "change": {"actions": ["no-op"]}},
+    {"address": "module.network.aws_vpc.main", "module_address": "module.network", "mode": "managed", "type": "aws_vpc", "name": "main", "change": {"actions": ["update"]}}
+  ],
+  "configuration": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "aws_instance.web",
+          "mode": "managed",
+          "type": "aws_instance",
+          "expressions": {
+            "ami": {"references": ["data.aws_ami.ubuntu.id", "data.aws_ami.ubuntu"]},
+            "subnet_id": {"references": ["module.network.subnet_id", "module.network"]},
+            "count": {"references": ["var.instances"]},
+            "network_interface": [{"security_groups": {"references": ["aws_security_group.web[0].id", "aws_security_group.web"]}}]
+          }
+        },
+        {
+          "address": "aws_security_group.web",
+          "mode": "managed",
+          "type": "aws_security_group",
+          "depends_on": ["module.network"]
+        },
+        {"address": "aws_kms_key.main", "mode": "managed", "type": "aws_kms_key"},
+        {
+          "address": "data.aws_ami.ubuntu",
+          "mode": "data",
+          "type": "aws_ami",
+          "expressions": {"owners": {"constant_value": ["099720109477"]}}
+        }
+      ],
+      "module_calls": {
+        "network": {
+          "expressions": {"key_id": {"references": ["aws_kms_key.main.arn", "aws_kms_key.main"]}},
+          "module": {
+            "resources": [
+              {
+                "address": "aws_vpc.main",
+                "mode": "managed",
+                "type": "aws_vpc",
+                "expressions": {"kms_key_id": {"references": ["var.key_id"]}}
+              },
+              {
+                "address": "aws_subnet.main",
+                "mode": "managed",
+                "type": "aws_subnet",
+                "expressions": {"vpc_id": {"references": ["aws_vpc.main.id", "aws_vpc.main", "local.cidr"]}}
+              }
+            ]
+          }
+        }
+      }
+    }
+  }
+}`
+
+func TestParsePlan(t *testing.T) {
+	g, err := ParsePlan(strings.NewReader(example))
+	tests.ExecuteE(err).NoError(t)
+	tests.ExecuteE(g.Validate()).NoError(t)
+
+	tcs := map[string]struct {
+		dependents []string
+		labels     map[string]string
+	}{
+		"aws_instance.web": {
+			labels: map[string]string{LabelType: "aws_instance", LabelMode: "managed", LabelActions: "create,delete"},
+		},
+		"aws_security_group.web": {
+			dependents: []string{"aws_instance.web"},
+			labels:     map[string]string{LabelType: "aws_security_group", LabelMode: "managed"},
+		},
+		"aws_kms_key.main": {
+			dependents: []string{"module.network.aws_vpc.main"},
+			labels:     map[string]string{LabelType: "aws_kms_key", LabelMode: "managed"},
+		},
+		"data.aws_ami.ubuntu": {
+			dependents: []string{"aws_instance.web"},
+			labels:     map[string]string{LabelType: "aws_ami", LabelMode: "data"},
+		},
+		"module.network.aws_subnet.main": {
+			dependents: []string{"aws_instance.web", "aws_security_group.web"},
+			labels:     map[string]string{LabelType: "aws_subnet", LabelMode: "managed"},
+		},
+		"module.network.aws_vpc.main": {
+			dependents: []string{"aws_instance.web", "aws_security_group.web", "module.network.aws_subnet.main"},
+			labels:     map[string]string{LabelType: "aws_vpc", LabelMode: "managed", LabelActions: "update"},
+		},
+	}
+
+	for address, tc := range tcs {
+		t.Run(address, func(t *testing.T) {
+			dependents, err := g.ReverseDependencies(address, false)
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(dependents).Equal(t, tc.dependents)
+
+			meta, ok := g.Meta(address)
+			tests.Execute(ok).Equal(t, true)
+			tests.Execute(meta.Labels).Equal(t, tc.labels)
+		})
+	}
+
+	tests.Execute(g.Plan().Generations).Equal(t, [][]string{
+		{"aws_kms_key.main", "data.aws_ami.ubuntu"},
+		{"module.network.aws_vpc.main"},
+		{"module.network.aws_subnet.main"},
+		{"aws_security_group.web"},
+		{"aws_instance.web"},
+	})
+}
+
+func TestParsePlan_Invalid(t *testing.T) {
+	_, err := ParsePlan(strings.NewReader("not json"))
+	tests.ExecuteE(err).MatchesError(t, "failed to decode plan (invalid character 'o' in literal null (expecting 'u'))")
+}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// FormatEvent renders an event as a single line of the canonical trace format.
+//
+// Each line contains the event type, followed by the node key for node events, followed by the cancel reason for
+// cancelled nodes, followed by the quoted error message if there is one:
+//
+//	walk.started
+//	node.started a
+//	node.errored a "failed to execute node (boom)"
+//	node.cancelled b fail_fast
+//	walk.finished "failed to execute node (boom)"
+//
+// Walk IDs and times are left out, so walks of the same graph with a Parallelism of 1 always produce the same trace.
+func FormatEvent(event Event) string {
+	parts := []string{string(event.Type)}
+	if len(event.Key) > 0 {
+		parts = append(parts, event.Key)
+	}
+	if len(event.Reason) > 0 {
+		parts = append(parts, string(event.Reason))
+	}
+	if event.Err != nil {
+		parts = append(parts, fmt.Sprintf("%q", event.Err.Error()))
+	}
+	return strings.Join(parts, " ")
+}
+
+// FormatTrace renders the events in the canonical trace format, one event per line.
+func FormatTrace(events []Event) string {
+	var builder strings.Builder
+	for _, event := range events {
+		builder.WriteString(FormatEvent(event))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// TraceSink returns a sink that writes every event to the given writer in the canonical trace format.
+//
+// Write errors are ignored, callers that care should wrap the writer.
+func TraceSink(writer io.Writer) Sink {
+	var mutex sync.Mutex
+	return SinkFunc(func(event Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		_, _ = io.WriteString(writer, FormatEvent(event)+"\n")
+	})
+}
@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestTraceSink(t *testing.T) {
+	g := NewGraph()
+	for _, key := range []string{"c", "b", "a"} {
+		g.AddNode(key, Executable(func(ctx context.Context) error {
+			return nil
+		}))
+	}
+
+	walk := func() string {
+		var builder strings.Builder
+		tests.ExecuteE(g.Walk(context.Background(), &Opts{
+			Parallelism: 1,
+			Bus:         NewBus(TraceSink(&builder)),
+		})).NoError(t)
+		return builder.String()
+	}
+
+	expected := "walk.started\n" +
+		"node.started a\n" +
+		"node.completed a\n" +
+		"node.started b\n" +
+		"node.completed b\n" +
+		"node.started c\n" +
+		"node.completed c\n" +
+		"walk.finished\n"
+	for ix := 0; ix < 5; ix++ {
+		tests.Execute(walk()).Equal(t, expected)
+	}
+}
@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"maps"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Transaction stages changes to a graph so they can be validated and then applied all at once, or thrown away. Until
+// the transaction is committed the changes are made to a copy of the graph, so anything reading or walking the live
+// graph never sees it half built. Observers of the live graph are notified of every staged change once it is
+// committed.
+//
+// A transaction isn't safe for concurrent use, and Commit must not run at the same time as anything else reading or
+// modifying the live graph, just like any other change to a graph.
+type Transaction struct {
+	// live is the graph the changes are committed to.
+	live Graph
+
+	// staged is the copy of the live graph the changes are made to.
+	staged Graph
+
+	// changes records the changes made to the staged graph, so they can be reported to the observers of the live graph.
+	changes *changeLog
+
+	closed bool
+}
+
+// Begin starts a transaction against the graph. The live graph is left alone until Transaction.Commit is called.
+func (g Graph) Begin() *Transaction {
+	tx := &Transaction{
+		live:    g,
+		staged:  g.Clone(),
+		changes: &changeLog{},
+	}
+	tx.staged.Observe(tx.changes)
+	return tx
+}
+
+// Graph returns the graph with the staged changes applied. Changes made to it directly are staged as well.
+func (tx *Transaction) Graph() Graph {
+	return tx.staged
+}
+
+// AddNode stages adding a node, see Graph.AddNode.
+func (tx *Transaction) AddNode(key string, impl interface{}) error {
+	return tx.AddNodeWithMeta(key, impl, Meta{})
+}
+
+// AddNodeWithMeta stages adding a node along with metadata describing it, see Graph.AddNodeWithMeta.
+func (tx *Transaction) AddNodeWithMeta(key string, impl interface{}, meta Meta) error {
+	if err := tx.open(); err != nil {
+		return err
+	}
+	return tx.staged.AddNodeWithMeta(key, impl, meta)
+}
+
+// Connect stages connecting two nodes, see Graph.Connect.
+func (tx *Transaction) Connect(from string, to string) error {
+	if err := tx.open(); err != nil {
+		return err
+	}
+	return tx.staged.Connect(from, to)
+}
+
+// RemoveNode stages removing a node, see Graph.RemoveNode.
+func (tx *Transaction) RemoveNode(key string) error {
+	if err := tx.open(); err != nil {
+		return err
+	}
+	return tx.staged.RemoveNode(key)
+}
+
+// Validate validates the graph with the staged changes applied, see Graph.Validate.
+func (tx *Transaction) Validate() error {
+	return tx.staged.Validate()
+}
+
+// Commit validates the staged changes and applies them to the live graph, which afterwards is exactly the graph
+// returned by Graph. If validation fails, the live graph is left unchanged and the transaction stays open, so the
+// changes can be fixed or rolled back.
+//
+// Commit returns an error with the ClosedTransaction code if the transaction was already committed or rolled back.
+func (tx *Transaction) Commit() error {
+	if err := tx.open(); err != nil {
+		return err
+	}
+	if err := tx.staged.Validate(); err != nil {
+		return err
+	}
+
+	// The staged graph is a complete copy, so swapping in its contents replaces the live graph wholesale.
+	replace(tx.live.nodes, tx.staged.nodes)
+	replace(tx.live.starters, tx.staged.starters)
+	replace(tx.live.finishers, tx.staged.finishers)
+	replace(tx.live.outputs, tx.staged.outputs)
+	replace(tx.live.inputs, tx.staged.inputs)
+	tx.live.memo.reset()
+	tx.closed = true
+
+	for _, change := range *tx.changes {
+		tx.live.observers.notify(change)
+	}
+	return nil
+}
+
+// Rollback throws away the staged changes. Rolling back a transaction that is already closed does nothing, so it can
+// safely be deferred.
+func (tx *Transaction) Rollback() {
+	tx.closed = true
+}
+
+func (tx *Transaction) open() error {
+	if tx.closed {
+		return errors.New(nil, ClosedTransaction, "transaction has already been committed or rolled back")
+	}
+	return nil
+}
+
+// replace replaces the contents of dst with the contents of src.
+func replace[K comparable, V any](dst map[K]V, src map[K]V) {
+	clear(dst)
+	maps.Copy(dst, src)
+}
+
+var _ Observer = (*changeLog)(nil)
+
+// changeLog is an Observer that records every change, so it can be replayed to other observers later.
+type changeLog []func(observer Observer)
+
+func (log *changeLog) NodeAdded(key string, meta Meta) {
+	*log = append(*log, func(observer Observer) {
+		observer.NodeAdded(key, meta)
+	})
+}
+
+func (log *changeLog) NodeReplaced(key string, meta Meta) {
+	*log = append(*log, func(observer Observer) {
+		observer.NodeReplaced(key, meta)
+	})
+}
+
+func (log *changeLog) NodeRemoved(key string) {
+	*log = append(*log, func(observer Observer) {
+		observer.NodeRemoved(key)
+	})
+}
+
+func (log *changeLog) EdgeAdded(from string, to string) {
+	*log = append(*log, func(observer Observer) {
+		observer.EdgeAdded(from, to)
+	})
+}
+
+func (log *changeLog) EdgeRemoved(from string, to string) {
+	*log = append(*log, func(observer Observer) {
+		observer.EdgeRemoved(from, to)
+	})
+}
@@ -0,0 +1,79 @@
+package graph
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestTransaction(t *testing.T) {
+	noop := Executable(func(ctx context.Context) error {
+		return nil
+	})
+
+	keys := func(g Graph) []string {
+		var keys []string
+		for key := range g.nodes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+
+	live := NewGraph()
+	live.AddNode("a", noop)
+	live.AddNode("b", noop)
+	live.Connect("a", "b")
+
+	t.Run("commit", func(t *testing.T) {
+		g := live.Clone()
+		tx := g.Begin()
+		defer tx.Rollback()
+
+		tests.ExecuteE(tx.AddNode("c", noop)).NoError(t)
+		tests.ExecuteE(tx.Connect("a", "c")).NoError(t)
+		tests.ExecuteE(tx.RemoveNode("b")).NoError(t)
+
+		// Nothing is visible until the transaction is committed.
+		tests.Execute(keys(g)).Equal(t, []string{"a", "b"})
+		tests.Execute(keys(tx.Graph())).Equal(t, []string{"a", "c"})
+
+		tests.ExecuteE(tx.Commit()).NoError(t)
+		tests.Execute(keys(g)).Equal(t, []string{"a", "c"})
+		tests.Execute(g.Finishers()).Equal(t, []string{"c"})
+
+		tests.Execute(errors.GetErrorCode(tx.AddNode("d", noop))).Equal(t, ClosedTransaction)
+		tests.ExecuteE(tx.Commit()).MatchesError(t, "transaction has already been committed or rolled back")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		g := live.Clone()
+		tx := g.Begin()
+		defer tx.Rollback()
+
+		tests.ExecuteE(tx.AddNode("c", noop)).NoError(t)
+		tests.ExecuteE(tx.Connect("b", "c")).NoError(t)
+		tests.ExecuteE(tx.Connect("c", "a")).NoError(t)
+
+		tests.ExecuteE(tx.Commit()).MatchesError(t, "found cycle in graph: a -> b -> c -> a")
+		tests.Execute(keys(g)).Equal(t, []string{"a", "b"})
+
+		// The transaction stays open, so the problem can be fixed.
+		tests.ExecuteE(tx.RemoveNode("c")).NoError(t)
+		tests.ExecuteE(tx.Commit()).NoError(t)
+		tests.Execute(keys(g)).Equal(t, []string{"a", "b"})
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		g := live.Clone()
+		tx := g.Begin()
+		tests.ExecuteE(tx.AddNode("c", noop)).NoError(t)
+		tx.Rollback()
+
+		tests.Execute(errors.GetErrorCode(tx.Commit())).Equal(t, ClosedTransaction)
+		tests.Execute(keys(g)).Equal(t, []string{"a", "b"})
+	})
+}
@@ -7,7 +7,8 @@ import (
 	"github.com/pasataleo/go-errors/errors"
 )
 
-// Validate validates the graph and returns an error if it detects any cycles.
+// Validate validates the graph and returns an error if it detects any cycles, or any inputs that are not bound to a
+// matching output.
 func (g Graph) Validate() error {
 	var keys []string
 	for key := range g.nodes {
@@ -21,7 +22,7 @@ func (g Graph) Validate() error {
 			return err
 		}
 	}
-	return nil
+	return g.validatePorts()
 }
 
 // dfs performs a depth-first search on the graph, returning an error if it detects any cycles.
@@ -29,7 +30,10 @@ func (g Graph) dfs(current string, visited map[string]bool, path []string) error
 	for ix, ancestor := range path {
 		if ancestor == string(current) {
 			// Then we have a cycle.
-			return errors.Newf(nil, errors.ErrorCodeUnknown, "found cycle in graph: %s", strings.Join(append(path[ix:], current), " -> "))
+			cycle := append(append([]string(nil), path[ix:]...), current)
+			err := errors.Newf(nil, CycleDetected, "found cycle in graph: %s", strings.Join(cycle, " -> "))
+			err = errors.Embed(err, NodeKey, current)
+			return errors.Embed(err, Cycle, cycle)
 		}
 	}
 
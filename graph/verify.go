@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// verifyDispatch asserts the invariants that must hold before a node is handed to a worker. It panics with a dump of
+// the walker state if they don't.
+func (walker *walker) verifyDispatch(key string) {
+	if _, ok := walker.executions[key]; ok {
+		walker.violation(key, "node %q dispatched more than once", key)
+	}
+	if walker.completed.has(key) {
+		walker.violation(key, "node %q dispatched after it completed", key)
+	}
+	// Nodes joining their parents with a timeout may run before enough of them have completed, and rollout gates run
+	// once their parents have finished whether they failed or not.
+	node, completed := walker.nodes[key], 0
+	_, gate := node.impl.(*rolloutGate)
+	for _, parent := range node.parents {
+		if _, errored := walker.errored[parent]; walker.completed.has(parent) || (gate && errored) {
+			completed++
+		} else if node.required() == len(node.parents) && node.meta.Readiness.Timeout == 0 {
+			walker.violation(key, "node %q dispatched before its parent %q completed", key, parent)
+		}
+	}
+	if completed < node.required() && node.meta.Readiness.Timeout == 0 {
+		walker.violation(key, "node %q dispatched after %d of its parents completed, it needs %d", key, completed, node.required())
+	}
+	if expander, ok := walker.expandedBy[key]; ok {
+		if _, ok := walker.subgraphStarters[expander]; !ok {
+			walker.violation(key, "node %q dispatched before %q expanded", key, expander)
+		}
+	}
+}
+
+// verifyFinish asserts the invariants that must hold when a node reports back from a worker.
+func (walker *walker) verifyFinish(key string) {
+	if walker.completed.has(key) {
+		walker.violation(key, "node %q finished more than once", key)
+	}
+	if _, ok := walker.errored[key]; ok {
+		walker.violation(key, "node %q finished after it errored", key)
+	}
+	if walker.cancelled.has(key) {
+		walker.violation(key, "node %q finished after it was cancelled", key)
+	}
+	if _, ok := walker.subgraphStarters[key]; ok {
+		return // expanded nodes are completed by their subgraph, after they stopped processing.
+	}
+	if !walker.processing[key] {
+		walker.violation(key, "node %q finished but was never dispatched", key)
+	}
+}
+
+// violation panics with an InvariantViolation error that describes the state of the walk.
+func (walker *walker) violation(key string, format string, args ...interface{}) {
+	err := errors.Newf(nil, InvariantViolation, "%s\n%s", fmt.Sprintf(format, args...), walker.dump())
+	panic(errors.Embed(err, NodeKey, key))
+}
+
+// dump renders the internal state of the walker for diagnostics.
+func (walker *walker) dump() string {
+	set := func(values map[string]bool) string {
+		var keys []string
+		for key, ok := range values {
+			if ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		return strings.Join(keys, ", ")
+	}
+
+	errored := make(map[string]bool, len(walker.errored))
+	for key := range walker.errored {
+		errored[key] = true
+	}
+	expanded := make(map[string]bool, len(walker.subgraphStarters))
+	for key := range walker.subgraphStarters {
+		expanded[key] = true
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "walk %s:\n", walker.id)
+	fmt.Fprintf(&builder, "  pending:    [%s]\n", strings.Join(walker.pending.keys(), ", "))
+	fmt.Fprintf(&builder, "  processing: [%s]\n", set(walker.processing))
+	fmt.Fprintf(&builder, "  completed:  [%s]\n", strings.Join(walker.completed.keys(), ", "))
+	fmt.Fprintf(&builder, "  errored:    [%s]\n", set(errored))
+	fmt.Fprintf(&builder, "  expanded:   [%s]\n", set(expanded))
+	for _, key := range (Graph{nodes: walker.nodes}).sortedKeys() {
+		fmt.Fprintf(&builder, "  %s <- [%s]\n", key, strings.Join(walker.nodes[key].parents, ", "))
+	}
+	return builder.String()
+}
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Verify(t *testing.T) {
+	var builder strings.Builder
+	write := func(value string) ExecutableNode {
+		return Executable(func(ctx context.Context) error {
+			builder.WriteString(value)
+			return nil
+		})
+	}
+
+	g := NewGraph()
+	g.AddNode("a", write("a"))
+	g.AddNode("b", Expandable(func(ctx context.Context) (Graph, error) {
+		subgraph := NewGraph()
+		subgraph.AddNode("b1", write("b1"))
+		subgraph.AddNode("b2", write("b2"))
+		subgraph.Connect("b1", "b2")
+		return subgraph, nil
+	}))
+	g.AddNode("c", write("c"))
+	g.Connect("a", "b")
+	g.Connect("b", "c")
+
+	tests.ExecuteE(g.Walk(context.Background(), &Opts{Parallelism: 4, Verify: true})).NoError(t)
+	tests.Execute(builder.String()).Equal(t, "ab1b2c")
+}
+
+func TestWalker_Verify_Violation(t *testing.T) {
+	nodes := map[string]*node{
+		"a": {key: "a", children: []string{"b"}},
+		"b": {key: "b", parents: []string{"a"}},
+	}
+
+	walker := &walker{
+		id:               "walk",
+		nodes:            nodes,
+		executions:       make(map[string]*execution),
+		pending:          new(queue),
+		processing:       map[string]bool{"a": true},
+		completed:        make(mapSet),
+		errored:          make(map[string]error),
+		subgraphStarters: make(map[string][]string),
+		expandedBy:       make(map[string]string),
+	}
+
+	var violation error
+	func() {
+		defer func() {
+			violation = recover().(error)
+		}()
+		walker.verifyDispatch("b")
+	}()
+
+	tests.Execute(errors.GetErrorCode(violation)).Equal(t, InvariantViolation)
+	tests.Execute(strings.Contains(violation.Error(), "node \"b\" dispatched before its parent \"a\" completed")).Equal(t, true)
+	tests.Execute(strings.Contains(violation.Error(), "processing: [a]")).Equal(t, true)
+}
@@ -2,6 +2,13 @@ package graph
 
 import (
 	"context"
+	stderrors "errors"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pasataleo/go-errors/errors"
 	"github.com/pasataleo/go-threading/threading"
@@ -10,54 +17,562 @@ import (
 type walker struct {
 	//sync.Mutex
 
+	// id uniquely identifies this walk in published events.
+	id string
+
+	// bus receives the events published during the walk.
+	bus *Bus
+
+	// result records what happened to every node that has been dispatched.
+	result *WalkResult
+
+	// executions contains the execution state of every node that has been dispatched.
+	executions map[string]*execution
+
 	// nodes is used to look up nodes by key.
 	nodes map[string]*node
 
-	// pending is a map of nodes that are pending execution.
-	pending map[string]bool
+	// pending is a queue of nodes that are ready to be dispatched, in the order they became ready or interleaved across
+	// tags if Opts.Weights is set.
+	pending pending
+
+	// readyAt records when each pending node became ready.
+	readyAt map[string]time.Time
+
+	// readySince records when each node that hasn't been dispatched yet first became ready, however often it has been
+	// held or throttled since. starved records the nodes that waited too long and why, and watchdog fires whenever
+	// they should be looked for. They are only set if Opts.StarvationThreshold is.
+	readySince map[string]time.Time
+	starved    map[string]StarveReason
+	watchdog   *time.Ticker
+
+	// scheduler records how the walker itself is performing.
+	scheduler *scheduler
 
 	// processing is a map of nodes that are currently being processed.
 	processing map[string]bool
 
-	// completed is a map of nodes that have finished.
-	completed map[string]bool
+	// completed is the set of nodes that have finished, including nodes that were pruned.
+	completed keySet
+
+	// pruned is the set of optional nodes that were skipped.
+	pruned keySet
 
 	// errored is a map of nodes that have errored.
 	errored map[string]error
 
+	// cancelled is the set of nodes that were cancelled.
+	cancelled keySet
+
 	// subgraphStarters keeps track of all the nodes that started a subgraph, mapped to the nodes that finish it.
 	subgraphStarters map[string][]string
 
 	// subgraphFinishers keeps track of all the nodes that finish a subgraph, mapped to the node that started it.
 	subgraphFinishers map[string]string
+
+	// remaining counts the parents of each node that haven't completed yet, so a node is ready as soon as it reaches
+	// zero without looking at every parent again.
+	remaining map[string]int
+
+	// unfinished counts the finishers of each expanded node's subgraph that haven't completed yet.
+	unfinished map[string]int
+
+	// rng breaks ties between ready nodes when the walk is seeded, it is nil otherwise.
+	rng *rand.Rand
+
+	// parallelism is the maximum number of nodes that are dispatched at the same time.
+	parallelism int
+
+	// deadline is when the walk should finish by, it is zero if the walk has no deadline.
+	deadline time.Time
+
+	// paths caches the critical path from each node to the end of the walk. It is reset whenever nodes are added.
+	paths map[string]time.Duration
+
+	// held maps the nodes that are waiting for their windows to open to when they open. timer fires when the first of
+	// them opens, it is nil when no nodes are held.
+	held  map[string]time.Time
+	timer *time.Timer
+
+	// now returns the current time when checking windows.
+	now func() time.Time
+
+	// quotaStore keeps track of Opts.Quotas, it is nil if the walk has no quotas.
+	quotaStore QuotaStore
+
+	// partial records the errors of nodes whose expansion was partial, they fail once their subgraph has finished.
+	partial map[string]error
+
+	// winners maps every group that is ready to the member that succeeded first, see Graph.AddGroup.
+	winners map[string]string
+
+	// joins maps the nodes that are waiting for their parents with a timeout to when it runs out, see Readiness.Timeout.
+	// They share the timer with held nodes.
+	joins map[string]time.Time
+
+	// lost records the nodes that lost a race and are being cancelled, see Readiness.Cancel.
+	lost map[string]bool
+
+	// attempts records the attempt number of nodes that are being retried, and previous the error their last attempt
+	// failed with. See ChildErrorHandler.
+	attempts map[string]int
+	previous map[string]error
+
+	// budget is Opts.MaxCost, and exhausted is set once the nodes have spent it.
+	budget    float64
+	exhausted bool
+
+	// batch is reused by Process to hand nodes to dispatch, so scheduling doesn't allocate once it has grown.
+	batch []string
+
+	// values contains the typed outputs set by nodes during the walk.
+	values *values
+
+	// expandedBy maps every node added by an expansion to the node that expanded into it.
+	expandedBy map[string]string
+
+	// subgraphDeadlines maps the nodes that expanded to when their subgraphs must finish by, see SubgraphLimits.
+	subgraphDeadlines map[string]time.Time
+
+	// running counts the nodes running in the subgraphs that limit their parallelism, and throttles contains the
+	// nodes waiting for them in the order they became ready. See SubgraphLimits.Parallelism.
+	running   map[string]int
+	throttles map[string][]string
+
+	// inFlight counts the nodes running in every fan-out group, fanOutFailures counts the nodes in them that failed, and
+	// fanOutWaiting contains the nodes waiting for them in the order they became ready. See FanOut.
+	inFlight       map[string]int
+	fanOutFailures map[string]int
+	fanOutWaiting  map[string][]string
+
+	// cancel cancels the context of the walk, with a cancelCause explaining why.
+	cancel context.CancelCauseFunc
+
+	// route decides who owns each node, and owners caches its answers. See Opts.Route.
+	route  func(key string, meta Meta) string
+	owners map[string]string
+
+	// stop is where Opts.Debugger stopped the walk, it is nil while the walk is running, and reported is set once the
+	// nodes that were running have drained and the debugger has been told. parked contains the nodes that were about to
+	// be dispatched when it stopped, and keep their workers until it resumes. released contains the nodes that were
+	// continued from their breakpoints, so they don't stop the walk again.
+	stop     *Stop
+	reported bool
+	parked   []string
+	released map[string]bool
+
+	// tracer logs the decisions made during the walk, it is nil unless Opts.SchedulerTrace is set.
+	tracer *tracer
+}
+
+// owner returns the owner of the node, or the empty string if it has none.
+func (walker *walker) owner(key string) string {
+	if owner, ok := walker.owners[key]; ok {
+		return owner
+	}
+
+	node, ok := walker.nodes[key]
+	if !ok {
+		return ""
+	}
+
+	owner := node.meta.Owner
+	if walker.route != nil {
+		owner = walker.route(key, node.meta)
+	}
+	walker.owners[key] = owner
+	return owner
+}
+
+// publish sends an event for this walk to the bus.
+func (walker *walker) publish(t EventType, key string, err error) {
+	walker.bus.Publish(Event{
+		Type:   t,
+		WalkID: walker.id,
+		Key:    key,
+		Owner:  walker.owner(key),
+		Err:    err,
+		Time:   time.Now(),
+	})
+}
+
+// publishCancelled sends a cancelled event for the node to the bus.
+func (walker *walker) publishCancelled(key string, reason CancelReason) {
+	walker.bus.Publish(Event{
+		Type:   EventNodeCancelled,
+		WalkID: walker.id,
+		Key:    key,
+		Owner:  walker.owner(key),
+		Reason: reason,
+		Time:   time.Now(),
+	})
+}
+
+// dispatch hands the given nodes over to the worker pool.
+func (walker *walker) dispatch(ctx context.Context, pool *threading.ThreadPool, worker *worker, keys []string) {
+	for ix, key := range keys {
+		if walker.stop != nil {
+			// The walk stopped while dispatching, so the rest of the nodes wait for it to resume.
+			walker.parked = append(walker.parked, keys[ix:]...)
+			return
+		}
+
+		node := walker.nodes[key]
+		if worker.opts.Verify {
+			walker.verifyDispatch(key)
+		}
+
+		if ctx.Err() != nil {
+			walker.tracer.log(slog.LevelDebug, key, "node cancelled before dispatch",
+				slog.String("reason", string(cancelReason(ctx))))
+			walker.scheduler.dropped()
+			walker.Cancelled(key, cancelReason(ctx))
+			continue
+		}
+
+		if walker.lost[key] {
+			walker.tracer.log(slog.LevelDebug, key, "node cancelled before dispatch", slog.String("reason", string(CancelRace)))
+			walker.scheduler.dropped()
+			walker.Lost(key)
+			continue
+		}
+
+		if walker.exhausted {
+			walker.tracer.log(slog.LevelDebug, key, "node cancelled before dispatch", slog.String("reason", string(CancelBudget)))
+			walker.scheduler.dropped()
+			walker.Cancelled(key, CancelBudget)
+			continue
+		}
+
+		if walker.overdue(key) {
+			walker.scheduler.dropped()
+			continue
+		}
+
+		if walker.prune(key) {
+			walker.scheduler.dropped()
+			walker.ready(walker.Pruned(key)...)
+			continue
+		}
+
+		if walker.resumed(key, worker.opts) || walker.quarantined(ctx, key, worker.opts) {
+			walker.scheduler.dropped()
+			continue
+		}
+
+		if walker.breakpoint(key, worker.opts) {
+			continue
+		}
+
+		if walker.outsideWindow(key, worker.opts) || walker.tripped(key, worker.opts) || walker.throttled(key) || walker.fannedOut(key, worker.opts) || walker.overQuota(ctx, key, worker.opts) {
+			walker.scheduler.dropped()
+			continue
+		}
+		walker.occupy(key)
+		walker.launch(key, worker.opts)
+		walker.tracer.log(slog.LevelDebug, key, "node dispatched",
+			slog.Int("processing", len(walker.processing)))
+
+		attempt := 1
+		if retried, ok := walker.attempts[key]; ok {
+			attempt = retried
+		}
+
+		exec := &execution{
+			key:        key,
+			walkID:     walker.id,
+			attempt:    attempt,
+			previous:   walker.previous[key],
+			baseLogger: worker.opts.Logger,
+			artifacts:  worker.opts.Artifacts,
+			values:     walker.values,
+			references: worker.opts.References,
+			handle: WalkHandle{
+				key:      key,
+				requests: worker.enqueued,
+				finished: worker.finished,
+				cancel:   walker.cancel,
+			},
+		}
+		if len(node.parents) > 0 {
+			exec.handle.parents = make([]NodeResult, 0, len(node.parents))
+			for _, parent := range node.parents {
+				if result, ok := walker.result.Nodes[parent]; ok {
+					exec.handle.parents = append(exec.handle.parents, *result)
+				}
+			}
+			slices.SortFunc(exec.handle.parents, func(left, right NodeResult) int {
+				return strings.Compare(left.Key, right.Key)
+			})
+		}
+		if worker.opts.Blackboard != nil {
+			exec.blackboard = worker.opts.Blackboard
+			exec.ancestors = walker.ancestors(key)
+		}
+
+		ready := walker.readyAt[key]
+		delete(walker.readyAt, key)
+		delete(walker.readySince, key)
+
+		walker.executions[key] = exec
+		walker.result.Nodes[key] = &NodeResult{
+			Key:    key,
+			Owner:  walker.owner(key),
+			Status: StatusRunning,
+			Ready:  ready,
+			Winner: walker.winners[key],
+		}
+
+		nodeCtx, release := walker.bounded(ctx, key)
+		if walker.racing(key) {
+			nodeCtx, exec.cancel = context.WithCancelCause(nodeCtx)
+		}
+
+		walker.publish(EventNodeStarted, key, nil)
+		threading.Run(withExecution(nodeCtx, exec), pool, func(ctx context.Context) {
+			started := time.Now()
+			exec.mutex.Lock()
+			exec.started = started
+			exec.mutex.Unlock()
+
+			defer release()
+
+			walker.scheduler.started(key, started.Sub(ready))
+			walker.profile(ctx, key, worker.opts, func(ctx context.Context) {
+				worker.work(ctx, node)
+			})
+			walker.scheduler.stopped()
+		})
+	}
+}
+
+// ancestors returns every node that must have completed before the given node could start, including the nodes that
+// expanded into it and their ancestors. An expanded node only completes once its whole subgraph has, so the nodes it
+// expanded into are ancestors as well, unless the given node is one of them.
+func (walker *walker) ancestors(key string) map[string]bool {
+	ancestors := make(map[string]bool)
+
+	// completed contains the ancestors that completed before the node started, rather than just started before it
+	// like the nodes that expanded into it.
+	completed := make(map[string]bool)
+
+	type ancestor struct {
+		key       string
+		completed bool
+	}
+	queue := []ancestor{{key: key}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		var next []ancestor
+		for _, parent := range walker.nodes[current.key].parents {
+			next = append(next, ancestor{key: parent, completed: true})
+		}
+		if expander, ok := walker.expandedBy[current.key]; ok {
+			next = append(next, ancestor{key: expander})
+		}
+		if current.completed {
+			for _, finisher := range walker.subgraphStarters[current.key] {
+				next = append(next, ancestor{key: finisher, completed: true})
+			}
+		}
+
+		for _, ancestor := range next {
+			if ancestor.completed && !completed[ancestor.key] {
+				ancestors[ancestor.key], completed[ancestor.key] = true, true
+				queue = append(queue, ancestor)
+			} else if !ancestors[ancestor.key] {
+				ancestors[ancestor.key] = true
+				queue = append(queue, ancestor)
+			}
+		}
+	}
+	return ancestors
+}
+
+// ready marks the nodes as ready to be dispatched. Nodes that become ready together are queued in order of their keys,
+// or in the order decided by the seed for seeded walks, so serial walks are repeatable.
+func (walker *walker) ready(keys ...string) {
+	keys = append([]string(nil), keys...)
+	sort.Strings(keys)
+	if walker.rng != nil {
+		walker.rng.Shuffle(len(keys), func(i, j int) {
+			keys[i], keys[j] = keys[j], keys[i]
+		})
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		walker.pending.push(key)
+		walker.readyAt[key] = now
+		walker.waiting(key, now)
+	}
+	walker.scheduler.queued(len(keys))
 }
 
+// upstream returns an UpstreamFailed error describing the chain of nodes from the failed node that stopped the given
+// node from completing, or nil if no failed node is responsible.
+func (walker *walker) upstream(key string) error {
+	// next maps every node we've visited to the node we visited it from, which is one step closer to key.
+	next := map[string]string{key: key}
+
+	queue := []string{key}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if _, failed := walker.errored[current]; failed && current != key {
+			chain := []string{current}
+			for current != key {
+				current = next[current]
+				chain = append(chain, current)
+			}
+
+			err := errors.Newf(nil, UpstreamFailed, "upstream node %q failed: %s", chain[0], strings.Join(chain, " -> "))
+			err = errors.Embed(err, NodeKey, key)
+			return errors.Embed(err, Ancestry, chain)
+		}
+
+		// Anything that hasn't completed could be holding this node up, that's incomplete parents and, for expanded
+		// nodes, the incomplete finishers of their subgraph.
+		blockers := append([]string(nil), walker.nodes[current].parents...)
+		blockers = append(blockers, walker.subgraphStarters[current]...)
+		for _, blocker := range blockers {
+			if _, ok := next[blocker]; ok || walker.completed.has(blocker) {
+				continue
+			}
+			next[blocker] = current
+			queue = append(queue, blocker)
+		}
+	}
+	return nil
+}
+
+// finish records the final status of a node in the result.
+func (walker *walker) finish(key string, status Status, err error) {
+	result, ok := walker.result.Nodes[key]
+	if !ok {
+		result = &NodeResult{Key: key, Owner: walker.owner(key)}
+		walker.result.Nodes[key] = result
+	}
+
+	result.Status = status
+	result.Err = err
+	result.Finished = time.Now()
+	result.Starved = walker.starved[key]
+	if exec, ok := walker.executions[key]; ok {
+		exec.mutex.Lock()
+		result.Stdout = exec.stdout.Bytes()
+		result.Stderr = exec.stderr.Bytes()
+		result.Started = exec.started
+		result.Attempts = exec.attempt
+		result.Speculated = exec.speculated
+		result.Reused = exec.reused
+		result.Artifacts = append([]string(nil), exec.produced...)
+		result.Annotations = append([]Annotation(nil), exec.annotations...)
+		result.Warnings = append([]error(nil), exec.warnings...)
+		result.Cost = exec.cost
+		exec.mutex.Unlock()
+
+		walker.spend(key, result.Cost)
+	}
+}
+
+// Process takes as many nodes from the front of the pending queue as there are free workers. The rest stay queued until
+// workers free up, which keeps the worker pool's own queue short.
+//
+// The returned slice is only valid until the next call.
 func (walker *walker) Process() []string {
-	var ready []string
-	for key := range walker.pending {
+	ready := walker.batch[:0]
+	for len(walker.processing) < walker.parallelism {
+		key, ok := walker.pending.pop()
+		if !ok {
+			break
+		}
 		ready = append(ready, key)
-		delete(walker.pending, key)
 		walker.processing[key] = true
 	}
+	walker.batch = ready
 	return ready
 }
 
+// schedule dispatches pending nodes until every worker is busy or nothing is pending. Nodes that are cancelled instead
+// of dispatched free their worker straight away, so this may take more than one round.
+func (walker *walker) schedule(ctx context.Context, pool *threading.ThreadPool, worker *worker) {
+	for walker.stop == nil {
+		ready := walker.Process()
+		if len(ready) == 0 {
+			return
+		}
+		walker.dispatch(ctx, pool, worker, ready)
+	}
+}
+
 func (walker *walker) Empty() bool {
-	return len(walker.pending) == 0 && len(walker.processing) == 0
+	return walker.pending.len() == 0 && len(walker.processing) == 0 && len(walker.held) == 0 && len(walker.joins) == 0
 }
 
 func (walker *walker) Errored(key string, err error) {
 	walker.errored[key] = err
 	delete(walker.processing, key)
+	walker.finish(key, StatusErrored, err)
+
+	for _, child := range walker.nodes[key].children {
+		walker.tracer.log(slog.LevelDebug, child, "node blocked", slog.String("failed", key))
+	}
+}
+
+// fail records that the node failed, and cancels the rest of the walk if it should fail fast.
+func (walker *walker) fail(key string, err error, opts *Opts) {
+	walker.publish(EventNodeErrored, key, err)
+	walker.Errored(key, err)
+	walker.ready(walker.tolerated(key)...)
+	walker.breach(key, opts)
+
+	// Failed members of groups and rollouts only fail the walk if the group or rollout does.
+	if opts.FailFast && !walker.hedged(key) && len(walker.gated(key)) == 0 {
+		walker.cancel(&cancelCause{reason: CancelFailFast})
+	}
+}
+
+// collisions returns an ExpansionCollision error if the subgraph the node expanded into contains any keys that are
+// already part of the walk.
+func (walker *walker) collisions(key string, subgraph Graph) error {
+	var collisions []string
+	for _, child := range subgraph.sortedKeys() {
+		if _, ok := walker.nodes[child]; ok {
+			collisions = append(collisions, child)
+		}
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+
+	err := errors.Newf(nil, ExpansionCollision, "node %q expanded into nodes that already exist: %v", key, collisions)
+	return errors.Embed(err, NodeKey, key)
+}
+
+// Cancelled records that the node was cancelled. None of its children will be processed.
+func (walker *walker) Cancelled(key string, reason CancelReason) {
+	walker.cancelled.add(key)
+	delete(walker.processing, key)
+	walker.finish(key, StatusCancelled, nil)
+	walker.result.Nodes[key].Reason = reason
+	walker.publishCancelled(key, reason)
 }
 
 func (walker *walker) Expand(key string, subgraph Graph) []string {
 	delete(walker.processing, key)
-	for key, node := range subgraph.nodes {
-		walker.nodes[key] = node
+	walker.paths = nil
+	for child, node := range subgraph.nodes {
+		walker.nodes[child] = node
+		walker.expandedBy[child] = key
+		walker.remaining[child] = node.required()
 	}
 
 	walker.subgraphStarters[key] = subgraph.Finishers()
+	walker.unfinished[key] = len(subgraph.finishers)
 	for _, finisher := range subgraph.Finishers() {
 		walker.subgraphFinishers[finisher] = key
 	}
@@ -66,23 +581,97 @@ func (walker *walker) Expand(key string, subgraph Graph) []string {
 	return starters
 }
 
+// enqueue adds a node requested through a WalkHandle or a Stream to the walk, marking it ready if it has no parents left
+// to wait for.
+func (walker *walker) enqueue(request enqueueRequest, reason string) error {
+	ready, err := walker.Enqueue(request)
+	if ready {
+		walker.tracer.log(slog.LevelDebug, request.node.key, "node ready", slog.String("reason", reason))
+		walker.ready(request.node.key)
+	} else if err == nil {
+		walker.tracer.log(LevelTrace, request.node.key, "node waiting", slog.String("reason", reason), slog.Any("waiting_on", request.node.parents))
+	}
+	return err
+}
+
+// Enqueue adds a node requested through a WalkHandle or a Stream to the walk, and returns whether it is ready to be processed.
+func (walker *walker) Enqueue(request enqueueRequest) (bool, error) {
+	key := request.node.key
+	if _, ok := walker.nodes[key]; ok {
+		err := errors.Newf(nil, DuplicateNode, "node %q already exists", key)
+		return false, errors.Embed(err, NodeKey, key)
+	}
+
+	if len(request.expander) > 0 {
+		if err := walker.emitted(request.expander, request.node.parents...); err != nil {
+			return false, err
+		}
+	}
+
+	remaining := 0
+	for _, parent := range request.node.parents {
+		if _, ok := walker.nodes[parent]; !ok {
+			err := errors.Newf(nil, MissingNode, "node %q does not exist", parent)
+			return false, errors.Embed(err, NodeKey, key)
+		}
+
+		if !walker.completed.has(parent) {
+			remaining++
+		}
+	}
+
+	for _, parent := range request.node.parents {
+		// The nodes may be shared with the graph being walked, so copy them before adding the new child.
+		original := walker.nodes[parent]
+		walker.nodes[parent] = &node{
+			key:      original.key,
+			impl:     original.impl,
+			meta:     original.meta,
+			parents:  original.parents,
+			children: append(append([]string(nil), original.children...), key),
+		}
+	}
+	// Nodes that don't need all of their parents only wait for as many as are still required.
+	remaining = max(0, request.node.required()-(len(request.node.parents)-remaining))
+
+	walker.nodes[key] = request.node
+	walker.remaining[key] = remaining
+	walker.paths = nil
+	if len(request.expander) > 0 {
+		walker.Emitted(request.expander, key)
+	}
+	return remaining == 0, nil
+}
+
 func (walker *walker) Completed(key string) []string {
-	walker.completed[key] = true   // First, mark the node as completed.
+	if err, ok := walker.partial[key]; ok {
+		// The part of the subgraph that was discovered has finished, so it's time to report the rest is missing. The
+		// work has already been done, so this doesn't make the walk fail fast.
+		walker.publish(EventNodeErrored, key, err)
+		walker.Errored(key, err)
+		return nil
+	}
+
+	walker.finish(key, StatusCompleted, nil)
+	walker.publish(EventNodeCompleted, key, nil)
+	return walker.resolved(key)
+}
+
+// resolved releases everything that was waiting for a node that completed or was pruned, and returns the nodes that are
+// now ready.
+func (walker *walker) resolved(key string) []string {
+	walker.completed.add(key)      // First, mark the node as completed.
 	delete(walker.processing, key) // Then, remove it from the pending list.
 
 	// Second, we're going to check if this is a finisher for any subgraphs.
 	if starter, ok := walker.subgraphFinishers[key]; ok {
-		// It is! That means we need to check if all the finishers have been completed.
-		starterComplete := true
-		for _, finisher := range walker.subgraphStarters[starter] {
-			if !walker.completed[finisher] {
-				starterComplete = false
-				break
-			}
-		}
+		// It is! If it was the last finisher to complete, then we can finally mark the starter as complete.
+		walker.unfinished[starter]--
 
-		if starterComplete {
-			// If all the finishers for the starter have been completed, then we can finally mark the starter as complete.
+		// Streaming expansions can only complete once they have stopped expanding, and never if they failed to.
+		_, failed := walker.errored[starter]
+		if walker.unfinished[starter] == 0 && !walker.processing[starter] && !failed {
+			walker.tracer.log(slog.LevelDebug, starter, "subgraph completed", slog.String("finisher", key))
 			return walker.Completed(starter)
 		}
 	}
@@ -90,123 +679,398 @@ func (walker *walker) Completed(key string) []string {
 	// If we're a "real" node, then we can check if all the children are ready to be executed.
 	var ready []string
 	for _, child := range walker.nodes[key].children {
-		// If all the parents of the child have been completed, then we can add it to the ready list.
-		allParentsComplete := true
-		for _, parent := range walker.nodes[child].parents {
-			if !walker.completed[parent] {
-				allParentsComplete = false
-				break
+		// If this was the last parent of the child to complete, then we can add it to the ready list.
+		walker.remaining[child]--
+		if walker.remaining[child] == 0 {
+			walker.tracer.log(slog.LevelDebug, child, "node ready", slog.String("reason", "parents completed"), slog.String("parent", key))
+			ready = append(ready, child)
+			if _, ok := walker.nodes[child].impl.(*groupNode); ok {
+				walker.winners[child] = key
+			}
+			if _, ok := walker.joins[child]; ok {
+				delete(walker.joins, child)
+				walker.arm()
 			}
+			walker.race(child)
+			continue
 		}
+		if walker.remaining[child] < 0 {
+			continue // the child didn't need this parent.
+		}
+		walker.join(child)
 
-		if allParentsComplete {
-			ready = append(ready, child)
+		if walker.tracer != nil {
+			var waiting []string
+			for _, parent := range walker.nodes[child].parents {
+				if !walker.completed.has(parent) {
+					waiting = append(waiting, parent)
+				}
+			}
+			walker.tracer.log(LevelTrace, child, "node waiting", slog.String("parent", key), slog.Any("waiting_on", waiting))
 		}
 	}
 	return ready
 }
 
 func (walker *walker) Walk(ctx context.Context, graph Graph, opts *Opts) error {
-	if len(graph.nodes) == 0 {
+	walker.result = &WalkResult{
+		WalkID:  walker.id,
+		Started: time.Now(),
+		Nodes:   make(map[string]*NodeResult),
+
+		Artifacts:  opts.Artifacts,
+		Blackboard: opts.Blackboard,
+	}
+	walker.executions = make(map[string]*execution)
+	walker.owners = make(map[string]string)
+	walker.route = opts.Route
+	walker.scheduler = &scheduler{metrics: opts.Metrics}
+	walker.publish(EventWalkStarted, "", nil)
+
+	ctx, walker.cancel = context.WithCancelCause(ctx)
+	defer walker.cancel(nil)
+	if opts.Debugger != nil {
+		defer opts.Debugger.finish()
+	}
+
+	err := walker.walk(ctx, graph, opts)
+
+	// Anything we never got to was either cancelled or skipped because something upstream failed. Expanded nodes are
+	// still running if their subgraph never finished, and are accounted for in the same way.
+	for _, key := range (Graph{nodes: walker.nodes}).sortedKeys() {
+		result, ok := walker.result.Nodes[key]
+		if !ok {
+			result = &NodeResult{
+				Key:    key,
+				Owner:  walker.owner(key),
+				Status: StatusPending,
+			}
+			walker.result.Nodes[key] = result
+		} else if result.Status != StatusRunning {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			result.Status = StatusCancelled
+			result.Reason = cancelReason(ctx)
+			walker.publishCancelled(key, result.Reason)
+			continue
+		}
+
+		if upstream := walker.upstream(key); upstream != nil {
+			result.Status = StatusSkipped
+			if _, expanded := walker.subgraphStarters[key]; expanded {
+				result.Status = StatusErrored
+				result.Finished = time.Now()
+			}
+			result.Err = upstream
+			continue
+		}
+
+		if walker.exhausted && result.Status == StatusPending {
+			// The node was never dispatched because the walk ran out of budget.
+			result.Status = StatusCancelled
+			result.Reason = CancelBudget
+			walker.publishCancelled(key, result.Reason)
+		}
+	}
+	for key, expander := range walker.expandedBy {
+		walker.result.Nodes[key].ExpandedBy = expander
+	}
+	for _, result := range walker.result.Nodes {
+		walker.result.Warnings += len(result.Warnings)
+	}
+	walker.remember(context.WithoutCancel(ctx), opts)
+	walker.result.Graph = walker.snapshot()
+	walker.result.Finished = time.Now()
+	walker.result.Scheduler = walker.scheduler.snapshot()
+
+	walker.publish(EventWalkFinished, "", err)
+	return err
+}
+
+func (walker *walker) walk(ctx context.Context, graph Graph, opts *Opts) error {
+	if len(graph.nodes) == 0 && opts.Stream == nil {
 		return nil
 	}
 
 	walker.nodes = make(map[string]*node, len(graph.nodes))
+	walker.remaining = make(map[string]int, len(graph.nodes))
+	walker.unfinished = make(map[string]int)
 	for key, node := range graph.nodes {
 		walker.nodes[key] = node
+		walker.remaining[key] = node.required()
+	}
+
+	walker.tracer = newTracer(opts.SchedulerTrace, opts.Logger, walker.id)
+	walker.parallelism = opts.Parallelism
+	if opts.StepMode {
+		walker.parallelism = 1
+	}
+	if opts.Seed != 0 {
+		walker.rng = rand.New(rand.NewPCG(uint64(opts.Seed), uint64(opts.Seed)))
+	}
+
+	walker.starved = make(map[string]StarveReason)
+	if opts.StarvationThreshold > 0 {
+		// Checking four times per threshold reports nodes at most a quarter of it late.
+		walker.readySince = make(map[string]time.Time)
+		walker.watchdog = time.NewTicker(opts.StarvationThreshold / 4)
+		defer walker.watchdog.Stop()
 	}
 
-	walker.pending = make(map[string]bool)
+	walker.pending = new(queue)
+	if len(opts.Weights) > 0 {
+		walker.pending = newFairQueue(opts.Weights, walker.class(opts.Weights))
+	}
+	walker.readyAt = make(map[string]time.Time)
 	for _, key := range graph.Starters() {
-		walker.pending[key] = true
+		walker.tracer.log(slog.LevelDebug, key, "node ready", slog.String("reason", "starter"))
 	}
+	walker.ready(graph.Starters()...)
 
 	walker.processing = make(map[string]bool)
-	walker.completed = make(map[string]bool)
+	ordinals := newOrdinals()
+	walker.completed = newKeySet(opts.Storage, ordinals)
 	walker.errored = make(map[string]error)
+	walker.cancelled = newKeySet(opts.Storage, ordinals)
+	walker.pruned = newKeySet(opts.Storage, ordinals)
+	walker.held = make(map[string]time.Time)
+	walker.now = opts.Now
+	if walker.now == nil {
+		walker.now = time.Now
+	}
+	walker.deadline, _ = deadline(ctx, opts)
+	if !walker.deadline.IsZero() {
+		walker.plan(graph, opts)
+	}
+	walker.budget = opts.MaxCost
+	if len(opts.Quotas) > 0 {
+		walker.quotaStore = opts.QuotaStore
+		if walker.quotaStore == nil {
+			walker.quotaStore = NewMemoryQuotaStore()
+		}
+		defer walker.quotaStore.Finish(context.WithoutCancel(ctx), walker.id)
+	}
 	walker.subgraphStarters = make(map[string][]string)
 	walker.subgraphFinishers = make(map[string]string)
+	walker.expandedBy = make(map[string]string)
+	walker.subgraphDeadlines = make(map[string]time.Time)
+	walker.running = make(map[string]int)
+	walker.throttles = make(map[string][]string)
+	walker.inFlight = make(map[string]int)
+	walker.fanOutFailures = make(map[string]int)
+	walker.fanOutWaiting = make(map[string][]string)
+	walker.attempts = make(map[string]int)
+	walker.previous = make(map[string]error)
+	walker.lost = make(map[string]bool)
+	walker.joins = make(map[string]time.Time)
+	walker.winners = make(map[string]string)
+	walker.partial = make(map[string]error)
+	walker.values = newValues()
+	walker.result.values = walker.values
+	walker.released = make(map[string]bool)
 
-	// errored, expanded, and completed are channels that the worker will send messages back to indicating the status of a
-	// node.
-	errored := make(chan map[string]error, 1)
-	expanded := make(chan map[string]Graph, 1)
-	completed := make(chan string, 1)
+	// results is the channel the workers send messages back on, indicating the status of a node.
+	results := make(chan outcome, opts.ResultBuffer)
+
+	// enqueued and edges are used by nodes to add new nodes and edges to the walk.
+	enqueued := make(chan enqueueRequest)
+	edges := make(chan connectRequest)
 
 	worker := &worker{
-		walker:    walker,
-		errored:   errored,
-		expanded:  expanded,
-		completed: completed,
+		opts:       opts,
+		results:    results,
+		enqueued:   enqueued,
+		edges:      edges,
+		finished:   make(chan struct{}),
+		scheduler:  walker.scheduler,
+		speculator: newSpeculator(opts.Speculation),
 	}
 
-	pool := threading.NewThreadPool(opts.Parallelism)
-	for _, key := range walker.Process() {
-		threading.Run(context.WithValue(ctx, "key", key), pool, worker.work)
+	// streamed, connected and closed are only set when nodes are streamed into the walk, so they block forever otherwise.
+	var streamed chan enqueueRequest
+	var connected chan connectRequest
+	var closed, done <-chan struct{}
+	if opts.Stream != nil {
+		streamed, connected, closed, done = opts.Stream.nodes, opts.Stream.edges, opts.Stream.done, ctx.Done()
+		defer opts.Stream.finish()
 	}
 
-	for !walker.Empty() {
+	pool := threading.NewThreadPool(opts.Parallelism)
+	walker.schedule(ctx, pool, worker)
+
+	for !walker.Empty() || closed != nil {
+		// holding is only set while nodes wait for their windows or joins, so they can be cancelled along with the walk.
+		var holding <-chan struct{}
+		if len(walker.held) > 0 || len(walker.joins) > 0 {
+			holding = ctx.Done()
+		}
+
+		// stopped is only set while the debugger has stopped the walk, so the nodes waiting for it can be cancelled too.
+		var stopped <-chan struct{}
+		if walker.stop != nil {
+			stopped = ctx.Done()
+		}
+
 		select {
-		case errored := <-errored:
-			for key, err := range errored {
-				opts.Callbacks.OnError(key, err)
-				walker.Errored(key, err)
-			}
+		case result := <-results:
+			walker.receive(ctx, result, opts)
 
-			for _, key := range walker.Process() {
-				threading.Run(context.WithValue(ctx, "key", key), pool, worker.work)
+			// Handle whatever else has already finished before scheduling again, up to the batch size. The walker is the
+			// only receiver, so the results that are buffered can be received without blocking.
+			received := 1
+			for ; received < opts.BatchSize && len(results) > 0; received++ {
+				walker.receive(ctx, <-results, opts)
 			}
-		case expanded := <-expanded:
-			for key, subgraph := range expanded {
-				opts.Callbacks.OnExpand(key)
+			walker.scheduler.batched(received)
 
-				pending := walker.Expand(key, subgraph)
-				if len(pending) == 0 {
-					pending = walker.Completed(key)
-				}
-				for _, starter := range pending {
-					walker.pending[starter] = true
-				}
+			walker.drained(opts)
+			walker.schedule(ctx, pool, worker)
+		case request := <-enqueued:
+			reason := "enqueued"
+			if len(request.expander) > 0 {
+				reason = "emitted"
 			}
+			request.reply <- walker.enqueue(request, reason)
 
-			for _, key := range walker.Process() {
-				threading.Run(context.WithValue(ctx, "key", key), pool, worker.work)
-			}
-		case completed := <-completed:
-			opts.Callbacks.OnComplete(completed)
+			walker.schedule(ctx, pool, worker)
+		case request := <-edges:
+			request.reply <- walker.Connect(request)
+		case request := <-streamed:
+			request.reply <- walker.enqueue(request, "streamed")
 
-			pending := walker.Completed(completed)
-			for _, key := range pending {
-				walker.pending[key] = true
-			}
+			walker.schedule(ctx, pool, worker)
+		case request := <-connected:
+			request.reply <- walker.Connect(request)
+		case <-walker.opened():
+			walker.Release()
 
-			for _, key := range walker.Process() {
-				threading.Run(context.WithValue(ctx, "key", key), pool, worker.work)
-			}
+			walker.schedule(ctx, pool, worker)
+		case <-walker.starving():
+			walker.Starved(opts.StarvationThreshold)
+		case command := <-walker.commands(opts):
+			walker.Debug(ctx, command, pool, worker)
+		case <-holding:
+			walker.CancelHeld(cancelReason(ctx))
+		case <-stopped:
+			walker.Unstop(cancelReason(ctx), opts)
+
+			// Whatever became ready while the walk was stopped is cancelled as it is dispatched.
+			walker.schedule(ctx, pool, worker)
+		case <-closed:
+			closed, done = nil, nil
+		case <-done:
+			// Nothing else will run once the walk is cancelled, so stop waiting for the stream.
+			closed, done = nil, nil
 		}
 	}
 
-	// Close the channels.
-	close(errored)
-	close(expanded)
-	close(completed)
+	// Close the channels. Nodes may outlive the walk, such as speculative copies that lost, so the channels they send
+	// requests on are left open and they are told the walk has finished instead.
+	close(results)
+	close(worker.finished)
 
 	// Close the thread pool.
 	pool.Close()
 
-	// If there are any errors, return them.
-	var multi error
-	for _, err := range walker.errored {
-		multi = errors.Append(err)
+	if err := ctx.Err(); err != nil {
+		reason := cancelReason(ctx)
+		if reason == CancelFailFast {
+			// The errors that caused the walk to fail fast explain everything.
+			return newWalkError(walker.failures(), nil)
+		}
+
+		// Report a cancellation instead of an incomplete graph, the nodes we never got to are already accounted for.
+		err := errors.Newf(err, Cancelled, "walk was cancelled (%s)", reason)
+		err = errors.Embed(err, Reason, reason)
+		err = errors.Embed(err, NodeCount, len(walker.nodes))
+		err = errors.Embed(err, CompletedCount, walker.completed.len())
+		err = errors.Embed(err, ErroredCount, len(walker.errored))
+		err = errors.Embed(err, CancelledCount, len(walker.nodes)-walker.completed.len()-len(walker.errored))
+		return newWalkError(walker.failures(), err)
+	}
+
+	if walker.exhausted && len(walker.nodes) != walker.settled() {
+		return newWalkError(walker.failures(), walker.overBudget())
 	}
 
-	if len(walker.nodes) != (len(walker.completed) + len(walker.errored)) {
+	if len(walker.nodes) != walker.settled() {
 		err := errors.New(nil, IncompleteGraph, "graph is incomplete")
 		err = errors.Embed(err, NodeCount, len(walker.nodes))
-		err = errors.Embed(err, CompletedCount, len(walker.completed))
+		err = errors.Embed(err, CompletedCount, walker.completed.len())
 		err = errors.Embed(err, ErroredCount, len(walker.errored))
-		multi = errors.Append(errors.Append(multi, err))
+		return newWalkError(walker.failures(), err)
+	}
+
+	return newWalkError(walker.failures(), nil)
+}
+
+// receive handles the outcome of a node that a worker has finished with.
+func (walker *walker) receive(ctx context.Context, result outcome, opts *Opts) {
+	walker.vacate(result.key)
+	walker.land(result.key, opts)
+	walker.warned(result.key)
+
+	if opts.Verify {
+		walker.verifyFinish(result.key)
 	}
 
-	return multi
+	switch result.kind {
+	case outcomeErrored:
+		if ctx.Err() != nil && stderrors.Is(result.err, ctx.Err()) {
+			// The node only failed because we cancelled it, so don't report it as an error.
+			walker.Cancelled(result.key, cancelReason(ctx))
+			break
+		}
+
+		if walker.lost[result.key] {
+			walker.Lost(result.key)
+			break
+		}
+
+		if walker.timedOut(result.key, result.err) {
+			walker.Cancelled(result.key, CancelDeadline)
+			break
+		}
+
+		walker.trip(result.key, true, opts)
+
+		if walker.childError(ctx, result.key, result.err) || walker.caught(result.key, result.err, opts) {
+			break
+		}
+
+		walker.fail(result.key, result.err, opts)
+	case outcomeExpanded:
+		key, subgraph := result.key, result.subgraph
+		if opts.Reverse {
+			subgraph = subgraph.reversed()
+		}
+		if err := walker.collisions(key, subgraph); err != nil {
+			walker.fail(key, err, opts)
+			break
+		}
+		walker.publish(EventNodeExpanded, key, result.err)
+		if result.err != nil {
+			walker.partial[key] = result.err
+		}
+
+		walker.bound(key)
+		pending := walker.Expand(key, subgraph)
+		if len(pending) == 0 {
+			pending = walker.Completed(key)
+		}
+		walker.ready(pending...)
+		for _, starter := range subgraph.Starters() {
+			walker.tracer.log(slog.LevelDebug, starter, "node ready", slog.String("reason", "expanded"), slog.String("parent", key))
+		}
+	case outcomeStreamed:
+		walker.publish(EventNodeExpanded, result.key, nil)
+		walker.ready(walker.Streamed(result.key)...)
+	case outcomeCompleted:
+		walker.trip(result.key, false, opts)
+		walker.ready(walker.Completed(result.key)...)
+	case outcomeCancelled:
+		walker.Cancelled(result.key, cancelReason(ctx))
+	}
 }
@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+var _ errors.Codeable = (*WalkError)(nil)
+
+// WalkError is returned by Walk and Run when a walk doesn't complete successfully. It wraps the error of every node
+// that failed, along with an error describing why the walk as a whole didn't finish if there is one, so errors.Is and
+// errors.As see through to the individual errors.
+type WalkError struct {
+	// nodes maps the keys of the failed nodes to the errors they returned.
+	nodes map[string]error
+
+	// err explains why the walk didn't finish, it is an IncompleteGraph or Cancelled error, or nil.
+	err error
+}
+
+// newWalkError returns a WalkError for the given errors, or nil if there are none.
+func newWalkError(nodes map[string]error, err error) error {
+	if len(nodes) == 0 && err == nil {
+		return nil
+	}
+
+	copied := make(map[string]error, len(nodes))
+	for key, nodeErr := range nodes {
+		copied[key] = nodeErr
+	}
+	return &WalkError{
+		nodes: copied,
+		err:   err,
+	}
+}
+
+// Error implements error. Node errors are listed in order of their keys, followed by the walk error.
+func (err *WalkError) Error() string {
+	var messages []string
+	for _, key := range err.Failed() {
+		messages = append(messages, key+": "+err.nodes[key].Error())
+	}
+	if err.err != nil {
+		messages = append(messages, err.err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// GetErrorCode implements errors.Codeable. It returns FailedNode if any node failed, and the code of the walk error
+// otherwise.
+func (err *WalkError) GetErrorCode() errors.ErrorCode {
+	if len(err.nodes) > 0 {
+		return FailedNode
+	}
+	return errors.GetErrorCode(err.err)
+}
+
+// Unwrap returns the node errors in order of their keys, followed by the walk error.
+func (err *WalkError) Unwrap() []error {
+	var errs []error
+	for _, key := range err.Failed() {
+		errs = append(errs, err.nodes[key])
+	}
+	if err.err != nil {
+		errs = append(errs, err.err)
+	}
+	return errs
+}
+
+// Errors returns the errors of every failed node, keyed by node key.
+func (err *WalkError) Errors() map[string]error {
+	errs := make(map[string]error, len(err.nodes))
+	for key, nodeErr := range err.nodes {
+		errs[key] = nodeErr
+	}
+	return errs
+}
+
+// Failed returns the keys of every failed node, sorted.
+func (err *WalkError) Failed() []string {
+	keys := make([]string, 0, len(err.nodes))
+	for key := range err.nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Err returns the error explaining why the walk as a whole didn't finish, or nil if every node that could run did.
+func (err *WalkError) Err() error {
+	return err.err
+}
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"context"
+	stderrors "errors"
+	"io/fs"
+	"testing"
+
+	"github.com/pasataleo/go-errors/errors"
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestWalkError(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("a", Executable(func(ctx context.Context) error {
+		return fs.ErrNotExist
+	}))
+	g.AddNode("b", Executable(func(ctx context.Context) error {
+		return &fs.PathError{Op: "open", Path: "b.txt", Err: fs.ErrPermission}
+	}))
+	g.AddNode("c", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect("b", "c")
+
+	err := g.Walk(context.Background(), &Opts{Parallelism: 2})
+	tests.ExecuteE(err).MatchesError(t, "a: failed to execute node (file does not exist); "+
+		"b: failed to execute node (open b.txt: permission denied); graph is incomplete")
+	tests.Execute(errors.GetErrorCode(err)).Equal(t, FailedNode)
+
+	var walkErr *WalkError
+	tests.Execute(stderrors.As(err, &walkErr)).Equal(t, true)
+	tests.Execute(walkErr.Failed()).Equal(t, []string{"a", "b"})
+	tests.Execute(len(walkErr.Errors())).Equal(t, 2)
+	tests.Execute(errors.GetErrorCode(walkErr.Err())).Equal(t, IncompleteGraph)
+
+	tests.Execute(stderrors.Is(err, fs.ErrNotExist)).Equal(t, true)
+	tests.Execute(stderrors.Is(walkErr.Errors()["b"], fs.ErrPermission)).Equal(t, true)
+	tests.Execute(stderrors.Is(walkErr.Errors()["b"], fs.ErrNotExist)).Equal(t, false)
+
+	var pathErr *fs.PathError
+	tests.Execute(stderrors.As(err, &pathErr)).Equal(t, true)
+	tests.Execute(pathErr.Path).Equal(t, "b.txt")
+}
@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"context"
+	"time"
+)
+
+// Warn records a warning for the node executing with the given context: something went wrong, but not badly enough to
+// fail the node, such as a cleanup step that failed or a deprecated input. Warnings are kept in NodeResult.Warnings,
+// counted in WalkResult.Warnings and shown in reports as annotations, so walks that completed with warnings can be told
+// apart from clean ones. Each warning is also published as an EventNodeWarned event once the node has finished.
+//
+// It does nothing if the error is nil, or if the context doesn't belong to a node.
+func Warn(ctx context.Context, err error) {
+	exec := executionFrom(ctx)
+	if exec == nil || err == nil {
+		return
+	}
+
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	exec.warnings = append(exec.warnings, err)
+	exec.annotations = append(exec.annotations, Annotation{Kind: AnnotationWarning, Value: err.Error(), Time: time.Now()})
+}
+
+// warned publishes the warnings of a node that a worker has finished with.
+func (walker *walker) warned(key string) {
+	exec, ok := walker.executions[key]
+	if !ok {
+		return
+	}
+
+	exec.mutex.Lock()
+	warnings := append([]error(nil), exec.warnings...)
+	exec.mutex.Unlock()
+
+	for _, warning := range warnings {
+		walker.publish(EventNodeWarned, key, warning)
+	}
+}
@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestGraph_Walk_Warn(t *testing.T) {
+	g := NewGraph()
+	g.AddNode("build", Executable(func(ctx context.Context) error {
+		Warn(ctx, fmt.Errorf("cache unavailable"))
+		Warn(ctx, nil)
+		AnnotateWarning(ctx, "deprecated flag")
+		return nil
+	}))
+	g.AddNode("test", Executable(func(ctx context.Context) error {
+		Warn(ctx, fmt.Errorf("slow test"))
+		return fmt.Errorf("failed")
+	}))
+	g.AddNode("lint", Executable(func(ctx context.Context) error {
+		return nil
+	}))
+	g.Connect("build", "test")
+
+	var mutex sync.Mutex
+	var events []string
+	bus := NewBus(SinkFunc(func(event Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch event.Type {
+		case EventNodeWarned:
+			events = append(events, fmt.Sprintf("%s %s: %s", event.Type, event.Key, event.Err))
+		case EventNodeCompleted, EventNodeErrored:
+			events = append(events, fmt.Sprintf("%s %s", event.Type, event.Key))
+		}
+	}))
+
+	result, _ := g.Run(context.Background(), &Opts{Parallelism: 1, Bus: bus})
+
+	tests.Execute(events).Equal(t, []string{
+		"node.warned build: cache unavailable",
+		"node.warned build: deprecated flag",
+		"node.completed build",
+		"node.completed lint",
+		"node.warned test: slow test",
+		"node.errored test",
+	})
+	tests.Execute(result.Warnings).Equal(t, 3)
+
+	var warnings []string
+	for _, warning := range result.Nodes["build"].Warnings {
+		warnings = append(warnings, warning.Error())
+	}
+	tests.Execute(warnings).Equal(t, []string{"cache unavailable", "deprecated flag"})
+	tests.Execute(len(result.Nodes["build"].Kind(AnnotationWarning))).Equal(t, 2)
+	tests.Execute(len(result.Nodes["lint"].Warnings)).Equal(t, 0)
+}
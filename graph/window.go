@@ -0,0 +1,372 @@
+package graph
+
+import (
+	"fmt"
+	"log/slog"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pasataleo/go-errors/errors"
+)
+
+// Window is a recurring period of time during which a node may run, such as outside a change freeze. Windows are
+// written as cron expressions of five space separated fields: minute, hour, day of month, month and day of week. The
+// node may run during any minute that matches the expression, for example "* 9-17 * * 1-5" allows a node to run during
+// working hours.
+//
+// Every field accepts "*", single values, ranges such as "1-5", lists such as "1,3,5" and steps such as "*/15" or
+// "0-30/10". Days of the week run from 0 (Sunday) to 6, and 7 is also accepted for Sunday. As with cron, if both the day
+// of month and the day of week are restricted then a day matching either of them matches.
+//
+// The zero value is a window that is always open.
+type Window struct {
+	expr string
+
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+
+	// anyDay and anyWeekday record whether the day fields were "*", which changes how they combine.
+	anyDay, anyWeekday bool
+
+	location *time.Location
+}
+
+// windowFields describes the range of every field in a window expression, in order.
+var windowFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseWindow parses a cron expression into a Window, evaluated in UTC. It returns an error with the InvalidWindow code
+// if the expression is malformed.
+func ParseWindow(expr string) (Window, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(windowFields) {
+		err := errors.Newf(nil, InvalidWindow, "window %q must have %d fields, found %d", expr, len(windowFields), len(fields))
+		return Window{}, errors.Embed(err, WindowExpr, expr)
+	}
+
+	var sets [5]uint64
+	for ix, field := range fields {
+		set, err := parseWindowField(field, windowFields[ix].min, windowFields[ix].max)
+		if err != nil {
+			err = errors.Newf(err, InvalidWindow, "window %q has an invalid %s", expr, windowFields[ix].name)
+			return Window{}, errors.Embed(err, WindowExpr, expr)
+		}
+		sets[ix] = set
+	}
+
+	// Sunday can be written as either 0 or 7.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return Window{
+		expr:       expr,
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+		location:   time.UTC,
+	}, nil
+}
+
+// MustParseWindow is like ParseWindow, but panics if the expression is malformed.
+func MustParseWindow(expr string) Window {
+	window, err := ParseWindow(expr)
+	if err != nil {
+		panic(err)
+	}
+	return window
+}
+
+// parseWindowField parses a single field of a window expression into a set of values.
+func parseWindowField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if rng, stepText, ok := strings.Cut(part, "/"); ok {
+			value, err := strconv.Atoi(stepText)
+			if err != nil || value < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+			part, step = rng, value
+		}
+
+		first, last := min, max
+		if part != "*" {
+			lowText, highText, isRange := strings.Cut(part, "-")
+			low, err := strconv.Atoi(lowText)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", lowText)
+			}
+			high := low
+			if isRange {
+				if high, err = strconv.Atoi(highText); err != nil {
+					return 0, fmt.Errorf("invalid value %q", highText)
+				}
+			}
+			if low < min || high > max || low > high {
+				return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+			}
+			first, last = low, high
+		}
+
+		for value := first; value <= last; value += step {
+			set |= 1 << value
+		}
+	}
+	return set, nil
+}
+
+// In returns a copy of the window that is evaluated in the given location.
+func (window Window) In(location *time.Location) Window {
+	window.location = location
+	return window
+}
+
+// IsZero returns true if the window is the zero value, which is always open.
+func (window Window) IsZero() bool {
+	return len(window.expr) == 0
+}
+
+// String returns the expression the window was parsed from.
+func (window Window) String() string {
+	return window.expr
+}
+
+// Contains returns true if the window is open at the given time.
+func (window Window) Contains(t time.Time) bool {
+	if window.IsZero() {
+		return true
+	}
+
+	t = t.In(window.location)
+	return window.months&(1<<int(t.Month())) != 0 &&
+		window.day(t) &&
+		window.hours&(1<<t.Hour()) != 0 &&
+		window.minutes&(1<<t.Minute()) != 0
+}
+
+// day returns true if the day of the given time matches the window.
+func (window Window) day(t time.Time) bool {
+	day := window.days&(1<<t.Day()) != 0
+	weekday := window.weekdays&(1<<int(t.Weekday())) != 0
+	if window.anyDay || window.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// windowSearch is how far ahead Next looks for the window to open, long enough to cover leap days.
+const windowSearch = 5 * 366 * 24 * time.Hour
+
+// Next returns the first time at or after t that the window is open, truncated to the minute unless t is already in the
+// window. It returns the zero time if the window never opens, for example because it only matches the 30th of February.
+func (window Window) Next(t time.Time) time.Time {
+	if window.Contains(t) {
+		return t
+	}
+
+	limit := t.Add(windowSearch)
+	t = t.In(window.location).Truncate(time.Minute).Add(time.Minute)
+	for t.Before(limit) {
+		switch {
+		case window.months&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !window.day(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case window.hours&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case window.minutes&(1<<t.Minute()) == 0:
+			// Skip straight to the next matching minute in this hour, if there is one.
+			next := window.minutes >> (t.Minute() + 1)
+			if next == 0 {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+				continue
+			}
+			t = t.Add(time.Duration(bits.TrailingZeros64(next)+1) * time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// WindowPolicy decides what happens to nodes that become ready outside their windows.
+type WindowPolicy string
+
+const (
+	// WindowWait holds nodes until their windows open. Held nodes don't occupy a worker.
+	WindowWait WindowPolicy = "wait"
+
+	// WindowSkip skips nodes instead of waiting for their windows to open. Anything that depends on a skipped node still
+	// runs, as if it had been pruned.
+	WindowSkip WindowPolicy = "skip"
+)
+
+// windows returns every window the node must be inside of to run, from its metadata and from its tags.
+func (walker *walker) windows(key string, opts *Opts) []Window {
+	node := walker.nodes[key]
+
+	var windows []Window
+	if !node.meta.Window.IsZero() {
+		windows = append(windows, node.meta.Window)
+	}
+	for _, tag := range node.meta.Tags {
+		if window, ok := opts.Windows[tag]; ok && !window.IsZero() {
+			windows = append(windows, window)
+		}
+	}
+	return windows
+}
+
+// opens returns the first time at or after now that every window is open at once, or the zero time if that never
+// happens.
+func opens(windows []Window, now time.Time) time.Time {
+	limit := now.Add(windowSearch)
+	for t := now; t.Before(limit); {
+		next := t
+		for _, window := range windows {
+			if next = window.Next(next); next.IsZero() {
+				return next
+			}
+		}
+		if next.Equal(t) {
+			return t
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+// outsideWindow returns true if the node can't run yet because it is outside one of its windows, in which case it has
+// been held, skipped or failed according to Opts.WindowPolicy.
+func (walker *walker) outsideWindow(key string, opts *Opts) bool {
+	windows := walker.windows(key, opts)
+	if len(windows) == 0 {
+		return false
+	}
+
+	now := walker.now()
+	at := opens(windows, now)
+	if at.Equal(now) {
+		return false
+	}
+
+	if at.IsZero() {
+		err := errors.Newf(nil, ClosedWindow, "node %q is outside a window that never opens", key)
+		walker.fail(key, errors.Embed(err, NodeKey, key), opts)
+		return true
+	}
+
+	if opts.WindowPolicy == WindowSkip {
+		walker.tracer.log(slog.LevelDebug, key, "node skipped", slog.Time("opens", at))
+		walker.ready(walker.Skipped(key)...)
+		return true
+	}
+
+	walker.tracer.log(slog.LevelDebug, key, "node held", slog.String("reason", "window"), slog.Time("opens", at))
+	walker.hold(key, at)
+	return true
+}
+
+// hold takes a node that was about to be dispatched and holds it without occupying a worker until the given time, when
+// it is marked ready again.
+func (walker *walker) hold(key string, at time.Time) {
+	delete(walker.processing, key)
+	walker.held[key] = at
+	walker.result.Nodes[key] = &NodeResult{
+		Key:    key,
+		Owner:  walker.owner(key),
+		Status: StatusHeld,
+		Ready:  walker.readyAt[key],
+	}
+	walker.publish(EventNodeHeld, key, nil)
+	walker.arm()
+}
+
+// arm sets the timer to fire when the first held node is due to be released.
+func (walker *walker) arm() {
+	var first time.Time
+	for _, at := range walker.held {
+		if first.IsZero() || at.Before(first) {
+			first = at
+		}
+	}
+	for _, at := range walker.joins {
+		if first.IsZero() || at.Before(first) {
+			first = at
+		}
+	}
+
+	if walker.timer != nil {
+		walker.timer.Stop()
+	}
+	if first.IsZero() {
+		walker.timer = nil
+		return
+	}
+	walker.timer = time.NewTimer(first.Sub(walker.now()))
+}
+
+// opened returns a channel that fires when a held node is due to be released or a join times out, or nil if there are
+// neither.
+func (walker *walker) opened() <-chan time.Time {
+	if walker.timer == nil {
+		return nil
+	}
+	return walker.timer.C
+}
+
+// Release marks every held node that is due to be released as ready again, along with every node whose join has timed
+// out.
+func (walker *walker) Release() {
+	now := walker.now()
+
+	ready := walker.Joined()
+	for key, at := range walker.held {
+		if !at.After(now) {
+			walker.tracer.log(slog.LevelDebug, key, "node ready", slog.String("reason", "released"))
+			delete(walker.held, key)
+			ready = append(ready, key)
+		}
+	}
+	walker.ready(ready...)
+	walker.arm()
+}
+
+// CancelHeld cancels every held node, because the walk was cancelled while they waited. Nodes waiting for their joins
+// to time out stop waiting, and are cancelled along with the rest of the walk.
+func (walker *walker) CancelHeld(reason CancelReason) {
+	clear(walker.joins)
+	for _, key := range (Graph{nodes: walker.nodes}).sortedKeys() {
+		if _, ok := walker.held[key]; ok {
+			delete(walker.held, key)
+			walker.Cancelled(key, reason)
+		}
+	}
+	walker.arm()
+}
+
+// Skipped records that a node was skipped because it was outside its window, its circuit was open or a Debugger skipped
+// it, and returns the children that are now ready.
+func (walker *walker) Skipped(key string) []string {
+	walker.finish(key, StatusSkipped, nil)
+	walker.publish(EventNodeSkipped, key, nil)
+	return walker.resolved(key)
+}
@@ -0,0 +1,193 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pasataleo/go-testing/tests"
+)
+
+func TestParseWindow(t *testing.T) {
+	tcs := map[string]struct {
+		expr string
+		err  string
+	}{
+		"always":   {expr: "* * * * *"},
+		"lists":    {expr: "0,30 9-17 1,15 */3 1-5"},
+		"sunday":   {expr: "* * * * 7"},
+		"fields":   {expr: "* * *", err: "window \"* * *\" must have 5 fields, found 3"},
+		"range":    {expr: "* 20-25 * * *", err: "window \"* 20-25 * * *\" has an invalid hour (\"20-25\" is outside 0-23)"},
+		"value":    {expr: "x * * * *", err: "window \"x * * * *\" has an invalid minute (invalid value \"x\")"},
+		"step":     {expr: "*/0 * * * *", err: "window \"*/0 * * * *\" has an invalid minute (invalid step \"0\")"},
+		"reversed": {expr: "* * 5-1 * *", err: "window \"* * 5-1 * *\" has an invalid day of month (\"5-1\" is outside 1-31)"},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			window, err := ParseWindow(tc.expr)
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+				return
+			}
+			tests.ExecuteE(err).NoError(t)
+			tests.Execute(window.String()).Equal(t, tc.expr)
+		})
+	}
+}
+
+func TestWindow_Next(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2024, time.January, 3, 10, 30, 15, 0, time.UTC)
+
+	tcs := map[string]struct {
+		expr     string
+		expected time.Time
+	}{
+		"open":       {expr: "* * * * *", expected: now},
+		"minute":     {expr: "45 * * * *", expected: time.Date(2024, time.January, 3, 10, 45, 0, 0, time.UTC)},
+		"hour":       {expr: "0 9-17/4 * * *", expected: time.Date(2024, time.January, 3, 13, 0, 0, 0, time.UTC)},
+		"weekend":    {expr: "* * * * 0,6", expected: time.Date(2024, time.January, 6, 0, 0, 0, 0, time.UTC)},
+		"month":      {expr: "0 0 1 3 *", expected: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		"either":     {expr: "0 0 10 * 5", expected: time.Date(2024, time.January, 5, 0, 0, 0, 0, time.UTC)},
+		"leap":       {expr: "0 0 29 2 *", expected: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		"impossible": {expr: "0 0 30 2 *"},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			tests.Execute(MustParseWindow(tc.expr).Next(now)).Equal(t, tc.expected)
+		})
+	}
+
+	// Windows are evaluated in their own location.
+	tokyo := time.FixedZone("Tokyo", 9*60*60)
+	tests.Execute(MustParseWindow("0 9 * * *").In(tokyo).Next(now)).Equal(t, time.Date(2024, time.January, 4, 9, 0, 0, 0, tokyo))
+}
+
+func TestGraph_Walk_Window(t *testing.T) {
+	type spec struct {
+		meta    Meta
+		parents []string
+	}
+
+	tcs := map[string]struct {
+		nodes   map[string]spec
+		policy  WindowPolicy
+		windows map[string]Window
+		ran     []string
+		skipped []string
+		held    []string
+		err     string
+	}{
+		"open": {
+			nodes: map[string]spec{
+				"a": {meta: Meta{Window: MustParseWindow("* 12 * * *")}},
+			},
+			ran: []string{"a"},
+		},
+		"wait": {
+			nodes: map[string]spec{
+				"a": {},
+				"b": {meta: Meta{Window: MustParseWindow("* 13 * * *")}, parents: []string{"a"}},
+				"c": {parents: []string{"a"}},
+			},
+			ran:  []string{"a", "c", "b"},
+			held: []string{"b"},
+		},
+		"tags": {
+			nodes: map[string]spec{
+				"a": {meta: Meta{Tags: []string{"deploy"}}},
+				"b": {parents: []string{"a"}},
+			},
+			windows: map[string]Window{"deploy": MustParseWindow("0 13 * * *")},
+			ran:     []string{"a", "b"},
+			held:    []string{"a"},
+		},
+		"skip": {
+			nodes: map[string]spec{
+				"a": {meta: Meta{Window: MustParseWindow("* 13 * * *")}},
+				"b": {parents: []string{"a"}},
+			},
+			policy:  WindowSkip,
+			ran:     []string{"b"},
+			skipped: []string{"a"},
+		},
+		"never": {
+			nodes: map[string]spec{
+				"a": {meta: Meta{Window: MustParseWindow("0 0 30 2 *")}},
+			},
+			err: "a: node \"a\" is outside a window that never opens",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var ran []string
+
+			g := NewGraph()
+			for key, spec := range tc.nodes {
+				g.AddNodeWithMeta(key, Executable(func(ctx context.Context) error {
+					mutex.Lock()
+					defer mutex.Unlock()
+					ran = append(ran, key)
+					return nil
+				}), spec.meta)
+			}
+			for key, spec := range tc.nodes {
+				for _, parent := range spec.parents {
+					g.Connect(parent, key)
+				}
+			}
+
+			// The clock starts just before 13:00 and moves as quickly as real time, so held nodes only wait for a moment.
+			start := time.Now()
+			clock := func() time.Time {
+				return time.Date(2024, time.January, 3, 12, 59, 59, 950_000_000, time.UTC).Add(time.Since(start))
+			}
+
+			var held []string
+			bus := NewBus(SinkFunc(func(event Event) {
+				if event.Type == EventNodeHeld {
+					held = append(held, event.Key)
+				}
+			}))
+
+			result, err := g.Run(context.Background(), &Opts{
+				Parallelism:  1,
+				Bus:          bus,
+				Windows:      tc.windows,
+				WindowPolicy: tc.policy,
+				Now:          clock,
+			})
+			if len(tc.err) > 0 {
+				tests.ExecuteE(err).MatchesError(t, tc.err)
+			} else {
+				tests.ExecuteE(err).NoError(t)
+			}
+			tests.Execute(ran).Equal(t, tc.ran)
+			tests.Execute(held).Equal(t, tc.held)
+			tests.Execute(result.Status(StatusSkipped)).Equal(t, tc.skipped)
+		})
+	}
+}
+
+func TestGraph_Walk_Window_Cancelled(t *testing.T) {
+	g := NewGraph()
+	g.AddNodeWithMeta("a", Executable(func(ctx context.Context) error {
+		return nil
+	}), Meta{Window: MustParseWindow("0 0 1 1 *")})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bus := NewBus(SinkFunc(func(event Event) {
+		if event.Type == EventNodeHeld {
+			cancel()
+		}
+	}))
+
+	result, err := g.Run(ctx, &Opts{Parallelism: 1, Bus: bus})
+	tests.ExecuteE(err).MatchesError(t, "walk was cancelled (context) (context canceled)")
+	tests.Execute(result.Nodes["a"].Status).Equal(t, StatusCancelled)
+}
@@ -2,47 +2,192 @@ package graph
 
 import (
 	"context"
+	"time"
 
 	"github.com/pasataleo/go-errors/errors"
 )
 
+// outcomeKind identifies how a worker finished with a node.
+type outcomeKind int
+
+const (
+	outcomeCompleted outcomeKind = iota
+	outcomeErrored
+	outcomeExpanded
+	outcomeStreamed
+	outcomeCancelled
+)
+
+// outcome is what a worker reports back to the main thread once it has finished with a node. It is sent by value, so
+// reporting a result doesn't allocate.
+type outcome struct {
+	kind outcomeKind
+	key  string
+
+	// err is set for outcomeErrored, and for outcomeExpanded if the expansion was partial.
+	err error
+
+	// subgraph is set for outcomeExpanded.
+	subgraph Graph
+}
+
 // worker is a worker that processes nodes in the graph.
 type worker struct {
-	walker *walker // retain a pointer to the walker.
+	opts *Opts // retain a pointer to the options of the walk.
+
+	// results notifies the main thread when a node completes, errors, expands or is cancelled.
+	results chan outcome
+
+	// enqueued forwards requests from nodes to add new nodes to the walk, and edges forwards requests from streaming
+	// expansions to add edges.
+	enqueued chan enqueueRequest
+	edges    chan connectRequest
 
-	// errored notifies the main thread when a node errors.
-	errored chan map[string]error
+	// finished is closed once the walk has finished, so nodes still running stop sending requests.
+	finished chan struct{}
+
+	// scheduler records how long workers wait for the main thread to accept their results.
+	scheduler *scheduler
+
+	// speculator runs speculative copies of straggling nodes, it is nil unless Opts.Speculation is set.
+	speculator *speculator
+}
 
-	// expanded notifies the main thread when a node is expanded.
-	expanded chan map[string]Graph
+// report sends a result back to the main thread, recording how long it had to wait.
+func (worker *worker) report(result outcome) {
+	start := time.Now()
+	worker.results <- result
+	worker.scheduler.blocked(time.Since(start))
+}
 
-	// completed notifies the main thread when a node is complete.
-	completed chan string
+// fail reports that the node failed.
+func (worker *worker) fail(key string, err error, text string) {
+	err = errors.Embed(errors.New(err, FailedNode, text), NodeKey, key)
+	worker.report(outcome{kind: outcomeErrored, key: key, err: err})
 }
 
 // work processes nodes in the graph. Callers should call this in a goroutine, and can call it multiple times.
-func (worker *worker) work(ctx context.Context) {
-	key := ctx.Value("key").(string)
+func (worker *worker) work(ctx context.Context, node *node) {
+	key := node.key
 
-	node := worker.walker.nodes[key]
+	walkCtx := ctx // keep the walk context, so deadlines added by ContextFn don't look like the walk being cancelled.
+	if worker.opts.ContextFn != nil {
+		ctx = worker.opts.ContextFn(ctx, key, node.meta)
+	}
 
-	if executor, ok := node.impl.(ExecutableNode); ok {
-		if err := executor.Execute(ctx); err != nil {
-			worker.errored <- map[string]error{key: errors.Embed(errors.New(err, FailedNode, "failed to execute node"), NodeKey, key)}
+	if worker.opts.Authorize != nil {
+		if err := worker.opts.Authorize(ctx, key, node.meta); err != nil {
+			err = errors.Embed(errors.New(err, Forbidden, "not authorized to execute node"), NodeKey, key)
+			worker.report(outcome{kind: outcomeErrored, key: key, err: err})
 			return
 		}
 	}
 
-	if expander, ok := node.impl.(ExpandableNode); ok {
+	env, err := resolveEnv(ctx, worker.opts.Resolver, key, node.meta.Env)
+	if err != nil {
+		worker.fail(key, err, "failed to resolve environment")
+		return
+	}
+	if exec := executionFrom(ctx); exec != nil && env != nil {
+		exec.mutex.Lock()
+		exec.env = env
+		exec.mutex.Unlock()
+	}
+
+	impl, release, err := materialize(ctx, node)
+	if err != nil {
+		worker.fail(key, err, "failed to load node")
+		return
+	}
+	defer release()
+
+	if executor, ok := impl.(ExecutableNode); ok {
+		_, expandable := impl.(ExpandableNode)
+
+		execute := executor.Execute
+		if !expandable && worker.speculator != nil {
+			execute = func(ctx context.Context) error {
+				return worker.speculator.execute(ctx, node, executor)
+			}
+		}
+
+		if fingerprint := node.meta.Fingerprint; !expandable && len(fingerprint) > 0 && worker.opts.Completions != nil {
+			acquired, err := worker.opts.Completions.Acquire(ctx, fingerprint)
+			if err != nil {
+				worker.fail(key, err, "failed to acquire completion")
+				return
+			}
+			if !acquired {
+				// Another node with the same fingerprint has already done the work.
+				if exec := executionFrom(ctx); exec != nil {
+					exec.mutex.Lock()
+					exec.reused = true
+					exec.mutex.Unlock()
+				}
+				worker.report(outcome{kind: outcomeCompleted, key: key})
+				return
+			}
+
+			run := execute
+			execute = func(ctx context.Context) error {
+				err := run(ctx)
+				if completeErr := worker.opts.Completions.Complete(context.WithoutCancel(ctx), fingerprint, err); err == nil {
+					err = completeErr
+				}
+				return err
+			}
+		}
+
+		if err := execute(ctx); err != nil {
+			worker.fail(key, err, "failed to execute node")
+			return
+		}
+	}
+
+	if expander, ok := impl.(ExpandableNode); ok {
+		if walkCtx.Err() != nil {
+			// Don't add more work to a walk that is being cancelled.
+			worker.report(outcome{kind: outcomeCancelled, key: key})
+			return
+		}
+
 		subgraph, err := expander.Expand(ctx)
+		var partial error
+		if err != nil {
+			if errors.GetErrorCode(err) != PartialExpansion {
+				worker.fail(key, err, "failed to expand node")
+				return
+			}
+			partial = errors.Embed(errors.New(err, FailedNode, "failed to expand node"), NodeKey, key)
+		}
+
+		subgraph, err = subgraph.resolveDependencies(ctx)
+		if err != nil {
+			worker.fail(key, err, "failed to expand node")
+			return
+		}
+
+		worker.report(outcome{kind: outcomeExpanded, key: key, subgraph: subgraph, err: partial})
+		return
+	}
+
+	if streamer, ok := impl.(StreamingExpandableNode); ok {
+		if walkCtx.Err() != nil {
+			worker.report(outcome{kind: outcomeCancelled, key: key})
+			return
+		}
+
+		emitter := &Emitter{key: key, nodes: worker.enqueued, edges: worker.edges}
+		err := streamer.Expand(ctx, emitter)
+		emitter.close()
 		if err != nil {
-			worker.errored <- map[string]error{key: errors.Embed(errors.New(err, FailedNode, "failed to expand node"), NodeKey, key)}
+			worker.fail(key, err, "failed to expand node")
 			return
 		}
 
-		worker.expanded <- map[string]Graph{key: subgraph}
+		worker.report(outcome{kind: outcomeStreamed, key: key})
 		return
 	}
 
-	worker.completed <- key
+	worker.report(outcome{kind: outcomeCompleted, key: key})
 }